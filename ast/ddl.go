@@ -26,6 +26,7 @@ var (
 	_ DDLNode = &DropTableStmt{}
 	_ DDLNode = &AlterTableStmt{}
 	_ DDLNode = &TruncateTableStmt{}
+	_ DDLNode = &RenameTableStmt{}
 	_ Node    = &IndexColName{}
 	_ Node    = &ReferenceDef{}
 	_ Node    = &ColumnOption{}
@@ -33,6 +34,7 @@ var (
 	_ Node    = &ColumnDef{}
 	_ Node    = &ColumnPosition{}
 	_ Node    = &AlterTableSpec{}
+	_ Node    = &TableToTable{}
 )
 
 // CharsetOpt is used for parsing charset option from SQL.
@@ -429,6 +431,7 @@ const (
 	TableOptionMaxRows
 	TableOptionMinRows
 	TableOptionDelayKeyWrite
+	TableOptionRowFormat
 )
 
 // TableOption is used for parsing table option from SQL.
@@ -486,6 +489,9 @@ const (
 	AlterTableDropPrimaryKey
 	AlterTableDropIndex
 	AlterTableDropForeignKey
+	AlterTableRenameTable
+	AlterTableModifyColumn
+	AlterTableChangeColumn
 
 // TODO: Add more actions
 )
@@ -501,6 +507,9 @@ type AlterTableSpec struct {
 	Column     *ColumnDef
 	DropColumn *ColumnName
 	Position   *ColumnPosition
+	NewTable   *TableName
+	// OldColumnName is the name of the column to change for AlterTableChangeColumn.
+	OldColumnName *ColumnName
 }
 
 // Accept implements Node Accept interface.
@@ -538,6 +547,20 @@ func (n *AlterTableSpec) Accept(v Visitor) (Node, bool) {
 		}
 		n.Position = node.(*ColumnPosition)
 	}
+	if n.NewTable != nil {
+		node, ok := n.NewTable.Accept(v)
+		if !ok {
+			return n, false
+		}
+		n.NewTable = node.(*TableName)
+	}
+	if n.OldColumnName != nil {
+		node, ok := n.OldColumnName.Accept(v)
+		if !ok {
+			return n, false
+		}
+		n.OldColumnName = node.(*ColumnName)
+	}
 	return v.Leave(n)
 }
 
@@ -594,3 +617,57 @@ func (n *TruncateTableStmt) Accept(v Visitor) (Node, bool) {
 	n.Table = node.(*TableName)
 	return v.Leave(n)
 }
+
+// TableToTable represents renaming old table to new table used in RenameTableStmt.
+type TableToTable struct {
+	node
+
+	OldTable *TableName
+	NewTable *TableName
+}
+
+// Accept implements Node Accept interface.
+func (n *TableToTable) Accept(v Visitor) (Node, bool) {
+	newNod, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNod)
+	}
+	n = newNod.(*TableToTable)
+	node, ok := n.OldTable.Accept(v)
+	if !ok {
+		return n, false
+	}
+	n.OldTable = node.(*TableName)
+	node, ok = n.NewTable.Accept(v)
+	if !ok {
+		return n, false
+	}
+	n.NewTable = node.(*TableName)
+	return v.Leave(n)
+}
+
+// RenameTableStmt is a statement to rename one or more tables, the tables are renamed
+// in order in one DDL job.
+// See: https://dev.mysql.com/doc/refman/5.7/en/rename-table.html
+type RenameTableStmt struct {
+	ddlNode
+
+	TableToTables []*TableToTable
+}
+
+// Accept implements Node Accept interface.
+func (n *RenameTableStmt) Accept(v Visitor) (Node, bool) {
+	newNod, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNod)
+	}
+	n = newNod.(*RenameTableStmt)
+	for i, t := range n.TableToTables {
+		node, ok := t.Accept(v)
+		if !ok {
+			return n, false
+		}
+		n.TableToTables[i] = node.(*TableToTable)
+	}
+	return v.Leave(n)
+}
@@ -761,6 +761,9 @@ type Limit struct {
 
 	Offset uint64
 	Count  uint64
+	// Literals are the literals of the count and the offset in the statement text,
+	// they are used to normalize the statement.
+	Literals []*ValueExpr
 }
 
 // Accept implements Node Accept interface.
@@ -17,6 +17,7 @@ import (
 	"fmt"
 	"regexp"
 
+	"github.com/pingcap/tidb/context"
 	"github.com/pingcap/tidb/model"
 	"github.com/pingcap/tidb/mysql"
 	"github.com/pingcap/tidb/parser/opcode"
@@ -53,6 +54,10 @@ var (
 // ValueExpr is the simple value expression.
 type ValueExpr struct {
 	exprNode
+
+	// Offset is the offset of the literal in the statement text if the value is parsed from
+	// a literal, whose text is set, it's used to normalize the statement.
+	Offset int
 }
 
 // NewValueExpr creates a ValueExpr with value, and sets default field type.
@@ -260,11 +265,33 @@ func (n *CaseExpr) Accept(v Visitor) (Node, bool) {
 	return v.Leave(n)
 }
 
+// SubqueryExec represents a subquery executor interface.
+// It is implemented in executor and used by the evaluator, so the evaluator
+// can execute the subquery without depending on the executor package.
+type SubqueryExec interface {
+	// EvalRows executes the subquery and returns at most rowCount rows, rowCount < 0 means no limit.
+	// If the subquery has a single column, a row is the column value, otherwise a row is
+	// a []interface{} of the column values.
+	EvalRows(ctx context.Context, rowCount int) ([]interface{}, error)
+}
+
 // SubqueryExpr represents a subquery.
 type SubqueryExpr struct {
 	exprNode
 	// Query is the query SelectNode.
 	Query ResultSetNode
+	// SubqueryExec is set by the plan builder if the subquery is evaluated by the evaluator.
+	SubqueryExec SubqueryExec
+	// Evaluated is true if the value has been evaluated, an uncorrelated subquery is evaluated only once.
+	Evaluated bool
+	// Correlated is true if the subquery refers to columns of the outer query,
+	// it must be evaluated for every outer row.
+	Correlated bool
+	// MultiRows is true if the subquery is used by in or comparison with any/all,
+	// the value is the list of all the rows.
+	MultiRows bool
+	// Exists is true if the subquery is used by exists, the value is whether it has any row.
+	Exists bool
 }
 
 // Accept implements Node Accept interface.
@@ -31,6 +31,9 @@ var (
 	_ StmtNode = &CreateUserStmt{}
 	_ StmtNode = &DoStmt{}
 	_ StmtNode = &GrantStmt{}
+	_ StmtNode = &AnalyzeTableStmt{}
+	_ StmtNode = &KillStmt{}
+	_ StmtNode = &AdminStmt{}
 
 	_ Node = &VariableAssignment{}
 )
@@ -51,6 +54,12 @@ type AuthOption struct {
 	// TODO: support auth_plugin
 }
 
+// Explain output formats.
+const (
+	ExplainFormatTraditional = "traditional"
+	ExplainFormatJSON        = "json"
+)
+
 // ExplainStmt is a statement to provide information about how is SQL statement executed
 // or get columns information in a table.
 // See: https://dev.mysql.com/doc/refman/5.7/en/explain.html
@@ -58,6 +67,8 @@ type ExplainStmt struct {
 	stmtNode
 
 	Stmt StmtNode
+	// Format is the output format, ExplainFormatTraditional or ExplainFormatJSON.
+	Format string
 }
 
 // Accept implements Node Accept interface.
@@ -168,6 +179,7 @@ const (
 	ShowCreateTable
 	ShowGrants
 	ShowTriggers
+	ShowProcessList
 )
 
 // ShowStmt is a statement to provide information about databases, tables, columns and so on.
@@ -385,6 +397,18 @@ type UserSpec struct {
 	AuthOpt *AuthOption
 }
 
+// RequireType is the type for the TLS requirement of accounts.
+type RequireType int
+
+const (
+	// RequireUnspecified means there is no REQUIRE clause, the requirement is not changed.
+	RequireUnspecified RequireType = iota
+	// RequireNone means the account doesn't require TLS connections.
+	RequireNone
+	// RequireSSL means the account must connect with TLS.
+	RequireSSL
+)
+
 // CreateUserStmt creates user account.
 // See: https://dev.mysql.com/doc/refman/5.7/en/create-user.html
 type CreateUserStmt struct {
@@ -392,6 +416,7 @@ type CreateUserStmt struct {
 
 	IfNotExists bool
 	Specs       []*UserSpec
+	Require     RequireType
 }
 
 // Accept implements Node Accept interface.
@@ -491,6 +516,7 @@ type GrantStmt struct {
 	ObjectType ObjectTypeType
 	Level      *GrantLevel
 	Users      []*UserSpec
+	Require    RequireType
 }
 
 // Accept implements Node Accept interface.
@@ -509,3 +535,91 @@ func (n *GrantStmt) Accept(v Visitor) (Node, bool) {
 	}
 	return v.Leave(n)
 }
+
+// AnalyzeTableStmt is a statement to collect the statistics of tables.
+// See: https://dev.mysql.com/doc/refman/5.7/en/analyze-table.html
+type AnalyzeTableStmt struct {
+	stmtNode
+
+	TableNames []*TableName
+}
+
+// Accept implements Node Accept interface.
+func (n *AnalyzeTableStmt) Accept(v Visitor) (Node, bool) {
+	newNod, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNod)
+	}
+	n = newNod.(*AnalyzeTableStmt)
+	for i, val := range n.TableNames {
+		node, ok := val.Accept(v)
+		if !ok {
+			return n, false
+		}
+		n.TableNames[i] = node.(*TableName)
+	}
+	return v.Leave(n)
+}
+
+// KillStmt is a statement to kill a query or connection.
+// See: https://dev.mysql.com/doc/refman/5.7/en/kill.html
+type KillStmt struct {
+	stmtNode
+
+	// Query indicates whether to terminate the statement the connection is executing
+	// and leave the connection intact.
+	Query        bool
+	ConnectionID uint64
+}
+
+// Accept implements Node Accept interface.
+func (n *KillStmt) Accept(v Visitor) (Node, bool) {
+	newNod, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNod)
+	}
+	n = newNod.(*KillStmt)
+	return v.Leave(n)
+}
+
+// AdminStmtType is the type for admin statement.
+type AdminStmtType int
+
+// Admin statement types.
+const (
+	AdminShowDDL AdminStmtType = iota + 1
+	AdminShowDDLJobs
+	AdminCancelDDLJobs
+	AdminCheckTable
+	AdminCheckIndex
+)
+
+// AdminStmt is the struct for the admin statements which inspect and manage the DDL jobs,
+// and check the consistency of the indices and the records.
+type AdminStmt struct {
+	stmtNode
+
+	Tp     AdminStmtType
+	JobIDs []int64
+	Tables []*TableName
+	Index  string
+	// Repair indicates whether to repair the inconsistent index entries found by the check.
+	Repair bool
+}
+
+// Accept implements Node Accept interface.
+func (n *AdminStmt) Accept(v Visitor) (Node, bool) {
+	newNod, skipChildren := v.Enter(n)
+	if skipChildren {
+		return v.Leave(newNod)
+	}
+	n = newNod.(*AdminStmt)
+	for i, val := range n.Tables {
+		node, ok := val.Accept(v)
+		if !ok {
+			return n, false
+		}
+		n.Tables[i] = node.(*TableName)
+	}
+	return v.Leave(n)
+}
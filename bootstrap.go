@@ -24,7 +24,10 @@ import (
 
 	"github.com/juju/errors"
 	"github.com/ngaut/log"
+	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/model"
 	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/sessionctx"
 	"github.com/pingcap/tidb/sessionctx/variable"
 	"github.com/pingcap/tidb/terror"
 )
@@ -32,9 +35,9 @@ import (
 const (
 	// CreateUserTable is the SQL statement creates User table in system db.
 	CreateUserTable = `CREATE TABLE if not exists mysql.user (
-		Host			CHAR(64),
-		User			CHAR(16),
-		Password		CHAR(41),
+		Host			CHAR(64) COLLATE utf8_bin,
+		User			CHAR(16) COLLATE utf8_bin,
+		Password		CHAR(41) COLLATE utf8_bin,
 		Select_priv		ENUM('N','Y') NOT NULL  DEFAULT 'N',
 		Insert_priv		ENUM('N','Y') NOT NULL  DEFAULT 'N',
 		Update_priv		ENUM('N','Y') NOT NULL  DEFAULT 'N',
@@ -47,12 +50,13 @@ const (
 		Execute_priv		ENUM('N','Y') NOT NULL  DEFAULT 'N',
 		Index_priv		ENUM('N','Y') NOT NULL  DEFAULT 'N',
 		Create_user_priv	ENUM('N','Y') NOT NULL  DEFAULT 'N',
+		Ssl_type		ENUM('','ANY','X509','SPECIFIED') NOT NULL  DEFAULT '',
 		PRIMARY KEY (Host, User));`
 	// CreateDBPrivTable is the SQL statement creates DB scope privilege table in system db.
 	CreateDBPrivTable = `CREATE TABLE if not exists mysql.db (
-		Host		CHAR(60),
-		DB		CHAR(64),
-		User		CHAR(16),
+		Host		CHAR(60) COLLATE utf8_bin,
+		DB		CHAR(64) COLLATE utf8_bin,
+		User		CHAR(16) COLLATE utf8_bin,
 		Select_priv	ENUM('N','Y') Not Null  DEFAULT 'N',
 		Insert_priv	ENUM('N','Y') Not Null  DEFAULT 'N',
 		Update_priv	ENUM('N','Y') Not Null  DEFAULT 'N',
@@ -66,22 +70,22 @@ const (
 		PRIMARY KEY (Host, DB, User));`
 	// CreateTablePrivTable is the SQL statement creates table scope privilege table in system db.
 	CreateTablePrivTable = `CREATE TABLE if not exists mysql.tables_priv (
-		Host		CHAR(60),
-		DB		CHAR(64),
-		User		CHAR(16),
-		Table_name	CHAR(64),
-		Grantor		CHAR(77),
+		Host		CHAR(60) COLLATE utf8_bin,
+		DB		CHAR(64) COLLATE utf8_bin,
+		User		CHAR(16) COLLATE utf8_bin,
+		Table_name	CHAR(64) COLLATE utf8_bin,
+		Grantor		CHAR(77) COLLATE utf8_bin,
 		Timestamp	Timestamp DEFAULT CURRENT_TIMESTAMP,
 		Table_priv	SET('Select','Insert','Update','Delete','Create','Drop','Grant', 'Index','Alter'),
 		Column_priv	SET('Select','Insert','Update'),
 		PRIMARY KEY (Host, DB, User, Table_name));`
 	// CreateColumnPrivTable is the SQL statement creates column scope privilege table in system db.
 	CreateColumnPrivTable = `CREATE TABLE if not exists mysql.columns_priv(
-		Host		CHAR(60),
-		DB		CHAR(64),
-		User		CHAR(16),
-		Table_name	CHAR(64),
-		Column_name	CHAR(64),
+		Host		CHAR(60) COLLATE utf8_bin,
+		DB		CHAR(64) COLLATE utf8_bin,
+		User		CHAR(16) COLLATE utf8_bin,
+		Table_name	CHAR(64) COLLATE utf8_bin,
+		Column_name	CHAR(64) COLLATE utf8_bin,
 		Timestamp	Timestamp DEFAULT CURRENT_TIMESTAMP,
 		Column_priv	SET('Select','Insert','Update'),
 		PRIMARY KEY (Host, DB, User, Table_name, Column_name));`
@@ -90,15 +94,26 @@ const (
 	// INFORMATION_SCHEMA is a virtual db in TiDB. So we put this table in system db.
 	// Maybe we will put it back to INFORMATION_SCHEMA.
 	CreateGloablVariablesTable = `CREATE TABLE if not exists mysql.GLOBAL_VARIABLES(
-		VARIABLE_NAME  VARCHAR(64) Not Null PRIMARY KEY,
-		VARIABLE_VALUE VARCHAR(1024) DEFAULT Null);`
+		VARIABLE_NAME  VARCHAR(64) COLLATE utf8_bin Not Null PRIMARY KEY,
+		VARIABLE_VALUE VARCHAR(1024) COLLATE utf8_bin DEFAULT Null);`
 	// CreateTiDBTable is the SQL statement creates a table in system db.
 	// This table is a key-value struct contains some information used by TiDB.
 	// Currently we only put bootstrapped in it which indicates if the system is already bootstrapped.
 	CreateTiDBTable = `CREATE TABLE if not exists mysql.tidb(
-		VARIABLE_NAME  VARCHAR(64) Not Null PRIMARY KEY,
-		VARIABLE_VALUE VARCHAR(1024) DEFAULT Null,
-		COMMENT VARCHAR(1024));`
+		VARIABLE_NAME  VARCHAR(64) COLLATE utf8_bin Not Null PRIMARY KEY,
+		VARIABLE_VALUE VARCHAR(1024) COLLATE utf8_bin DEFAULT Null,
+		COMMENT VARCHAR(1024) COLLATE utf8_bin);`
+)
+
+const (
+	// notBootstrapped is the version of the store which isn't bootstrapped.
+	notBootstrapped = 0
+	// version1 is the version of the stores bootstrapped before the versions are recorded.
+	version1 = 1
+	// version2 adds the Ssl_type column to mysql.user.
+	version2 = 2
+	// currentBootstrapVersion is the version of the system tables created by bootstrap.
+	currentBootstrapVersion = version2
 )
 
 // Bootstrap initiates system DB for a store.
@@ -183,6 +198,40 @@ func doDDLWorks(s Session) {
 	mustExecute(s, CreateTiDBTable)
 }
 
+// upgrade upgrades the system tables of a store bootstrapped by an older version of TiDB.
+// Every step checks whether it's done already, so the upgrade can be run again if it's
+// interrupted or run by other servers at the same time.
+func upgrade(s Session, ver int64) {
+	if ver < version2 {
+		upgradeToVer2(s)
+	}
+}
+
+// upgradeToVer2 adds the Ssl_type column to mysql.user, it's read on login for REQUIRE SSL.
+func upgradeToVer2(s Session) {
+	sql := fmt.Sprintf(`ALTER TABLE %s.%s ADD COLUMN Ssl_type ENUM('','ANY','X509','SPECIFIED') NOT NULL DEFAULT ''`,
+		mysql.SystemDB, mysql.UserTable)
+	mustExecuteIfColumnNotExists(s, mysql.UserTable, "Ssl_type", sql)
+}
+
+// mustExecuteIfColumnNotExists executes the statement adding the column of the system table
+// if the column doesn't exist.
+func mustExecuteIfColumnNotExists(s Session, tbl, col, sql string) {
+	columnExists := func() bool {
+		is := sessionctx.GetDomain(s.(context.Context)).InfoSchema()
+		return is.ColumnExists(model.NewCIStr(mysql.SystemDB), model.NewCIStr(tbl), model.NewCIStr(col))
+	}
+	if columnExists() {
+		return
+	}
+	_, err := s.Execute(sql)
+	if err != nil && !columnExists() {
+		// The column isn't added by other servers.
+		debug.PrintStack()
+		log.Fatal(err)
+	}
+}
+
 // Execute DML statements in bootstrap stage.
 // All the statements run in a single transaction.
 func doDMLWorks(s Session) {
@@ -190,7 +239,7 @@ func doDMLWorks(s Session) {
 
 	// Insert a default user with empty password.
 	mustExecute(s, `INSERT INTO mysql.user VALUES
-		("%", "root", "", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y")`)
+		("%", "root", "", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "")`)
 
 	// Init global system variables table.
 	values := make([]string, 0, len(variable.SysVars))
@@ -17,6 +17,7 @@ import (
 	"fmt"
 
 	"github.com/pingcap/tidb/parser/coldef"
+	"github.com/pingcap/tidb/table"
 )
 
 // AlterTableSpecification.Action types.
@@ -29,6 +30,9 @@ const (
 	AlterDropPrimaryKey
 	AlterDropIndex
 	AlterDropForeignKey
+	AlterRenameTable
+	AlterModifyColumn
+	AlterChangeColumn
 )
 
 // ColumnPosition Types.
@@ -66,6 +70,8 @@ type AlterSpecification struct {
 	TableOpts  []*coldef.TableOpt
 	Column     *coldef.ColumnDef
 	Position   *ColumnPosition
+	// NewTable is the new name of the table if Action is AlterRenameTable.
+	NewTable table.Ident
 }
 
 // String implements fmt.Stringer.
@@ -87,6 +93,19 @@ func (as *AlterSpecification) String() string {
 		return fmt.Sprintf("DROP INDEX %s", as.Name)
 	case AlterDropForeignKey:
 		return fmt.Sprintf("DROP FOREIGN KEY %s", as.Name)
+	case AlterRenameTable:
+		return fmt.Sprintf("RENAME TO %s", as.NewTable)
+	case AlterModifyColumn, AlterChangeColumn:
+		var str string
+		if as.Action == AlterModifyColumn {
+			str = fmt.Sprintf("MODIFY COLUMN %s", as.Column.String())
+		} else {
+			str = fmt.Sprintf("CHANGE COLUMN %s %s", as.Name, as.Column.String())
+		}
+		if ps := as.Position.String(); len(ps) > 0 {
+			str = fmt.Sprintf("%s %s", str, ps)
+		}
+		return str
 	case AlterAddColumn:
 		ps := as.Position.String()
 		if len(ps) > 0 {
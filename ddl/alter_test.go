@@ -15,8 +15,10 @@ package ddl
 
 import (
 	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/model"
 	"github.com/pingcap/tidb/mysql"
 	"github.com/pingcap/tidb/parser/coldef"
+	"github.com/pingcap/tidb/table"
 	"github.com/pingcap/tidb/util/types"
 )
 
@@ -34,6 +36,8 @@ func (s *testDDLSuite) TestAlterSpecification(c *C) {
 			Name: "c"},
 		{Action: AlterDropIndex,
 			Name: "index_c"},
+		{Action: AlterRenameTable,
+			NewTable: table.Ident{Name: model.NewCIStr("t")}},
 		{Action: AlterAddConstr,
 			Constraint: nil},
 		{Action: AlterAddConstr,
@@ -65,6 +69,20 @@ func (s *testDDLSuite) TestAlterSpecification(c *C) {
 			},
 			Position: &ColumnPosition{Type: ColumnPositionAfter,
 				RelativeColumn: "c"}},
+		{Action: AlterModifyColumn,
+			Name: "c",
+			Column: &coldef.ColumnDef{
+				Name: "c",
+				Tp:   types.NewFieldType(mysql.TypeLonglong),
+			},
+			Position: &ColumnPosition{}},
+		{Action: AlterChangeColumn,
+			Name: "c",
+			Column: &coldef.ColumnDef{
+				Name: "d",
+				Tp:   types.NewFieldType(mysql.TypeLong),
+			},
+			Position: &ColumnPosition{Type: ColumnPositionFirst}},
 
 		// Invalid action returns empty string
 		{Action: -1},
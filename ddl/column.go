@@ -14,12 +14,15 @@
 package ddl
 
 import (
+	"strings"
+
 	"github.com/juju/errors"
 	"github.com/ngaut/log"
 	"github.com/pingcap/tidb/column"
 	"github.com/pingcap/tidb/kv"
 	"github.com/pingcap/tidb/meta"
 	"github.com/pingcap/tidb/model"
+	"github.com/pingcap/tidb/mysql"
 	"github.com/pingcap/tidb/table"
 	"github.com/pingcap/tidb/table/tables"
 	"github.com/pingcap/tidb/terror"
@@ -129,6 +132,10 @@ func (d *ddl) onAddColumn(t *meta.Meta, job *model.Job) error {
 		return errors.Trace(err)
 	}
 
+	if job.IsRollingBack() {
+		return d.rollbackAddedColumn(t, job, tblInfo, columnInfo, nil, ErrCancelledDDLJob.Error())
+	}
+
 	switch columnInfo.State {
 	case model.StateNone:
 		// none -> delete only
@@ -352,12 +359,11 @@ func (d *ddl) backfillColumnData(t table.Table, columnInfo *model.ColumnInfo, ha
 				return nil
 			}
 
-			backfillKey := t.RecordKey(handle, &column.Col{ColumnInfo: *columnInfo})
-			backfillValue, err := txn.Get(backfillKey)
-			if err != nil && !kv.IsErrNotFound(err) {
+			col := &column.Col{ColumnInfo: *columnInfo}
+			exist, err = t.ColValueExists(txn, handle, col)
+			if err != nil {
 				return errors.Trace(err)
-			}
-			if backfillValue != nil {
+			} else if exist {
 				return nil
 			}
 
@@ -377,7 +383,7 @@ func (d *ddl) backfillColumnData(t table.Table, columnInfo *model.ColumnInfo, ha
 				return errors.Trace(err)
 			}
 
-			err = t.SetColValue(txn, backfillKey, v)
+			err = t.SetRecordColValue(txn, handle, col, v)
 			if err != nil {
 				return errors.Trace(err)
 			}
@@ -415,9 +421,8 @@ func (d *ddl) dropTableColumn(t table.Table, colInfo *model.ColumnInfo, reorgInf
 
 			var h int64
 			for _, h = range handles {
-				key := t.RecordKey(h, col)
-				err1 := txn.Delete(key)
-				if err1 != nil && !terror.ErrorEqual(err1, kv.ErrNotExist) {
+				err1 := t.RemoveRecordColValue(txn, h, col)
+				if err1 != nil {
 					return errors.Trace(err1)
 				}
 			}
@@ -428,3 +433,644 @@ func (d *ddl) dropTableColumn(t table.Table, colInfo *model.ColumnInfo, reorgInf
 		}
 	}
 }
+
+// The name prefixes of the changing column and indices while modifying a column with a
+// reorganization, and of the origin column and indices after they are replaced.
+const (
+	changingColumnPrefix = "_Col$_"
+	changingIndexPrefix  = "_Idx$_"
+)
+
+// columnDataError is the error of converting the column data or rebuilding the indices
+// while modifying a column, the job is rolled back if it occurs.
+type columnDataError struct {
+	err error
+}
+
+func (e *columnDataError) Error() string {
+	return e.err.Error()
+}
+
+var integerTypeRank = map[byte]int{
+	mysql.TypeTiny:     1,
+	mysql.TypeShort:    2,
+	mysql.TypeInt24:    3,
+	mysql.TypeLong:     4,
+	mysql.TypeLonglong: 5,
+}
+
+// isLosslessChange returns whether the stored values and index keys of the column with the old type
+// are valid for the new type, then the column can be modified without a reorganization. The stored
+// values may be NULL if the new type adds NOT NULL, they're checked in the reorganization.
+func isLosslessChange(oldTp, newTp *types.FieldType) bool {
+	if oldTp.Charset != newTp.Charset || oldTp.Collate != newTp.Collate ||
+		!mysql.HasNotNullFlag(oldTp.Flag) && mysql.HasNotNullFlag(newTp.Flag) ||
+		mysql.HasUnsignedFlag(oldTp.Flag) != mysql.HasUnsignedFlag(newTp.Flag) ||
+		mysql.HasBinaryFlag(oldTp.Flag) != mysql.HasBinaryFlag(newTp.Flag) {
+		return false
+	}
+
+	oldRank, ok1 := integerTypeRank[oldTp.Tp]
+	newRank, ok2 := integerTypeRank[newTp.Tp]
+	if ok1 && ok2 {
+		// Widening an integer column, the display width doesn't matter.
+		return newRank >= oldRank
+	}
+
+	if oldTp.Tp != newTp.Tp {
+		return false
+	}
+
+	if types.IsTypeChar(newTp.Tp) || types.IsTypeBlob(newTp.Tp) {
+		if newTp.Flen == types.UnspecifiedLength {
+			return true
+		}
+		return oldTp.Flen != types.UnspecifiedLength && newTp.Flen >= oldTp.Flen
+	}
+
+	if oldTp.Flen != newTp.Flen || oldTp.Decimal != newTp.Decimal || len(oldTp.Elems) != len(newTp.Elems) {
+		return false
+	}
+	for i := range oldTp.Elems {
+		if oldTp.Elems[i] != newTp.Elems[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func findColByID(cols []*model.ColumnInfo, id int64) *model.ColumnInfo {
+	for _, col := range cols {
+		if col.ID == id {
+			return col
+		}
+	}
+
+	return nil
+}
+
+// findColumnIndices finds the indices which cover the column.
+func findColumnIndices(tblInfo *model.TableInfo, colName model.CIStr) []*model.IndexInfo {
+	var indices []*model.IndexInfo
+	for _, idx := range tblInfo.Indices {
+		for _, ic := range idx.Columns {
+			if ic.Name.L == colName.L {
+				indices = append(indices, idx)
+				break
+			}
+		}
+	}
+	return indices
+}
+
+// renameIndexColumn renames the column covered by the indices.
+func renameIndexColumn(indices []*model.IndexInfo, oldName, newName model.CIStr) {
+	for _, idx := range indices {
+		for _, ic := range idx.Columns {
+			if ic.Name.L == oldName.L {
+				ic.Name = newName
+			}
+		}
+	}
+}
+
+// moveColumn moves the column to the position, the column is kept in its place if the position
+// type is ColumnPositionNone.
+func moveColumn(tblInfo *model.TableInfo, colInfo *model.ColumnInfo, pos *ColumnPosition) {
+	cols := make([]*model.ColumnInfo, 0, len(tblInfo.Columns))
+	position := -1
+	for i, col := range tblInfo.Columns {
+		if col == colInfo {
+			position = i
+			continue
+		}
+		cols = append(cols, col)
+	}
+
+	switch pos.Type {
+	case ColumnPositionFirst:
+		position = 0
+	case ColumnPositionAfter:
+		name := strings.ToLower(pos.RelativeColumn)
+		for i, col := range cols {
+			if col.Name.L == name {
+				position = i + 1
+				break
+			}
+		}
+	}
+	if position < 0 || position > len(cols) {
+		position = len(cols)
+	}
+
+	newCols := make([]*model.ColumnInfo, 0, len(tblInfo.Columns))
+	newCols = append(newCols, cols[:position]...)
+	newCols = append(newCols, colInfo)
+	newCols = append(newCols, cols[position:]...)
+	tblInfo.Columns = newCols
+}
+
+// resetColumnOffsets sets the offsets of the columns to their positions and updates the offsets
+// of the index columns, the columns which are not public must be at the end of the columns.
+func resetColumnOffsets(tblInfo *model.TableInfo) {
+	for i, col := range tblInfo.Columns {
+		col.Offset = i
+	}
+
+	for _, idx := range tblInfo.Indices {
+		for _, ic := range idx.Columns {
+			if col := findCol(tblInfo.Columns, ic.Name.L); col != nil {
+				ic.Offset = col.Offset
+			}
+		}
+	}
+}
+
+// How to modify a column?
+//  1. If the column values don't need to be converted, change the column info directly.
+//  2. Otherwise add a changing column with the new definition and the changing indices for the
+//     indices covering the column, the values of the origin column are converted and written to
+//     the changing column, and the changing indices are built in reorganization.
+//  3. Replace the origin column and indices with the changing ones, then drop the origin ones like
+//     dropping a column. If the column values can't be converted, the changing ones are dropped and
+//     the job is cancelled.
+func (d *ddl) onModifyColumn(t *meta.Meta, job *model.Job) error {
+	schemaID := job.SchemaID
+	tblInfo, err := d.getTableInfo(t, job)
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	newCol := &model.ColumnInfo{}
+	var oldName model.CIStr
+	pos := &ColumnPosition{}
+	var reorgErr string
+	err = job.DecodeArgs(newCol, &oldName, pos, &reorgErr)
+	if err != nil {
+		job.State = model.JobCancelled
+		return errors.Trace(err)
+	}
+
+	changingCol := findColByID(tblInfo.Columns, newCol.ID)
+	if changingCol == nil {
+		return d.startModifyColumn(t, job, tblInfo, newCol, oldName, pos)
+	}
+
+	_, err = t.GenSchemaVersion()
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	if changingCol.State == model.StatePublic {
+		// The origin column has been replaced, drop it.
+		return d.dropOriginColumn(t, job, tblInfo, changingCol)
+	}
+
+	oldCol := findColByID(tblInfo.Columns, changingCol.OriginID)
+	if oldCol == nil {
+		job.State = model.JobCancelled
+		return errors.Errorf("column %s doesn't exist", oldName)
+	}
+	changingIndices := findColumnIndices(tblInfo, changingCol.Name)
+	if len(reorgErr) == 0 && job.IsRollingBack() {
+		// The job is cancelled, save the error to return it when the rollback is done.
+		reorgErr = ErrCancelledDDLJob.Error()
+		job.Args = []interface{}{newCol, oldName, pos, reorgErr}
+	}
+	if len(reorgErr) > 0 {
+		return d.rollbackAddedColumn(t, job, tblInfo, changingCol, changingIndices, reorgErr)
+	}
+
+	switch changingCol.State {
+	case model.StateDeleteOnly:
+		// delete only -> write only
+		job.SchemaState = model.StateWriteOnly
+		setColumnAndIndicesState(changingCol, changingIndices, model.StateWriteOnly)
+		err = t.UpdateTable(schemaID, tblInfo)
+		return errors.Trace(err)
+	case model.StateWriteOnly:
+		// write only -> reorganization
+		job.SchemaState = model.StateWriteReorganization
+		setColumnAndIndicesState(changingCol, changingIndices, model.StateWriteReorganization)
+		// initialize SnapshotVer to 0 for later reorganization check.
+		job.SnapshotVer = 0
+		err = t.UpdateTable(schemaID, tblInfo)
+		return errors.Trace(err)
+	case model.StateWriteReorganization:
+		// reorganization -> public
+		reorgInfo, err := d.getReorgInfo(t, job)
+		if err != nil || reorgInfo.first {
+			// if we run reorg firstly, we should update the job snapshot version
+			// and then run the reorg next time.
+			return errors.Trace(err)
+		}
+
+		tbl, err := d.getTable(t, schemaID, tblInfo)
+		if err != nil {
+			return errors.Trace(err)
+		}
+
+		err = d.runReorgJob(func() error {
+			return d.backfillChangingColumn(tbl, oldCol, changingCol, changingIndices, reorgInfo)
+		})
+
+		if terror.ErrorEqual(err, errWaitReorgTimeout) {
+			// if timeout, we should return, check for the owner and re-wait job done.
+			return nil
+		}
+		if _, ok := errors.Cause(err).(*columnDataError); ok {
+			// The column data can't be converted, roll back the job and save the error
+			// to return it when the rollback is done.
+			log.Warnf("modify column %s err %v, roll back", oldName, err)
+			job.Args = []interface{}{newCol, oldName, pos, errors.Cause(err).Error()}
+			job.SchemaState = model.StateDeleteOnly
+			setColumnAndIndicesState(changingCol, changingIndices, model.StateDeleteOnly)
+			err = t.UpdateTable(schemaID, tblInfo)
+			return errors.Trace(err)
+		}
+		if err != nil {
+			return errors.Trace(err)
+		}
+
+		// Replace the origin column and indices with the changing ones, the origin ones are kept
+		// writable for the servers which still use them.
+		oldIndices := findColumnIndices(tblInfo, oldCol.Name)
+		oldColName := oldCol.Name
+		oldCol.Name = model.NewCIStr(changingColumnPrefix + oldColName.O)
+		oldCol.OriginID = changingCol.ID
+		renameIndexColumn(oldIndices, oldColName, oldCol.Name)
+		for _, idx := range oldIndices {
+			idx.Name = model.NewCIStr(changingIndexPrefix + idx.Name.O)
+		}
+		setColumnAndIndicesState(oldCol, oldIndices, model.StateWriteOnly)
+
+		changingColName := changingCol.Name
+		changingCol.Name = newCol.Name
+		changingCol.OriginID = 0
+		renameIndexColumn(changingIndices, changingColName, changingCol.Name)
+		for _, idx := range changingIndices {
+			idx.Name = model.NewCIStr(strings.TrimPrefix(idx.Name.O, changingIndexPrefix))
+		}
+		setColumnAndIndicesState(changingCol, changingIndices, model.StatePublic)
+
+		// Put the changing column in the position of the origin column first, then move it.
+		cols := make([]*model.ColumnInfo, 0, len(tblInfo.Columns))
+		for _, col := range tblInfo.Columns {
+			if col == oldCol {
+				cols = append(cols, changingCol)
+			} else if col != changingCol {
+				cols = append(cols, col)
+			}
+		}
+		tblInfo.Columns = append(cols, oldCol)
+		moveColumn(tblInfo, changingCol, pos)
+		resetColumnOffsets(tblInfo)
+
+		// The new column is public now, the job can't be cancelled when dropping the origin column.
+		job.SchemaState = model.StatePublic
+		err = t.UpdateTable(schemaID, tblInfo)
+		return errors.Trace(err)
+	default:
+		return errors.Errorf("invalid column state %v", changingCol.State)
+	}
+}
+
+func setColumnAndIndicesState(colInfo *model.ColumnInfo, indices []*model.IndexInfo, state model.SchemaState) {
+	colInfo.State = state
+	for _, idx := range indices {
+		idx.State = state
+	}
+}
+
+func (d *ddl) startModifyColumn(t *meta.Meta, job *model.Job, tblInfo *model.TableInfo, newCol *model.ColumnInfo, oldName model.CIStr, pos *ColumnPosition) error {
+	oldCol := findCol(tblInfo.Columns, oldName.L)
+	if oldCol == nil || oldCol.State != model.StatePublic {
+		job.State = model.JobCancelled
+		return errors.Errorf("column %s doesn't exist", oldName)
+	}
+	if newCol.Name.L != oldName.L && findCol(tblInfo.Columns, newCol.Name.L) != nil {
+		job.State = model.JobCancelled
+		return errors.Errorf("MODIFY COLUMN: column already exist %s", newCol.Name)
+	}
+	if pos.Type == ColumnPositionAfter {
+		c := findCol(tblInfo.Columns, pos.RelativeColumn)
+		if c == nil || c == oldCol {
+			job.State = model.JobCancelled
+			return errors.Errorf("No such column: %v", pos.RelativeColumn)
+		}
+	}
+
+	_, err := t.GenSchemaVersion()
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	if isLosslessChange(&oldCol.FieldType, &newCol.FieldType) {
+		// Only the column info is changed, the column keeps its ID and values.
+		renameIndexColumn(findColumnIndices(tblInfo, oldCol.Name), oldCol.Name, newCol.Name)
+		oldCol.Name = newCol.Name
+		oldCol.FieldType = newCol.FieldType
+		oldCol.DefaultValue = newCol.DefaultValue
+		moveColumn(tblInfo, oldCol, pos)
+		resetColumnOffsets(tblInfo)
+		if err = t.UpdateTable(job.SchemaID, tblInfo); err != nil {
+			return errors.Trace(err)
+		}
+
+		// finish this job
+		job.SchemaState = model.StatePublic
+		job.State = model.JobDone
+		return nil
+	}
+
+	// Add the changing column at the end of the columns, and the changing indices.
+	changingName := model.NewCIStr(changingColumnPrefix + newCol.Name.O)
+	if findCol(tblInfo.Columns, changingName.L) != nil {
+		job.State = model.JobCancelled
+		return errors.Errorf("MODIFY COLUMN: column already exist %s", changingName)
+	}
+	changingCol := newCol.Clone()
+	changingCol.Name = changingName
+	changingCol.Offset = len(tblInfo.Columns)
+	changingCol.OriginID = oldCol.ID
+	changingCol.State = model.StateDeleteOnly
+	tblInfo.Columns = append(tblInfo.Columns, changingCol)
+
+	for _, idx := range findColumnIndices(tblInfo, oldCol.Name) {
+		changingIdx := idx.Clone()
+		changingIdx.ID, err = t.GenGlobalID()
+		if err != nil {
+			return errors.Trace(err)
+		}
+		changingIdx.Name = model.NewCIStr(changingIndexPrefix + idx.Name.O)
+		// The changing index is rebuilt, the strings are encoded under the collations.
+		changingIdx.Collated = true
+		for _, ic := range changingIdx.Columns {
+			if ic.Name.L == oldCol.Name.L {
+				ic.Name = changingCol.Name
+				ic.Offset = changingCol.Offset
+			}
+		}
+		changingIdx.State = model.StateDeleteOnly
+		tblInfo.Indices = append(tblInfo.Indices, changingIdx)
+	}
+
+	// none -> delete only
+	job.SchemaState = model.StateDeleteOnly
+	err = t.UpdateTable(job.SchemaID, tblInfo)
+	return errors.Trace(err)
+}
+
+// dropOriginColumn drops the origin column and indices after they are replaced by the changing ones,
+// the schema state of the job is kept public.
+func (d *ddl) dropOriginColumn(t *meta.Meta, job *model.Job, tblInfo *model.TableInfo, newCol *model.ColumnInfo) error {
+	var oldCol *model.ColumnInfo
+	for _, col := range tblInfo.Columns {
+		if col.OriginID == newCol.ID {
+			oldCol = col
+		}
+	}
+	if oldCol == nil {
+		job.State = model.JobCancelled
+		return errors.Errorf("origin column of %s doesn't exist", newCol.Name)
+	}
+	oldIndices := findColumnIndices(tblInfo, oldCol.Name)
+
+	var err error
+	switch oldCol.State {
+	case model.StateWriteOnly:
+		// write only -> delete only
+		setColumnAndIndicesState(oldCol, oldIndices, model.StateDeleteOnly)
+		err = t.UpdateTable(job.SchemaID, tblInfo)
+		return errors.Trace(err)
+	case model.StateDeleteOnly:
+		// delete only -> reorganization
+		setColumnAndIndicesState(oldCol, oldIndices, model.StateDeleteReorganization)
+		// initialize SnapshotVer to 0 and remove the handle of the previous reorganization.
+		job.SnapshotVer = 0
+		if err = t.RemoveDDLReorgHandle(job); err != nil {
+			return errors.Trace(err)
+		}
+		err = t.UpdateTable(job.SchemaID, tblInfo)
+		return errors.Trace(err)
+	case model.StateDeleteReorganization:
+		// reorganization -> absent
+		done, err := d.removeColumnAndIndices(t, job, tblInfo, oldCol, oldIndices)
+		if err != nil || !done {
+			return errors.Trace(err)
+		}
+
+		// finish this job
+		job.SchemaState = model.StatePublic
+		job.State = model.JobDone
+		return nil
+	default:
+		return errors.Errorf("invalid column state %v", oldCol.State)
+	}
+}
+
+// rollbackAddedColumn drops the column and indices added by the job when the job is cancelled or the
+// column data can't be converted, the job is cancelled with the error message at last.
+func (d *ddl) rollbackAddedColumn(t *meta.Meta, job *model.Job, tblInfo *model.TableInfo, changingCol *model.ColumnInfo, changingIndices []*model.IndexInfo, reorgErr string) error {
+	var err error
+	switch changingCol.State {
+	case model.StateWriteOnly, model.StateWriteReorganization:
+		// write only or reorganization -> delete only
+		job.SchemaState = model.StateDeleteOnly
+		setColumnAndIndicesState(changingCol, changingIndices, model.StateDeleteOnly)
+		err = t.UpdateTable(job.SchemaID, tblInfo)
+		return errors.Trace(err)
+	case model.StateDeleteOnly:
+		// delete only -> reorganization
+		job.SchemaState = model.StateDeleteReorganization
+		setColumnAndIndicesState(changingCol, changingIndices, model.StateDeleteReorganization)
+		// initialize SnapshotVer to 0 and remove the handle of the previous reorganization.
+		job.SnapshotVer = 0
+		if err = t.RemoveDDLReorgHandle(job); err != nil {
+			return errors.Trace(err)
+		}
+		err = t.UpdateTable(job.SchemaID, tblInfo)
+		return errors.Trace(err)
+	case model.StateDeleteReorganization:
+		// reorganization -> absent
+		done, err := d.removeColumnAndIndices(t, job, tblInfo, changingCol, changingIndices)
+		if err != nil || !done {
+			return errors.Trace(err)
+		}
+
+		job.SchemaState = model.StateNone
+		job.State = model.JobCancelled
+		return errors.New(reorgErr)
+	default:
+		return errors.Errorf("invalid column state %v", changingCol.State)
+	}
+}
+
+// removeColumnAndIndices drops the data of the column and indices in delete reorganization state,
+// then removes them from the table, it returns false if the reorganization is not done.
+func (d *ddl) removeColumnAndIndices(t *meta.Meta, job *model.Job, tblInfo *model.TableInfo, colInfo *model.ColumnInfo, indices []*model.IndexInfo) (bool, error) {
+	reorgInfo, err := d.getReorgInfo(t, job)
+	if err != nil || reorgInfo.first {
+		// if we run reorg firstly, we should update the job snapshot version
+		// and then run the reorg next time.
+		return false, errors.Trace(err)
+	}
+
+	tbl, err := d.getTable(t, job.SchemaID, tblInfo)
+	if err != nil {
+		return false, errors.Trace(err)
+	}
+
+	err = d.runReorgJob(func() error {
+		if err1 := d.dropTableColumn(tbl, colInfo, reorgInfo); err1 != nil {
+			return errors.Trace(err1)
+		}
+		for _, idx := range indices {
+			if err1 := d.dropTableIndex(tbl, idx); err1 != nil {
+				return errors.Trace(err1)
+			}
+		}
+		return nil
+	})
+
+	if terror.ErrorEqual(err, errWaitReorgTimeout) {
+		// if timeout, we should return, check for the owner and re-wait job done.
+		return false, nil
+	}
+	if err != nil {
+		return false, errors.Trace(err)
+	}
+
+	// all reorganization jobs done, remove the column and indices.
+	newColumns := make([]*model.ColumnInfo, 0, len(tblInfo.Columns))
+	for _, col := range tblInfo.Columns {
+		if col != colInfo {
+			newColumns = append(newColumns, col)
+		}
+	}
+	tblInfo.Columns = newColumns
+	dropped := make(map[*model.IndexInfo]bool, len(indices))
+	for _, idx := range indices {
+		dropped[idx] = true
+	}
+	newIndices := make([]*model.IndexInfo, 0, len(tblInfo.Indices))
+	for _, idx := range tblInfo.Indices {
+		if !dropped[idx] {
+			newIndices = append(newIndices, idx)
+		}
+	}
+	tblInfo.Indices = newIndices
+	resetColumnOffsets(tblInfo)
+
+	err = t.UpdateTable(job.SchemaID, tblInfo)
+	return err == nil, errors.Trace(err)
+}
+
+// How to backfill the changing column data in reorganization state?
+//  1. Generate a snapshot with special version.
+//  2. Traverse the snapshot, get every row in the table.
+//  3. For one row, if the row has been already deleted, skip to next row.
+//  4. If not deleted, convert the origin column value to the changing column type, write it to
+//     the changing column and build the changing indices if they don't exist.
+func (d *ddl) backfillChangingColumn(t table.Table, oldCol, changingCol *model.ColumnInfo, indices []*model.IndexInfo, reorgInfo *reorgInfo) error {
+	seekHandle := reorgInfo.Handle
+	version := reorgInfo.SnapshotVer
+
+	for {
+		handles, err := d.getSnapshotRows(t, version, seekHandle)
+		if err != nil {
+			return errors.Trace(err)
+		} else if len(handles) == 0 {
+			return nil
+		}
+
+		seekHandle = handles[len(handles)-1] + 1
+		err = d.backfillChangingColumnData(t, oldCol, changingCol, indices, handles, reorgInfo)
+		if err != nil {
+			return errors.Trace(err)
+		}
+	}
+}
+
+func (d *ddl) backfillChangingColumnData(t table.Table, oldCol, changingCol *model.ColumnInfo, indices []*model.IndexInfo, handles []int64, reorgInfo *reorgInfo) error {
+	col := &column.Col{ColumnInfo: *changingCol}
+	kvXs := make([]kv.Index, 0, len(indices))
+	for _, idx := range indices {
+		kvXs = append(kvXs, tables.NewIndex(t.IndexPrefix(), t.Meta().Columns, idx))
+	}
+
+	for _, handle := range handles {
+		log.Debug("backfill changing column...", handle)
+
+		err := kv.RunInNewTxn(d.store, true, func(txn kv.Transaction) error {
+			if err := d.isReorgRunnable(txn); err != nil {
+				return errors.Trace(err)
+			}
+
+			// First check if row exists.
+			exist, err := checkRowExist(txn, t, handle)
+			if err != nil {
+				return errors.Trace(err)
+			} else if !exist {
+				// If row doesn't exist, skip it.
+				return nil
+			}
+
+			row, err := t.RowWithCols(txn, handle, t.Cols())
+			if err != nil {
+				return errors.Trace(err)
+			}
+
+			v, err := types.Convert(row[oldCol.Offset], &changingCol.FieldType)
+			if err != nil {
+				return errors.Trace(&columnDataError{err: err})
+			}
+			if v == nil && mysql.HasNotNullFlag(changingCol.Flag) {
+				return errors.Trace(&columnDataError{err: errors.Errorf("Invalid use of NULL value")})
+			}
+
+			err = lockRow(txn, t, handle)
+			if err != nil {
+				return errors.Trace(err)
+			}
+
+			err = t.SetRecordColValue(txn, handle, col, v)
+			if err != nil {
+				return errors.Trace(err)
+			}
+
+			for i, idx := range indices {
+				vals := make([]interface{}, 0, len(idx.Columns))
+				for _, ic := range idx.Columns {
+					if ic.Offset == changingCol.Offset {
+						vals = append(vals, v)
+					} else {
+						vals = append(vals, row[ic.Offset])
+					}
+				}
+
+				exist, _, err = kvXs[i].Exist(txn, vals, handle)
+				if terror.ErrorEqual(err, kv.ErrKeyExists) {
+					name := strings.TrimPrefix(idx.Name.O, changingIndexPrefix)
+					return errors.Trace(&columnDataError{err: errors.Errorf("Duplicate entry for key '%s'", name)})
+				} else if err != nil {
+					return errors.Trace(err)
+				} else if exist {
+					continue
+				}
+
+				if err = kvXs[i].Create(txn, vals, handle); err != nil {
+					return errors.Trace(err)
+				}
+			}
+
+			return errors.Trace(reorgInfo.UpdateHandle(txn, handle))
+		})
+
+		if err != nil {
+			return errors.Trace(err)
+		}
+	}
+
+	return nil
+}
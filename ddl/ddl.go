@@ -58,6 +58,7 @@ type DDL interface {
 	DropIndex(ctx context.Context, tableIdent table.Ident, indexName model.CIStr) error
 	GetInformationSchema() infoschema.InfoSchema
 	AlterTable(ctx context.Context, tableIdent table.Ident, spec []*AlterSpecification) error
+	RenameTables(ctx context.Context, oldIdents, newIdents []table.Ident) error
 	// SetLease will reset the lease time for online DDL change, it is a very dangerous function and you must guarantee that
 	// all servers have the same lease time.
 	SetLease(lease time.Duration)
@@ -89,6 +90,8 @@ type ddl struct {
 	// TODO: now we use goroutine to simulate reorganization jobs, later we may
 	// use a persistent job list.
 	reorgDoneCh chan error
+	// reorgCancelled is set to 1 to stop the running reorganization when its job is cancelled.
+	reorgCancelled int32
 
 	quitCh chan struct{}
 	wait   sync.WaitGroup
@@ -357,6 +360,15 @@ func (d *ddl) buildColumnAndConstraint(offset int, colDef *coldef.ColumnDef) (*c
 	if len(colDef.Tp.Charset) == 0 {
 		switch colDef.Tp.Tp {
 		case mysql.TypeString, mysql.TypeVarchar, mysql.TypeVarString, mysql.TypeBlob, mysql.TypeTinyBlob, mysql.TypeMediumBlob, mysql.TypeLongBlob:
+			if len(colDef.Tp.Collate) != 0 {
+				// The charset is decided by the collation.
+				c, err := charset.GetCollationByName(colDef.Tp.Collate)
+				if err != nil {
+					return nil, nil, errors.Trace(err)
+				}
+				colDef.Tp.Charset, colDef.Tp.Collate = c.CharsetName, c.Name
+				break
+			}
 			colDef.Tp.Charset, colDef.Tp.Collate = getDefaultCharsetAndCollate()
 		default:
 			colDef.Tp.Charset = charset.CharsetBin
@@ -423,7 +435,8 @@ func checkConstraintNames(constraints []*coldef.TableConstraint) error {
 
 func (d *ddl) buildTableInfo(tableName model.CIStr, cols []*column.Col, constraints []*coldef.TableConstraint) (tbInfo *model.TableInfo, err error) {
 	tbInfo = &model.TableInfo{
-		Name: tableName,
+		Name:      tableName,
+		RowFormat: model.RowFormatSingleKV,
 	}
 	tbInfo.ID, err = d.genGlobalID()
 	if err != nil {
@@ -448,9 +461,10 @@ func (d *ddl) buildTableInfo(tableName model.CIStr, cols []*column.Col, constrai
 			})
 		}
 		idxInfo := &model.IndexInfo{
-			Name:    model.NewCIStr(constr.ConstrName),
-			Columns: indexColumns,
-			State:   model.StatePublic,
+			Name:     model.NewCIStr(constr.ConstrName),
+			Columns:  indexColumns,
+			State:    model.StatePublic,
+			Collated: true,
 		}
 		switch constr.Tp {
 		case coldef.ConstrPrimaryKey:
@@ -523,6 +537,12 @@ func (d *ddl) AlterTable(ctx context.Context, ident table.Ident, specs []*AlterS
 			err = d.DropColumn(ctx, ident, model.NewCIStr(spec.Name))
 		case AlterDropIndex:
 			err = d.DropIndex(ctx, ident, model.NewCIStr(spec.Name))
+		case AlterTableOpt:
+			err = d.alterTableOptions(ctx, ident, spec.TableOpts)
+		case AlterRenameTable:
+			err = d.RenameTables(ctx, []table.Ident{ident}, []table.Ident{spec.NewTable.Full(ctx)})
+		case AlterModifyColumn, AlterChangeColumn:
+			err = d.ModifyColumn(ctx, ident, spec)
 		case AlterAddConstr:
 			constr := spec.Constraint
 			switch spec.Constraint.Tp {
@@ -633,6 +653,135 @@ func (d *ddl) DropColumn(ctx context.Context, ti table.Ident, colName model.CISt
 	return errors.Trace(err)
 }
 
+// ModifyColumn will change the definition of a column of the table, the column is renamed if the name
+// of the new column definition is different from spec.Name.
+func (d *ddl) ModifyColumn(ctx context.Context, ti table.Ident, spec *AlterSpecification) error {
+	is := d.infoHandle.Get()
+	schema, ok := is.SchemaByName(ti.Schema)
+	if !ok {
+		return errors.Trace(terror.DatabaseNotExists)
+	}
+
+	t, err := is.TableByName(ti.Schema, ti.Name)
+	if err != nil {
+		return errors.Trace(ErrNotExists)
+	}
+
+	// Check whether the modified column has existed.
+	oldName := model.NewCIStr(spec.Name)
+	oldCol := column.FindCol(t.Cols(), oldName.L)
+	if oldCol == nil {
+		return errors.Errorf("column %s doesn’t exist", oldName)
+	}
+
+	colName := spec.Column.Name
+	if oldName.L != strings.ToLower(colName) && column.FindCol(t.Cols(), colName) != nil {
+		return errors.Errorf("column %s already exists", colName)
+	}
+
+	// Check whether the modified column constraints are supported.
+	for _, constraint := range spec.Column.Constraints {
+		switch constraint.Tp {
+		case coldef.ConstrAutoIncrement:
+			if !mysql.HasAutoIncrementFlag(oldCol.Flag) {
+				return errors.Errorf("unsupported modify column constraint - %s", constraint)
+			}
+		case coldef.ConstrForeignKey, coldef.ConstrPrimaryKey, coldef.ConstrUniq, coldef.ConstrUniqKey,
+			coldef.ConstrIndex, coldef.ConstrUniqIndex, coldef.ConstrKey, coldef.ConstrFulltext:
+			return errors.Errorf("unsupported modify column constraint - %s", constraint)
+		}
+	}
+
+	pos := spec.Position
+	if pos == nil {
+		pos = &ColumnPosition{}
+	}
+	if pos.Type == ColumnPositionAfter {
+		c := column.FindCol(t.Cols(), pos.RelativeColumn)
+		if c == nil || c.Offset == oldCol.Offset {
+			return errors.Errorf("No such column: %v", pos.RelativeColumn)
+		}
+	}
+
+	// ignore table constraints now, the new column gets a new column ID, which is used if the
+	// column data must be converted.
+	col, _, err := d.buildColumnAndConstraint(oldCol.Offset, spec.Column)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	// Keep the index flags of the column, the indices are changed with the column.
+	col.Flag |= oldCol.Flag & (mysql.PriKeyFlag | mysql.UniqueKeyFlag | mysql.MultipleKeyFlag)
+
+	job := &model.Job{
+		SchemaID: schema.ID,
+		TableID:  t.Meta().ID,
+		Type:     model.ActionModifyColumn,
+		Args:     []interface{}{&col.ColumnInfo, oldName, pos, ""},
+	}
+
+	err = d.startJob(ctx, job)
+	err = d.hook.OnChanged(err)
+	return errors.Trace(err)
+}
+
+func (d *ddl) alterTableOptions(ctx context.Context, ti table.Ident, opts []*coldef.TableOpt) error {
+	for _, opt := range opts {
+		switch opt.Tp {
+		case coldef.TblOptRowFormat:
+			format, err := getRowFormat(opt.StrValue)
+			if err != nil {
+				return errors.Trace(err)
+			}
+			if err = d.ConvertRowFormat(ctx, ti, format); err != nil {
+				return errors.Trace(err)
+			}
+		default:
+			// nothing to do now.
+		}
+	}
+
+	return nil
+}
+
+// getRowFormat gets the row format for the MySQL ROW_FORMAT table option.
+// REDUNDANT, the oldest format in MySQL, stores every column as a separate KV pair,
+// others store the whole row in one KV pair.
+func getRowFormat(name string) (model.RowFormat, error) {
+	switch strings.ToUpper(name) {
+	case "REDUNDANT":
+		return model.RowFormatColumn, nil
+	case "DEFAULT", "DYNAMIC", "FIXED", "COMPRESSED", "COMPACT":
+		return model.RowFormatSingleKV, nil
+	default:
+		return model.RowFormatColumn, errors.Errorf("unknown row format %s", name)
+	}
+}
+
+// ConvertRowFormat converts all the rows of the table to the row format.
+func (d *ddl) ConvertRowFormat(ctx context.Context, ti table.Ident, format model.RowFormat) error {
+	is := d.infoHandle.Get()
+	schema, ok := is.SchemaByName(ti.Schema)
+	if !ok {
+		return errors.Trace(terror.DatabaseNotExists)
+	}
+
+	t, err := is.TableByName(ti.Schema, ti.Name)
+	if err != nil {
+		return errors.Trace(ErrNotExists)
+	}
+
+	job := &model.Job{
+		SchemaID: schema.ID,
+		TableID:  t.Meta().ID,
+		Type:     model.ActionConvertRowFormat,
+		Args:     []interface{}{format},
+	}
+
+	err = d.startJob(ctx, job)
+	err = d.hook.OnChanged(err)
+	return errors.Trace(err)
+}
+
 // DropTable will proceed even if some table in the list does not exists.
 func (d *ddl) DropTable(ctx context.Context, ti table.Ident) (err error) {
 	is := d.GetInformationSchema()
@@ -657,6 +806,85 @@ func (d *ddl) DropTable(ctx context.Context, ti table.Ident) (err error) {
 	return errors.Trace(err)
 }
 
+// renameTableArg is the argument of the rename table job for renaming one table.
+type renameTableArg struct {
+	OldSchemaID int64       `json:"old_schema_id"`
+	NewSchemaID int64       `json:"new_schema_id"`
+	TableID     int64       `json:"table_id"`
+	NewName     model.CIStr `json:"new_name"`
+}
+
+// RenameTables renames oldIdents[i] to newIdents[i] in order in one job, so all the tables
+// are renamed atomically, and two tables can be swapped through a temporary name.
+// A table is moved to another database if the new schema is different.
+func (d *ddl) RenameTables(ctx context.Context, oldIdents, newIdents []table.Ident) error {
+	if len(oldIdents) == 0 || len(oldIdents) != len(newIdents) {
+		return errors.Errorf("invalid rename tables %v to %v", oldIdents, newIdents)
+	}
+
+	is := d.GetInformationSchema()
+	// renamed holds the tables renamed by the former renames in the list,
+	// the ID is 0 if the name is renamed away.
+	renamed := make(map[string]int64)
+	args := make([]*renameTableArg, 0, len(oldIdents))
+	for i, oldIdent := range oldIdents {
+		newIdent := newIdents[i]
+		oldSchema, ok := is.SchemaByName(oldIdent.Schema)
+		if !ok {
+			return terror.DatabaseNotExists.Gen("database %s not exists", oldIdent.Schema)
+		}
+		newSchema, ok := is.SchemaByName(newIdent.Schema)
+		if !ok {
+			return terror.DatabaseNotExists.Gen("database %s not exists", newIdent.Schema)
+		}
+
+		tableID := renamedTableID(is, renamed, oldIdent)
+		if tableID == 0 {
+			return errors.Trace(ErrNotExists)
+		}
+		if renamedTableID(is, renamed, newIdent) != 0 {
+			return errors.Trace(ErrExists)
+		}
+
+		renamed[renameKey(oldIdent)] = 0
+		renamed[renameKey(newIdent)] = tableID
+		args = append(args, &renameTableArg{
+			OldSchemaID: oldSchema.ID,
+			NewSchemaID: newSchema.ID,
+			TableID:     tableID,
+			NewName:     newIdent.Name,
+		})
+	}
+
+	job := &model.Job{
+		SchemaID: args[0].OldSchemaID,
+		TableID:  args[0].TableID,
+		Type:     model.ActionRenameTable,
+		Args:     []interface{}{args},
+	}
+
+	err := d.startJob(ctx, job)
+	err = d.hook.OnChanged(err)
+	return errors.Trace(err)
+}
+
+func renameKey(ti table.Ident) string {
+	return ti.Schema.L + "." + ti.Name.L
+}
+
+// renamedTableID returns the ID of the table ti after the renames in renamed,
+// 0 is returned if the table doesn't exist.
+func renamedTableID(is infoschema.InfoSchema, renamed map[string]int64, ti table.Ident) int64 {
+	if id, ok := renamed[renameKey(ti)]; ok {
+		return id
+	}
+	t, err := is.TableByName(ti.Schema, ti.Name)
+	if err != nil {
+		return 0
+	}
+	return t.Meta().ID
+}
+
 func (d *ddl) CreateIndex(ctx context.Context, ti table.Ident, unique bool, indexName model.CIStr, idxColNames []*coldef.IndexColName) error {
 	is := d.infoHandle.Get()
 	schema, ok := is.SchemaByName(ti.Schema)
@@ -29,9 +29,11 @@ import (
 	"github.com/pingcap/tidb/context"
 	"github.com/pingcap/tidb/kv"
 	"github.com/pingcap/tidb/model"
+	"github.com/pingcap/tidb/mysql"
 	"github.com/pingcap/tidb/sessionctx"
 	"github.com/pingcap/tidb/table"
 	"github.com/pingcap/tidb/terror"
+	"github.com/pingcap/tidb/util"
 	"github.com/pingcap/tidb/util/types"
 )
 
@@ -75,6 +77,7 @@ func (s *testDBSuite) SetUpSuite(c *C) {
 
 	s.mustExec(c, "create table t1 (c1 int, c2 int, c3 int, primary key(c1))")
 	s.mustExec(c, "create table t2 (c1 int, c2 int, c3 int)")
+	s.mustExec(c, "create table t3 (c1 int, c2 int, c3 int, primary key(c1))")
 
 	// set proper schema lease
 	s.lease = 500 * time.Millisecond
@@ -465,9 +468,9 @@ LOOP:
 	// check c4 does not exist
 	err = t.IterRecords(txn, t.FirstKey(), t.Cols(), func(h int64, data []interface{}, cols []*column.Col) (bool, error) {
 		i++
-		k := t.RecordKey(h, col)
-		_, err1 := txn.Get([]byte(k))
-		c.Assert(terror.ErrorEqual(err1, kv.ErrNotExist), IsTrue)
+		exist, err1 := t.ColValueExists(txn, h, col)
+		c.Assert(err1, IsNil)
+		c.Assert(exist, IsFalse)
 		return true, nil
 	})
 	c.Assert(err, IsNil)
@@ -475,6 +478,186 @@ LOOP:
 	c.Assert(i, LessEqual, num+step)
 }
 
+func (s *testDBSuite) TestRowFormat(c *C) {
+	c.Assert(s.testGetTable(c, "t3").Meta().RowFormat, Equals, model.RowFormatSingleKV)
+
+	num := 100
+	for i := 0; i < num; i++ {
+		s.mustExec(c, "insert into t3 values (?, ?, ?)", i, i, i)
+	}
+
+	num = s.testConvertRowFormat(c, "redundant", num)
+	t := s.testGetTable(c, "t3")
+	c.Assert(t.Meta().RowFormat, Equals, model.RowFormatColumn)
+	// Every row has a lock key and a key for each column now.
+	c.Assert(s.countRecordKeys(c, t), Equals, 4*num)
+
+	num = s.testConvertRowFormat(c, "compact", num)
+	t = s.testGetTable(c, "t3")
+	c.Assert(t.Meta().RowFormat, Equals, model.RowFormatSingleKV)
+	// Every row has only one key now.
+	c.Assert(s.countRecordKeys(c, t), Equals, num)
+
+	_, err := s.db.Exec("alter table t3 row_format = unknown")
+	c.Assert(err, NotNil)
+}
+
+func (s *testDBSuite) TestRenameTable(c *C) {
+	s.mustExec(c, "create table rename_t1 (c1 int auto_increment primary key, c2 int)")
+	s.mustExec(c, "create table rename_t2 (c1 int)")
+	s.mustExec(c, "insert into rename_t1 (c2) values (1), (2), (3)")
+	s.mustExec(c, "insert into rename_t2 values (10)")
+	t1ID := s.testGetTable(c, "rename_t1").Meta().ID
+
+	s.mustExec(c, "rename table rename_t1 to rename_t3")
+	c.Assert(s.testGetTable(c, "rename_t3").Meta().ID, Equals, t1ID)
+	_, err := s.db.Exec("select * from rename_t1")
+	c.Assert(err, NotNil)
+
+	// swap the tables.
+	s.mustExec(c, "rename table rename_t3 to rename_tmp, rename_t2 to rename_t3, rename_tmp to rename_t2")
+	c.Assert(s.testGetTable(c, "rename_t2").Meta().ID, Equals, t1ID)
+	matchRows(c, s.mustQuery(c, "select c2 from rename_t2"), [][]interface{}{{1}, {2}, {3}})
+	matchRows(c, s.mustQuery(c, "select c1 from rename_t3"), [][]interface{}{{10}})
+
+	// the renames in one statement are all done or none.
+	_, err = s.db.Exec("rename table rename_t2 to rename_t4, rename_t3 to rename_t2, rename_xxx to rename_t5")
+	c.Assert(err, NotNil)
+	_, err = s.db.Exec("rename table rename_t2 to rename_t3")
+	c.Assert(err, NotNil)
+	_, err = s.db.Exec("rename table rename_t2 to test_rename_xxx.rename_t2")
+	c.Assert(err, NotNil)
+	c.Assert(s.testGetTable(c, "rename_t2").Meta().ID, Equals, t1ID)
+
+	// move the table to another database, the auto increment ID goes on.
+	s.mustExec(c, "create database test_rename_db")
+	s.mustExec(c, "rename table rename_t2 to test_rename_db.rename_t1")
+	s.mustExec(c, "insert into test_rename_db.rename_t1 (c2) values (4)")
+	matchRows(c, s.mustQuery(c, "select count(*) from test_rename_db.rename_t1 where c1 > 3"), [][]interface{}{{1}})
+	matchRows(c, s.mustQuery(c, "select count(*) from test_rename_db.rename_t1"), [][]interface{}{{4}})
+
+	// move it back with alter table, the new name is in the current database.
+	s.mustExec(c, "alter table test_rename_db.rename_t1 rename to rename_t1")
+	c.Assert(s.testGetTable(c, "rename_t1").Meta().ID, Equals, t1ID)
+	matchRows(c, s.mustQuery(c, "select count(*) from rename_t1"), [][]interface{}{{4}})
+	s.mustExec(c, "alter table rename_t1 rename rename_t2")
+	s.mustExec(c, "insert into rename_t2 (c2) values (5)")
+	matchRows(c, s.mustQuery(c, "select count(distinct c1) from rename_t2"), [][]interface{}{{5}})
+
+	s.mustExec(c, "drop database test_rename_db")
+	s.mustExec(c, "drop table rename_t2, rename_t3")
+}
+
+func (s *testDBSuite) TestModifyColumn(c *C) {
+	s.mustExec(c, "create table modify_t (c1 int, c2 int, c3 int, primary key(c1), key c2 (c2))")
+	s.mustExec(c, "alter table modify_t row_format = redundant")
+	num := 100
+	for i := 0; i < num; i++ {
+		s.mustExec(c, "insert into modify_t values (?, ?, ?)", i, i, i)
+	}
+	indexID := s.testGetTable(c, "modify_t").Meta().Indices[1].ID
+
+	done := make(chan struct{}, 1)
+	go func() {
+		s.mustExec(c, "alter table modify_t modify c2 varchar(20) first")
+		done <- struct{}{}
+	}()
+
+	ticker := time.NewTicker(s.lease / 2)
+	defer ticker.Stop()
+	step := 10
+LOOP:
+	for {
+		select {
+		case <-done:
+			break LOOP
+		case <-ticker.C:
+			// update and delete some rows, and add some data
+			for i := num; i < num+step; i++ {
+				n := rand.Intn(num)
+				s.mustExec(c, "update modify_t set c3 = c3 + 1 where c1 = ?", n)
+				n = rand.Intn(num)
+				s.mustExec(c, "delete from modify_t where c1 = ?", n)
+				s.mustExec(c, "insert into modify_t (c1, c2, c3) values (?, ?, ?)", n, n, n)
+				s.mustExec(c, "insert into modify_t (c1, c2, c3) values (?, ?, ?)", i, i, i)
+			}
+			num += step
+		}
+	}
+
+	t := s.testGetTable(c, "modify_t")
+	c.Assert(t.Cols(), HasLen, 3)
+	c.Assert(t.Cols()[0].Name.O, Equals, "c2")
+	c.Assert(t.Cols()[0].Tp, Equals, mysql.TypeVarchar)
+	c.Assert(t.Meta().Columns, HasLen, 3)
+	c.Assert(t.Meta().Indices, HasLen, 2)
+	c.Assert(t.Meta().Indices[1].Name.O, Equals, "c2")
+	c.Assert(t.Meta().Indices[1].ID, Not(Equals), indexID)
+	// Every row has a lock key and a key for each column, the origin column data is removed.
+	c.Assert(s.countRecordKeys(c, t), Equals, 4*num)
+
+	rows := s.mustQuery(c, "select count(*) from modify_t where c2 = c1 and (c3 >= c1)")
+	matchRows(c, rows, [][]interface{}{{num}})
+	for i := 0; i < num; i += 7 {
+		rows = s.mustQuery(c, "select c1 from modify_t where c2 = ?", fmt.Sprintf("%d", i))
+		matchRows(c, rows, [][]interface{}{{i}})
+	}
+
+	s.mustExec(c, "drop table modify_t")
+}
+
+func (s *testDBSuite) testConvertRowFormat(c *C, format string, num int) int {
+	done := make(chan struct{}, 1)
+
+	go func() {
+		s.mustExec(c, "alter table t3 row_format = "+format)
+		done <- struct{}{}
+	}()
+
+	ticker := time.NewTicker(s.lease / 2)
+	defer ticker.Stop()
+	step := 10
+LOOP:
+	for {
+		select {
+		case <-done:
+			break LOOP
+		case <-ticker.C:
+			// update and delete some rows, and add some data
+			for i := num; i < num+step; i++ {
+				n := rand.Intn(num)
+				s.mustExec(c, "update t3 set c3 = c2 + 1 where c1 = ?", n)
+				n = rand.Intn(num)
+				s.mustExec(c, "delete from t3 where c1 = ?", n)
+				s.mustExec(c, "insert into t3 values (?, ?, ?)", n, n, n)
+				s.mustExec(c, "insert into t3 values (?, ?, ?)", i, i, i)
+			}
+			num += step
+		}
+	}
+
+	rows := s.mustQuery(c, "select count(*) from t3")
+	matchRows(c, rows, [][]interface{}{{num}})
+	rows = s.mustQuery(c, "select count(*) from t3 where c1 = c2 and (c3 = c2 or c3 = c2 + 1)")
+	matchRows(c, rows, [][]interface{}{{num}})
+	return num
+}
+
+func (s *testDBSuite) countRecordKeys(c *C, t table.Table) int {
+	ctx := s.s.(context.Context)
+	txn, err := ctx.GetTxn(true)
+	c.Assert(err, IsNil)
+	defer ctx.FinishTxn(true)
+
+	cnt := 0
+	err = util.ScanMetaWithPrefix(txn, t.KeyPrefix(), func(k, v []byte) bool {
+		cnt++
+		return true
+	})
+	c.Assert(err, IsNil)
+	return cnt
+}
+
 func (s *testDBSuite) mustExec(c *C, query string, args ...interface{}) sql.Result {
 	r, err := s.db.Exec(query, args...)
 	c.Assert(err, IsNil, Commentf("query %s, args %v", query, args))
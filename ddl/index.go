@@ -19,6 +19,7 @@ import (
 
 	"github.com/juju/errors"
 	"github.com/ngaut/log"
+	"github.com/pingcap/tidb/column"
 	"github.com/pingcap/tidb/kv"
 	"github.com/pingcap/tidb/meta"
 	"github.com/pingcap/tidb/model"
@@ -53,11 +54,12 @@ func buildIndexInfo(tblInfo *model.TableInfo, unique bool, indexName model.CIStr
 	}
 	// create index info
 	idxInfo := &model.IndexInfo{
-		ID:      indexID,
-		Name:    indexName,
-		Columns: idxColumns,
-		Unique:  unique,
-		State:   model.StateNone,
+		ID:       indexID,
+		Name:     indexName,
+		Columns:  idxColumns,
+		Unique:   unique,
+		State:    model.StateNone,
+		Collated: true,
 	}
 	return idxInfo, nil
 }
@@ -143,6 +145,10 @@ func (d *ddl) onCreateIndex(t *meta.Meta, job *model.Job) error {
 		return errors.Trace(err)
 	}
 
+	if job.IsRollingBack() {
+		return d.rollbackAddIndex(t, job, tblInfo, indexInfo)
+	}
+
 	switch indexInfo.State {
 	case model.StateNone:
 		// none -> delete only
@@ -207,6 +213,61 @@ func (d *ddl) onCreateIndex(t *meta.Meta, job *model.Job) error {
 	}
 }
 
+// rollbackAddIndex drops the index added by the cancelled job like dropping an index.
+func (d *ddl) rollbackAddIndex(t *meta.Meta, job *model.Job, tblInfo *model.TableInfo, indexInfo *model.IndexInfo) error {
+	var err error
+	switch indexInfo.State {
+	case model.StateWriteOnly, model.StateWriteReorganization:
+		// write only or reorganization -> delete only
+		job.SchemaState = model.StateDeleteOnly
+		indexInfo.State = model.StateDeleteOnly
+		err = t.UpdateTable(job.SchemaID, tblInfo)
+		return errors.Trace(err)
+	case model.StateDeleteOnly:
+		// delete only -> reorganization
+		job.SchemaState = model.StateDeleteReorganization
+		indexInfo.State = model.StateDeleteReorganization
+		err = t.UpdateTable(job.SchemaID, tblInfo)
+		return errors.Trace(err)
+	case model.StateDeleteReorganization:
+		// reorganization -> absent
+		tbl, err := d.getTable(t, job.SchemaID, tblInfo)
+		if err != nil {
+			return errors.Trace(err)
+		}
+
+		err = d.runReorgJob(func() error {
+			return d.dropTableIndex(tbl, indexInfo)
+		})
+
+		if terror.ErrorEqual(err, errWaitReorgTimeout) {
+			// if timeout, we should return, check for the owner and re-wait job done.
+			return nil
+		}
+		if err != nil {
+			return errors.Trace(err)
+		}
+
+		// all reorganization jobs done, remove this index
+		newIndices := make([]*model.IndexInfo, 0, len(tblInfo.Indices))
+		for _, idx := range tblInfo.Indices {
+			if idx != indexInfo {
+				newIndices = append(newIndices, idx)
+			}
+		}
+		tblInfo.Indices = newIndices
+		if err = t.UpdateTable(job.SchemaID, tblInfo); err != nil {
+			return errors.Trace(err)
+		}
+
+		job.SchemaState = model.StateNone
+		job.State = model.JobCancelled
+		return errors.Trace(ErrCancelledDDLJob)
+	default:
+		return errors.Errorf("invalid index state %v", indexInfo.State)
+	}
+}
+
 func (d *ddl) onDropIndex(t *meta.Meta, job *model.Job) error {
 	schemaID := job.SchemaID
 	tblInfo, err := d.getTableInfo(t, job)
@@ -314,21 +375,19 @@ func checkRowExist(txn kv.Transaction, t table.Table, handle int64) (bool, error
 func fetchRowColVals(txn kv.Transaction, t table.Table, handle int64, indexInfo *model.IndexInfo) ([]interface{}, error) {
 	// fetch datas
 	cols := t.Cols()
-	var vals []interface{}
+	idxCols := make([]*column.Col, 0, len(indexInfo.Columns))
 	for _, v := range indexInfo.Columns {
-		var val interface{}
+		idxCols = append(idxCols, cols[v.Offset])
+	}
 
-		col := cols[v.Offset]
-		k := t.RecordKey(handle, col)
-		data, err := txn.Get([]byte(k))
-		if err != nil {
-			return nil, errors.Trace(err)
-		}
-		val, err = t.DecodeValue(data, col)
-		if err != nil {
-			return nil, errors.Trace(err)
-		}
-		vals = append(vals, val)
+	row, err := t.RowWithCols(txn, handle, idxCols)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	vals := make([]interface{}, 0, len(idxCols))
+	for _, col := range idxCols {
+		vals = append(vals, row[col.Offset])
 	}
 
 	return vals, nil
@@ -415,15 +474,11 @@ func (d *ddl) getSnapshotRows(t table.Table, version uint64, seekHandle int64) (
 }
 
 func lockRow(txn kv.Transaction, t table.Table, h int64) error {
-	// Get row lock key
-	lockKey := t.RecordKey(h, nil)
-	// set row lock key to current txn
-	err := txn.Set([]byte(lockKey), []byte(txn.String()))
-	return errors.Trace(err)
+	return errors.Trace(t.LockRecord(txn, h))
 }
 
 func (d *ddl) backfillTableIndex(t table.Table, indexInfo *model.IndexInfo, handles []int64, reorgInfo *reorgInfo) error {
-	kvX := kv.NewKVIndex(t.IndexPrefix(), indexInfo.Name.L, indexInfo.ID, indexInfo.Unique)
+	kvX := tables.NewIndex(t.IndexPrefix(), t.Meta().Columns, indexInfo)
 
 	for _, handle := range handles {
 		log.Debug("building index...", handle)
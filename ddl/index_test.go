@@ -20,6 +20,7 @@ import (
 	. "github.com/pingcap/check"
 	"github.com/pingcap/tidb/column"
 	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/inspectkv"
 	"github.com/pingcap/tidb/kv"
 	"github.com/pingcap/tidb/model"
 	"github.com/pingcap/tidb/parser/coldef"
@@ -625,6 +626,93 @@ func (s *testIndexSuite) TestAddIndex(c *C) {
 	s.d.start()
 }
 
+func (s *testIndexSuite) TestCancelAddIndex(c *C) {
+	d := newDDL(s.store, nil, nil, 100*time.Millisecond)
+	tblInfo := testTableInfo(c, d, "t", 3)
+	ctx := testNewContext(c, d)
+
+	_, err := ctx.GetTxn(true)
+	c.Assert(err, IsNil)
+
+	testCreateTable(c, ctx, d, s.dbInfo, tblInfo)
+
+	t := testGetTable(c, d, s.dbInfo.ID, tblInfo.ID)
+
+	row := []interface{}{int64(1), int64(2), int64(3)}
+	handle, err := t.AddRecord(ctx, row, 0)
+	c.Assert(err, IsNil)
+
+	err = ctx.FinishTxn(false)
+	c.Assert(err, IsNil)
+
+	var states []model.SchemaState
+	cancelled := false
+	tc := &testDDLCallback{}
+	tc.onJobUpdated = func(job *model.Job) {
+		if job.Type != model.ActionAddIndex {
+			return
+		}
+
+		states = append(states, job.SchemaState)
+		if job.SchemaState != model.StateWriteReorganization || cancelled {
+			return
+		}
+
+		// cancel the job after the index is written by the new rows.
+		cancelled = true
+		kv.RunInNewTxn(d.store, false, func(txn kv.Transaction) error {
+			errs, err1 := inspectkv.CancelJobs(txn, []int64{job.ID})
+			c.Assert(err1, IsNil)
+			c.Assert(errs[0], IsNil)
+			return nil
+		})
+	}
+
+	d.hook = tc
+
+	// Use local ddl for callback test.
+	s.d.close()
+
+	d.close()
+	d.start()
+
+	id, err := d.genGlobalID()
+	c.Assert(err, IsNil)
+	job := &model.Job{
+		SchemaID: s.dbInfo.ID,
+		TableID:  tblInfo.ID,
+		Type:     model.ActionAddIndex,
+		Args:     []interface{}{true, model.NewCIStr("c1_uni"), id, []*coldef.IndexColName{{ColumnName: "c1", Length: 256}}},
+	}
+	err = d.startJob(ctx, job)
+	c.Assert(err, NotNil)
+	c.Assert(err.Error(), Equals, ErrCancelledDDLJob.Error())
+	testCheckJobCancelled(c, d, job)
+	c.Assert(states, DeepEquals, []model.SchemaState{model.StateDeleteOnly, model.StateWriteOnly,
+		model.StateWriteReorganization, model.StateDeleteOnly, model.StateDeleteReorganization, model.StateNone})
+
+	// the index and its data are dropped.
+	t = testGetTable(c, d, s.dbInfo.ID, tblInfo.ID)
+	c.Assert(getIndex(t, "c1"), IsNil)
+	txn, err := ctx.GetTxn(true)
+	c.Assert(err, IsNil)
+	it, err := txn.Seek([]byte(t.IndexPrefix()))
+	c.Assert(err, IsNil)
+	c.Assert(it.Valid() && strings.HasPrefix(it.Key(), t.IndexPrefix()), IsFalse)
+	it.Close()
+	_, err = t.RowWithCols(txn, handle, t.Cols())
+	c.Assert(err, IsNil)
+
+	job = testDropTable(c, ctx, d, s.dbInfo, tblInfo)
+	testCheckJobDone(c, d, job, false)
+
+	err = ctx.FinishTxn(false)
+	c.Assert(err, IsNil)
+
+	d.close()
+	s.d.start()
+}
+
 func (s *testIndexSuite) TestDropIndex(c *C) {
 	d := newDDL(s.store, nil, nil, 100*time.Millisecond)
 	tblInfo := testTableInfo(c, d, "t", 3)
@@ -16,9 +16,11 @@ package ddl
 import (
 	"fmt"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/juju/errors"
+	"github.com/ngaut/log"
 	"github.com/pingcap/tidb/context"
 	"github.com/pingcap/tidb/kv"
 	"github.com/pingcap/tidb/meta"
@@ -105,6 +107,7 @@ func (d *ddl) runReorgJob(f func() error) error {
 		// start a reorganization job
 		d.wait.Add(1)
 		d.reorgDoneCh = make(chan error, 1)
+		atomic.StoreInt32(&d.reorgCancelled, 0)
 		go func() {
 			defer d.wait.Done()
 			d.reorgDoneCh <- f()
@@ -135,12 +138,34 @@ func (d *ddl) runReorgJob(f func() error) error {
 	}
 }
 
+// cancelReorg stops the running reorganization and waits for it to exit, it returns errWaitReorgTimeout
+// if the reorganization is still running.
+func (d *ddl) cancelReorg() error {
+	if d.reorgDoneCh == nil {
+		return nil
+	}
+
+	atomic.StoreInt32(&d.reorgCancelled, 1)
+	// the reorganization is running, so the function won't be called.
+	err := d.runReorgJob(nil)
+	if terror.ErrorEqual(err, errWaitReorgTimeout) {
+		return errors.Trace(err)
+	}
+	log.Warnf("reorganization is cancelled, err %v", err)
+	return nil
+}
+
 func (d *ddl) isReorgRunnable(txn kv.Transaction) error {
 	if d.isClosed() {
 		// worker is closed, can't run reorganization.
 		return errors.Trace(ErrWorkerClosed)
 	}
 
+	if atomic.LoadInt32(&d.reorgCancelled) == 1 {
+		// the job of the reorganization is cancelled.
+		return errors.Trace(ErrCancelledDDLJob)
+	}
+
 	t := meta.NewMeta(txn)
 	owner, err := t.GetDDLOwner()
 	if err != nil {
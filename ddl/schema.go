@@ -131,6 +131,11 @@ func (d *ddl) onDropSchema(t *meta.Meta, job *model.Job) error {
 		if err = t.DropDatabase(dbInfo.ID); err != nil {
 			return errors.Trace(err)
 		}
+		for _, tblInfo := range tables {
+			if err = t.DropTableStats(tblInfo.ID); err != nil {
+				return errors.Trace(err)
+			}
+		}
 
 		// finish this job
 		job.State = model.JobDone
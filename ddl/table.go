@@ -15,6 +15,7 @@ package ddl
 
 import (
 	"github.com/juju/errors"
+	"github.com/pingcap/tidb/kv"
 	"github.com/pingcap/tidb/meta"
 	"github.com/pingcap/tidb/meta/autoid"
 	"github.com/pingcap/tidb/model"
@@ -140,6 +141,9 @@ func (d *ddl) onDropTable(t *meta.Meta, job *model.Job) error {
 		if err = t.DropTable(schemaID, tableID); err != nil {
 			return errors.Trace(err)
 		}
+		if err = t.DropTableStats(tableID); err != nil {
+			return errors.Trace(err)
+		}
 
 		// finish this job
 		job.SchemaState = model.StateNone
@@ -150,6 +154,222 @@ func (d *ddl) onDropTable(t *meta.Meta, job *model.Job) error {
 	}
 }
 
+func (d *ddl) onConvertRowFormat(t *meta.Meta, job *model.Job) error {
+	schemaID := job.SchemaID
+	tblInfo, err := d.getTableInfo(t, job)
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	var format model.RowFormat
+	err = job.DecodeArgs(&format)
+	if err != nil {
+		job.State = model.JobCancelled
+		return errors.Trace(err)
+	}
+
+	_, err = t.GenSchemaVersion()
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	switch job.SchemaState {
+	case model.StateNone:
+		if tblInfo.RowFormat == format {
+			// nothing to convert, finish this job.
+			job.SchemaState = model.StatePublic
+			job.State = model.JobDone
+			return nil
+		}
+
+		// none -> reorganization
+		// New rows are written in the new row format after this state,
+		// and old rows are converted in reorganization.
+		job.SchemaState = model.StateWriteReorganization
+		tblInfo.RowFormat = format
+		// initialize SnapshotVer to 0 for later reorganization check.
+		job.SnapshotVer = 0
+		err = t.UpdateTable(schemaID, tblInfo)
+		return errors.Trace(err)
+	case model.StateWriteReorganization:
+		// reorganization -> public
+		reorgInfo, err := d.getReorgInfo(t, job)
+		if err != nil || reorgInfo.first {
+			// if we run reorg firstly, we should update the job snapshot version
+			// and then run the reorg next time.
+			return errors.Trace(err)
+		}
+
+		var tbl table.Table
+		tbl, err = d.getTable(t, schemaID, tblInfo)
+		if err != nil {
+			return errors.Trace(err)
+		}
+
+		err = d.runReorgJob(func() error {
+			return d.convertTableRows(tbl, reorgInfo)
+		})
+
+		if terror.ErrorEqual(err, errWaitReorgTimeout) {
+			// if timeout, we should return, check for the owner and re-wait job done.
+			return nil
+		}
+		if err != nil {
+			return errors.Trace(err)
+		}
+
+		// finish this job
+		job.SchemaState = model.StatePublic
+		job.State = model.JobDone
+		return nil
+	default:
+		return errors.Errorf("invalid job schema state %v", job.SchemaState)
+	}
+}
+
+// convertTableRows rewrites all the rows which exist in the reorganization snapshot
+// in the row format of the table.
+func (d *ddl) convertTableRows(t table.Table, reorgInfo *reorgInfo) error {
+	version := reorgInfo.SnapshotVer
+	seekHandle := reorgInfo.Handle
+
+	for {
+		handles, err := d.getSnapshotRows(t, version, seekHandle)
+		if err != nil {
+			return errors.Trace(err)
+		} else if len(handles) == 0 {
+			return nil
+		}
+
+		seekHandle = handles[len(handles)-1] + 1
+
+		err = kv.RunInNewTxn(d.store, true, func(txn kv.Transaction) error {
+			if err1 := d.isReorgRunnable(txn); err1 != nil {
+				return errors.Trace(err1)
+			}
+
+			var h int64
+			for _, h = range handles {
+				if err1 := t.ConvertRecord(txn, h); err1 != nil {
+					return errors.Trace(err1)
+				}
+			}
+			return errors.Trace(reorgInfo.UpdateHandle(txn, h))
+		})
+		if err != nil {
+			return errors.Trace(err)
+		}
+	}
+}
+
+func (d *ddl) onRenameTable(t *meta.Meta, job *model.Job) error {
+	var args []*renameTableArg
+	if err := job.DecodeArgs(&args); err != nil {
+		// arg error, cancel this job.
+		job.State = model.JobCancelled
+		return errors.Trace(err)
+	}
+
+	// check the renames again with the latest meta, the renames are applied to
+	// schemaTables in order like executing them one by one.
+	schemaTables := make(map[int64]map[string]*model.TableInfo)
+	tblInfos := make([]*model.TableInfo, 0, len(args))
+	for _, arg := range args {
+		oldTables, err := listSchemaTables(t, job, schemaTables, arg.OldSchemaID)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		newTables, err := listSchemaTables(t, job, schemaTables, arg.NewSchemaID)
+		if err != nil {
+			return errors.Trace(err)
+		}
+
+		var tblInfo *model.TableInfo
+		for _, tbl := range oldTables {
+			if tbl.ID == arg.TableID {
+				tblInfo = tbl
+				break
+			}
+		}
+		if tblInfo == nil {
+			job.State = model.JobCancelled
+			return errors.Trace(ErrNotExists)
+		}
+		if tblInfo.State != model.StatePublic {
+			job.State = model.JobCancelled
+			return errors.Errorf("table %s is not in public, but %s", tblInfo.Name.L, tblInfo.State)
+		}
+		if _, ok := newTables[arg.NewName.L]; ok {
+			job.State = model.JobCancelled
+			return errors.Trace(ErrExists)
+		}
+
+		delete(oldTables, tblInfo.Name.L)
+		tblInfo.Name = arg.NewName
+		newTables[tblInfo.Name.L] = tblInfo
+		tblInfos = append(tblInfos, tblInfo)
+	}
+
+	_, err := t.GenSchemaVersion()
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	for i, arg := range args {
+		tblInfo := tblInfos[i]
+		if arg.OldSchemaID == arg.NewSchemaID {
+			if err = t.UpdateTable(arg.NewSchemaID, tblInfo); err != nil {
+				return errors.Trace(err)
+			}
+			continue
+		}
+
+		// The auto ID is saved in the database, move it to the new database too.
+		var baseID int64
+		baseID, err = t.GetAutoTableID(arg.OldSchemaID, arg.TableID)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if err = t.DropTable(arg.OldSchemaID, arg.TableID); err != nil {
+			return errors.Trace(err)
+		}
+		if err = t.CreateTable(arg.NewSchemaID, tblInfo); err != nil {
+			return errors.Trace(err)
+		}
+		if _, err = t.GenAutoTableID(arg.NewSchemaID, arg.TableID, baseID); err != nil {
+			return errors.Trace(err)
+		}
+	}
+
+	// finish this job
+	job.SchemaState = model.StatePublic
+	job.State = model.JobDone
+	return nil
+}
+
+// listSchemaTables returns the tables of the schema by the lower case name, the tables
+// are cached in schemaTables.
+func listSchemaTables(t *meta.Meta, job *model.Job, schemaTables map[int64]map[string]*model.TableInfo, schemaID int64) (map[string]*model.TableInfo, error) {
+	if tables, ok := schemaTables[schemaID]; ok {
+		return tables, nil
+	}
+
+	tblInfos, err := t.ListTables(schemaID)
+	if terror.ErrorEqual(err, meta.ErrDBNotExists) {
+		job.State = model.JobCancelled
+		return nil, errors.Trace(terror.DatabaseNotExists)
+	} else if err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	tables := make(map[string]*model.TableInfo, len(tblInfos))
+	for _, tbl := range tblInfos {
+		tables[tbl.Name.L] = tbl
+	}
+	schemaTables[schemaID] = tables
+	return tables, nil
+}
+
 func (d *ddl) getTable(t *meta.Meta, schemaID int64, tblInfo *model.TableInfo) (table.Table, error) {
 	alloc := autoid.NewAllocator(d.store, schemaID)
 	tbl, err := table.TableFromMeta(alloc, tblInfo)
@@ -21,6 +21,7 @@ import (
 	"github.com/pingcap/tidb/context"
 	"github.com/pingcap/tidb/kv"
 	"github.com/pingcap/tidb/meta"
+	"github.com/pingcap/tidb/metric"
 	"github.com/pingcap/tidb/model"
 	"github.com/pingcap/tidb/terror"
 )
@@ -169,6 +170,9 @@ var ErrNotOwner = errors.New("DDL: not owner")
 // ErrWorkerClosed means we have already closed the DDL worker.
 var ErrWorkerClosed = errors.New("DDL: worker is closed")
 
+// ErrCancelledDDLJob means the DDL job is cancelled by the user.
+var ErrCancelledDDLJob = errors.New("DDL: job is cancelled")
+
 func (d *ddl) handleJobQueue() error {
 	for {
 		if d.isClosed() {
@@ -190,12 +194,15 @@ func (d *ddl) handleJobQueue() error {
 
 			// become the owner
 			// get the first job and run
+			if n, err1 := t.DDLJobLength(); err1 == nil {
+				metric.SetGauge("tidb_ddl_queued_jobs", n)
+			}
 			job, err = d.getFirstJob(t)
 			if job == nil || err != nil {
 				return errors.Trace(err)
 			}
 
-			if job.IsRunning() {
+			if job.IsRunning() || job.IsRollingBack() {
 				// if we enter a new state, crash when waiting 2 * lease time, and restart quickly,
 				// we may run the job immediately again, but we don't wait enough 2 * lease time to
 				// let other servers update the schema.
@@ -215,7 +222,9 @@ func (d *ddl) handleJobQueue() error {
 
 			// if run job meets error, we will save this error in job Error
 			// and retry later if the job is not cancelled.
+			startTime := time.Now()
 			d.runJob(t, job)
+			metric.ObserveDuration(metric.Label("tidb_ddl_job_run_duration_seconds", "type", job.Type.String()), startTime)
 
 			if job.IsFinished() {
 				err = d.finishJob(t, job)
@@ -244,11 +253,14 @@ func (d *ddl) handleJobQueue() error {
 		}
 
 		d.hook.OnJobUpdated(job)
+		if job.IsFinished() {
+			metric.Inc(metric.Label("tidb_ddl_jobs_total", "type", job.Type.String(), "state", job.State.String()), 1)
+		}
 
 		// here means the job enters another state (delete only, write only, public, etc...) or is cancelled.
-		// if the job is done or still running, we will wait 2 * lease time to guarantee other servers to update
-		// the newest schema.
-		if job.State == model.JobRunning || job.State == model.JobDone {
+		// if the job is done, still running or rolling back, we will wait 2 * lease time to guarantee other
+		// servers to update the newest schema.
+		if job.IsRunning() || job.IsRollingBack() || job.IsCancelling() || job.State == model.JobDone {
 			d.waitSchemaChanged(waitTime)
 		}
 
@@ -299,7 +311,18 @@ func (d *ddl) runJob(t *meta.Meta, job *model.Job) {
 		return
 	}
 
-	job.State = model.JobRunning
+	if job.IsCancelling() {
+		if err := d.cancelJob(job); err != nil {
+			log.Warnf("cancel job %d err %v, try again later", job.ID, err)
+			return
+		}
+	}
+	if job.IsFinished() {
+		return
+	}
+	if !job.IsRollingBack() {
+		job.State = model.JobRunning
+	}
 
 	var err error
 	switch job.Type {
@@ -319,6 +342,12 @@ func (d *ddl) runJob(t *meta.Meta, job *model.Job) {
 		err = d.onCreateIndex(t, job)
 	case model.ActionDropIndex:
 		err = d.onDropIndex(t, job)
+	case model.ActionConvertRowFormat:
+		err = d.onConvertRowFormat(t, job)
+	case model.ActionRenameTable:
+		err = d.onRenameTable(t, job)
+	case model.ActionModifyColumn:
+		err = d.onModifyColumn(t, job)
 	default:
 		// invalid job, cancel it.
 		job.State = model.JobCancelled
@@ -337,6 +366,32 @@ func (d *ddl) runJob(t *meta.Meta, job *model.Job) {
 	}
 }
 
+// cancelJob handles the job cancelled by the user. If the job hasn't changed the schema, it's cancelled
+// directly, otherwise it rolls back its schema changes in the following runs.
+func (d *ddl) cancelJob(job *model.Job) error {
+	// stop the reorganization of the job first, it may be still running.
+	if err := d.cancelReorg(); err != nil {
+		return errors.Trace(err)
+	}
+
+	if job.SchemaState == model.StateNone {
+		job.State = model.JobCancelled
+		job.Error = ErrCancelledDDLJob.Error()
+		job.ErrorCount++
+		return nil
+	}
+
+	switch job.Type {
+	case model.ActionAddColumn, model.ActionAddIndex, model.ActionModifyColumn:
+		job.State = model.JobRollingBack
+	default:
+		// the job can't be rolled back now, go on running it.
+		log.Warnf("job %v can't be rolled back, ignore the cancellation", job)
+		job.State = model.JobRunning
+	}
+	return nil
+}
+
 // for every lease seconds, we will re-update the whole schema, so we will wait 2 * lease time
 // to guarantee that all servers have already updated schema.
 func (d *ddl) waitSchemaChanged(waitTime time.Duration) {
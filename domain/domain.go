@@ -26,6 +26,7 @@ import (
 	"github.com/pingcap/tidb/meta"
 	"github.com/pingcap/tidb/model"
 	"github.com/pingcap/tidb/sessionctx/variable"
+	"github.com/pingcap/tidb/statistics"
 	"github.com/pingcap/tidb/store/localstore"
 	"github.com/pingcap/tidb/terror"
 )
@@ -35,10 +36,14 @@ var ddlLastReloadSchemaTS = "ddl_last_reload_schema_ts"
 // Domain represents a storage space. Different domains can use the same database name.
 // Multiple domains can be used in parallel without synchronization.
 type Domain struct {
-	store      kv.Storage
-	infoHandle *infoschema.Handle
-	ddl        ddl.DDL
-	leaseCh    chan time.Duration
+	store       kv.Storage
+	infoHandle  *infoschema.Handle
+	statsHandle *statistics.Handle
+	ddl         ddl.DDL
+	leaseCh     chan time.Duration
+	// exitCh is closed by Close to stop the schema loading loop.
+	exitCh    chan struct{}
+	closeOnce sync.Once
 	// nano seconds
 	lastLeaseTS int64
 	m           sync.Mutex
@@ -90,6 +95,43 @@ func (do *Domain) loadInfoSchema(txn kv.Transaction) (err error) {
 	return errors.Trace(err)
 }
 
+func (do *Domain) loadTableStats(txn kv.Transaction) error {
+	m := meta.NewMeta(txn)
+	statsVersion, err := m.GetStatsVersion()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if statsVersion <= do.statsHandle.Version() {
+		return nil
+	}
+
+	stats, err := m.ListTableStats()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	tables := make([]*statistics.Table, 0, len(stats))
+	for _, data := range stats {
+		t, err := statistics.UnmarshalTable(data)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		tables = append(tables, t)
+	}
+
+	log.Infof("loadTableStats %d", statsVersion)
+	do.statsHandle.Set(tables, statsVersion)
+	return nil
+}
+
+func (do *Domain) load(txn kv.Transaction) error {
+	err := do.loadInfoSchema(txn)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	err = do.loadTableStats(txn)
+	return errors.Trace(err)
+}
+
 // InfoSchema gets information schema from domain.
 func (do *Domain) InfoSchema() infoschema.InfoSchema {
 	// try reload if possible.
@@ -97,6 +139,11 @@ func (do *Domain) InfoSchema() infoschema.InfoSchema {
 	return do.infoHandle.Get()
 }
 
+// StatsHandle gets the statistics handle from domain.
+func (do *Domain) StatsHandle() *statistics.Handle {
+	return do.statsHandle
+}
+
 // DDL gets DDL from domain.
 func (do *Domain) DDL() ddl.DDL {
 	return do.ddl
@@ -159,7 +206,7 @@ func (do *Domain) reload() error {
 		var err error
 
 		for {
-			err = kv.RunInNewTxn(do.store, false, do.loadInfoSchema)
+			err = kv.RunInNewTxn(do.store, false, do.load)
 			// if err is db closed, we will return it directly, otherwise, we will
 			// check reloading again.
 			if terror.ErrorEqual(err, localstore.ErrDBClosed) {
@@ -218,6 +265,8 @@ func (do *Domain) loadSchemaInLoop(lease time.Duration) {
 			} else if err != nil {
 				log.Fatalf("reload schema err %v", errors.ErrorStack(err))
 			}
+		case <-do.exitCh:
+			return
 		case newLease := <-do.leaseCh:
 			if newLease <= 0 {
 				newLease = defaultLoadTime
@@ -236,6 +285,18 @@ func (do *Domain) loadSchemaInLoop(lease time.Duration) {
 	}
 }
 
+// Close stops the DDL worker and the schema loading loop, it waits for the running DDL job
+// to finish its current step and gives up the DDL owner so other servers can take over.
+func (do *Domain) Close() error {
+	var err error
+	// The lock m isn't held since the running DDL job may reload the schema.
+	do.closeOnce.Do(func() {
+		close(do.exitCh)
+		err = do.ddl.Stop()
+	})
+	return errors.Trace(err)
+}
+
 type ddlCallback struct {
 	ddl.BaseCallback
 	do *Domain
@@ -256,9 +317,11 @@ func NewDomain(store kv.Storage, lease time.Duration) (d *Domain, err error) {
 	d = &Domain{
 		store:   store,
 		leaseCh: make(chan time.Duration, 1),
+		exitCh:  make(chan struct{}),
 	}
 
 	d.infoHandle = infoschema.NewHandle(d.store)
+	d.statsHandle = statistics.NewHandle()
 	d.ddl = ddl.NewDDL(d.store, d.infoHandle, &ddlCallback{do: d}, lease)
 	d.mustReload()
 
@@ -15,6 +15,7 @@ package executor
 
 import (
 	"github.com/juju/errors"
+	"github.com/pingcap/tidb/ast"
 	"github.com/pingcap/tidb/column"
 	"github.com/pingcap/tidb/context"
 	"github.com/pingcap/tidb/field"
@@ -22,6 +23,7 @@ import (
 	"github.com/pingcap/tidb/optimizer/plan"
 	oplan "github.com/pingcap/tidb/plan"
 	"github.com/pingcap/tidb/rset"
+	"github.com/pingcap/tidb/stmt"
 	"github.com/pingcap/tidb/util/format"
 	"github.com/pingcap/tidb/util/types"
 )
@@ -117,6 +119,18 @@ func (a *recordsetAdapter) Close() error {
 type statementAdapter struct {
 	is   infoschema.InfoSchema
 	plan plan.Plan
+	text string
+	// node is the ast node the plan is optimized from.
+	node ast.StmtNode
+}
+
+// StmtNode returns the ast node of the statement compiled by the new optimizer, it returns
+// nil if the statement is converted to the old statement.
+func StmtNode(s stmt.Statement) ast.StmtNode {
+	if a, ok := s.(*statementAdapter); ok {
+		return a.node
+	}
+	return nil
 }
 
 func (a *statementAdapter) Explain(ctx context.Context, w format.Formatter) {
@@ -124,11 +138,11 @@ func (a *statementAdapter) Explain(ctx context.Context, w format.Formatter) {
 }
 
 func (a *statementAdapter) OriginText() string {
-	return ""
+	return a.text
 }
 
 func (a *statementAdapter) SetText(text string) {
-	return
+	a.text = text
 }
 
 func (a *statementAdapter) IsDDL() bool {
@@ -141,6 +155,10 @@ func (a *statementAdapter) Exec(ctx context.Context) (rset.Recordset, error) {
 	if b.err != nil {
 		return nil, errors.Trace(b.err)
 	}
+	if len(e.Fields()) == 0 {
+		// The executor without fields doesn't return a result set, like the analyze executor.
+		return nil, errors.Trace(runToEnd(e))
+	}
 	fields := make([]*field.ResultField, 0, len(e.Fields()))
 	for _, v := range e.Fields() {
 		f := &field.ResultField{
@@ -162,3 +180,13 @@ func (a *statementAdapter) Exec(ctx context.Context) (rset.Recordset, error) {
 		fields:   fields,
 	}, nil
 }
+
+func runToEnd(e Executor) error {
+	defer e.Close()
+	for {
+		row, err := e.Next()
+		if row == nil || err != nil {
+			return errors.Trace(err)
+		}
+	}
+}
@@ -0,0 +1,257 @@
+// Copyright 2015 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/column"
+	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/inspectkv"
+	"github.com/pingcap/tidb/kv"
+	"github.com/pingcap/tidb/meta"
+	"github.com/pingcap/tidb/model"
+	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/privilege"
+	"github.com/pingcap/tidb/sessionctx"
+	"github.com/pingcap/tidb/sessionctx/variable"
+	"github.com/pingcap/tidb/table"
+)
+
+// adminShowDDLJobsHistoryNum is the number of the history jobs returned by ADMIN SHOW DDL JOBS.
+const adminShowDDLJobsHistoryNum = 10
+
+// AdminExec represents an admin executor.
+// ADMIN SHOW DDL returns the schema version, the DDL owner and the running job,
+// ADMIN SHOW DDL JOBS returns the jobs in the queue followed by the recent history jobs,
+// ADMIN CANCEL DDL JOBS cancels the jobs and returns a row for the result of every job.
+// ADMIN CHECK TABLE and ADMIN CHECK INDEX return a row for every inconsistent index entry,
+// the entries are fixed in the repair mode.
+type AdminExec struct {
+	tp      ast.AdminStmtType
+	jobIDs  []int64
+	schemas []*model.DBInfo
+	tables  []table.Table
+	index   string
+	repair  bool
+	ctx     context.Context
+	fields  []*ast.ResultField
+	rows    []*Row
+	cursor  int
+}
+
+// Fields implements Executor Fields interface.
+func (e *AdminExec) Fields() []*ast.ResultField {
+	return e.fields
+}
+
+// Next implements Execution Next interface.
+func (e *AdminExec) Next() (*Row, error) {
+	if e.rows == nil {
+		var err error
+		switch e.tp {
+		case ast.AdminShowDDL:
+			err = e.fetchShowDDL()
+		case ast.AdminShowDDLJobs:
+			err = e.fetchShowDDLJobs()
+		case ast.AdminCancelDDLJobs:
+			err = e.cancelDDLJobs()
+		case ast.AdminCheckTable, ast.AdminCheckIndex:
+			err = e.checkIndices()
+		}
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	if e.cursor >= len(e.rows) {
+		return nil, nil
+	}
+	row := e.rows[e.cursor]
+	e.cursor++
+	return row, nil
+}
+
+// Close implements Executor Close interface.
+func (e *AdminExec) Close() error {
+	return nil
+}
+
+func (e *AdminExec) fetchShowDDL() error {
+	txn, err := e.ctx.GetTxn(false)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	info, err := inspectkv.GetDDLInfo(txn)
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	var owner, job string
+	if info.Owner != nil {
+		owner = info.Owner.OwnerID
+	}
+	if info.Job != nil {
+		job = info.Job.String()
+	}
+	e.rows = []*Row{{Data: []interface{}{
+		strconv.FormatInt(info.SchemaVer, 10), owner, job, strconv.FormatInt(info.ReorgHandle, 10),
+	}}}
+	return nil
+}
+
+func (e *AdminExec) fetchShowDDLJobs() error {
+	txn, err := e.ctx.GetTxn(false)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	jobs, err := inspectkv.GetDDLJobs(txn)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	historyJobs, err := inspectkv.GetHistoryDDLJobs(txn, adminShowDDLJobsHistoryNum)
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	t := meta.NewMeta(txn)
+	e.rows = []*Row{}
+	for _, job := range append(jobs, historyJobs...) {
+		handle, err := t.GetDDLReorgHandle(job)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		e.rows = append(e.rows, &Row{Data: jobRowData(job, handle)})
+	}
+	return nil
+}
+
+func jobRowData(job *model.Job, reorgHandle int64) []interface{} {
+	return []interface{}{
+		strconv.FormatInt(job.ID, 10),
+		job.Type.String(),
+		strconv.FormatInt(job.SchemaID, 10),
+		strconv.FormatInt(job.TableID, 10),
+		job.State.String(),
+		job.SchemaState.String(),
+		strconv.FormatInt(reorgHandle, 10),
+		job.Error,
+	}
+}
+
+// cancelDDLJobs cancels the jobs in a new transaction, so the DDL worker can roll back
+// them even if the current transaction is not committed.
+func (e *AdminExec) cancelDDLJobs() error {
+	ok, err := e.hasPrivilege(nil, nil, mysql.AllPriv)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if !ok {
+		return errors.New("You do not have the privilege to cancel DDL jobs.")
+	}
+
+	var errs []error
+	err = kv.RunInNewTxn(sessionctx.GetDomain(e.ctx).Store(), true, func(txn kv.Transaction) error {
+		var err1 error
+		errs, err1 = inspectkv.CancelJobs(txn, e.jobIDs)
+		return errors.Trace(err1)
+	})
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	e.rows = []*Row{}
+	for i, id := range e.jobIDs {
+		result := "successful"
+		if errs[i] != nil {
+			result = errs[i].Error()
+		}
+		e.rows = append(e.rows, &Row{Data: []interface{}{strconv.FormatInt(id, 10), result}})
+	}
+	return nil
+}
+
+// checkIndices checks the public indices of the tables, or the index of the table for ADMIN CHECK INDEX.
+func (e *AdminExec) checkIndices() error {
+	if e.repair {
+		// The repair inserts the missing index entries and deletes the dangling ones.
+		for i, t := range e.tables {
+			for _, priv := range []mysql.PrivilegeType{mysql.InsertPriv, mysql.DeletePriv} {
+				ok, err := e.hasPrivilege(e.schemas[i], t.Meta(), priv)
+				if err != nil {
+					return errors.Trace(err)
+				}
+				if !ok {
+					return errors.Errorf("You do not have the privilege to repair table %s.%s.", e.schemas[i].Name.O, t.Meta().Name.O)
+				}
+			}
+		}
+	}
+
+	txn, err := e.ctx.GetTxn(false)
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	e.rows = []*Row{}
+	for _, t := range e.tables {
+		var indices []*column.IndexedCol
+		for _, idx := range t.Indices() {
+			if idx.State != model.StatePublic {
+				continue
+			}
+			if e.tp == ast.AdminCheckTable || idx.Name.L == strings.ToLower(e.index) {
+				indices = append(indices, idx)
+			}
+		}
+		if e.tp == ast.AdminCheckIndex && len(indices) == 0 {
+			return errors.Errorf("index %s doesn't exist in table %s", e.index, t.Meta().Name)
+		}
+
+		for _, idx := range indices {
+			problems, err := inspectkv.CheckIndex(txn, t, idx)
+			if err != nil {
+				return errors.Trace(err)
+			}
+			if e.repair {
+				if err = inspectkv.RepairIndex(txn, idx, problems); err != nil {
+					return errors.Trace(err)
+				}
+			}
+			for _, p := range problems {
+				e.rows = append(e.rows, &Row{Data: []interface{}{
+					t.Meta().Name.O, idx.Name.O, strconv.FormatInt(p.Handle, 10), p.Kind.String(),
+				}})
+			}
+		}
+	}
+	return nil
+}
+
+// hasPrivilege returns whether the current user has the privilege on the table, the global
+// privilege is checked if db and tbl are nil.
+func (e *AdminExec) hasPrivilege(db *model.DBInfo, tbl *model.TableInfo, priv mysql.PrivilegeType) (bool, error) {
+	if len(variable.GetSessionVars(e.ctx).User) == 0 {
+		// In embedded db mode, user does not need to login.
+		return true, nil
+	}
+	checker := privilege.GetPrivilegeChecker(e.ctx)
+	if checker == nil {
+		return false, nil
+	}
+	ok, err := checker.Check(e.ctx, db, tbl, priv)
+	return ok, errors.Trace(err)
+}
@@ -0,0 +1,417 @@
+// Copyright 2015 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/optimizer/evaluator"
+	"github.com/pingcap/tidb/util/collate"
+	"github.com/pingcap/tidb/util/types"
+)
+
+var (
+	_ Executor = &HashAggExec{}
+	_ Executor = &StreamAggExec{}
+)
+
+// Aggregate function names.
+const (
+	aggFuncCount       = "count"
+	aggFuncSum         = "sum"
+	aggFuncAvg         = "avg"
+	aggFuncMax         = "max"
+	aggFuncMin         = "min"
+	aggFuncGroupConcat = "group_concat"
+)
+
+// valueSet is a set of values, it filters out duplicated values for aggregate functions with DISTINCT.
+type valueSet struct {
+	buckets map[string][][]interface{}
+}
+
+func newValueSet() *valueSet {
+	return &valueSet{buckets: map[string][][]interface{}{}}
+}
+
+// add adds values to the set, returns false if the values already exist.
+func (s *valueSet) add(vals []interface{}) (bool, error) {
+	key, err := hashKey(vals)
+	if err != nil {
+		return false, errors.Trace(err)
+	}
+	for _, existed := range s.buckets[key] {
+		cmp, err := compareKeys(existed, vals)
+		if err != nil {
+			return false, errors.Trace(err)
+		}
+		if cmp == 0 {
+			return false, nil
+		}
+	}
+	s.buckets[key] = append(s.buckets[key], vals)
+	return true, nil
+}
+
+// aggState is the state of an aggregate function in a group.
+type aggState struct {
+	name     string
+	distinct *valueSet
+	// collations are the collations of the arguments.
+	collations []string
+	// count is the number of aggregated values.
+	count int64
+	// value is the sum for sum and avg, the current result for max, min and group_concat.
+	value interface{}
+}
+
+func newAggState(fn *ast.AggregateFuncExpr) *aggState {
+	s := &aggState{name: strings.ToLower(fn.F)}
+	for _, arg := range fn.Args {
+		s.collations = append(s.collations, exprCollation(arg))
+	}
+	if fn.Distinct {
+		switch s.name {
+		case aggFuncCount, aggFuncSum, aggFuncAvg, aggFuncGroupConcat:
+			// Only these aggregate functions support distinct.
+			s.distinct = newValueSet()
+		}
+	}
+	return s
+}
+
+// update aggregates the argument values of a row.
+func (s *aggState) update(args []interface{}) error {
+	for _, arg := range args {
+		// NULL values are ignored by all the aggregate functions.
+		if types.IsNil(arg) {
+			return nil
+		}
+	}
+	if s.distinct != nil {
+		keys := make([]interface{}, len(args))
+		copy(keys, args)
+		ok, err := s.distinct.add(collate.SortKeys(s.collations, keys))
+		if err != nil || !ok {
+			return errors.Trace(err)
+		}
+	}
+	var err error
+	switch s.name {
+	case aggFuncSum, aggFuncAvg:
+		s.value, err = calculateSum(s.value, args[0])
+		if err != nil {
+			return errors.Errorf("eval %s aggregate err: %v", strings.ToUpper(s.name), err)
+		}
+	case aggFuncMax, aggFuncMin:
+		if s.value != nil {
+			cmp, err := collate.Compare(s.collations[0], s.value, args[0])
+			if err != nil {
+				return errors.Trace(err)
+			}
+			if (s.name == aggFuncMax && cmp >= 0) || (s.name == aggFuncMin && cmp <= 0) {
+				break
+			}
+		}
+		s.value = args[0]
+	case aggFuncGroupConcat:
+		// TODO: support order by, separator and group_concat_max_len.
+		var buf bytes.Buffer
+		if s.value != nil {
+			buf.WriteString(s.value.(string))
+			buf.WriteString(",")
+		}
+		for _, arg := range args {
+			str, err := types.ToString(arg)
+			if err != nil {
+				return errors.Trace(err)
+			}
+			buf.WriteString(str)
+		}
+		s.value = buf.String()
+	}
+	s.count++
+	return nil
+}
+
+// result returns the aggregated result.
+func (s *aggState) result() interface{} {
+	switch s.name {
+	case aggFuncCount:
+		return s.count
+	case aggFuncAvg:
+		switch x := s.value.(type) {
+		case float64:
+			return x / float64(s.count)
+		case mysql.Decimal:
+			return x.Div(mysql.NewDecimalFromUint(uint64(s.count), 0))
+		}
+		return nil
+	}
+	return s.value
+}
+
+// calculateSum adds v to sum, sum and avg use decimal for integer and decimal type, float for others.
+// See https://dev.mysql.com/doc/refman/5.7/en/group-by-functions.html
+func calculateSum(sum interface{}, v interface{}) (interface{}, error) {
+	var (
+		data interface{}
+		err  error
+	)
+	v = types.RawData(v)
+	switch y := v.(type) {
+	case int, uint, int8, uint8, int16, uint16, int32, uint32, int64, uint64:
+		data, err = mysql.ConvertToDecimal(v)
+	case mysql.Decimal:
+		data = y
+	default:
+		data, err = types.ToFloat64(v)
+	}
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	switch x := sum.(type) {
+	case nil:
+		return data, nil
+	case float64:
+		f, err := types.ToFloat64(data)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		return x + f, nil
+	case mysql.Decimal:
+		if f, ok := data.(float64); ok {
+			d, _ := x.Float64()
+			return d + f, nil
+		}
+		return x.Add(data.(mysql.Decimal)), nil
+	}
+	return nil, errors.Errorf("invalid value %v(%T) for aggregate", sum, sum)
+}
+
+// aggGroup is a group of rows with the same group by values.
+type aggGroup struct {
+	// row is the first row of the group, columns not in aggregate functions
+	// get their values from it.
+	row    *Row
+	keys   []interface{}
+	states []*aggState
+}
+
+func newAggGroup(row *Row, keys []interface{}, aggFuncs []*ast.AggregateFuncExpr) *aggGroup {
+	g := &aggGroup{
+		row:    row,
+		keys:   keys,
+		states: make([]*aggState, len(aggFuncs)),
+	}
+	for i, fn := range aggFuncs {
+		g.states[i] = newAggState(fn)
+	}
+	return g
+}
+
+// update evaluates the aggregate function arguments on current row and aggregates them.
+func (g *aggGroup) update(ctx context.Context, aggFuncs []*ast.AggregateFuncExpr) error {
+	for i, fn := range aggFuncs {
+		args := make([]interface{}, len(fn.Args))
+		for j, arg := range fn.Args {
+			var err error
+			args[j], err = evaluator.Eval(ctx, arg)
+			if err != nil {
+				return errors.Trace(err)
+			}
+		}
+		if err := g.states[i].update(args); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return nil
+}
+
+// output sets the values of the fields and aggregate functions to the group result.
+func (g *aggGroup) output(fields []*ast.ResultField, aggFuncs []*ast.AggregateFuncExpr) *Row {
+	setFieldsValue(fields, g.row.Data)
+	for i, fn := range aggFuncs {
+		fn.SetValue(g.states[i].result())
+	}
+	return &Row{Data: g.row.Data}
+}
+
+// emptyGroup returns the group for empty source without group by items,
+// aggregate functions return their values on no rows, columns are NULL.
+func emptyGroup(fields []*ast.ResultField, aggFuncs []*ast.AggregateFuncExpr) *aggGroup {
+	row := &Row{Data: make([]interface{}, len(fields))}
+	return newAggGroup(row, nil, aggFuncs)
+}
+
+// HashAggExec represents a hash aggregation executor.
+// It puts all the source rows into groups by a hash table, then returns a row for each group.
+type HashAggExec struct {
+	Src          Executor
+	AggFuncs     []*ast.AggregateFuncExpr
+	GroupByItems []ast.ExprNode
+
+	ctx      context.Context
+	groups   []*aggGroup
+	executed bool
+	cursor   int
+}
+
+// Fields implements Executor Fields interface.
+func (e *HashAggExec) Fields() []*ast.ResultField {
+	return e.Src.Fields()
+}
+
+func (e *HashAggExec) aggregate() error {
+	buckets := map[string][]*aggGroup{}
+	for {
+		row, err := e.Src.Next()
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if row == nil {
+			break
+		}
+		keys, _, err := evalKeys(e.ctx, e.GroupByItems)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		hk, err := hashKey(keys)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		var group *aggGroup
+		for _, g := range buckets[hk] {
+			cmp, err := compareKeys(g.keys, keys)
+			if err != nil {
+				return errors.Trace(err)
+			}
+			if cmp == 0 {
+				group = g
+				break
+			}
+		}
+		if group == nil {
+			group = newAggGroup(row, keys, e.AggFuncs)
+			buckets[hk] = append(buckets[hk], group)
+			e.groups = append(e.groups, group)
+		}
+		if err = group.update(e.ctx, e.AggFuncs); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	if len(e.groups) == 0 && len(e.GroupByItems) == 0 {
+		e.groups = append(e.groups, emptyGroup(e.Src.Fields(), e.AggFuncs))
+	}
+	return nil
+}
+
+// Next implements Executor Next interface.
+func (e *HashAggExec) Next() (*Row, error) {
+	if !e.executed {
+		e.executed = true
+		if err := e.aggregate(); err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	if e.cursor >= len(e.groups) {
+		return nil, nil
+	}
+	group := e.groups[e.cursor]
+	e.cursor++
+	return group.output(e.Src.Fields(), e.AggFuncs), nil
+}
+
+// Close implements Executor Close interface.
+func (e *HashAggExec) Close() error {
+	e.groups = nil
+	return e.Src.Close()
+}
+
+// StreamAggExec represents a stream aggregation executor.
+// The source rows must be sorted by the group by items, so a group is
+// returned as soon as a row of the next group is fetched.
+type StreamAggExec struct {
+	Src          Executor
+	AggFuncs     []*ast.AggregateFuncExpr
+	GroupByItems []ast.ExprNode
+
+	ctx      context.Context
+	group    *aggGroup
+	finished bool
+}
+
+// Fields implements Executor Fields interface.
+func (e *StreamAggExec) Fields() []*ast.ResultField {
+	return e.Src.Fields()
+}
+
+// Next implements Executor Next interface.
+func (e *StreamAggExec) Next() (*Row, error) {
+	if e.finished {
+		return nil, nil
+	}
+	for {
+		row, err := e.Src.Next()
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if row == nil {
+			e.finished = true
+			if e.group == nil {
+				if len(e.GroupByItems) > 0 {
+					return nil, nil
+				}
+				e.group = emptyGroup(e.Src.Fields(), e.AggFuncs)
+			}
+			return e.group.output(e.Src.Fields(), e.AggFuncs), nil
+		}
+		keys, _, err := evalKeys(e.ctx, e.GroupByItems)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		var done *aggGroup
+		if e.group != nil {
+			cmp, err := compareKeys(e.group.keys, keys)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			if cmp != 0 {
+				done = e.group
+				e.group = nil
+			}
+		}
+		if e.group == nil {
+			e.group = newAggGroup(row, keys, e.AggFuncs)
+		}
+		if err = e.group.update(e.ctx, e.AggFuncs); err != nil {
+			return nil, errors.Trace(err)
+		}
+		if done != nil {
+			return done.output(e.Src.Fields(), e.AggFuncs), nil
+		}
+	}
+}
+
+// Close implements Executor Close interface.
+func (e *StreamAggExec) Close() error {
+	e.group = nil
+	e.finished = false
+	return e.Src.Close()
+}
@@ -0,0 +1,102 @@
+// Copyright 2015 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"time"
+
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/column"
+	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/kv"
+	"github.com/pingcap/tidb/meta"
+	"github.com/pingcap/tidb/sessionctx"
+	"github.com/pingcap/tidb/statistics"
+	"github.com/pingcap/tidb/table"
+)
+
+// AnalyzeExec represents an analyze executor.
+// It samples the rows of the tables to build the statistics, saves them and
+// returns no rows.
+type AnalyzeExec struct {
+	tables []table.Table
+	ctx    context.Context
+	done   bool
+}
+
+// Fields implements Executor Fields interface.
+func (e *AnalyzeExec) Fields() []*ast.ResultField {
+	return nil
+}
+
+// Next implements Execution Next interface.
+func (e *AnalyzeExec) Next() (*Row, error) {
+	if e.done {
+		return nil, nil
+	}
+	e.done = true
+	for _, t := range e.tables {
+		err := e.analyzeTable(t)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	return nil, nil
+}
+
+// Close implements Executor Close interface.
+func (e *AnalyzeExec) Close() error {
+	return nil
+}
+
+func (e *AnalyzeExec) analyzeTable(t table.Table) error {
+	txn, err := e.ctx.GetTxn(false)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	collector := statistics.NewSampleCollector(statistics.DefaultSampleSize, time.Now().UnixNano())
+	err = t.IterRecords(txn, t.FirstKey(), t.Cols(), func(h int64, data []interface{}, cols []*column.Col) (bool, error) {
+		collector.Collect(data)
+		return true, nil
+	})
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	colIDs := make([]int64, len(t.Cols()))
+	for i, col := range t.Cols() {
+		colIDs[i] = col.ID
+	}
+	stats, err := statistics.BuildTable(t.Meta().ID, collector.Count, colIDs, collector.Samples, statistics.DefaultBucketCount)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	data, err := stats.Marshal()
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	// The statistics are saved in a new transaction, so they can be used by other
+	// sessions even if the current transaction is rolled back.
+	do := sessionctx.GetDomain(e.ctx)
+	err = kv.RunInNewTxn(do.Store(), true, func(txn kv.Transaction) error {
+		return errors.Trace(meta.NewMeta(txn).SetTableStats(stats.TableID, data))
+	})
+	if err != nil {
+		return errors.Trace(err)
+	}
+	do.StatsHandle().Update(stats)
+	return nil
+}
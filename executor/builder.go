@@ -57,12 +57,72 @@ func (b *executorBuilder) build(p plan.Plan) Executor {
 		return b.buildSort(v)
 	case *plan.Limit:
 		return b.buildLimit(v)
+	case *plan.Join:
+		return b.buildJoin(v)
+	case *plan.Aggregate:
+		return b.buildAggregate(v)
+	case *plan.Analyze:
+		return b.buildAnalyze(v)
+	case *plan.Admin:
+		return b.buildAdmin(v)
+	case *plan.ExplainPlan:
+		return b.buildExplain(v)
 	default:
 		b.err = ErrUnknownPlan.Gen("Unknown Plan %T", p)
 		return nil
 	}
 }
 
+func (b *executorBuilder) buildAnalyze(v *plan.Analyze) Executor {
+	e := &AnalyzeExec{
+		ctx: b.ctx,
+	}
+	for _, tblInfo := range v.Tables {
+		tbl, _ := b.is.TableByID(tblInfo.ID)
+		e.tables = append(e.tables, tbl)
+	}
+	return e
+}
+
+func (b *executorBuilder) buildAdmin(v *plan.Admin) Executor {
+	e := &AdminExec{
+		ctx:     b.ctx,
+		tp:      v.Tp,
+		jobIDs:  v.JobIDs,
+		schemas: v.Schemas,
+		index:   v.Index,
+		repair:  v.Repair,
+	}
+	for _, tblInfo := range v.Tables {
+		tbl, _ := b.is.TableByID(tblInfo.ID)
+		e.tables = append(e.tables, tbl)
+	}
+	switch v.Tp {
+	case ast.AdminShowDDL:
+		e.fields = buildExplainFields("SCHEMA_VER", "OWNER", "JOB", "REORG_HANDLE")
+	case ast.AdminShowDDLJobs:
+		e.fields = buildExplainFields("JOB_ID", "JOB_TYPE", "SCHEMA_ID", "TABLE_ID", "STATE",
+			"SCHEMA_STATE", "REORG_HANDLE", "ERROR")
+	case ast.AdminCancelDDLJobs:
+		e.fields = buildExplainFields("JOB_ID", "RESULT")
+	case ast.AdminCheckTable, ast.AdminCheckIndex:
+		e.fields = buildExplainFields("TABLE_NAME", "INDEX_NAME", "HANDLE", "PROBLEM")
+	}
+	return e
+}
+
+func (b *executorBuilder) buildExplain(v *plan.ExplainPlan) Executor {
+	e := &ExplainExec{
+		plan: v,
+	}
+	if v.Format == ast.ExplainFormatJSON {
+		e.fields = buildExplainFields("EXPLAIN")
+	} else {
+		e.fields = buildExplainFields("id", "count", "cost", "operator info")
+	}
+	return e
+}
+
 func (b *executorBuilder) buildTableScan(v *plan.TableScan) Executor {
 	table, _ := b.is.TableByID(v.Table.ID)
 	return &TableScanExec{
@@ -100,6 +160,12 @@ func (b *executorBuilder) buildIndexScan(v *plan.IndexScan) Executor {
 	for i, val := range v.Ranges {
 		e.Ranges[i] = b.buildIndexRange(e, val)
 	}
+	if v.Desc {
+		// The ranges are ordered, scan them from the last one in descending order.
+		for i, j := 0, len(e.Ranges)-1; i < j; i, j = i+1, j-1 {
+			e.Ranges[i], e.Ranges[j] = e.Ranges[j], e.Ranges[i]
+		}
+	}
 	return e
 }
 
@@ -115,6 +181,9 @@ func (b *executorBuilder) buildIndexRange(scan *IndexScanExec, v *plan.IndexRang
 }
 
 func (b *executorBuilder) joinConditions(conditions []ast.ExprNode) ast.ExprNode {
+	if len(conditions) == 0 {
+		return nil
+	}
 	if len(conditions) == 1 {
 		return conditions[0]
 	}
@@ -149,6 +218,9 @@ func (b *executorBuilder) buildSelectLock(v *plan.SelectLock) Executor {
 		Src:  src,
 		Lock: v.Lock,
 		ctx:  b.ctx,
+		rebuild: func() Executor {
+			return b.build(v.Src())
+		},
 	}
 	return e
 }
@@ -185,3 +257,70 @@ func (b *executorBuilder) buildLimit(v *plan.Limit) Executor {
 	}
 	return e
 }
+
+func (b *executorBuilder) buildJoin(v *plan.Join) Executor {
+	left := b.build(v.Left)
+	right := b.build(v.Right)
+	if b.err != nil {
+		return nil
+	}
+	switch v.Kind {
+	case plan.JoinSemi, plan.JoinAntiSemi:
+		return &SemiJoinExec{
+			Outer:     left,
+			Inner:     right,
+			OuterKeys: v.LeftKeys,
+			InnerKeys: v.RightKeys,
+			Anti:      v.Kind == plan.JoinAntiSemi,
+			NullAware: v.NullAware,
+			Condition: b.joinConditions(v.Conditions),
+			ctx:       b.ctx,
+		}
+	}
+	if v.Strategy == plan.JoinMerge {
+		return &MergeJoinExec{
+			Left:      left,
+			Right:     right,
+			LeftKeys:  v.LeftKeys,
+			RightKeys: v.RightKeys,
+			Kind:      v.Kind,
+			Condition: b.joinConditions(v.Conditions),
+			fields:    v.Fields(),
+			ctx:       b.ctx,
+		}
+	}
+	e := &HashJoinExec{
+		Build:     right,
+		Probe:     left,
+		BuildKeys: v.RightKeys,
+		ProbeKeys: v.LeftKeys,
+		Outer:     v.Kind != plan.JoinInner,
+		Condition: b.joinConditions(v.Conditions),
+		fields:    v.Fields(),
+		ctx:       b.ctx,
+	}
+	if v.BuildLeft() {
+		e.BuildLeft = true
+		e.Build, e.Probe = left, right
+		e.BuildKeys, e.ProbeKeys = v.LeftKeys, v.RightKeys
+	}
+	return e
+}
+
+func (b *executorBuilder) buildAggregate(v *plan.Aggregate) Executor {
+	src := b.build(v.Src())
+	if v.Strategy == plan.AggregateStream {
+		return &StreamAggExec{
+			Src:          src,
+			AggFuncs:     v.AggFuncs,
+			GroupByItems: v.GroupByItems,
+			ctx:          b.ctx,
+		}
+	}
+	return &HashAggExec{
+		Src:          src,
+		AggFuncs:     v.AggFuncs,
+		GroupByItems: v.GroupByItems,
+		ctx:          b.ctx,
+	}
+}
@@ -36,13 +36,15 @@ type Compiler struct {
 func (c *Compiler) Compile(ctx context.Context, node ast.StmtNode) (stmt.Statement, error) {
 	if optimizer.IsSupported(node) {
 		is := sessionctx.GetDomain(ctx).InfoSchema()
-		p, err := optimizer.Optimize(is, ctx, node)
+		p, err := optimizer.Optimize(is, ctx, node, &subqueryBuilder{is: is})
 		if err != nil {
 			return nil, errors.Trace(err)
 		}
 		a := &statementAdapter{
 			is:   is,
 			plan: p,
+			text: node.Text(),
+			node: node,
 		}
 		return a, nil
 	}
@@ -658,6 +658,12 @@ func convertAlterTableSpec(converter *expressionConverter, v *ast.AlterTableSpec
 		oldAlterSpec.Action = ddl.AlterDropPrimaryKey
 	case ast.AlterTableOption:
 		oldAlterSpec.Action = ddl.AlterTableOpt
+	case ast.AlterTableRenameTable:
+		oldAlterSpec.Action = ddl.AlterRenameTable
+	case ast.AlterTableModifyColumn:
+		oldAlterSpec.Action = ddl.AlterModifyColumn
+	case ast.AlterTableChangeColumn:
+		oldAlterSpec.Action = ddl.AlterChangeColumn
 	}
 	if v.Column != nil {
 		oldColDef, err := convertColumnDef(converter, v.Column)
@@ -665,6 +671,9 @@ func convertAlterTableSpec(converter *expressionConverter, v *ast.AlterTableSpec
 			return nil, errors.Trace(err)
 		}
 		oldAlterSpec.Column = oldColDef
+		if v.Tp == ast.AlterTableModifyColumn {
+			oldAlterSpec.Name = oldColDef.Name
+		}
 	}
 	if v.Position != nil {
 		oldAlterSpec.Position = &ddl.ColumnPosition{}
@@ -683,6 +692,12 @@ func convertAlterTableSpec(converter *expressionConverter, v *ast.AlterTableSpec
 	if v.DropColumn != nil {
 		oldAlterSpec.Name = joinColumnName(v.DropColumn)
 	}
+	if v.OldColumnName != nil {
+		oldAlterSpec.Name = joinColumnName(v.OldColumnName)
+	}
+	if v.NewTable != nil {
+		oldAlterSpec.NewTable = table.Ident{Schema: v.NewTable.Schema, Name: v.NewTable.Name}
+	}
 	if v.Constraint != nil {
 		oldConstraint, err := convertConstraint(converter, v.Constraint)
 		if err != nil {
@@ -726,6 +741,8 @@ func convertAlterTableSpec(converter *expressionConverter, v *ast.AlterTableSpec
 			oldOpt.Tp = coldef.TblOptMinRows
 		case ast.TableOptionDelayKeyWrite:
 			oldOpt.Tp = coldef.TblOptDelayKeyWrite
+		case ast.TableOptionRowFormat:
+			oldOpt.Tp = coldef.TblOptRowFormat
 		}
 		oldAlterSpec.TableOpts = append(oldAlterSpec.TableOpts, oldOpt)
 	}
@@ -747,6 +764,17 @@ func convertAlterTable(converter *expressionConverter, v *ast.AlterTableStmt) (*
 	return oldAlterTable, nil
 }
 
+func convertRenameTable(converter *expressionConverter, v *ast.RenameTableStmt) (*stmts.RenameTableStmt, error) {
+	oldRenameTable := &stmts.RenameTableStmt{
+		Text: v.Text(),
+	}
+	for _, val := range v.TableToTables {
+		oldRenameTable.OldIdents = append(oldRenameTable.OldIdents, table.Ident{Schema: val.OldTable.Schema, Name: val.OldTable.Name})
+		oldRenameTable.NewIdents = append(oldRenameTable.NewIdents, table.Ident{Schema: val.NewTable.Schema, Name: val.NewTable.Name})
+	}
+	return oldRenameTable, nil
+}
+
 func convertTruncateTable(converter *expressionConverter, v *ast.TruncateTableStmt) (*stmts.TruncateTableStmt, error) {
 	return &stmts.TruncateTableStmt{
 		TableIdent: table.Ident{
@@ -878,6 +906,8 @@ func convertShow(converter *expressionConverter, v *ast.ShowStmt) (*stmts.ShowSt
 		oldShow.Target = stmt.ShowTableStatus
 	case ast.ShowTriggers:
 		oldShow.Target = stmt.ShowTriggers
+	case ast.ShowProcessList:
+		oldShow.Target = stmt.ShowProcessList
 	case ast.ShowNone:
 		oldShow.Target = stmt.ShowNone
 	}
@@ -970,9 +1000,21 @@ func convertUserSpec(converter *expressionConverter, v *ast.UserSpec) (*coldef.U
 	return oldSpec, nil
 }
 
+func convertRequire(v ast.RequireType) int {
+	switch v {
+	case ast.RequireNone:
+		return coldef.RequireNone
+	case ast.RequireSSL:
+		return coldef.RequireSSL
+	default:
+		return coldef.RequireUnspecified
+	}
+}
+
 func convertCreateUser(converter *expressionConverter, v *ast.CreateUserStmt) (*stmts.CreateUserStmt, error) {
 	oldCreateUser := &stmts.CreateUserStmt{
 		IfNotExists: v.IfNotExists,
+		Require:     convertRequire(v.Require),
 		Text:        v.Text(),
 	}
 	for _, val := range v.Specs {
@@ -1012,7 +1054,8 @@ func convertPrivElem(converter *expressionConverter, v *ast.PrivElem) (*coldef.P
 
 func convertGrant(converter *expressionConverter, v *ast.GrantStmt) (*stmts.GrantStmt, error) {
 	oldGrant := &stmts.GrantStmt{
-		Text: v.Text(),
+		Require: convertRequire(v.Require),
+		Text:    v.Text(),
 	}
 	for _, val := range v.Privs {
 		oldPrim, err := convertPrivElem(converter, val)
@@ -1053,3 +1096,11 @@ func convertGrant(converter *expressionConverter, v *ast.GrantStmt) (*stmts.Gran
 	}
 	return oldGrant, nil
 }
+
+func convertKill(converter *expressionConverter, v *ast.KillStmt) (*stmts.KillStmt, error) {
+	return &stmts.KillStmt{
+		Query:        v.Query,
+		ConnectionID: v.ConnectionID,
+		Text:         v.Text(),
+	}, nil
+}
@@ -56,6 +56,8 @@ func (con *Converter) Convert(node ast.Node) (stmt.Statement, error) {
 		return convertAlterTable(c, v)
 	case *ast.TruncateTableStmt:
 		return convertTruncateTable(c, v)
+	case *ast.RenameTableStmt:
+		return convertRenameTable(c, v)
 	case *ast.ExplainStmt:
 		return convertExplain(c, v)
 	case *ast.PrepareStmt:
@@ -86,6 +88,8 @@ func (con *Converter) Convert(node ast.Node) (stmt.Statement, error) {
 		return convertDo(c, v)
 	case *ast.GrantStmt:
 		return convertGrant(c, v)
+	case *ast.KillStmt:
+		return convertKill(c, v)
 	}
 	return nil, nil
 }
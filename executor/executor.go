@@ -24,10 +24,12 @@ import (
 	"github.com/pingcap/tidb/optimizer/evaluator"
 	"github.com/pingcap/tidb/optimizer/plan"
 	"github.com/pingcap/tidb/sessionctx/forupdate"
+	"github.com/pingcap/tidb/sessionctx/variable"
 	"github.com/pingcap/tidb/table"
 	"github.com/pingcap/tidb/table/tables"
 	"github.com/pingcap/tidb/terror"
 	"github.com/pingcap/tidb/util"
+	"github.com/pingcap/tidb/util/collate"
 	"github.com/pingcap/tidb/util/types"
 )
 
@@ -90,6 +92,9 @@ func (e *TableScanExec) Fields() []*ast.ResultField {
 
 // Next implements Execution Next interface.
 func (e *TableScanExec) Next() (*Row, error) {
+	if err := variable.CheckKilled(e.ctx); err != nil {
+		return nil, errors.Trace(err)
+	}
 	if e.iter == nil {
 		txn, err := e.ctx.GetTxn(false)
 		if err != nil {
@@ -112,18 +117,27 @@ func (e *TableScanExec) Next() (*Row, error) {
 	// r2_col1 -> r2 col1 value
 	// r2_col2 -> r2 col2 value
 	// ...
+	// or in single kv row format:
+	// r1 -> r1 row data
+	// r2 -> r2 row data
+	// ...
 	rowKey := e.iter.Key()
 	handle, err := tables.DecodeRecordKeyHandle(rowKey)
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
 
+	txn, err := e.ctx.GetTxn(false)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
 	// TODO: we could just fetch mentioned columns' values
 	row := &Row{}
-	row.Data, err = e.t.Row(e.ctx, handle)
+	row.Data, err = e.t.DecodeRow(txn, handle, e.iter.Value(), e.t.Cols())
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
+	variable.AddRowsExamined(e.ctx, 1)
 	// Set result fields value.
 	for i, v := range e.fields {
 		v.Expr.SetValue(row.Data[i])
@@ -163,10 +177,16 @@ type IndexRangeExec struct {
 	lowExclude  bool
 	highVals    []interface{}
 	highExclude bool
+	// lowKeys and highKeys are the bounds to compare with the index values, the strings
+	// are converted to the weight keys under the case insensitive collations.
+	lowKeys  []interface{}
+	highKeys []interface{}
 
-	iter       kv.IndexIterator
-	skipLowCmp bool
-	finished   bool
+	iter kv.IndexIterator
+	// skipStartCmp indicates the start bound doesn't need to be compared, the start
+	// bound is the low bound for ascending scan and the high bound for descending scan.
+	skipStartCmp bool
+	finished     bool
 }
 
 // Fields implements Executor Fields interface.
@@ -176,27 +196,29 @@ func (e *IndexRangeExec) Fields() []*ast.ResultField {
 
 // Next implements Executor Next interface.
 func (e *IndexRangeExec) Next() (*Row, error) {
+	if err := variable.CheckKilled(e.scan.ctx); err != nil {
+		return nil, errors.Trace(err)
+	}
 	if e.iter == nil {
-		seekVals := make([]interface{}, len(e.lowVals))
-		for i := 0; i < len(seekVals); i++ {
-			var err error
-			if e.lowVals[i] == plan.MinNotNullVal {
-				seekVals[i] = []byte{}
-			} else {
-				seekVals[i], err = types.Convert(e.lowVals[i], e.scan.valueTypes[i])
-				if err != nil {
-					return nil, errors.Trace(err)
-				}
-			}
+		var err error
+		e.lowKeys, err = e.boundKeys(e.lowVals)
+		if err != nil {
+			return nil, errors.Trace(err)
 		}
-
-		txn, err := e.scan.ctx.GetTxn(false)
+		e.highKeys, err = e.boundKeys(e.highVals)
 		if err != nil {
 			return nil, errors.Trace(err)
 		}
-		e.iter, _, err = e.scan.idx.Seek(txn, seekVals)
+		if e.scan.Desc {
+			err = e.seekReverse()
+		} else {
+			err = e.seek()
+		}
 		if err != nil {
-			return nil, types.EOFAsNil(err)
+			return nil, errors.Trace(err)
+		}
+		if e.finished {
+			return nil, nil
 		}
 	}
 	for {
@@ -207,22 +229,28 @@ func (e *IndexRangeExec) Next() (*Row, error) {
 		if err != nil {
 			return nil, types.EOFAsNil(err)
 		}
-		if !e.skipLowCmp {
-			var cmp int
-			cmp, err = indexCompare(idxKey, e.lowVals)
+		var beyondStart, beyondEnd func([]interface{}) (bool, error)
+		if e.scan.Desc {
+			beyondStart, beyondEnd = e.aboveHigh, e.belowLow
+		} else {
+			beyondStart, beyondEnd = e.belowLow, e.aboveHigh
+		}
+		if !e.skipStartCmp {
+			var out bool
+			out, err = beyondStart(idxKey)
 			if err != nil {
 				return nil, errors.Trace(err)
 			}
-			if cmp < 0 || (cmp == 0 && e.lowExclude) {
+			if out {
 				continue
 			}
-			e.skipLowCmp = true
+			e.skipStartCmp = true
 		}
-		cmp, err := indexCompare(idxKey, e.highVals)
+		out, err := beyondEnd(idxKey)
 		if err != nil {
 			return nil, errors.Trace(err)
 		}
-		if cmp > 0 || (cmp == 0 && e.highExclude) {
+		if out {
 			// This span has finished iteration.
 			e.finished = true
 			continue
@@ -236,6 +264,102 @@ func (e *IndexRangeExec) Next() (*Row, error) {
 	}
 }
 
+// seek positions the iterator on the low bound.
+func (e *IndexRangeExec) seek() error {
+	seekVals := make([]interface{}, len(e.lowVals))
+	for i := 0; i < len(seekVals); i++ {
+		var err error
+		if e.lowVals[i] == plan.MinNotNullVal {
+			seekVals[i] = []byte{}
+		} else {
+			seekVals[i], err = types.Convert(e.lowVals[i], e.scan.valueTypes[i])
+			if err != nil {
+				return errors.Trace(err)
+			}
+		}
+	}
+
+	txn, err := e.scan.ctx.GetTxn(false)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	e.iter, _, err = e.scan.idx.Seek(txn, seekVals)
+	if err != nil {
+		e.finished = true
+		return types.EOFAsNil(err)
+	}
+	return nil
+}
+
+// seekReverse positions the iterator on the high bound, the index is iterated in descending order.
+func (e *IndexRangeExec) seekReverse() error {
+	var seekVals []interface{}
+	for i := 0; i < len(e.highVals); i++ {
+		if e.highVals[i] == plan.MaxVal {
+			// All the entries with the previous values as prefix are not larger than the high bound.
+			break
+		}
+		var val interface{}
+		if e.highVals[i] == plan.MinNotNullVal {
+			val = []byte{}
+		} else {
+			var err error
+			val, err = types.Convert(e.highVals[i], e.scan.valueTypes[i])
+			if err != nil {
+				return errors.Trace(err)
+			}
+		}
+		seekVals = append(seekVals, val)
+	}
+
+	txn, err := e.scan.ctx.GetTxn(false)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	e.iter, err = e.scan.idx.SeekReverse(txn, seekVals)
+	if err != nil {
+		e.finished = true
+		return types.EOFAsNil(err)
+	}
+	return nil
+}
+
+// boundKeys converts the bound values to compare with the index values.
+func (e *IndexRangeExec) boundKeys(vals []interface{}) ([]interface{}, error) {
+	keys := make([]interface{}, len(vals))
+	for i, v := range vals {
+		keys[i] = v
+		tp := e.scan.valueTypes[i]
+		if v == nil || v == plan.MinNotNullVal || v == plan.MaxVal || !collate.IsCaseInsensitive(tp.Collate) {
+			continue
+		}
+		cv, err := types.Convert(v, tp)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		keys[i] = collate.SortKey(tp.Collate, cv)
+	}
+	return keys, nil
+}
+
+// belowLow checks if the index values are less than the low bound.
+func (e *IndexRangeExec) belowLow(idxKey []interface{}) (bool, error) {
+	cmp, err := indexCompare(idxKey, e.lowKeys)
+	if err != nil {
+		return false, errors.Trace(err)
+	}
+	return cmp < 0 || (cmp == 0 && e.lowExclude), nil
+}
+
+// aboveHigh checks if the index values are larger than the high bound.
+func (e *IndexRangeExec) aboveHigh(idxKey []interface{}) (bool, error) {
+	cmp, err := indexCompare(idxKey, e.highKeys)
+	if err != nil {
+		return false, errors.Trace(err)
+	}
+	return cmp > 0 || (cmp == 0 && e.highExclude), nil
+}
+
 // indexCompare compares multi column index.
 // The length of boundVals may be less than idxKey.
 func indexCompare(idxKey []interface{}, boundVals []interface{}) (int, error) {
@@ -293,6 +417,7 @@ func (e *IndexRangeExec) lookupRow(h int64) (*Row, error) {
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
+	variable.AddRowsExamined(e.scan.ctx, 1)
 	rowKey := &RowKeyEntry{
 		Tbl: e.scan.tbl,
 		Key: string(e.scan.tbl.RecordKey(h, nil)),
@@ -308,7 +433,7 @@ func (e *IndexRangeExec) Close() error {
 		e.iter = nil
 	}
 	e.finished = false
-	e.skipLowCmp = false
+	e.skipStartCmp = false
 	return nil
 }
 
@@ -450,11 +575,26 @@ func (e *FilterExec) Close() error {
 	return e.Src.Close()
 }
 
+// maxSelectLockRetry is the max times SelectLockExec reads the rows again when the locked
+// rows are changed by other transactions.
+const maxSelectLockRetry = 10
+
 // SelectLockExec represents a select lock executor.
+// With FOR UPDATE in a pessimistic transaction, all the rows are locked before the first one
+// is returned. If a locked row is changed after it is read, the rows are read again from the
+// latest snapshot with the source executor rebuilt. Otherwise the rows are locked one by one
+// when they are returned.
 type SelectLockExec struct {
 	Src  Executor
 	Lock ast.SelectLockType
 	ctx  context.Context
+	// rebuild builds a new source executor.
+	rebuild func() Executor
+	rows    []*Row
+	cursor  int
+	locked  bool
+	// buffered is true if the rows are read and locked before the first one is returned.
+	buffered bool
 }
 
 // Fields implements Executor Fields interface.
@@ -464,31 +604,106 @@ func (e *SelectLockExec) Fields() []*ast.ResultField {
 
 // Next implements Executor Next interface.
 func (e *SelectLockExec) Next() (*Row, error) {
-	row, err := e.Src.Next()
-	if err != nil {
-		return nil, errors.Trace(err)
-	}
-	if row == nil {
-		return nil, nil
+	if e.Lock != ast.SelectLockForUpdate {
+		return e.Src.Next()
 	}
-	if len(row.RowKeys) != 0 && e.Lock == ast.SelectLockForUpdate {
-		forupdate.SetForUpdate(e.ctx)
-		txn, err := e.ctx.GetTxn(false)
-		if err != nil {
+	if !e.locked {
+		e.locked = true
+		if _, err := e.ctx.GetTxn(false); err != nil {
 			return nil, errors.Trace(err)
 		}
-		for _, k := range row.RowKeys {
-			err = txn.LockKeys([]byte(k.Key))
+		e.buffered = variable.GetSessionVars(e.ctx).PessimisticTxn
+		if e.buffered {
+			err := e.lockRows()
 			if err != nil {
 				return nil, errors.Trace(err)
 			}
 		}
 	}
+	if !e.buffered {
+		row, err := e.Src.Next()
+		if row == nil || err != nil {
+			return nil, errors.Trace(err)
+		}
+		return row, errors.Trace(lockRowKeys(e.ctx, row))
+	}
+	if e.cursor >= len(e.rows) {
+		return nil, nil
+	}
+	row := e.rows[e.cursor]
+	e.cursor++
 	return row, nil
 }
 
+func (e *SelectLockExec) lockRows() error {
+	for i := 0; ; i++ {
+		err := e.fetchAndLock()
+		if err == nil {
+			return nil
+		}
+		if !terror.ErrorEqual(err, kv.ErrWriteConflict) || e.rebuild == nil || i >= maxSelectLockRetry {
+			return errors.Trace(err)
+		}
+		err = e.Src.Close()
+		if err != nil {
+			return errors.Trace(err)
+		}
+		e.Src = e.rebuild()
+	}
+}
+
+func (e *SelectLockExec) fetchAndLock() error {
+	e.rows = e.rows[:0]
+	var conflict error
+	for {
+		row, err := e.Src.Next()
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if row == nil {
+			return errors.Trace(conflict)
+		}
+		e.rows = append(e.rows, row)
+		err = lockRowKeys(e.ctx, row)
+		if terror.ErrorEqual(err, kv.ErrWriteConflict) {
+			// Lock all the rows before reading them again, so they are not changed again.
+			conflict = err
+			continue
+		}
+		if err != nil {
+			return errors.Trace(err)
+		}
+	}
+}
+
+// lockRowKeys locks the keys of the row, all the keys are locked even if some of them
+// return ErrWriteConflict.
+func lockRowKeys(ctx context.Context, row *Row) error {
+	if len(row.RowKeys) == 0 {
+		return nil
+	}
+	forupdate.SetForUpdate(ctx)
+	txn, err := ctx.GetTxn(false)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	var conflict error
+	for _, k := range row.RowKeys {
+		err = txn.LockKeys([]byte(k.Key))
+		if terror.ErrorEqual(err, kv.ErrWriteConflict) {
+			conflict = err
+			continue
+		}
+		if err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return errors.Trace(conflict)
+}
+
 // Close implements Executor Close interface.
 func (e *SelectLockExec) Close() error {
+	e.rows = nil
 	return e.Src.Close()
 }
 
@@ -618,6 +833,7 @@ func (e *SortExec) Next() (*Row, error) {
 				if err != nil {
 					return nil, errors.Trace(err)
 				}
+				orderRow.key[i] = collate.SortKey(exprCollation(byItem.Expr), orderRow.key[i])
 			}
 			e.Rows = append(e.Rows, orderRow)
 		}
@@ -0,0 +1,159 @@
+// Copyright 2015 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/model"
+	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/optimizer/plan"
+	"github.com/pingcap/tidb/util/types"
+)
+
+// ExplainExec represents an explain executor.
+// In traditional format, every operator of the chosen plan is a row, followed by a row
+// for every rejected alternative. In json format, the result is a single json document.
+type ExplainExec struct {
+	plan   *plan.ExplainPlan
+	fields []*ast.ResultField
+	rows   []*Row
+	cursor int
+}
+
+// explainJSON is the document returned by explain in json format.
+type explainJSON struct {
+	Plan                 *plan.ExplainNode   `json:"plan"`
+	RejectedAlternatives []*plan.ExplainNode `json:"rejected_alternatives,omitempty"`
+}
+
+func buildExplainFields(names ...string) []*ast.ResultField {
+	fields := make([]*ast.ResultField, len(names))
+	for i, name := range names {
+		col := &model.ColumnInfo{
+			Name:      model.NewCIStr(name),
+			FieldType: *types.NewFieldType(mysql.TypeVarchar),
+		}
+		col.Charset = mysql.DefaultCharset
+		col.Collate = mysql.DefaultCollationName
+		fields[i] = &ast.ResultField{
+			Column:       col,
+			ColumnAsName: col.Name,
+		}
+	}
+	return fields
+}
+
+// Fields implements Executor Fields interface.
+func (e *ExplainExec) Fields() []*ast.ResultField {
+	return e.fields
+}
+
+// Next implements Execution Next interface.
+func (e *ExplainExec) Next() (*Row, error) {
+	if e.rows == nil {
+		var err error
+		if e.plan.Format == ast.ExplainFormatJSON {
+			err = e.fetchJSON()
+		} else {
+			err = e.fetchRows()
+		}
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	if e.cursor >= len(e.rows) {
+		return nil, nil
+	}
+	row := e.rows[e.cursor]
+	e.cursor++
+	return row, nil
+}
+
+func (e *ExplainExec) fetchJSON() error {
+	doc := &explainJSON{}
+	var err error
+	doc.Plan, err = plan.ExplainTree(e.plan.StmtPlan)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	for _, alt := range e.plan.Alternatives {
+		node, err := plan.ExplainTree(alt)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		doc.RejectedAlternatives = append(doc.RejectedAlternatives, node)
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	// Keep the comparison operators in the operator info readable.
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	err = enc.Encode(doc)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	e.rows = []*Row{{Data: []interface{}{strings.TrimSpace(buf.String())}}}
+	return nil
+}
+
+func (e *ExplainExec) fetchRows() error {
+	root, err := plan.ExplainTree(e.plan.StmtPlan)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	e.rows = []*Row{}
+	e.appendNode(root, "", "")
+	for _, alt := range e.plan.Alternatives {
+		str, err := plan.Explain(alt)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		e.rows = append(e.rows, &Row{Data: []interface{}{
+			"Rejected", formatCost(alt.RowCount()), formatCost(alt.TotalCost()), str,
+		}})
+	}
+	return nil
+}
+
+// appendNode appends the rows of the node and its children, the operators are
+// indented to show the tree.
+func (e *ExplainExec) appendNode(node *plan.ExplainNode, prefix, childPrefix string) {
+	e.rows = append(e.rows, &Row{Data: []interface{}{
+		prefix + node.Operator, formatCost(node.RowCount), formatCost(node.TotalCost), node.Info,
+	}})
+	for i, child := range node.Children {
+		if i == len(node.Children)-1 {
+			e.appendNode(child, childPrefix+"└─", childPrefix+"  ")
+		} else {
+			e.appendNode(child, childPrefix+"├─", childPrefix+"│ ")
+		}
+	}
+}
+
+func formatCost(v float64) string {
+	return strconv.FormatFloat(v, 'f', 2, 64)
+}
+
+// Close implements Executor Close interface.
+func (e *ExplainExec) Close() error {
+	e.rows = nil
+	e.cursor = 0
+	return nil
+}
@@ -0,0 +1,604 @@
+// Copyright 2015 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/optimizer/evaluator"
+	"github.com/pingcap/tidb/optimizer/plan"
+	"github.com/pingcap/tidb/util/codec"
+	"github.com/pingcap/tidb/util/collate"
+	"github.com/pingcap/tidb/util/types"
+)
+
+var (
+	_ Executor = &HashJoinExec{}
+	_ Executor = &MergeJoinExec{}
+	_ Executor = &SemiJoinExec{}
+)
+
+// keyedRow is a row with its evaluated join key values.
+type keyedRow struct {
+	row  *Row
+	keys []interface{}
+}
+
+// joinRows joins a left row and a right row, a nil row is filled with NULL values.
+func joinRows(left *Row, leftLen int, right *Row, rightLen int) *Row {
+	joined := &Row{
+		Data: make([]interface{}, leftLen+rightLen),
+	}
+	if left != nil {
+		copy(joined.Data, left.Data)
+		joined.RowKeys = append(joined.RowKeys, left.RowKeys...)
+	}
+	if right != nil {
+		copy(joined.Data[leftLen:], right.Data)
+		joined.RowKeys = append(joined.RowKeys, right.RowKeys...)
+	}
+	return joined
+}
+
+// setFieldsValue sets the row data to result fields, so expressions referring
+// to the fields can be evaluated on the row.
+func setFieldsValue(fields []*ast.ResultField, data []interface{}) {
+	for i, f := range fields {
+		f.Expr.SetValue(data[i])
+	}
+}
+
+// evalKeys evaluates join keys, hasNull is true if any key is NULL, which never matches.
+// The strings are converted to the sort keys under the collations of the key expressions.
+func evalKeys(ctx context.Context, keys []ast.ExprNode) (vals []interface{}, hasNull bool, err error) {
+	vals = make([]interface{}, len(keys))
+	for i, key := range keys {
+		vals[i], err = evaluator.Eval(ctx, key)
+		if err != nil {
+			return nil, false, errors.Trace(err)
+		}
+		if types.IsNil(vals[i]) {
+			hasNull = true
+		}
+		vals[i] = collate.SortKey(exprCollation(key), vals[i])
+	}
+	return vals, hasNull, nil
+}
+
+// exprCollation returns the collation of the expression, it's empty if the type of the
+// expression is unknown.
+func exprCollation(expr ast.ExprNode) string {
+	if tp := expr.GetType(); tp != nil {
+		return tp.Collate
+	}
+	return ""
+}
+
+// compareKeys compares two join keys.
+func compareKeys(a, b []interface{}) (int, error) {
+	for i := range a {
+		cmp, err := types.Compare(a[i], b[i])
+		if err != nil {
+			return 0, errors.Trace(err)
+		}
+		if cmp != 0 {
+			return cmp, nil
+		}
+	}
+	return 0, nil
+}
+
+// hashKey encodes join key or group key values to a hash key. Values of the same type class
+// may have different Go types, so they are normalized before encoding, matched keys must be
+// checked by compareKeys again, because the normalization may lose precision.
+func hashKey(vals []interface{}) (string, error) {
+	normalized := make([]interface{}, len(vals))
+	for i, val := range vals {
+		switch x := types.RawData(val).(type) {
+		case nil:
+			normalized[i] = nil
+		case string, []byte:
+			normalized[i] = x
+		case mysql.Time:
+			normalized[i] = x.String()
+		case mysql.Duration:
+			normalized[i] = int64(x.Duration)
+		default:
+			f, err := types.ToFloat64(x)
+			if err != nil {
+				return "", errors.Trace(err)
+			}
+			normalized[i] = f
+		}
+	}
+	b, err := codec.EncodeValue(nil, normalized...)
+	if err != nil {
+		return "", errors.Trace(err)
+	}
+	return string(b), nil
+}
+
+// HashJoinExec represents a hash join executor.
+// It builds a hash table from the build side, then probes it with rows of the probe side.
+type HashJoinExec struct {
+	Build     Executor
+	Probe     Executor
+	BuildKeys []ast.ExprNode
+	ProbeKeys []ast.ExprNode
+	// BuildLeft indicates the build side is the left side of the join.
+	BuildLeft bool
+	// Outer indicates the probe rows without matched rows are returned with NULL values.
+	Outer bool
+	// Condition is evaluated on the joined row, it can be nil.
+	Condition ast.ExprNode
+
+	fields   []*ast.ResultField
+	ctx      context.Context
+	table    map[string][]*keyedRow
+	prepared bool
+	probe    *keyedRow
+	matches  []*keyedRow
+	cursor   int
+	matched  bool
+}
+
+// Fields implements Executor Fields interface.
+func (e *HashJoinExec) Fields() []*ast.ResultField {
+	return e.fields
+}
+
+func (e *HashJoinExec) prepare() error {
+	e.table = make(map[string][]*keyedRow)
+	for {
+		row, err := e.Build.Next()
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if row == nil {
+			break
+		}
+		setFieldsValue(e.Build.Fields(), row.Data)
+		keys, hasNull, err := evalKeys(e.ctx, e.BuildKeys)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if hasNull {
+			continue
+		}
+		key, err := hashKey(keys)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		e.table[key] = append(e.table[key], &keyedRow{row: row, keys: keys})
+	}
+	e.prepared = true
+	return nil
+}
+
+// fetchProbe fetches the next probe row and looks up the rows it may match.
+func (e *HashJoinExec) fetchProbe() (bool, error) {
+	row, err := e.Probe.Next()
+	if err != nil || row == nil {
+		return false, errors.Trace(err)
+	}
+	setFieldsValue(e.Probe.Fields(), row.Data)
+	keys, hasNull, err := evalKeys(e.ctx, e.ProbeKeys)
+	if err != nil {
+		return false, errors.Trace(err)
+	}
+	e.probe = &keyedRow{row: row, keys: keys}
+	e.matches = nil
+	e.cursor = 0
+	e.matched = false
+	if !hasNull {
+		key, err := hashKey(keys)
+		if err != nil {
+			return false, errors.Trace(err)
+		}
+		e.matches = e.table[key]
+	}
+	return true, nil
+}
+
+func (e *HashJoinExec) joinRow(build *Row) *Row {
+	buildLen, probeLen := len(e.Build.Fields()), len(e.Probe.Fields())
+	var joined *Row
+	if e.BuildLeft {
+		joined = joinRows(build, buildLen, e.probe.row, probeLen)
+	} else {
+		joined = joinRows(e.probe.row, probeLen, build, buildLen)
+	}
+	setFieldsValue(e.fields, joined.Data)
+	return joined
+}
+
+// Next implements Executor Next interface.
+func (e *HashJoinExec) Next() (*Row, error) {
+	if !e.prepared {
+		if err := e.prepare(); err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	for {
+		if e.probe == nil {
+			ok, err := e.fetchProbe()
+			if err != nil || !ok {
+				return nil, errors.Trace(err)
+			}
+		}
+		for e.cursor < len(e.matches) {
+			build := e.matches[e.cursor]
+			e.cursor++
+			cmp, err := compareKeys(e.probe.keys, build.keys)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			if cmp != 0 {
+				continue
+			}
+			joined := e.joinRow(build.row)
+			if e.Condition != nil {
+				match, err := evaluator.EvalBool(e.ctx, e.Condition)
+				if err != nil {
+					return nil, errors.Trace(err)
+				}
+				if !match {
+					continue
+				}
+			}
+			e.matched = true
+			return joined, nil
+		}
+		if e.Outer && !e.matched {
+			joined := e.joinRow(nil)
+			e.probe, e.matches = nil, nil
+			return joined, nil
+		}
+		e.probe, e.matches = nil, nil
+	}
+}
+
+// Close implements Executor Close interface.
+func (e *HashJoinExec) Close() error {
+	e.table = nil
+	e.probe, e.matches = nil, nil
+	e.prepared = false
+	err := e.Build.Close()
+	if err1 := e.Probe.Close(); err == nil {
+		err = err1
+	}
+	return errors.Trace(err)
+}
+
+// MergeJoinExec represents a merge join executor.
+// Both sides must be sorted by the join keys in ascending order.
+type MergeJoinExec struct {
+	Left      Executor
+	Right     Executor
+	LeftKeys  []ast.ExprNode
+	RightKeys []ast.ExprNode
+	Kind      plan.JoinType
+	// Condition is evaluated on the joined row, it can be nil.
+	Condition ast.ExprNode
+
+	fields []*ast.ResultField
+	ctx    context.Context
+
+	// For right join, the outer side is the right side,
+	// otherwise the outer side is the left side.
+	outerRow *keyedRow
+	// group holds the inner rows with the same keys.
+	group     []*keyedRow
+	groupKeys []interface{}
+	// innerRow is the next inner row that is not in group.
+	innerRow   *keyedRow
+	innerStart bool
+	innerDone  bool
+	// matches is the group if the outer row has no NULL keys.
+	matches []*keyedRow
+	cursor  int
+	matched bool
+}
+
+// Fields implements Executor Fields interface.
+func (e *MergeJoinExec) Fields() []*ast.ResultField {
+	return e.fields
+}
+
+func (e *MergeJoinExec) sides() (outer, inner Executor, outerKeys, innerKeys []ast.ExprNode) {
+	if e.Kind == plan.JoinRight {
+		return e.Right, e.Left, e.RightKeys, e.LeftKeys
+	}
+	return e.Left, e.Right, e.LeftKeys, e.RightKeys
+}
+
+func (e *MergeJoinExec) fetch(src Executor, keys []ast.ExprNode) (*keyedRow, bool, error) {
+	row, err := src.Next()
+	if err != nil || row == nil {
+		return nil, false, errors.Trace(err)
+	}
+	setFieldsValue(src.Fields(), row.Data)
+	vals, hasNull, err := evalKeys(e.ctx, keys)
+	if err != nil {
+		return nil, false, errors.Trace(err)
+	}
+	return &keyedRow{row: row, keys: vals}, hasNull, nil
+}
+
+// fetchInner fetches the next inner row, rows with NULL keys are skipped.
+func (e *MergeJoinExec) fetchInner() error {
+	_, inner, _, innerKeys := e.sides()
+	for {
+		row, hasNull, err := e.fetch(inner, innerKeys)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if row == nil {
+			e.innerRow, e.innerDone = nil, true
+			return nil
+		}
+		if !hasNull {
+			e.innerRow = row
+			return nil
+		}
+	}
+}
+
+// buildGroup collects the inner rows which have the same keys as the outer row.
+func (e *MergeJoinExec) buildGroup(keys []interface{}) error {
+	if e.groupKeys != nil {
+		cmp, err := compareKeys(keys, e.groupKeys)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if cmp == 0 {
+			// The outer row has the same keys as the previous one.
+			return nil
+		}
+	}
+	e.group, e.groupKeys = nil, keys
+	if !e.innerStart {
+		if err := e.fetchInner(); err != nil {
+			return errors.Trace(err)
+		}
+		e.innerStart = true
+	}
+	for !e.innerDone {
+		cmp, err := compareKeys(e.innerRow.keys, keys)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if cmp > 0 {
+			break
+		}
+		if cmp == 0 {
+			e.group = append(e.group, e.innerRow)
+		}
+		if err = e.fetchInner(); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return nil
+}
+
+func (e *MergeJoinExec) joinRow(inner *Row) *Row {
+	leftLen, rightLen := len(e.Left.Fields()), len(e.Right.Fields())
+	var joined *Row
+	if e.Kind == plan.JoinRight {
+		joined = joinRows(inner, leftLen, e.outerRow.row, rightLen)
+	} else {
+		joined = joinRows(e.outerRow.row, leftLen, inner, rightLen)
+	}
+	setFieldsValue(e.fields, joined.Data)
+	return joined
+}
+
+// Next implements Executor Next interface.
+func (e *MergeJoinExec) Next() (*Row, error) {
+	outer, _, outerKeys, _ := e.sides()
+	for {
+		if e.outerRow == nil {
+			row, hasNull, err := e.fetch(outer, outerKeys)
+			if err != nil || row == nil {
+				return nil, errors.Trace(err)
+			}
+			e.outerRow, e.matches, e.cursor, e.matched = row, nil, 0, false
+			if !hasNull {
+				// NULL keys never match, so the row has no matches.
+				if err = e.buildGroup(row.keys); err != nil {
+					return nil, errors.Trace(err)
+				}
+				e.matches = e.group
+			}
+		}
+		for e.cursor < len(e.matches) {
+			inner := e.matches[e.cursor]
+			e.cursor++
+			joined := e.joinRow(inner.row)
+			if e.Condition != nil {
+				match, err := evaluator.EvalBool(e.ctx, e.Condition)
+				if err != nil {
+					return nil, errors.Trace(err)
+				}
+				if !match {
+					continue
+				}
+			}
+			e.matched = true
+			return joined, nil
+		}
+		if e.Kind != plan.JoinInner && !e.matched {
+			joined := e.joinRow(nil)
+			e.outerRow, e.matches = nil, nil
+			return joined, nil
+		}
+		e.outerRow, e.matches = nil, nil
+	}
+}
+
+// Close implements Executor Close interface.
+func (e *MergeJoinExec) Close() error {
+	e.outerRow, e.innerRow = nil, nil
+	e.group, e.groupKeys, e.matches = nil, nil, nil
+	e.innerStart, e.innerDone = false, false
+	err := e.Left.Close()
+	if err1 := e.Right.Close(); err == nil {
+		err = err1
+	}
+	return errors.Trace(err)
+}
+
+// SemiJoinExec represents a hash semi join executor.
+// It builds a hash table from the inner side, then returns the outer rows which match
+// any inner row, or the outer rows which don't match any inner row for anti semi join.
+type SemiJoinExec struct {
+	Outer     Executor
+	Inner     Executor
+	OuterKeys []ast.ExprNode
+	InnerKeys []ast.ExprNode
+	// Anti indicates the outer rows without matched rows are returned.
+	Anti bool
+	// NullAware is set for "not in", the outer row is not returned if it is compared with NULL.
+	NullAware bool
+	// Condition is evaluated on the outer row and inner row, it can be nil.
+	Condition ast.ExprNode
+
+	ctx      context.Context
+	table    map[string][]*keyedRow
+	prepared bool
+	// innerEmpty is true if the inner side has no rows.
+	innerEmpty bool
+	// innerHasNull is true if any inner row has NULL keys.
+	innerHasNull bool
+}
+
+// Fields implements Executor Fields interface.
+func (e *SemiJoinExec) Fields() []*ast.ResultField {
+	return e.Outer.Fields()
+}
+
+func (e *SemiJoinExec) prepare() error {
+	e.table = make(map[string][]*keyedRow)
+	e.innerEmpty = true
+	for {
+		row, err := e.Inner.Next()
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if row == nil {
+			break
+		}
+		e.innerEmpty = false
+		setFieldsValue(e.Inner.Fields(), row.Data)
+		keys, hasNull, err := evalKeys(e.ctx, e.InnerKeys)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if hasNull {
+			e.innerHasNull = true
+			continue
+		}
+		key, err := hashKey(keys)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		e.table[key] = append(e.table[key], &keyedRow{row: row, keys: keys})
+	}
+	e.prepared = true
+	return nil
+}
+
+// match checks if the current outer row matches any inner row.
+func (e *SemiJoinExec) match(keys []interface{}) (bool, error) {
+	key, err := hashKey(keys)
+	if err != nil {
+		return false, errors.Trace(err)
+	}
+	for _, inner := range e.table[key] {
+		cmp, err := compareKeys(keys, inner.keys)
+		if err != nil {
+			return false, errors.Trace(err)
+		}
+		if cmp != 0 {
+			continue
+		}
+		if e.Condition == nil {
+			return true, nil
+		}
+		setFieldsValue(e.Inner.Fields(), inner.row.Data)
+		match, err := evaluator.EvalBool(e.ctx, e.Condition)
+		if err != nil {
+			return false, errors.Trace(err)
+		}
+		if match {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Next implements Executor Next interface.
+func (e *SemiJoinExec) Next() (*Row, error) {
+	if !e.prepared {
+		if err := e.prepare(); err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	for {
+		row, err := e.Outer.Next()
+		if err != nil || row == nil {
+			return nil, errors.Trace(err)
+		}
+		setFieldsValue(e.Outer.Fields(), row.Data)
+		keys, hasNull, err := evalKeys(e.ctx, e.OuterKeys)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		matched := false
+		if !hasNull {
+			matched, err = e.match(keys)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+		}
+		if !e.Anti {
+			if matched {
+				return row, nil
+			}
+			continue
+		}
+		if matched {
+			continue
+		}
+		if e.NullAware && !e.innerEmpty && (hasNull || e.innerHasNull) {
+			// "not in" is NULL if the value is compared with NULL and no value matches.
+			continue
+		}
+		return row, nil
+	}
+}
+
+// Close implements Executor Close interface.
+func (e *SemiJoinExec) Close() error {
+	e.table = nil
+	e.prepared = false
+	e.innerHasNull = false
+	err := e.Outer.Close()
+	if err1 := e.Inner.Close(); err == nil {
+		err = err1
+	}
+	return errors.Trace(err)
+}
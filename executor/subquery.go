@@ -0,0 +1,74 @@
+// Copyright 2015 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package executor
+
+import (
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/infoschema"
+	"github.com/pingcap/tidb/optimizer/plan"
+)
+
+var (
+	_ ast.SubqueryExec     = &subqueryExec{}
+	_ plan.SubQueryBuilder = &subqueryBuilder{}
+)
+
+// subqueryExec executes a subquery plan for the evaluator.
+// A new executor is built for every evaluation, because a correlated
+// subquery is evaluated for every outer row.
+type subqueryExec struct {
+	is   infoschema.InfoSchema
+	plan plan.Plan
+}
+
+// EvalRows implements ast.SubqueryExec interface.
+func (s *subqueryExec) EvalRows(ctx context.Context, rowCount int) ([]interface{}, error) {
+	b := newExecutorBuilder(ctx, s.is)
+	e := b.build(s.plan)
+	if b.err != nil {
+		return nil, errors.Trace(b.err)
+	}
+	defer e.Close()
+	var rows []interface{}
+	for rowCount < 0 || len(rows) < rowCount {
+		row, err := e.Next()
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if row == nil {
+			break
+		}
+		if len(row.Data) == 1 {
+			rows = append(rows, row.Data[0])
+		} else {
+			rows = append(rows, row.Data)
+		}
+	}
+	return rows, nil
+}
+
+// subqueryBuilder implements plan.SubQueryBuilder interface.
+type subqueryBuilder struct {
+	is infoschema.InfoSchema
+}
+
+// Build implements plan.SubQueryBuilder interface.
+func (b *subqueryBuilder) Build(p plan.Plan) ast.SubqueryExec {
+	return &subqueryExec{
+		is:   b.is,
+		plan: p,
+	}
+}
@@ -25,6 +25,7 @@ import (
 	"github.com/pingcap/tidb/context"
 	"github.com/pingcap/tidb/mysql"
 	"github.com/pingcap/tidb/parser/opcode"
+	"github.com/pingcap/tidb/util/collate"
 	"github.com/pingcap/tidb/util/types"
 )
 
@@ -393,7 +394,7 @@ func (o *BinaryOperation) evalComparisonOp(ctx context.Context, args map[interfa
 		return nil, nil
 	}
 
-	n, err := types.Compare(a, b)
+	n, err := collate.Compare(collationOf(o.L, o.R), a, b)
 	if err != nil {
 		return nil, o.traceErr(err)
 	}
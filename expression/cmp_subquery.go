@@ -20,7 +20,7 @@ import (
 	"github.com/pingcap/tidb/context"
 
 	"github.com/pingcap/tidb/parser/opcode"
-	"github.com/pingcap/tidb/util/types"
+	"github.com/pingcap/tidb/util/collate"
 )
 
 // CompareSubQuery is the expression for "expr cmp (select ...)".
@@ -100,7 +100,7 @@ func (cs *CompareSubQuery) checkAllResult(lv interface{}, result []interface{})
 			continue
 		}
 
-		comRes, err := types.Compare(lv, v)
+		comRes, err := collate.Compare(collationOf(cs.L), lv, v)
 		if err != nil {
 			return nil, errors.Trace(err)
 		}
@@ -132,7 +132,7 @@ func (cs *CompareSubQuery) checkAnyResult(lv interface{}, result []interface{})
 			continue
 		}
 
-		comRes, err := types.Compare(lv, v)
+		comRes, err := collate.Compare(collationOf(cs.L), lv, v)
 		if err != nil {
 			return nil, errors.Trace(err)
 		}
@@ -32,6 +32,7 @@ import (
 	"github.com/pingcap/tidb/mysql"
 	"github.com/pingcap/tidb/parser/opcode"
 	"github.com/pingcap/tidb/sessionctx/variable"
+	"github.com/pingcap/tidb/util/collate"
 	"github.com/pingcap/tidb/util/types"
 )
 
@@ -179,6 +180,29 @@ func IsAggregateFunc(name string) bool {
 	return f.IsAggregate
 }
 
+// Collation returns the collation of the expression, it's the collation of the referenced field
+// for the identifier, and is empty for the other expressions.
+func Collation(e Expression) string {
+	switch x := e.(type) {
+	case *Ident:
+		return x.Collation
+	case *PExpr:
+		return Collation(x.Expr)
+	}
+	return ""
+}
+
+// collationOf returns the collation to compare the values of the expressions, it's the first
+// case insensitive collation of the expressions, the strings are compared by bytes if none.
+func collationOf(exprs ...Expression) string {
+	for _, e := range exprs {
+		if c := Collation(e); collate.IsCaseInsensitive(c) {
+			return c
+		}
+	}
+	return ""
+}
+
 // MentionedColumns returns a list of names for Ident expression.
 func MentionedColumns(e Expression) []string {
 	var names []string
@@ -46,6 +46,10 @@ type Ident struct {
 
 	// ReferIndex is the index to get the identifer data.
 	ReferIndex int
+
+	// Collation is the collation of the referenced field, the strings are compared
+	// under it.
+	Collation string
 }
 
 // Clone implements the Expression Clone interface.
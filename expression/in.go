@@ -24,6 +24,7 @@ import (
 	"github.com/juju/errors"
 	"github.com/pingcap/tidb/context"
 
+	"github.com/pingcap/tidb/util/collate"
 	"github.com/pingcap/tidb/util/types"
 )
 
@@ -98,7 +99,7 @@ func (n *PatternIn) checkInList(in interface{}, list []interface{}) (interface{}
 			continue
 		}
 
-		r, err := types.Compare(in, v)
+		r, err := collate.Compare(collationOf(n.Expr), in, v)
 		if err != nil {
 			return nil, err
 		}
@@ -22,6 +22,7 @@ import (
 
 	"github.com/juju/errors"
 	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/util/collate"
 	"github.com/pingcap/tidb/util/types"
 )
 
@@ -90,6 +91,9 @@ func (p *PatternLike) Eval(ctx context.Context, args map[interface{}]interface{}
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
+	// The pattern matches case insensitively under the case insensitive collations.
+	collation := collationOf(p.Expr)
+	sexpr = collate.Fold(collation, sexpr)
 
 	// We need to compile pattern if it has not been compiled or it is not static.
 	var needCompile = len(p.patChars) == 0 || !p.Pattern.IsStatic()
@@ -105,7 +109,12 @@ func (p *PatternLike) Eval(ctx context.Context, args map[interface{}]interface{}
 		if err != nil {
 			return nil, errors.Trace(err)
 		}
-		p.patChars, p.patTypes = compilePattern(spattern, p.Escape)
+		escape := p.Escape
+		if collate.IsCaseInsensitive(collation) && escape >= 'a' && escape <= 'z' {
+			// The escape character is folded with the pattern.
+			escape -= 'a' - 'A'
+		}
+		p.patChars, p.patTypes = compilePattern(collate.Fold(collation, spattern), escape)
 	}
 	match := doMatch(sexpr, p.patChars, p.patTypes)
 	if p.Not {
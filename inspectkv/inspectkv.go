@@ -14,6 +14,7 @@
 package inspectkv
 
 import (
+	"bytes"
 	"io"
 
 	"github.com/juju/errors"
@@ -23,6 +24,8 @@ import (
 	"github.com/pingcap/tidb/model"
 	"github.com/pingcap/tidb/table"
 	"github.com/pingcap/tidb/terror"
+	"github.com/pingcap/tidb/util/codec"
+	"github.com/pingcap/tidb/util/collate"
 )
 
 // DDLInfo is for DDL information.
@@ -63,6 +66,111 @@ func GetDDLInfo(txn kv.Transaction) (*DDLInfo, error) {
 	return info, nil
 }
 
+// GetDDLJobs returns the DDL jobs in the queue.
+func GetDDLJobs(txn kv.Transaction) ([]*model.Job, error) {
+	t := meta.NewMeta(txn)
+	cnt, err := t.DDLJobLength()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	jobs := make([]*model.Job, 0, cnt)
+	for i := int64(0); i < cnt; i++ {
+		job, err := t.GetDDLJob(i)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, nil
+}
+
+// GetHistoryDDLJobs returns at most maxNum recent history DDL jobs, the latest job is the first.
+func GetHistoryDDLJobs(txn kv.Transaction, maxNum int) ([]*model.Job, error) {
+	t := meta.NewMeta(txn)
+	jobs, err := t.GetAllHistoryDDLJobs()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	if len(jobs) > maxNum {
+		jobs = jobs[len(jobs)-maxNum:]
+	}
+	for i, j := 0, len(jobs)-1; i < j; i, j = i+1, j-1 {
+		jobs[i], jobs[j] = jobs[j], jobs[i]
+	}
+	return jobs, nil
+}
+
+// isJobCancellable returns whether the job can be cancelled. A job which hasn't changed the schema can
+// be cancelled, and the jobs adding a column or an index can be rolled back before they are public.
+func isJobCancellable(job *model.Job) bool {
+	if job.SchemaState == model.StateNone {
+		return true
+	}
+
+	switch job.Type {
+	case model.ActionAddColumn, model.ActionAddIndex, model.ActionModifyColumn:
+		return job.SchemaState != model.StatePublic
+	}
+	return false
+}
+
+// CancelJobs cancels the DDL jobs in the queue, the jobs are rolled back by the DDL worker later.
+// It returns the errors of the jobs which can't be cancelled, errs[i] is the error of ids[i].
+func CancelJobs(txn kv.Transaction, ids []int64) ([]error, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	jobs, err := GetDDLJobs(txn)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	errs := make([]error, len(ids))
+	t := meta.NewMeta(txn)
+	for i, id := range ids {
+		found := false
+		for j, job := range jobs {
+			if id != job.ID {
+				continue
+			}
+
+			found = true
+			if job.IsCancelling() || job.IsRollingBack() || job.IsFinished() {
+				errs[i] = errors.Errorf("DDL job %d is already cancelled or finished", id)
+				break
+			}
+			if !isJobCancellable(job) {
+				errs[i] = errors.Errorf("DDL job %d can't be cancelled in %s state", id, job.SchemaState)
+				break
+			}
+
+			job.State = model.JobCancelling
+			err = t.UpdateDDLJob(int64(j), job)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			break
+		}
+		if found {
+			continue
+		}
+
+		historyJob, err := t.GetHistoryDDLJob(id)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if historyJob != nil {
+			errs[i] = errors.Errorf("DDL job %d is already cancelled or finished", id)
+		} else {
+			errs[i] = errors.Errorf("DDL job %d not found", id)
+		}
+	}
+	return errs, nil
+}
+
 func nextIndexVals(data []interface{}) []interface{} {
 	// Add 0x0 to the end of data.
 	return append(data, nil)
@@ -162,3 +270,204 @@ func ScanSnapshotTableData(store kv.Storage, ver kv.Version, t table.Table, star
 
 	return records, nextHandle, errors.Trace(err)
 }
+
+// IndexProblemKind is the kind of the inconsistency between an index and the records.
+type IndexProblemKind int
+
+// List index problem kinds.
+const (
+	// DanglingIndexEntry means the index entry refers to a record which doesn't exist.
+	DanglingIndexEntry IndexProblemKind = iota + 1
+	// MissingIndexEntry means the record has no index entry.
+	MissingIndexEntry
+	// IndexValueMismatch means the values of the index entry differ from the record values.
+	IndexValueMismatch
+)
+
+// String implements fmt.Stringer interface.
+func (k IndexProblemKind) String() string {
+	switch k {
+	case DanglingIndexEntry:
+		return "dangling index entry"
+	case MissingIndexEntry:
+		return "missing index entry"
+	case IndexValueMismatch:
+		return "index value mismatch"
+	default:
+		return "unknown"
+	}
+}
+
+// IndexProblem is an inconsistency between an index and the records of the table.
+type IndexProblem struct {
+	Kind   IndexProblemKind
+	Handle int64
+	// Key is the key of the index entry, it's nil for the missing entry.
+	Key kv.Key
+	// IndexValues are the values decoded from the index entry, the strings under the case
+	// insensitive collations are their weight keys.
+	IndexValues  []interface{}
+	RecordValues []interface{}
+}
+
+// CheckIndex compares the entries of the index with the records of the table. It returns the
+// dangling entries, the missing entries and the entries whose values mismatch the records.
+func CheckIndex(txn kv.Transaction, t table.Table, idx *column.IndexedCol) ([]*IndexProblem, error) {
+	problems, err := checkIndexEntries(txn, t, idx)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	// The records of the mismatched entries are checked already.
+	checked := make(map[int64]bool, len(problems))
+	for _, p := range problems {
+		checked[p.Handle] = true
+	}
+	err = t.IterRecords(txn, t.FirstKey(), t.Cols(),
+		func(h int64, data []interface{}, cols []*column.Col) (bool, error) {
+			if checked[h] {
+				return true, nil
+			}
+			vals, err1 := idx.FetchValues(data)
+			if err1 != nil {
+				return false, errors.Trace(err1)
+			}
+			exist, err1 := indexEntryExist(txn, idx, vals, h)
+			if err1 != nil {
+				return false, errors.Trace(err1)
+			}
+			if !exist {
+				problems = append(problems, &IndexProblem{Kind: MissingIndexEntry, Handle: h, RecordValues: vals})
+			}
+			return true, nil
+		})
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return problems, nil
+}
+
+// checkIndexEntries checks whether every entry of the index refers to a record with the same values.
+func checkIndexEntries(txn kv.Transaction, t table.Table, idx *column.IndexedCol) ([]*IndexProblem, error) {
+	it, err := idx.X.SeekFirst(txn)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	defer it.Close()
+
+	prefix := kv.GenIndexPrefix(t.IndexPrefix(), idx.ID)
+	var collations []string
+	if idx.Collated {
+		collations = make([]string, len(idx.Columns))
+		for i, ic := range idx.Columns {
+			collations[i] = t.Cols()[ic.Offset].Collate
+		}
+	}
+
+	var problems []*IndexProblem
+	for {
+		vals, h, err := it.Next()
+		if terror.ErrorEqual(err, io.EOF) {
+			return problems, nil
+		} else if err != nil {
+			return nil, errors.Trace(err)
+		}
+		key, err := indexEntryKey(prefix, idx.Unique, vals, h)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+
+		data, err := t.RowWithCols(txn, h, t.Cols())
+		if kv.IsErrNotFound(err) {
+			problems = append(problems, &IndexProblem{Kind: DanglingIndexEntry, Handle: h, Key: key, IndexValues: vals})
+			continue
+		} else if err != nil {
+			return nil, errors.Trace(err)
+		}
+
+		recordVals, err := idx.FetchValues(data)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		sortKeys := collate.SortKeys(collations, append([]interface{}(nil), recordVals...))
+		equal, err := equalEncodedValues(vals, sortKeys)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if !equal {
+			problems = append(problems, &IndexProblem{
+				Kind:         IndexValueMismatch,
+				Handle:       h,
+				Key:          key,
+				IndexValues:  vals,
+				RecordValues: recordVals,
+			})
+		}
+	}
+}
+
+// indexEntryKey returns the key of the index entry with the values decoded from the index, the values
+// are encoded as they are since they are the sort keys already.
+func indexEntryKey(prefix string, unique bool, vals []interface{}, h int64) (kv.Key, error) {
+	encoded := append([]interface{}(nil), vals...)
+	distinct := unique
+	for _, v := range vals {
+		if v == nil {
+			distinct = false
+		}
+	}
+	if !distinct {
+		encoded = append(encoded, h)
+	}
+	key, err := codec.EncodeKey([]byte(prefix), encoded...)
+	return key, errors.Trace(err)
+}
+
+func equalEncodedValues(a, b []interface{}) (bool, error) {
+	ka, err := codec.EncodeKey(nil, a...)
+	if err != nil {
+		return false, errors.Trace(err)
+	}
+	kb, err := codec.EncodeKey(nil, b...)
+	if err != nil {
+		return false, errors.Trace(err)
+	}
+	return bytes.Equal(ka, kb), nil
+}
+
+// indexEntryExist returns whether the index has the entry of the record values for the handle.
+func indexEntryExist(txn kv.Transaction, idx *column.IndexedCol, vals []interface{}, h int64) (bool, error) {
+	exist, _, err := idx.X.Exist(txn, vals, h)
+	if terror.ErrorEqual(err, kv.ErrKeyExists) {
+		// the entry refers to another record.
+		return false, nil
+	}
+	return exist, errors.Trace(err)
+}
+
+// RepairIndex fixes the problems found by CheckIndex. The dangling and mismatched entries are removed,
+// and the entries of the records are built if they are missing.
+func RepairIndex(txn kv.Transaction, idx *column.IndexedCol, problems []*IndexProblem) error {
+	for _, p := range problems {
+		if p.Key != nil {
+			if err := txn.Delete(p.Key); err != nil {
+				return errors.Trace(err)
+			}
+		}
+		if p.Kind == DanglingIndexEntry {
+			continue
+		}
+
+		exist, err := indexEntryExist(txn, idx, p.RecordValues, p.Handle)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if exist {
+			continue
+		}
+		if err = idx.X.Create(txn, p.RecordValues, p.Handle); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return nil
+}
@@ -165,3 +165,150 @@ func (s *testInspectSuite) TestInspect(c *C) {
 	c.Assert(nextVals, DeepEquals, []interface{}{nil})
 	c.Assert(err, IsNil)
 }
+
+func (s *testInspectSuite) TestDDLJobs(c *C) {
+	driver := localstore.Driver{Driver: goleveldb.MemoryDriver{}}
+	store, err := driver.Open("memory:test_ddl_jobs")
+	c.Assert(err, IsNil)
+	defer store.Close()
+
+	txn, err := store.Begin()
+	c.Assert(err, IsNil)
+	defer txn.Rollback()
+
+	t := meta.NewMeta(txn)
+	jobs := []*model.Job{
+		{ID: 1, Type: model.ActionCreateSchema},
+		{ID: 2, Type: model.ActionAddIndex, SchemaState: model.StateWriteReorganization},
+		{ID: 3, Type: model.ActionDropColumn, SchemaState: model.StateWriteOnly},
+		{ID: 4, Type: model.ActionAddColumn, State: model.JobCancelling, SchemaState: model.StateDeleteOnly},
+	}
+	for _, job := range jobs {
+		err = t.EnQueueDDLJob(job)
+		c.Assert(err, IsNil)
+	}
+	for i := int64(5); i <= 7; i++ {
+		err = t.AddHistoryDDLJob(&model.Job{ID: i, State: model.JobDone})
+		c.Assert(err, IsNil)
+	}
+
+	queued, err := GetDDLJobs(txn)
+	c.Assert(err, IsNil)
+	c.Assert(queued, HasLen, 4)
+	c.Assert(queued[3].ID, Equals, int64(4))
+
+	history, err := GetHistoryDDLJobs(txn, 2)
+	c.Assert(err, IsNil)
+	c.Assert(history, HasLen, 2)
+	c.Assert(history[0].ID, Equals, int64(7))
+	c.Assert(history[1].ID, Equals, int64(6))
+
+	errs, err := CancelJobs(txn, []int64{1, 2, 3, 4, 5, 8})
+	c.Assert(err, IsNil)
+	c.Assert(errs[0], IsNil)
+	c.Assert(errs[1], IsNil)
+	c.Assert(errs[2], NotNil)
+	c.Assert(errs[3], NotNil)
+	c.Assert(errs[4], NotNil)
+	c.Assert(errs[5], NotNil)
+
+	queued, err = GetDDLJobs(txn)
+	c.Assert(err, IsNil)
+	c.Assert(queued[0].State, Equals, model.JobCancelling)
+	c.Assert(queued[1].State, Equals, model.JobCancelling)
+	c.Assert(queued[2].State, Equals, model.JobNone)
+}
+
+func (s *testInspectSuite) TestCheckIndex(c *C) {
+	driver := localstore.Driver{Driver: goleveldb.MemoryDriver{}}
+	store, err := driver.Open("memory:test_check_index")
+	c.Assert(err, IsNil)
+	defer store.Close()
+
+	txn, err := store.Begin()
+	c.Assert(err, IsNil)
+	dbInfo := &model.DBInfo{
+		ID:   1,
+		Name: model.NewCIStr("a"),
+	}
+
+	col := &model.ColumnInfo{
+		Name:      model.NewCIStr("c"),
+		ID:        1,
+		Offset:    0,
+		State:     model.StatePublic,
+		FieldType: *types.NewFieldType(mysql.TypeLong),
+	}
+	idxInfo := &model.IndexInfo{
+		Name: model.NewCIStr("c"),
+		ID:   1,
+		Columns: []*model.IndexColumn{{
+			Name:   model.NewCIStr("c"),
+			Offset: 0,
+			Length: types.UnspecifiedLength,
+		}},
+		State: model.StatePublic,
+	}
+	tbInfo := &model.TableInfo{
+		ID:      1,
+		Name:    model.NewCIStr("t"),
+		State:   model.StatePublic,
+		Columns: []*model.ColumnInfo{col},
+		Indices: []*model.IndexInfo{idxInfo},
+	}
+	t := meta.NewMeta(txn)
+	err = t.CreateDatabase(dbInfo)
+	c.Assert(err, IsNil)
+	err = t.CreateTable(dbInfo.ID, tbInfo)
+	c.Assert(err, IsNil)
+	c.Assert(txn.Commit(), IsNil)
+
+	ctx := mock.NewContext()
+	ctx.Store = store
+	variable.BindSessionVars(ctx)
+
+	alloc := autoid.NewAllocator(store, dbInfo.ID)
+	tb, err := tables.TableFromMeta(alloc, tbInfo)
+	c.Assert(err, IsNil)
+	idx := tb.Indices()[0]
+	for _, v := range []int64{10, 20, 30} {
+		_, err = tb.AddRecord(ctx, []interface{}{v}, 0)
+		c.Assert(err, IsNil)
+	}
+	c.Assert(ctx.FinishTxn(false), IsNil)
+
+	txn, err = store.Begin()
+	c.Assert(err, IsNil)
+	problems, err := CheckIndex(txn, tb, idx)
+	c.Assert(err, IsNil)
+	c.Assert(problems, HasLen, 0)
+
+	// Make a dangling entry, a missing entry and a mismatched entry.
+	c.Assert(idx.X.Create(txn, []interface{}{int64(40)}, 4), IsNil)
+	c.Assert(idx.X.Delete(txn, []interface{}{int64(20)}, 2), IsNil)
+	c.Assert(idx.X.Delete(txn, []interface{}{int64(30)}, 3), IsNil)
+	c.Assert(idx.X.Create(txn, []interface{}{int64(31)}, 3), IsNil)
+
+	problems, err = CheckIndex(txn, tb, idx)
+	c.Assert(err, IsNil)
+	c.Assert(problems, HasLen, 3)
+	c.Assert(problems[0].Kind, Equals, IndexValueMismatch)
+	c.Assert(problems[0].Handle, Equals, int64(3))
+	c.Assert(problems[0].IndexValues, DeepEquals, []interface{}{int64(31)})
+	c.Assert(problems[0].RecordValues, DeepEquals, []interface{}{int64(30)})
+	c.Assert(problems[1].Kind, Equals, DanglingIndexEntry)
+	c.Assert(problems[1].Handle, Equals, int64(4))
+	c.Assert(problems[2].Kind, Equals, MissingIndexEntry)
+	c.Assert(problems[2].Handle, Equals, int64(2))
+	c.Assert(problems[2].Key, IsNil)
+
+	err = RepairIndex(txn, idx, problems)
+	c.Assert(err, IsNil)
+	problems, err = CheckIndex(txn, tb, idx)
+	c.Assert(err, IsNil)
+	c.Assert(problems, HasLen, 0)
+	idxRows, _, err := ScanIndexData(txn, idx.X, []interface{}{nil}, 10)
+	c.Assert(err, IsNil)
+	c.Assert(idxRows, HasLen, 3)
+	c.Assert(txn.Commit(), IsNil)
+}
@@ -65,10 +65,11 @@ func (b *btreeBuffer) Release() {
 }
 
 type btreeIter struct {
-	e  *memkv.Enumerator
-	k  string
-	v  []byte
-	ok bool
+	e       *memkv.Enumerator
+	k       string
+	v       []byte
+	ok      bool
+	reverse bool
 }
 
 // Seek creates a new Iterator based on the provided key.
@@ -96,6 +97,35 @@ func (b *btreeBuffer) Seek(k Key) (Iterator, error) {
 	return iter, nil
 }
 
+// SeekReverse creates a new reversed Iterator based on the provided key.
+func (b *btreeBuffer) SeekReverse(k Key) (Iterator, error) {
+	var e *memkv.Enumerator
+	var err error
+	if k != nil {
+		e, _ = b.tree.Seek(toIfaces([]byte(k)))
+		// Skip the first entry which is not less than k, the enumerator moves to
+		// the previous entry. If there is no such entry, all the entries are less
+		// than k, iterate from the last one.
+		_, _, err = e.Prev()
+	}
+	if k == nil || terror.ErrorEqual(err, io.EOF) {
+		e, err = b.tree.SeekLast()
+	}
+	if err != nil {
+		if terror.ErrorEqual(err, io.EOF) {
+			return &btreeIter{ok: false}, nil
+		}
+		return &btreeIter{ok: false}, errors.Trace(err)
+	}
+	iter := &btreeIter{e: e, reverse: true}
+	// the initial push...
+	err = iter.Next()
+	if err != nil {
+		return &btreeIter{ok: false}, errors.Trace(err)
+	}
+	return iter, nil
+}
+
 // Close implements Iterator Close.
 func (i *btreeIter) Close() {
 	//noop
@@ -113,7 +143,13 @@ func (i *btreeIter) Value() []byte {
 
 // Next implements Iterator Next.
 func (i *btreeIter) Next() error {
-	k, v, err := i.e.Next()
+	var k, v []interface{}
+	var err error
+	if i.reverse {
+		k, v, err = i.e.Prev()
+	} else {
+		k, v, err = i.e.Next()
+	}
 	if err != nil {
 		i.ok = false
 		if terror.ErrorEqual(err, io.EOF) {
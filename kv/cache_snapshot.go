@@ -136,7 +136,20 @@ func (c *cacheSnapshot) Seek(k Key) (Iterator, error) {
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
-	return newUnionIter(cacheIter, snapshotIter), nil
+	return newUnionIter(cacheIter, snapshotIter, false), nil
+}
+
+// SeekReverse creates a reversed iterator of snapshot.
+func (c *cacheSnapshot) SeekReverse(k Key) (Iterator, error) {
+	cacheIter, err := c.cache.SeekReverse(k)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	snapshotIter, err := c.snapshot.SeekReverse(k)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return newUnionIter(cacheIter, snapshotIter, true), nil
 }
 
 // Release reset membuffer and release snapshot.
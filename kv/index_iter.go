@@ -21,6 +21,7 @@ import (
 
 	"github.com/juju/errors"
 	"github.com/pingcap/tidb/util/codec"
+	"github.com/pingcap/tidb/util/collate"
 )
 
 var (
@@ -73,8 +74,8 @@ func (c *indexIter) Next() (val []interface{}, h int64, err error) {
 	if err != nil {
 		return nil, 0, errors.Trace(err)
 	}
-	// if index is *not* unique, the handle is in keybuf
-	if !c.idx.unique {
+	// if index is *not* unique or the indexed values have null, the handle is in keybuf
+	if !c.idx.unique || hasNull(vv) {
 		h = vv[len(vv)-1].(int64)
 		val = vv[0 : len(vv)-1]
 	} else {
@@ -93,12 +94,24 @@ func (c *indexIter) Next() (val []interface{}, h int64, err error) {
 	return
 }
 
+func hasNull(vals []interface{}) bool {
+	for _, v := range vals {
+		if v == nil {
+			return true
+		}
+	}
+	return false
+}
+
 // kvIndex is the data structure for index data in the KV store.
 type kvIndex struct {
 	indexName string
 	indexID   int64
 	unique    bool
 	prefix    string
+	// collations are the collations of the indexed columns, the strings are encoded
+	// as their weight keys under the case insensitive collations.
+	collations []string
 }
 
 // GenIndexPrefix generates the index prefix.
@@ -121,6 +134,25 @@ func NewKVIndex(indexPrefix string, indexName string, indexID int64, unique bool
 	return index
 }
 
+// NewCollatedKVIndex builds a new kvIndex object whose indexed values are encoded under the
+// collations of the indexed columns, the iterator returns the weight keys of the strings
+// instead of the strings under the case insensitive collations.
+func NewCollatedKVIndex(indexPrefix string, indexName string, indexID int64, unique bool, collations []string) Index {
+	index := NewKVIndex(indexPrefix, indexName, indexID, unique).(*kvIndex)
+	index.collations = collations
+	return index
+}
+
+// sortKeys returns the values to encode for the indexed values.
+func (c *kvIndex) sortKeys(indexedValues []interface{}) []interface{} {
+	if len(c.collations) == 0 {
+		return indexedValues
+	}
+	vals := make([]interface{}, len(indexedValues))
+	copy(vals, indexedValues)
+	return collate.SortKeys(c.collations, vals)
+}
+
 // GenIndexKey generates storage key for index values. Returned distinct indicates whether the
 // indexed values should be distinct in storage (i.e. whether handle is encoded in the key).
 func (c *kvIndex) GenIndexKey(indexedValues []interface{}, h int64) (key []byte, distinct bool, err error) {
@@ -138,6 +170,7 @@ func (c *kvIndex) GenIndexKey(indexedValues []interface{}, h int64) (key []byte,
 		}
 	}
 
+	indexedValues = c.sortKeys(indexedValues)
 	key = append(key, []byte(c.prefix)...)
 	if distinct {
 		key, err = codec.EncodeKey(key, indexedValues...)
@@ -236,6 +269,24 @@ func (c *kvIndex) SeekFirst(rm RetrieverMutator) (iter IndexIterator, err error)
 	return &indexIter{it: it, idx: c, prefix: c.prefix}, nil
 }
 
+// SeekReverse returns an iterator which iterates the KV index in descending order,
+// starting from the last entry whose indexed values are not larger than indexedValues.
+func (c *kvIndex) SeekReverse(rm RetrieverMutator, indexedValues []interface{}) (iter IndexIterator, err error) {
+	key := Key(c.prefix)
+	if indexedValues != nil {
+		key, err = codec.EncodeKey(key, c.sortKeys(indexedValues)...)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	// All the entries with the key as prefix should be iterated.
+	it, err := rm.SeekReverse(key.PrefixNext())
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return &indexIter{it: it, idx: c, prefix: c.prefix}, nil
+}
+
 func (c *kvIndex) Exist(rm RetrieverMutator, indexedValues []interface{}, h int64) (bool, int64, error) {
 	key, distinct, err := c.GenIndexKey(indexedValues, h)
 	if err != nil {
@@ -140,6 +140,56 @@ func (s *testIndexSuite) TestIndex(c *C) {
 	c.Assert(h, Equals, int64(1))
 	c.Assert(exist, IsTrue)
 
+	// The null values are not unique, the handle is in the key.
+	nullValues := []interface{}{nil, 2}
+	err = index.Create(txn, nullValues, 3)
+	c.Assert(err, IsNil)
+	err = index.Create(txn, nullValues, 4)
+	c.Assert(err, IsNil)
+
+	it, err = index.SeekFirst(txn)
+	c.Assert(err, IsNil)
+	for _, handle := range []int64{3, 4, 1} {
+		getValues, h, err = it.Next()
+		c.Assert(err, IsNil)
+		c.Assert(getValues, HasLen, 2)
+		c.Assert(h, Equals, handle)
+	}
+	it.Close()
+
+	err = txn.Commit()
+	c.Assert(err, IsNil)
+}
+
+func (s *testIndexSuite) TestSeekReverse(c *C) {
+	index := kv.NewKVIndex("i", "test", 2, false)
+	txn, err := s.s.Begin()
+	c.Assert(err, IsNil)
+	for i := 0; i < 10; i++ {
+		err = index.Create(txn, []interface{}{i / 2, i}, int64(i))
+		c.Assert(err, IsNil)
+	}
+
+	// Entries with the prefix values are included.
+	it, err := index.SeekReverse(txn, []interface{}{2})
+	c.Assert(err, IsNil)
+	for i := 5; i >= 0; i-- {
+		vals, h, err := it.Next()
+		c.Assert(err, IsNil)
+		c.Assert(vals, DeepEquals, []interface{}{int64(i / 2), int64(i)})
+		c.Assert(h, Equals, int64(i))
+	}
+	_, _, err = it.Next()
+	c.Assert(terror.ErrorEqual(err, io.EOF), IsTrue)
+	it.Close()
+
+	it, err = index.SeekReverse(txn, nil)
+	c.Assert(err, IsNil)
+	_, h, err := it.Next()
+	c.Assert(err, IsNil)
+	c.Assert(h, Equals, int64(9))
+	it.Close()
+
 	err = txn.Commit()
 	c.Assert(err, IsNil)
 }
@@ -13,7 +13,10 @@
 
 package kv
 
-import "github.com/juju/errors"
+import (
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/mysql"
+)
 
 var (
 	// ErrClosed is used when close an already closed txn.
@@ -26,6 +29,9 @@ var (
 	ErrConditionNotMatch = errors.New("Error: Condition not match")
 	// ErrLockConflict is used when try to lock an already locked key.
 	ErrLockConflict = errors.New("Error: Lock conflict")
+	// ErrWriteConflict is used when a key is committed by another transaction after
+	// the start of the transaction which writes it.
+	ErrWriteConflict = errors.New("Error: Write conflict")
 	// ErrLazyConditionPairsNotMatch is used when value in store differs from expect pairs.
 	ErrLazyConditionPairsNotMatch = errors.New("Error: Lazy condition pairs not match")
 	// ErrRetryable is used when KV store occurs RPC error or some other
@@ -35,6 +41,10 @@ var (
 	ErrCannotSetNilValue = errors.New("can not set nil value")
 	// ErrInvalidTxn is the error when commits or rollbacks in an invalid transaction.
 	ErrInvalidTxn = errors.New("invalid transaction")
+	// ErrLockWaitTimeout is used when it times out to wait for a pessimistic lock.
+	ErrLockWaitTimeout = mysql.NewErr(mysql.ErrLockWaitTimeout)
+	// ErrDeadlock is used when waiting for a pessimistic lock causes a deadlock.
+	ErrDeadlock = mysql.NewErr(mysql.ErrLockDeadlock)
 )
 
 // NextUntil applies FnKeyCmp to each entry of the iterator until meets some condition.
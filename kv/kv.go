@@ -16,6 +16,7 @@ package kv
 import (
 	"bytes"
 	"math"
+	"time"
 
 	"github.com/juju/errors"
 )
@@ -31,6 +32,36 @@ func (k Key) Next() Key {
 	return buf
 }
 
+// PrefixNext returns the next prefix key, it is the smallest key which is larger
+// than all the keys with k as prefix.
+//
+// Assume there are keys like:
+//
+//	rowkey1
+//	rowkey1_column1
+//	rowkey1_column2
+//	rowkey2
+//
+// The Next of rowkey1 is less than rowkey1_column1, while the PrefixNext of
+// rowkey1 is larger than rowkey1_column2 and not larger than rowkey2.
+func (k Key) PrefixNext() Key {
+	buf := make([]byte, len([]byte(k)))
+	copy(buf, []byte(k))
+	var i int
+	for i = len(k) - 1; i >= 0; i-- {
+		buf[i]++
+		if buf[i] != 0 {
+			break
+		}
+	}
+	if i == -1 {
+		// All the bytes are 0xff, there is no larger key with the same length.
+		copy(buf, k)
+		buf = append(buf, 0)
+	}
+	return buf
+}
+
 // Cmp returns the comparison result of two key.
 // The result will be 0 if a==b, -1 if a < b, and +1 if a > b.
 func (k Key) Cmp(another Key) int {
@@ -117,8 +148,19 @@ const (
 	// transaction's commit.
 	// This option is an optimization for frequent checks during a transaction, e.g. batch inserts.
 	PresumeKeyNotExists
+
+	// PessimisticLockWaitTimeout directives that LockKeys acquires the locks at once instead of checking
+	// them at commit time. It waits for the locks held by other transactions for at most the option value
+	// of time.Duration, or DefaultLockWaitTimeout if the value is nil. If a key is committed by another
+	// transaction after it is read, LockKeys locks it and returns ErrWriteConflict, the following reads
+	// of the transaction see the latest data.
+	// The stores which don't support pessimistic locks ignore this option.
+	PessimisticLockWaitTimeout
 )
 
+// DefaultLockWaitTimeout is the default time to wait for a pessimistic lock.
+const DefaultLockWaitTimeout = 50 * time.Second
+
 // Retriever is the interface wraps the basic Get and Seek methods.
 type Retriever interface {
 	// Get gets the value for key k from kv store.
@@ -128,6 +170,12 @@ type Retriever interface {
 	// If such entry is not found, it returns an invalid Iterator with no error.
 	// The Iterator must be Closed after use.
 	Seek(k Key) (Iterator, error)
+	// SeekReverse creates a reversed Iterator positioned on the last entry that entry's key < k,
+	// the Iterator's Next moves to the entry with a smaller key.
+	// If k is nil, the Iterator is positioned on the last entry.
+	// If such entry is not found, it returns an invalid Iterator with no error.
+	// The Iterator must be Closed after use.
+	SeekReverse(k Key) (Iterator, error)
 }
 
 // Mutator is the interface wraps the basic Set and Delete methods.
@@ -194,6 +242,8 @@ type Transaction interface {
 	String() string
 	// LockKeys tries to lock the entries with the keys in KV store.
 	LockKeys(keys ...Key) error
+	// StartTS returns the version of the snapshot the transaction starts at.
+	StartTS() uint64
 }
 
 // Snapshot defines the interface for the snapshot fetched from KV store.
@@ -265,4 +315,9 @@ type Index interface {
 	Seek(rw RetrieverMutator, indexedValues []interface{}) (iter IndexIterator, hit bool, err error)
 	// SeekFirst supports aggregate min and ascend order by.
 	SeekFirst(rw RetrieverMutator) (iter IndexIterator, err error)
+	// SeekReverse supports descend order by, the iterator is positioned on the last
+	// entry whose indexed values have indexedValues as prefix or are less than it, and
+	// iterates in descending order. If indexedValues is nil, it is positioned on the
+	// last entry of the index.
+	SeekReverse(rw RetrieverMutator, indexedValues []interface{}) (iter IndexIterator, err error)
 }
@@ -200,6 +200,58 @@ func (s *testKVSuite) TestNewIteratorMin(c *C) {
 	}
 }
 
+func (s *testKVSuite) TestSeekReverse(c *C) {
+	for _, buffer := range s.bs {
+		// should be invalid
+		iter, err := buffer.SeekReverse(nil)
+		c.Assert(err, IsNil)
+		c.Assert(iter.Valid(), IsFalse)
+
+		for i := 0; i < 10; i++ {
+			val := encodeInt(i * indexStep)
+			err = buffer.Set(val, val)
+			c.Assert(err, IsNil)
+		}
+
+		// Iterate all the entries from the last one.
+		iter, err = buffer.SeekReverse(nil)
+		c.Assert(err, IsNil)
+		for i := 9; i >= 0; i-- {
+			c.Assert(iter.Valid(), IsTrue)
+			c.Assert(iter.Key(), Equals, string(encodeInt(i*indexStep)))
+			c.Assert(iter.Next(), IsNil)
+		}
+		c.Assert(iter.Valid(), IsFalse)
+		iter.Close()
+
+		// The entry equals to the key is not included.
+		iter, err = buffer.SeekReverse(encodeInt(4 * indexStep))
+		c.Assert(err, IsNil)
+		c.Assert(iter.Valid(), IsTrue)
+		c.Assert(iter.Key(), Equals, string(encodeInt(3*indexStep)))
+		c.Assert(iter.Next(), IsNil)
+		c.Assert(iter.Key(), Equals, string(encodeInt(2*indexStep)))
+		iter.Close()
+
+		// Non exist but between existing keys.
+		iter, err = buffer.SeekReverse(encodeInt(4*indexStep + 1))
+		c.Assert(err, IsNil)
+		c.Assert(iter.Key(), Equals, string(encodeInt(4*indexStep)))
+		iter.Close()
+
+		// Beyond maximum and below minimum.
+		iter, err = buffer.SeekReverse(encodeInt(100))
+		c.Assert(err, IsNil)
+		c.Assert(iter.Key(), Equals, string(encodeInt(9*indexStep)))
+		iter.Close()
+		iter, err = buffer.SeekReverse(encodeInt(0))
+		c.Assert(err, IsNil)
+		c.Assert(iter.Valid(), IsFalse)
+
+		buffer.Release()
+	}
+}
+
 var opCnt = 100000
 
 func BenchmarkBTreeBufferSequential(b *testing.B) {
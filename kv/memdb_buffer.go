@@ -30,7 +30,8 @@ type memDbBuffer struct {
 }
 
 type memDbIter struct {
-	iter iterator.Iterator
+	iter    iterator.Iterator
+	reverse bool
 }
 
 // NewMemDbBuffer creates a new memDbBuffer.
@@ -50,6 +51,18 @@ func (m *memDbBuffer) Seek(k Key) (Iterator, error) {
 	return i, nil
 }
 
+// SeekReverse creates a reversed Iterator.
+func (m *memDbBuffer) SeekReverse(k Key) (Iterator, error) {
+	var i *memDbIter
+	if k == nil {
+		i = &memDbIter{iter: m.db.NewIterator(&util.Range{}), reverse: true}
+	} else {
+		i = &memDbIter{iter: m.db.NewIterator(&util.Range{Limit: []byte(k)}), reverse: true}
+	}
+	i.iter.Last()
+	return i, nil
+}
+
 // Get returns the value associated with key.
 func (m *memDbBuffer) Get(k Key) ([]byte, error) {
 	v, err := m.db.Get(k)
@@ -81,7 +94,11 @@ func (m *memDbBuffer) Release() {
 
 // Next implements the Iterator Next.
 func (i *memDbIter) Next() error {
-	i.iter.Next()
+	if i.reverse {
+		i.iter.Prev()
+	} else {
+		i.iter.Next()
+	}
 	return nil
 }
 
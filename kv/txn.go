@@ -33,6 +33,7 @@ func IsRetryableError(err error) bool {
 
 	if terror.ErrorEqual(err, ErrRetryable) ||
 		terror.ErrorEqual(err, ErrLockConflict) ||
+		terror.ErrorEqual(err, ErrWriteConflict) ||
 		terror.ErrorEqual(err, ErrConditionNotMatch) ||
 		terror.ErrorEqual(err, themis.ErrRetryable) ||
 		// HBase exception message will tell you if you should retry or not
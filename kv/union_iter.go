@@ -29,14 +29,17 @@ type UnionIter struct {
 
 	curIsDirty bool
 	isValid    bool
+	// reverse indicates the iterators iterate in descending order.
+	reverse bool
 }
 
-func newUnionIter(dirtyIt Iterator, snapshotIt Iterator) *UnionIter {
+func newUnionIter(dirtyIt Iterator, snapshotIt Iterator, reverse bool) *UnionIter {
 	it := &UnionIter{
 		dirtyIt:       dirtyIt,
 		snapshotIt:    snapshotIt,
 		dirtyValid:    dirtyIt.Valid(),
 		snapshotValid: snapshotIt.Valid(),
+		reverse:       reverse,
 	}
 	it.updateCur()
 	return it
@@ -82,6 +85,10 @@ func (iter *UnionIter) updateCur() {
 			snapshotKey := []byte(iter.snapshotIt.Key())
 			dirtyKey := []byte(iter.dirtyIt.Key())
 			cmp := bytes.Compare(dirtyKey, snapshotKey)
+			if iter.reverse {
+				// The larger key comes first.
+				cmp = -cmp
+			}
 			// if equal, means both have value
 			if cmp == 0 {
 				if len(iter.dirtyIt.Value()) == 0 {
@@ -519,19 +519,43 @@ func (m *Meta) GetHistoryDDLJob(id int64) (*model.Job, error) {
 	return job, errors.Trace(err)
 }
 
+// GetAllHistoryDDLJobs gets all the history DDL jobs, in the ascending order of the job IDs.
+func (m *Meta) GetAllHistoryDDLJobs() ([]*model.Job, error) {
+	pairs, err := m.txn.HGetAll(mDDLJobHistoryKey)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	jobs := make([]*model.Job, 0, len(pairs))
+	for _, pair := range pairs {
+		job := &model.Job{}
+		if err = job.Decode(pair.Value); err != nil {
+			return nil, errors.Trace(err)
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, nil
+}
+
 // IsBootstrapped returns whether we have already run bootstrap or not.
 // return true means we don't need doing any other bootstrap.
 func (m *Meta) IsBootstrapped() (bool, error) {
-	value, err := m.txn.GetInt64(mBootstrapKey)
+	version, err := m.GetBootstrapVersion()
 	if err != nil {
 		return false, errors.Trace(err)
 	}
-	return value == 1, nil
+	return version > 0, nil
+}
+
+// GetBootstrapVersion returns the version of the bootstrap, 0 means we haven't run bootstrap.
+func (m *Meta) GetBootstrapVersion() (int64, error) {
+	value, err := m.txn.GetInt64(mBootstrapKey)
+	return value, errors.Trace(err)
 }
 
-// FinishBootstrap finishes bootstrap.
-func (m *Meta) FinishBootstrap() error {
-	err := m.txn.Set(mBootstrapKey, []byte("1"))
+// FinishBootstrap finishes bootstrap with the version.
+func (m *Meta) FinishBootstrap(version int64) error {
+	err := m.txn.Set(mBootstrapKey, []byte(strconv.FormatInt(version, 10)))
 	return errors.Trace(err)
 }
 
@@ -552,3 +576,71 @@ func (m *Meta) GetDDLReorgHandle(job *model.Job) (int64, error) {
 	value, err := m.txn.HGetInt64(mDDLJobReorgKey, m.jobIDKey(job.ID))
 	return value, errors.Trace(err)
 }
+
+// Statistics structure
+//	StatsVersion -> int64
+//	TableStats -> {
+//		Table:1 -> table statistics []byte
+//		Table:2 -> table statistics []byte
+//	}
+//
+// The statistics version is increased whenever the statistics of a table
+// are changed, so the loaded statistics can be reloaded only when needed.
+
+var (
+	mStatsVersionKey = []byte("StatsVersion")
+	mTableStatsKey   = []byte("TableStats")
+)
+
+// GetStatsVersion gets current global statistics version.
+func (m *Meta) GetStatsVersion() (int64, error) {
+	return m.txn.GetInt64(mStatsVersionKey)
+}
+
+// SetTableStats saves the statistics of a table.
+func (m *Meta) SetTableStats(tableID int64, data []byte) error {
+	err := m.txn.HSet(mTableStatsKey, m.tableKey(tableID), data)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	_, err = m.txn.Inc(mStatsVersionKey, 1)
+	return errors.Trace(err)
+}
+
+// GetTableStats gets the statistics of a table, returns nil if the table is not analyzed.
+func (m *Meta) GetTableStats(tableID int64) ([]byte, error) {
+	value, err := m.txn.HGet(mTableStatsKey, m.tableKey(tableID))
+	return value, errors.Trace(err)
+}
+
+// DropTableStats removes the statistics of a table.
+func (m *Meta) DropTableStats(tableID int64) error {
+	value, err := m.txn.HGet(mTableStatsKey, m.tableKey(tableID))
+	if err != nil || value == nil {
+		return errors.Trace(err)
+	}
+	err = m.txn.HDel(mTableStatsKey, m.tableKey(tableID))
+	if err != nil {
+		return errors.Trace(err)
+	}
+	_, err = m.txn.Inc(mStatsVersionKey, 1)
+	return errors.Trace(err)
+}
+
+// ListTableStats lists the statistics of all the analyzed tables, keyed by table ID.
+func (m *Meta) ListTableStats() (map[int64][]byte, error) {
+	res, err := m.txn.HGetAll(mTableStatsKey)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	stats := make(map[int64][]byte, len(res))
+	for _, r := range res {
+		tableID, err := m.parseTableID(string(r.Field))
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		stats[tableID] = r.Value
+	}
+	return stats, nil
+}
@@ -150,13 +150,17 @@ func (s *testSuite) TestMeta(c *C) {
 	c.Assert(err, IsNil)
 	c.Assert(bootstrapped, IsFalse)
 
-	err = t.FinishBootstrap()
+	err = t.FinishBootstrap(2)
 	c.Assert(err, IsNil)
 
 	bootstrapped, err = t.IsBootstrapped()
 	c.Assert(err, IsNil)
 	c.Assert(bootstrapped, IsTrue)
 
+	version, err := t.GetBootstrapVersion()
+	c.Assert(err, IsNil)
+	c.Assert(version, Equals, int64(2))
+
 	err = txn.Commit()
 	c.Assert(err, IsNil)
 }
@@ -221,6 +225,61 @@ func (s *testSuite) TestDDL(c *C) {
 	c.Assert(err, IsNil)
 	c.Assert(v, DeepEquals, job)
 
+	jobs, err := t.GetAllHistoryDDLJobs()
+	c.Assert(err, IsNil)
+	c.Assert(jobs, DeepEquals, []*model.Job{job})
+
+	err = txn.Commit()
+	c.Assert(err, IsNil)
+}
+
+func (s *testSuite) TestTableStats(c *C) {
+	driver := localstore.Driver{Driver: goleveldb.MemoryDriver{}}
+	store, err := driver.Open("memory")
+	c.Assert(err, IsNil)
+	defer store.Close()
+
+	txn, err := store.Begin()
+	c.Assert(err, IsNil)
+
+	defer txn.Rollback()
+
+	t := meta.NewMeta(txn)
+
+	v, err := t.GetStatsVersion()
+	c.Assert(err, IsNil)
+	c.Assert(v, Equals, int64(0))
+
+	err = t.SetTableStats(1, []byte("a"))
+	c.Assert(err, IsNil)
+	err = t.SetTableStats(2, []byte("b"))
+	c.Assert(err, IsNil)
+
+	v, err = t.GetStatsVersion()
+	c.Assert(err, IsNil)
+	c.Assert(v, Equals, int64(2))
+
+	data, err := t.GetTableStats(1)
+	c.Assert(err, IsNil)
+	c.Assert(data, BytesEquals, []byte("a"))
+
+	stats, err := t.ListTableStats()
+	c.Assert(err, IsNil)
+	c.Assert(stats, DeepEquals, map[int64][]byte{1: []byte("a"), 2: []byte("b")})
+
+	err = t.DropTableStats(1)
+	c.Assert(err, IsNil)
+	data, err = t.GetTableStats(1)
+	c.Assert(err, IsNil)
+	c.Assert(data, IsNil)
+
+	// Dropping the statistics of a table which is not analyzed doesn't change the version.
+	err = t.DropTableStats(3)
+	c.Assert(err, IsNil)
+	v, err = t.GetStatsVersion()
+	c.Assert(err, IsNil)
+	c.Assert(v, Equals, int64(3))
+
 	err = txn.Commit()
 	c.Assert(err, IsNil)
 }
@@ -28,6 +28,10 @@ var (
 
 // Register registers a new metric for observation.
 func Register(name string, m interface{}) {
+	if h, ok := m.(*Histogram); ok {
+		registerHistogram(name, h)
+		return
+	}
 	r.Register(name, m)
 }
 
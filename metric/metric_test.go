@@ -14,6 +14,8 @@
 package metric
 
 import (
+	"bytes"
+	"strings"
 	"testing"
 	"time"
 
@@ -61,3 +63,37 @@ func (t *testSuite) TestRegMetric(c *C) {
 	c.Assert(r.Get(testTimeMetricName).(metrics.Histogram).Max(), GreaterEqual, int64(100))
 	c.Assert(r.Get(testTimeMetricName).(metrics.Histogram).Min(), Less, int64(100))
 }
+
+func (t *testSuite) TestWritePrometheus(c *C) {
+	Inc(Label("test_query_total", "type", "Select"), 2)
+	Inc(Label("test_query_total", "type", `a"b`), 1)
+	Inc("test_query_total_other", 1)
+	SetGauge("test-gauge", 3)
+	h := NewHistogram([]float64{1, 2})
+	Register(Label("test_duration_seconds", "type", "get"), h)
+	h.Observe(0.5)
+	h.Observe(1.5)
+	h.Observe(3)
+	start := time.Now()
+	ObserveDuration(Label("test_duration_seconds", "type", "get"), start)
+	ObserveDuration("test_latency_seconds", start)
+	c.Assert(histograms["test_latency_seconds"].Count(), Equals, int64(1))
+
+	var buf bytes.Buffer
+	c.Assert(WritePrometheus(&buf), IsNil)
+	out := buf.String()
+	for _, s := range []string{
+		"# TYPE test_query_total counter\ntest_query_total{type=\"Select\"} 2\ntest_query_total{type=\"a\\\"b\"} 1\n",
+		"# TYPE test_query_total_other counter\ntest_query_total_other 1\n",
+		"# TYPE test_gauge gauge\ntest_gauge 3\n",
+		"# TYPE test_duration_seconds histogram\n" +
+			"test_duration_seconds_bucket{type=\"get\",le=\"1\"} 2\n" +
+			"test_duration_seconds_bucket{type=\"get\",le=\"2\"} 3\n" +
+			"test_duration_seconds_bucket{type=\"get\",le=\"+Inf\"} 4\n",
+		"test_duration_seconds_count{type=\"get\"} 4\n",
+		"# TYPE time_metric summary\n",
+	} {
+		c.Assert(strings.Contains(out, s), IsTrue, Commentf("%s not in %s", s, out))
+	}
+	c.Assert(strings.Count(out, "# TYPE test_query_total counter"), Equals, 1)
+}
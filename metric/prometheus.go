@@ -0,0 +1,259 @@
+// Copyright 2015 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metric
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/juju/errors"
+	"github.com/rcrowley/go-metrics"
+)
+
+// histograms are registered here since the registry of go-metrics only accepts its own types.
+var (
+	histogramsMu sync.RWMutex
+	histograms   = make(map[string]*Histogram)
+)
+
+// latencyBuckets are the upper bounds in seconds of the buckets of the latency histograms,
+// from 0.5ms to about 16s.
+var latencyBuckets = exponentialBuckets(0.0005, 2, 16)
+
+func exponentialBuckets(start, factor float64, count int) []float64 {
+	buckets := make([]float64, count)
+	for i := range buckets {
+		buckets[i] = start
+		start *= factor
+	}
+	return buckets
+}
+
+// Histogram counts the observed values in buckets, it's exported as a Prometheus histogram.
+type Histogram struct {
+	mu sync.Mutex
+	// upperBounds are sorted, the last bucket is +Inf and not in it.
+	upperBounds []float64
+	counts      []int64
+	sum         float64
+	count       int64
+}
+
+// NewHistogram creates a Histogram with the sorted upper bounds of the buckets.
+func NewHistogram(upperBounds []float64) *Histogram {
+	return &Histogram{
+		upperBounds: upperBounds,
+		counts:      make([]int64, len(upperBounds)+1),
+	}
+}
+
+// Observe adds a value to the histogram.
+func (h *Histogram) Observe(v float64) {
+	i := sort.SearchFloat64s(h.upperBounds, v)
+	h.mu.Lock()
+	h.counts[i]++
+	h.sum += v
+	h.count++
+	h.mu.Unlock()
+}
+
+// Count returns the number of the observed values.
+func (h *Histogram) Count() int64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.count
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// Label formats the name of a metric with the label names and values in Prometheus style,
+// e.g. Label("tidb_query_total", "type", "Select") returns `tidb_query_total{type="Select"}`.
+// The metrics with the same name and different labels are exported as one metric family.
+func Label(name string, labels ...string) string {
+	if len(labels) == 0 {
+		return name
+	}
+	pairs := make([]string, 0, len(labels)/2)
+	for i := 0; i+1 < len(labels); i += 2 {
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, labels[i], labelEscaper.Replace(labels[i+1])))
+	}
+	return fmt.Sprintf("%s{%s}", name, strings.Join(pairs, ","))
+}
+
+// SetGauge sets the value of specific gauge metric.
+func SetGauge(name string, v int64) {
+	if g := r.GetOrRegister(name, metrics.NewGauge()); g != nil {
+		g.(metrics.Gauge).Update(v)
+	}
+}
+
+func registerHistogram(name string, h *Histogram) {
+	histogramsMu.Lock()
+	if _, ok := histograms[name]; !ok {
+		histograms[name] = h
+	}
+	histogramsMu.Unlock()
+}
+
+// ObserveDuration records time elapse in seconds from startTime for given histogram metric,
+// the histogram is created with the latency buckets if it's not registered.
+func ObserveDuration(name string, startTime time.Time) {
+	histogramsMu.RLock()
+	h, ok := histograms[name]
+	histogramsMu.RUnlock()
+	if !ok {
+		histogramsMu.Lock()
+		if h, ok = histograms[name]; !ok {
+			h = NewHistogram(latencyBuckets)
+			histograms[name] = h
+		}
+		histogramsMu.Unlock()
+	}
+	h.Observe(time.Since(startTime).Seconds())
+}
+
+// WritePrometheus writes all the metrics in the Prometheus text format.
+// See: https://prometheus.io/docs/instrumenting/exposition_formats/
+func WritePrometheus(w io.Writer) error {
+	all := make(map[string]interface{})
+	r.Each(func(name string, i interface{}) {
+		all[name] = i
+	})
+	histogramsMu.RLock()
+	for name, h := range histograms {
+		all[name] = h
+	}
+	histogramsMu.RUnlock()
+	names := make([]string, 0, len(all))
+	for name := range all {
+		names = append(names, name)
+	}
+	sort.Sort(byFamily(names))
+
+	bw := bufio.NewWriter(w)
+	lastFamily := ""
+	for _, name := range names {
+		family, labels := splitName(name)
+		family = invalidNameChars.ReplaceAllString(family, "_")
+		typ, samples := exportMetric(family, labels, all[name])
+		if typ == "" {
+			continue
+		}
+		if family != lastFamily {
+			fmt.Fprintf(bw, "# TYPE %s %s\n", family, typ)
+			lastFamily = family
+		}
+		for _, s := range samples {
+			bw.WriteString(s)
+			bw.WriteByte('\n')
+		}
+	}
+	return errors.Trace(bw.Flush())
+}
+
+// invalidNameChars matches the characters not allowed in the Prometheus metric names.
+var invalidNameChars = regexp.MustCompile("[^a-zA-Z0-9_:]")
+
+// byFamily sorts the metric names by the family names, then the labels.
+type byFamily []string
+
+func (b byFamily) Len() int      { return len(b) }
+func (b byFamily) Swap(i, j int) { b[i], b[j] = b[j], b[i] }
+func (b byFamily) Less(i, j int) bool {
+	fi, li := splitName(b[i])
+	fj, lj := splitName(b[j])
+	if fi != fj {
+		return fi < fj
+	}
+	return li < lj
+}
+
+// splitName splits the name formatted by Label to the metric family name and the labels.
+func splitName(name string) (string, string) {
+	pos := strings.IndexByte(name, '{')
+	if pos < 0 || !strings.HasSuffix(name, "}") {
+		return name, ""
+	}
+	return name[:pos], name[pos+1 : len(name)-1]
+}
+
+func sample(name, labels string, v interface{}, extra ...string) string {
+	if len(extra) > 0 {
+		l := Label("", extra...)
+		l = l[1 : len(l)-1]
+		if labels == "" {
+			labels = l
+		} else {
+			labels += "," + l
+		}
+	}
+	if labels != "" {
+		name = fmt.Sprintf("%s{%s}", name, labels)
+	}
+	return fmt.Sprintf("%s %v", name, v)
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// exportMetric returns the Prometheus type and the samples of the metric, the type is
+// empty if the metric is not supported.
+func exportMetric(family, labels string, i interface{}) (string, []string) {
+	switch m := i.(type) {
+	case metrics.Counter:
+		return "counter", []string{sample(family, labels, m.Count())}
+	case metrics.Meter:
+		return "counter", []string{sample(family, labels, m.Count())}
+	case metrics.Gauge:
+		return "gauge", []string{sample(family, labels, m.Value())}
+	case metrics.GaugeFloat64:
+		return "gauge", []string{sample(family, labels, formatFloat(m.Value()))}
+	case metrics.Histogram:
+		// The histograms of go-metrics keep samples only, they are exported as summaries.
+		quantiles := []float64{0.5, 0.9, 0.99}
+		ps := m.Percentiles(quantiles)
+		samples := make([]string, 0, len(quantiles)+2)
+		for i, q := range quantiles {
+			samples = append(samples, sample(family, labels, formatFloat(ps[i]), "quantile", formatFloat(q)))
+		}
+		samples = append(samples, sample(family+"_sum", labels, formatFloat(m.Mean()*float64(m.Count()))))
+		samples = append(samples, sample(family+"_count", labels, m.Count()))
+		return "summary", samples
+	case *Histogram:
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		samples := make([]string, 0, len(m.counts)+2)
+		var cumulative int64
+		for i, c := range m.counts {
+			cumulative += c
+			le := "+Inf"
+			if i < len(m.upperBounds) {
+				le = formatFloat(m.upperBounds[i])
+			}
+			samples = append(samples, sample(family+"_bucket", labels, cumulative, "le", le))
+		}
+		samples = append(samples, sample(family+"_sum", labels, formatFloat(m.sum)))
+		samples = append(samples, sample(family+"_count", labels, m.count))
+		return "histogram", samples
+	}
+	return "", nil
+}
@@ -34,6 +34,9 @@ const (
 	ActionDropColumn
 	ActionAddIndex
 	ActionDropIndex
+	ActionConvertRowFormat
+	ActionRenameTable
+	ActionModifyColumn
 )
 
 func (action ActionType) String() string {
@@ -54,6 +57,12 @@ func (action ActionType) String() string {
 		return "add index"
 	case ActionDropIndex:
 		return "drop index"
+	case ActionConvertRowFormat:
+		return "convert row format"
+	case ActionRenameTable:
+		return "rename table"
+	case ActionModifyColumn:
+		return "modify column"
 	default:
 		return "none"
 	}
@@ -80,12 +89,14 @@ type Job struct {
 	LastUpdateTS int64 `json:"last_update_ts"`
 }
 
-// Encode encodes job with json format.
+// Encode encodes job with json format, the raw args are kept if the args are not decoded.
 func (job *Job) Encode() ([]byte, error) {
 	var err error
-	job.RawArgs, err = json.Marshal(job.Args)
-	if err != nil {
-		return nil, errors.Trace(err)
+	if job.Args != nil || len(job.RawArgs) == 0 {
+		job.RawArgs, err = json.Marshal(job.Args)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
 	}
 
 	var b []byte
@@ -124,6 +135,16 @@ func (job *Job) IsRunning() bool {
 	return job.State == JobRunning
 }
 
+// IsCancelling returns whether job is cancelled by the user and not rolled back yet.
+func (job *Job) IsCancelling() bool {
+	return job.State == JobCancelling
+}
+
+// IsRollingBack returns whether job is rolling back its schema changes after it's cancelled.
+func (job *Job) IsRollingBack() bool {
+	return job.State == JobRollingBack
+}
+
 // JobState is for job state.
 type JobState byte
 
@@ -133,6 +154,8 @@ const (
 	JobRunning
 	JobDone
 	JobCancelled
+	JobCancelling
+	JobRollingBack
 )
 
 // String implements fmt.Stringer interface.
@@ -144,6 +167,10 @@ func (s JobState) String() string {
 		return "done"
 	case JobCancelled:
 		return "cancelled"
+	case JobCancelling:
+		return "cancelling"
+	case JobRollingBack:
+		return "rolling back"
 	default:
 		return "none"
 	}
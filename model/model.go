@@ -64,6 +64,9 @@ type ColumnInfo struct {
 	DefaultValue    interface{} `json:"default"`
 	types.FieldType `json:"type"`
 	State           SchemaState `json:"state"`
+	// OriginID is the ID of the column whose values are converted and written to this
+	// column when this column is not public, it's set while the column type is changing.
+	OriginID int64 `json:"origin_id,omitempty"`
 }
 
 // Clone clones ColumnInfo.
@@ -72,6 +75,28 @@ func (c *ColumnInfo) Clone() *ColumnInfo {
 	return &nc
 }
 
+// RowFormat is the format in which the rows of a table are stored.
+type RowFormat byte
+
+const (
+	// RowFormatColumn stores every column of a row as a separate KV pair,
+	// the row key itself only holds the row lock.
+	// Tables created before RowFormat was introduced use this format.
+	RowFormatColumn RowFormat = iota
+	// RowFormatSingleKV encodes all columns of a row into the value of the row key.
+	RowFormatSingleKV
+)
+
+// String implements fmt.Stringer interface.
+func (f RowFormat) String() string {
+	switch f {
+	case RowFormatSingleKV:
+		return "single kv"
+	default:
+		return "column"
+	}
+}
+
 // TableInfo provides meta data describing a DB table.
 type TableInfo struct {
 	ID      int64  `json:"id"`
@@ -79,9 +104,10 @@ type TableInfo struct {
 	Charset string `json:"charset"`
 	Collate string `json:"collate"`
 	// Columns are listed in the order in which they appear in the schema.
-	Columns []*ColumnInfo `json:"cols"`
-	Indices []*IndexInfo  `json:"index_info"`
-	State   SchemaState   `json:"state"`
+	Columns   []*ColumnInfo `json:"cols"`
+	Indices   []*IndexInfo  `json:"index_info"`
+	State     SchemaState   `json:"state"`
+	RowFormat RowFormat     `json:"row_format"`
 }
 
 // Clone clones TableInfo.
@@ -124,6 +150,9 @@ type IndexInfo struct {
 	Unique  bool           `json:"is_unique"`  // Whether the index is unique.
 	Primary bool           `json:"is_primary"` // Whether the index is primary key.
 	State   SchemaState    `json:"state"`
+	// Collated is true if the strings are encoded as their weight keys under the collations of
+	// the columns, it's false for the indices created before the collations are supported.
+	Collated bool `json:"collated"`
 }
 
 // Clone clones IndexInfo.
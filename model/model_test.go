@@ -107,6 +107,12 @@ func (*testSuite) TestJobCodec(c *C) {
 	job.State = JobDone
 	c.Assert(job.IsFinished(), IsTrue)
 	c.Assert(job.IsRunning(), IsFalse)
+
+	job.State = JobCancelling
+	c.Assert(job.IsFinished(), IsFalse)
+	c.Assert(job.IsCancelling(), IsTrue)
+	job.State = JobRollingBack
+	c.Assert(job.IsRollingBack(), IsTrue)
 }
 
 func (testSuite) TestState(c *C) {
@@ -126,6 +132,8 @@ func (testSuite) TestState(c *C) {
 		JobRunning,
 		JobDone,
 		JobCancelled,
+		JobCancelling,
+		JobRollingBack,
 	}
 
 	for _, state := range jobTbl {
@@ -141,9 +149,15 @@ func (testSuite) TestState(c *C) {
 		ActionDropColumn,
 		ActionAddIndex,
 		ActionDropIndex,
+		ActionConvertRowFormat,
+		ActionRenameTable,
+		ActionModifyColumn,
 	}
 
 	for _, action := range actionTbl {
 		c.Assert(len(action.String()), Greater, 0)
 	}
+
+	c.Assert(RowFormatColumn.String(), Equals, "column")
+	c.Assert(RowFormatSingleKV.String(), Equals, "single kv")
 }
@@ -26,6 +26,15 @@ const (
 	ErrHeader         byte = 0xff
 	EOFHeader         byte = 0xfe
 	LocalInFileHeader byte = 0xfb
+	AuthSwitchHeader  byte = 0xfe
+	AuthMoreHeader    byte = 0x01
+)
+
+// Authentication plugin names.
+// See: https://dev.mysql.com/doc/internals/en/authentication-method.html
+const (
+	AuthNativePassword      = "mysql_native_password"
+	AuthCachingSha2Password = "caching_sha2_password"
 )
 
 // Server informations.
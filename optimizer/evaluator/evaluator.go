@@ -26,18 +26,21 @@ import (
 	"github.com/pingcap/tidb/sessionctx/variable"
 	"github.com/pingcap/tidb/terror"
 	"github.com/pingcap/tidb/util/charset"
+	"github.com/pingcap/tidb/util/collate"
 	"github.com/pingcap/tidb/util/types"
 	"golang.org/x/text/transform"
 )
 
 // Error instances.
 var (
-	ErrInvalidOperation = terror.ClassEvaluator.New(CodeInvalidOperation, "invalid operation")
+	ErrInvalidOperation     = terror.ClassEvaluator.New(CodeInvalidOperation, "invalid operation")
+	ErrSubqueryMoreThan1Row = terror.ClassEvaluator.New(CodeSubqueryMoreThan1Row, "Subquery returns more than 1 row")
 )
 
 // Error codes.
 const (
 	CodeInvalidOperation terror.ErrCode = iota + 1
+	CodeSubqueryMoreThan1Row
 )
 
 // Eval evaluates an expression to a value.
@@ -81,6 +84,15 @@ type Evaluator struct {
 
 // Enter implements ast.Visitor interface.
 func (e *Evaluator) Enter(in ast.Node) (out ast.Node, skipChildren bool) {
+	switch in.(type) {
+	case *ast.AggregateFuncExpr:
+		// The value of aggregate function is set by aggregate executor,
+		// the arguments are not needed to be evaluated.
+		return in, true
+	case *ast.SubqueryExpr:
+		// Subquery is evaluated by its executor.
+		return in, true
+	}
 	return in, false
 }
 
@@ -189,7 +201,7 @@ func (e *Evaluator) caseExpr(v *ast.CaseExpr) bool {
 	}
 	if target != nil {
 		for _, val := range v.WhenClauses {
-			cmp, err := types.Compare(target, val.Expr.GetValue())
+			cmp, err := collate.Compare(collationOf(v.Value, val.Expr), target, val.Expr.GetValue())
 			if err != nil {
 				e.err = err
 				return false
@@ -207,13 +219,101 @@ func (e *Evaluator) caseExpr(v *ast.CaseExpr) bool {
 }
 
 func (e *Evaluator) subquery(v *ast.SubqueryExpr) bool {
+	if v.Evaluated && !v.Correlated {
+		// An uncorrelated subquery always has the same value.
+		return true
+	}
+	if v.SubqueryExec == nil {
+		e.err = ErrInvalidOperation.Gen("subquery is not built")
+		return false
+	}
+	switch {
+	case v.Exists:
+		rows, err := v.SubqueryExec.EvalRows(e.ctx, 1)
+		if err != nil {
+			e.err = errors.Trace(err)
+			return false
+		}
+		v.SetValue(boolToInt64(len(rows) > 0))
+	case v.MultiRows:
+		rows, err := v.SubqueryExec.EvalRows(e.ctx, -1)
+		if err != nil {
+			e.err = errors.Trace(err)
+			return false
+		}
+		v.SetValue(rows)
+	default:
+		// Fetch 2 rows to check if the subquery returns more than 1 row.
+		rows, err := v.SubqueryExec.EvalRows(e.ctx, 2)
+		if err != nil {
+			e.err = errors.Trace(err)
+			return false
+		}
+		switch len(rows) {
+		case 0:
+			v.SetValue(nil)
+		case 1:
+			v.SetValue(rows[0])
+		default:
+			e.err = ErrSubqueryMoreThan1Row
+			return false
+		}
+	}
+	v.Evaluated = true
 	return true
 }
 
 func (e *Evaluator) compareSubquery(v *ast.CompareSubqueryExpr) bool {
+	rows, ok := v.R.GetValue().([]interface{})
+	if !ok {
+		e.err = ErrInvalidOperation.Gen("invalid subquery value %v", v.R.GetValue())
+		return false
+	}
+	if len(rows) == 0 {
+		// ALL is true and ANY is false for empty subquery, even if the left value is NULL.
+		v.SetValue(boolToInt64(v.All))
+		return true
+	}
+	lv := v.L.GetValue()
+	if types.IsNil(lv) {
+		v.SetValue(nil)
+		return true
+	}
+	hasNull := false
+	for _, row := range rows {
+		if types.IsNil(row) {
+			hasNull = true
+			continue
+		}
+		r, err := e.compareValues(v.Op, collationOf(v.L), lv, row)
+		if err != nil {
+			e.err = errors.Trace(err)
+			return false
+		}
+		if r != v.All {
+			// A false comparison for ALL or a true comparison for ANY decides the result.
+			v.SetValue(boolToInt64(r))
+			return true
+		}
+	}
+	if hasNull {
+		v.SetValue(nil)
+		return true
+	}
+	v.SetValue(boolToInt64(v.All))
 	return true
 }
 
+// compareValues compares two non-NULL values under the collation with the comparison operator.
+func (e *Evaluator) compareValues(op opcode.Op, collation string, a, b interface{}) (bool, error) {
+	a, b = types.Coerce(a, b)
+	n, err := collate.Compare(collation, a, b)
+	if err != nil {
+		return false, errors.Trace(err)
+	}
+	return getCompResult(op, n)
+}
+
 func (e *Evaluator) columnName(v *ast.ColumnNameExpr) bool {
 	v.SetValue(v.Refer.Expr.GetValue())
 	return true
@@ -224,10 +324,11 @@ func (e *Evaluator) defaultExpr(v *ast.DefaultExpr) bool {
 }
 
 func (e *Evaluator) existsSubquery(v *ast.ExistsSubqueryExpr) bool {
+	v.SetValue(v.Sel.GetValue())
 	return true
 }
 
-func (e *Evaluator) checkInList(not bool, in interface{}, list []interface{}) (interface{}, error) {
+func (e *Evaluator) checkInList(not bool, collation string, in interface{}, list []interface{}) (interface{}, error) {
 	hasNull := false
 	for _, v := range list {
 		if types.IsNil(v) {
@@ -235,7 +336,7 @@ func (e *Evaluator) checkInList(not bool, in interface{}, list []interface{}) (i
 			continue
 		}
 
-		r, err := types.Compare(in, v)
+		r, err := collate.Compare(collation, in, v)
 		if err != nil {
 			return nil, err
 		}
@@ -255,6 +356,9 @@ func (e *Evaluator) checkInList(not bool, in interface{}, list []interface{}) (i
 }
 
 func (e *Evaluator) patternIn(n *ast.PatternInExpr) bool {
+	if n.Sel != nil {
+		return e.patternInSubquery(n)
+	}
 	lhs := n.Expr.GetValue()
 	if types.IsNil(lhs) {
 		n.SetValue(nil)
@@ -266,7 +370,7 @@ func (e *Evaluator) patternIn(n *ast.PatternInExpr) bool {
 			hasNull = true
 			continue
 		}
-		r, err := types.Compare(n.Expr.GetValue(), v.GetValue())
+		r, err := collate.Compare(collationOf(n.Expr, v), n.Expr.GetValue(), v.GetValue())
 		if err != nil {
 			e.err = err
 			return false
@@ -286,6 +390,35 @@ func (e *Evaluator) patternIn(n *ast.PatternInExpr) bool {
 	return true
 }
 
+func (e *Evaluator) patternInSubquery(n *ast.PatternInExpr) bool {
+	rows, ok := n.Sel.GetValue().([]interface{})
+	if !ok {
+		e.err = ErrInvalidOperation.Gen("invalid subquery value %v", n.Sel.GetValue())
+		return false
+	}
+	if len(rows) == 0 {
+		// in returns false for empty subquery, even if the value is NULL.
+		n.SetValue(boolToInt64(n.Not))
+		return true
+	}
+	lhs := n.Expr.GetValue()
+	if types.IsNil(lhs) {
+		n.SetValue(nil)
+		return true
+	}
+	r, err := e.checkInList(n.Not, collationOf(n.Expr), lhs, rows)
+	if err != nil {
+		e.err = errors.Trace(err)
+		return false
+	}
+	if b, ok := r.(bool); ok {
+		n.SetValue(boolToInt64(b))
+	} else {
+		n.SetValue(nil)
+	}
+	return true
+}
+
 func (e *Evaluator) isNull(v *ast.IsNullExpr) bool {
 	var boolVal bool
 	if types.IsNil(v.Expr.GetValue()) {
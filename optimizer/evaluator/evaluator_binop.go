@@ -20,6 +20,7 @@ import (
 	"github.com/pingcap/tidb/ast"
 	"github.com/pingcap/tidb/mysql"
 	"github.com/pingcap/tidb/parser/opcode"
+	"github.com/pingcap/tidb/util/collate"
 	"github.com/pingcap/tidb/util/types"
 )
 
@@ -154,7 +155,7 @@ func (e *Evaluator) handleComparisonOp(o *ast.BinaryOperationExpr) bool {
 		// for <=>, if a and b are both nil, return true.
 		// if a or b is nil, return false.
 		if o.Op == opcode.NullEQ {
-			if types.IsNil(a) || types.IsNil(b) {
+			if types.IsNil(a) && types.IsNil(b) {
 				o.SetValue(oneI64)
 			} else {
 				o.SetValue(zeroI64)
@@ -165,7 +166,7 @@ func (e *Evaluator) handleComparisonOp(o *ast.BinaryOperationExpr) bool {
 		return true
 	}
 
-	n, err := types.Compare(a, b)
+	n, err := collate.Compare(collationOf(o.L, o.R), a, b)
 	if err != nil {
 		e.err = err
 		return false
@@ -184,6 +185,20 @@ func (e *Evaluator) handleComparisonOp(o *ast.BinaryOperationExpr) bool {
 	return true
 }
 
+// collationOf returns the collation to compare the values of the expressions, it's the first
+// case insensitive collation of the expressions, the strings are compared by bytes if none.
+func collationOf(exprs ...ast.ExprNode) string {
+	for _, expr := range exprs {
+		if expr == nil {
+			continue
+		}
+		if tp := expr.GetType(); tp != nil && collate.IsCaseInsensitive(tp.Collate) {
+			return tp.Collate
+		}
+	}
+	return ""
+}
+
 func getCompResult(op opcode.Op, value int) (bool, error) {
 	switch op {
 	case opcode.LT:
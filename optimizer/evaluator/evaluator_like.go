@@ -16,6 +16,7 @@ package evaluator
 import (
 	"github.com/juju/errors"
 	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/util/collate"
 	"github.com/pingcap/tidb/util/types"
 	"regexp"
 )
@@ -138,6 +139,9 @@ func (e *Evaluator) patternLike(p *ast.PatternLikeExpr) bool {
 		e.err = errors.Trace(err)
 		return false
 	}
+	// The pattern matches case insensitively under the case insensitive collations.
+	collation := collationOf(p.Expr)
+	sexpr = collate.Fold(collation, sexpr)
 
 	// We need to compile pattern if it has not been compiled or it is not static.
 	var needCompile = len(p.PatChars) == 0 || !ast.IsConstant(p.Pattern)
@@ -152,7 +156,12 @@ func (e *Evaluator) patternLike(p *ast.PatternLikeExpr) bool {
 			e.err = errors.Trace(err)
 			return false
 		}
-		p.PatChars, p.PatTypes = compilePattern(spattern, p.Escape)
+		escape := p.Escape
+		if collate.IsCaseInsensitive(collation) && escape >= 'a' && escape <= 'z' {
+			// The escape character is folded with the pattern.
+			escape -= 'a' - 'A'
+		}
+		p.PatChars, p.PatTypes = compilePattern(collate.Fold(collation, spattern), escape)
 	}
 	match := doMatch(sexpr, p.PatChars, p.PatTypes)
 	if p.Not {
@@ -20,9 +20,11 @@ import (
 
 	. "github.com/pingcap/check"
 	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/context"
 	"github.com/pingcap/tidb/mysql"
 	"github.com/pingcap/tidb/parser"
 	"github.com/pingcap/tidb/parser/opcode"
+	"github.com/pingcap/tidb/terror"
 	"github.com/pingcap/tidb/util/charset"
 	"github.com/pingcap/tidb/util/mock"
 	"github.com/pingcap/tidb/util/types"
@@ -107,6 +109,11 @@ func (s *testEvaluatorSuite) TestBinopComparison(c *C) {
 		{1, opcode.LT, 2, 1},
 		{1, opcode.LT, 1, 0},
 		{1, opcode.LE, 1, 1},
+
+		// test NullEQ
+		{nil, opcode.NullEQ, nil, 1},
+		{nil, opcode.NullEQ, 1, 0},
+		{1, opcode.NullEQ, 1, 1},
 	}
 	for _, t := range tbl {
 		expr := &ast.BinaryOperationExpr{Op: t.op, L: ast.NewValueExpr(t.lhs), R: ast.NewValueExpr(t.rhs)}
@@ -669,6 +676,78 @@ func (s *testEvaluatorSuite) TestPatternIn(c *C) {
 	s.runTests(c, cases)
 }
 
+type mockSubqueryExec struct {
+	rows []interface{}
+}
+
+func (m *mockSubqueryExec) EvalRows(ctx context.Context, rowCount int) ([]interface{}, error) {
+	if rowCount >= 0 && len(m.rows) > rowCount {
+		return m.rows[:rowCount], nil
+	}
+	return m.rows, nil
+}
+
+// subquerySetter sets the subquery executor and how the subquery is evaluated.
+type subquerySetter struct {
+	exec ast.SubqueryExec
+}
+
+func (v *subquerySetter) Enter(in ast.Node) (ast.Node, bool) {
+	switch x := in.(type) {
+	case *ast.ExistsSubqueryExpr:
+		x.Sel.(*ast.SubqueryExpr).Exists = true
+	case *ast.PatternInExpr:
+		x.Sel.(*ast.SubqueryExpr).MultiRows = true
+	case *ast.CompareSubqueryExpr:
+		x.R.(*ast.SubqueryExpr).MultiRows = true
+	case *ast.SubqueryExpr:
+		x.SubqueryExec = v.exec
+		return in, true
+	}
+	return in, false
+}
+
+func (v *subquerySetter) Leave(in ast.Node) (ast.Node, bool) {
+	return in, true
+}
+
+func (s *testEvaluatorSuite) TestSubquery(c *C) {
+	ctx := mock.NewContext()
+	cases := []struct {
+		exprStr   string
+		rows      []interface{}
+		resultStr string
+	}{
+		{"2 in (select c from t)", []interface{}{1, 2}, "1"},
+		{"3 in (select c from t)", []interface{}{1, nil}, "<nil>"},
+		{"3 not in (select c from t)", []interface{}{1, 2}, "1"},
+		{"null in (select c from t)", nil, "0"},
+		{"null not in (select c from t)", []interface{}{1}, "<nil>"},
+		{"2 > any (select c from t)", []interface{}{1, 3}, "1"},
+		{"2 > any (select c from t)", []interface{}{3, nil}, "<nil>"},
+		{"2 > all (select c from t)", []interface{}{1, 3}, "0"},
+		{"2 > all (select c from t)", []interface{}{1, nil}, "<nil>"},
+		{"null > all (select c from t)", nil, "1"},
+		{"exists (select c from t)", nil, "0"},
+		{"exists (select c from t)", []interface{}{nil}, "1"},
+		{"(select c from t)", []interface{}{5}, "5"},
+		{"(select c from t)", nil, "<nil>"},
+	}
+	for _, ca := range cases {
+		expr := parseExpr(c, ca.exprStr)
+		expr.Accept(&subquerySetter{exec: &mockSubqueryExec{rows: ca.rows}})
+		val, err := Eval(ctx, expr)
+		c.Assert(err, IsNil)
+		valStr := fmt.Sprintf("%v", val)
+		c.Assert(valStr, Equals, ca.resultStr, Commentf("for %s", ca.exprStr))
+	}
+
+	expr := parseExpr(c, "(select c from t)")
+	expr.Accept(&subquerySetter{exec: &mockSubqueryExec{rows: []interface{}{1, 2}}})
+	_, err := Eval(ctx, expr)
+	c.Assert(terror.ErrorEqual(err, ErrSubqueryMoreThan1Row), IsTrue)
+}
+
 func (s *testEvaluatorSuite) TestIsNull(c *C) {
 	cases := []testCase{
 		{
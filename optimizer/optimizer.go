@@ -21,12 +21,15 @@ import (
 	"github.com/pingcap/tidb/context"
 	"github.com/pingcap/tidb/infoschema"
 	"github.com/pingcap/tidb/optimizer/plan"
+	"github.com/pingcap/tidb/sessionctx"
+	"github.com/pingcap/tidb/statistics"
 )
 
 // Optimize do optimization and create a Plan.
 // InfoSchema has to be passed in as parameter because
 // it can not be changed after resolving name.
-func Optimize(is infoschema.InfoSchema, ctx context.Context, node ast.Node) (plan.Plan, error) {
+// The subqueries evaluated by the evaluator use executors built by sb.
+func Optimize(is infoschema.InfoSchema, ctx context.Context, node ast.Node, sb plan.SubQueryBuilder) (plan.Plan, error) {
 	if err := validate(node); err != nil {
 		return nil, errors.Trace(err)
 	}
@@ -40,7 +43,15 @@ func Optimize(is infoschema.InfoSchema, ctx context.Context, node ast.Node) (pla
 	if err := preEvaluate(ctx, node); err != nil {
 		return nil, errors.Trace(err)
 	}
-	p, err := plan.BuildPlan(node)
+	var stats *statistics.Handle
+	if do := sessionctx.GetDomain(ctx); do != nil {
+		stats = do.StatsHandle()
+	}
+	explain, isExplain := node.(*ast.ExplainStmt)
+	if isExplain {
+		node = explain.Stmt
+	}
+	p, err := plan.BuildPlan(node, sb, stats)
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
@@ -51,59 +62,94 @@ func Optimize(is infoschema.InfoSchema, ctx context.Context, node ast.Node) (pla
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
+	var rejected []plan.Plan
 	for _, alt := range alts {
 		cost := plan.EstimateCost(alt)
 		if cost < bestCost {
+			rejected = append(rejected, bestPlan)
 			bestCost = cost
 			bestPlan = alt
+		} else {
+			rejected = append(rejected, alt)
 		}
 	}
+	if isExplain {
+		// The plans may share sub plans, estimate them again to explain the costs of their own.
+		for _, alt := range rejected {
+			plan.EstimateCost(alt)
+		}
+		plan.EstimateCost(bestPlan)
+		return &plan.ExplainPlan{
+			StmtPlan:     bestPlan,
+			Alternatives: rejected,
+			Format:       explain.Format,
+		}, nil
+	}
 	return bestPlan, nil
 }
 
 type supportChecker struct {
 	unsupported bool
+	// noFrom is true if the select statement doesn't have from clause,
+	// the outer statements are pushed to the stack when visiting a subquery.
+	noFrom      bool
+	noFromStack []bool
 }
 
 func (c *supportChecker) Enter(in ast.Node) (ast.Node, bool) {
 	switch in.(type) {
-	case *ast.SubqueryExpr, *ast.AggregateFuncExpr, *ast.GroupByClause, *ast.HavingClause, *ast.ParamMarkerExpr:
+	case *ast.ParamMarkerExpr:
 		c.unsupported = true
-	case *ast.Join:
-		x := in.(*ast.Join)
-		if x.Right != nil {
+	case *ast.SubqueryExpr:
+		x := in.(*ast.SubqueryExpr)
+		if _, ok := x.Query.(*ast.SelectStmt); !ok {
+			c.unsupported = true
+		}
+		c.noFromStack = append(c.noFromStack, c.noFrom)
+	case *ast.AggregateFuncExpr, *ast.GroupByClause, *ast.HavingClause:
+		// Aggregation is only supported with from clause.
+		if c.noFrom {
+			c.unsupported = true
+		}
+	case *ast.TableSource:
+		x := in.(*ast.TableSource)
+		tn, ok := x.Source.(*ast.TableName)
+		if !ok {
+			c.unsupported = true
+		} else if strings.EqualFold(tn.Schema.O, infoschema.Name) {
 			c.unsupported = true
-		} else {
-			ts, tsok := x.Left.(*ast.TableSource)
-			if !tsok {
-				c.unsupported = true
-			} else {
-				tn, tnok := ts.Source.(*ast.TableName)
-				if !tnok {
-					c.unsupported = true
-				} else if strings.EqualFold(tn.Schema.O, infoschema.Name) {
-					c.unsupported = true
-				}
-			}
 		}
 	case *ast.SelectStmt:
 		x := in.(*ast.SelectStmt)
 		if x.Distinct {
 			c.unsupported = true
 		}
+		c.noFrom = x.From == nil
 	}
 	return in, c.unsupported
 }
 
 func (c *supportChecker) Leave(in ast.Node) (ast.Node, bool) {
+	if _, ok := in.(*ast.SubqueryExpr); ok {
+		c.noFrom = c.noFromStack[len(c.noFromStack)-1]
+		c.noFromStack = c.noFromStack[:len(c.noFromStack)-1]
+	}
 	return in, !c.unsupported
 }
 
 // IsSupported checks if the node is supported to use new plan.
-// We first support single table select statement without group by clause or aggregate functions.
-// TODO: 1. insert/update/delete. 2. join tables. 3. subquery. 4. group by and aggregate function.
+// We first support select statement without union subquery or distinct,
+// analyze table statement and admin statement are only supported by the new plan.
+// An explain statement is supported if the explained statement is supported.
+// TODO: 1. insert/update/delete. 2. union subquery. 3. select distinct.
 func IsSupported(node ast.Node) bool {
-	if _, ok := node.(*ast.SelectStmt); !ok {
+	switch x := node.(type) {
+	case *ast.ExplainStmt:
+		return IsSupported(x.Stmt)
+	case *ast.SelectStmt:
+	case *ast.AnalyzeTableStmt, *ast.AdminStmt:
+		return true
+	default:
 		return false
 	}
 	var checker supportChecker
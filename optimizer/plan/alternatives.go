@@ -13,16 +13,33 @@
 
 package plan
 
-import "github.com/juju/errors"
+import (
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/model"
+	"github.com/pingcap/tidb/util/collate"
+)
 
 // Alternatives returns multiple alternative plans that
 // can be picked base on their cost.
 func Alternatives(p Plan) ([]Plan, error) {
 	var plans []Plan
 	switch x := p.(type) {
-	case nil:
+	case nil, *Analyze, *Admin:
 	case *TableScan:
 		plans = tableScanAlternatives(x)
+	case *Join:
+		var err error
+		plans, err = joinAlternatives(x)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+	case *Aggregate:
+		var err error
+		plans, err = aggregateAlternatives(x)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
 	case WithSrcPlan:
 		var err error
 		plans, err = planWithSrcAlternatives(x)
@@ -45,6 +62,15 @@ func Alternatives(p Plan) ([]Plan, error) {
 func tableScanAlternatives(p *TableScan) []Plan {
 	var alts []Plan
 	for _, v := range p.Table.Indices {
+		if v.State != model.StatePublic {
+			// Only the public indices can be read.
+			continue
+		}
+		if !v.Collated && hasCaseInsensitiveColumn(p.Table, v) {
+			// The strings are encoded by bytes in the index, they're not in the order of
+			// the collation.
+			continue
+		}
 		fullRange := &IndexRange{
 			LowVal:  []interface{}{nil},
 			HighVal: []interface{}{MaxVal},
@@ -53,6 +79,7 @@ func tableScanAlternatives(p *TableScan) []Plan {
 			Index:  v,
 			Table:  p.Table,
 			Ranges: []*IndexRange{fullRange},
+			Stats:  p.Stats,
 		}
 		ip.SetFields(p.Fields())
 		alts = append(alts, ip)
@@ -60,6 +87,131 @@ func tableScanAlternatives(p *TableScan) []Plan {
 	return alts
 }
 
+// hasCaseInsensitiveColumn returns true if any column of the index is case insensitive.
+func hasCaseInsensitiveColumn(tbl *model.TableInfo, idx *model.IndexInfo) bool {
+	for _, ic := range idx.Columns {
+		if ic.Offset < len(tbl.Columns) && collate.IsCaseInsensitive(tbl.Columns[ic.Offset].Collate) {
+			return true
+		}
+	}
+	return false
+}
+
+// joinAlternatives returns join plans with different strategies.
+// The two sides don't affect each other, so the cheapest plan of each
+// side is picked instead of enumerating all the combinations.
+func joinAlternatives(p *Join) ([]Plan, error) {
+	left, err := bestAlternative(p.Left)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	right, err := bestAlternative(p.Right)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	hashJoin := *p
+	hashJoin.Strategy = JoinHash
+	hashJoin.Left, hashJoin.Right = left, right
+	alts := []Plan{&hashJoin}
+	if len(p.LeftKeys) == 0 || p.Kind == JoinSemi || p.Kind == JoinAntiSemi {
+		// Semi join is only executed by hash join.
+		return alts, nil
+	}
+	// Merge join needs both sides sorted by the join keys, the sort
+	// can be bypassed if the source is an index scan in key order.
+	mergeJoin := *p
+	mergeJoin.Strategy = JoinMerge
+	mergeJoin.Left, err = bestSortedAlternative(p.Left, p.LeftKeys)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	mergeJoin.Right, err = bestSortedAlternative(p.Right, p.RightKeys)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return append(alts, &mergeJoin), nil
+}
+
+// bestAlternative returns the plan with the lowest cost from p and its alternatives.
+func bestAlternative(p Plan) (Plan, error) {
+	alts, err := Alternatives(p)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	best, bestCost := p, EstimateCost(p)
+	for _, alt := range alts {
+		cost := EstimateCost(alt)
+		if cost < bestCost {
+			best, bestCost = alt, cost
+		}
+	}
+	return best, nil
+}
+
+// bestSortedAlternative returns the plan with the lowest cost from p and its alternatives,
+// the returned plan is sorted by keys.
+func bestSortedAlternative(p Plan, keys []ast.ExprNode) (Plan, error) {
+	alts, err := Alternatives(p)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	byItems := make([]*ast.ByItem, len(keys))
+	for i, key := range keys {
+		byItems[i] = &ast.ByItem{Expr: key}
+	}
+	var best Plan
+	var bestCost float64
+	for _, alt := range append([]Plan{p}, alts...) {
+		sort := &Sort{ByItems: byItems}
+		sort.SetSrc(alt)
+		sort.SetFields(alt.Fields())
+		if err = refine(sort); err != nil {
+			return nil, errors.Trace(err)
+		}
+		cost := EstimateCost(sort)
+		if best == nil || cost < bestCost {
+			best, bestCost = sort, cost
+		}
+	}
+	return best, nil
+}
+
+// aggregateAlternatives returns hash aggregate plans with alternative sources,
+// and stream aggregate plans if the source is already sorted by the group by items.
+func aggregateAlternatives(p *Aggregate) ([]Plan, error) {
+	alts, err := planWithSrcAlternatives(p)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if len(p.GroupByItems) == 0 {
+		return alts, nil
+	}
+	byItems := make([]*ast.ByItem, len(p.GroupByItems))
+	for i, item := range p.GroupByItems {
+		byItems[i] = &ast.ByItem{Expr: item}
+	}
+	srcs := []Plan{p.Src()}
+	for _, alt := range alts {
+		srcs = append(srcs, alt.(*Aggregate).Src())
+	}
+	for _, src := range srcs {
+		sort := &Sort{ByItems: byItems}
+		sort.SetSrc(src)
+		sort.SetFields(src.Fields())
+		if err = refine(sort); err != nil {
+			return nil, errors.Trace(err)
+		}
+		if !sort.Bypass {
+			continue
+		}
+		stream := *p
+		stream.Strategy = AggregateStream
+		stream.SetSrc(sort)
+		alts = append(alts, &stream)
+	}
+	return alts, nil
+}
+
 // planWithSrcAlternatives shallow copies the WithSrcPlan,
 // and set its src to src alternatives.
 func planWithSrcAlternatives(p WithSrcPlan) ([]Plan, error) {
@@ -93,6 +245,9 @@ func shallowCopy(p WithSrcPlan) WithSrcPlan {
 	case *Limit:
 		n := *x
 		copied = &n
+	case *Aggregate:
+		n := *x
+		copied = &n
 	}
 	return copied
 }
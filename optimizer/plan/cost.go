@@ -15,6 +15,12 @@ package plan
 
 import (
 	"math"
+
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/parser/opcode"
+	"github.com/pingcap/tidb/statistics"
+	"github.com/pingcap/tidb/util/types"
 )
 
 // Pre-defined cost factors.
@@ -25,7 +31,9 @@ const (
 	RowCost          = 1.0
 	IndexCost        = 2.0
 	SortCost         = 2.0
+	HashCost         = 1.5
 	FilterRate       = 0.5
+	GroupRate        = 0.1
 )
 
 // CostEstimator estimates the cost of a plan.
@@ -43,11 +51,15 @@ func (c *costEstimator) Leave(p Plan) (Plan, bool) {
 	case *IndexScan:
 		c.indexScan(v)
 	case *TableScan:
+		rowCount := float64(FullRangeCount)
+		if v.Stats != nil {
+			rowCount = float64(v.Stats.Count)
+		}
 		v.startupCost = 0
 		if v.limit == 0 {
-			v.rowCount = FullRangeCount
+			v.rowCount = rowCount
 		} else {
-			v.rowCount = math.Min(FullRangeCount, v.limit)
+			v.rowCount = math.Min(rowCount, v.limit)
 		}
 		v.totalCost = v.rowCount * RowCost
 	case *SelectFields:
@@ -62,7 +74,7 @@ func (c *costEstimator) Leave(p Plan) (Plan, bool) {
 		v.totalCost = v.Src().TotalCost()
 	case *Filter:
 		v.startupCost = v.Src().StartupCost()
-		v.rowCount = v.Src().RowCount() * FilterRate
+		v.rowCount = c.filterRowCount(v)
 		v.totalCost = v.Src().TotalCost()
 	case *Sort:
 		if v.Bypass {
@@ -84,12 +96,76 @@ func (c *costEstimator) Leave(p Plan) (Plan, bool) {
 		v.rowCount = v.Src().RowCount()
 		v.startupCost = v.Src().StartupCost()
 		v.totalCost = v.Src().TotalCost()
+	case *Join:
+		c.join(v)
+	case *Aggregate:
+		c.aggregate(v)
 	}
 	return p, true
 }
+
+func (c *costEstimator) aggregate(v *Aggregate) {
+	srcCount := v.Src().RowCount()
+	if len(v.GroupByItems) == 0 {
+		// All the rows are aggregated into a single row.
+		v.rowCount = 1
+	} else {
+		v.rowCount = srcCount * GroupRate
+	}
+	if v.Strategy == AggregateStream {
+		// Source rows are sorted, a group is returned as soon as the next group starts.
+		v.startupCost = v.Src().StartupCost()
+		v.totalCost = v.Src().TotalCost() + v.RowCount()*RowCost
+		return
+	}
+	// Hash aggregation must retrieve all the rows before returns the first row.
+	v.startupCost = v.Src().TotalCost() + srcCount*HashCost
+	v.totalCost = v.startupCost + v.RowCount()*RowCost
+}
+
+func (c *costEstimator) join(v *Join) {
+	leftCount, rightCount := v.Left.RowCount(), v.Right.RowCount()
+	var rowCount float64
+	if len(v.LeftKeys) > 0 {
+		// Assume every row of the smaller side matches a row of the larger side.
+		rowCount = math.Max(leftCount, rightCount)
+	} else {
+		rowCount = leftCount * rightCount
+	}
+	if len(v.Conditions) > 0 {
+		rowCount *= FilterRate
+	}
+	switch v.Kind {
+	case JoinLeft:
+		rowCount = math.Max(rowCount, leftCount)
+	case JoinRight:
+		rowCount = math.Max(rowCount, rightCount)
+	case JoinSemi, JoinAntiSemi:
+		// Every left row is returned at most once.
+		rowCount = leftCount * FilterRate
+	}
+	v.rowCount = rowCount
+	if v.Strategy == JoinMerge {
+		// Both sides are sorted, rows are returned as soon as they are merged.
+		v.startupCost = v.Left.StartupCost() + v.Right.StartupCost()
+		v.totalCost = v.Left.TotalCost() + v.Right.TotalCost() + v.RowCount()*RowCost
+		return
+	}
+	// Hash join must build the hash table before returns the first row.
+	build, probe := v.Right, v.Left
+	if v.BuildLeft() {
+		build, probe = v.Left, v.Right
+	}
+	buildCost := build.TotalCost() + build.RowCount()*HashCost
+	v.startupCost = buildCost + probe.StartupCost()
+	v.totalCost = buildCost + probe.TotalCost() + v.RowCount()*RowCost
+}
+
 func (c *costEstimator) indexScan(v *IndexScan) {
 	var rowCount float64
-	if len(v.Ranges) == 1 && v.Ranges[0].LowVal[0] == nil && v.Ranges[0].HighVal[0] == MaxVal {
+	if count, ok := indexScanRowCount(v); ok {
+		rowCount = count
+	} else if len(v.Ranges) == 1 && v.Ranges[0].LowVal[0] == nil && v.Ranges[0].HighVal[0] == MaxVal {
 		// full range use default row count.
 		rowCount = FullRangeCount
 	} else {
@@ -126,6 +202,223 @@ func (c *costEstimator) indexScan(v *IndexScan) {
 	v.totalCost = v.rowCount * RowCost
 }
 
+// indexScanRowCount estimates the row count of the index scan by the statistics of
+// the first index column, returns false if the column is not analyzed.
+func indexScanRowCount(v *IndexScan) (float64, bool) {
+	if v.Stats == nil {
+		return 0, false
+	}
+	colInfo := v.Table.Columns[v.Index.Columns[0].Offset]
+	col := v.Stats.Column(colInfo.ID)
+	if col == nil {
+		return 0, false
+	}
+	var rowCount float64
+	points := 0
+	for _, ran := range v.Ranges {
+		count, err := rangeRowCount(v.Stats, col, &colInfo.FieldType, ran)
+		if err != nil {
+			return 0, false
+		}
+		rowCount += count
+		if ran.IsPoint() && len(ran.LowVal) == len(v.Index.Columns) {
+			points++
+		}
+	}
+	if v.Index.Unique && points == len(v.Ranges) {
+		// Every point range of an unique index matches at most one row.
+		rowCount = math.Min(rowCount, float64(points))
+	}
+	return math.Min(rowCount, float64(v.Stats.Count)), true
+}
+
+// rangeRowCount estimates the number of rows whose first index column value is in the range.
+// The null values are considered less than the other values, like they are in the index.
+func rangeRowCount(stats *statistics.Table, col *statistics.Column, ft *types.FieldType, ran *IndexRange) (float64, error) {
+	// The exclusion only applies to the first column if the range has only one column.
+	lowExclude := ran.LowExclude && len(ran.LowVal) == 1
+	highExclude := ran.HighExclude && len(ran.HighVal) == 1
+	lowLess, lowEqual, err := boundRowCount(stats, col, ft, ran.LowVal[0])
+	if err != nil {
+		return 0, errors.Trace(err)
+	}
+	highLess, highEqual, err := boundRowCount(stats, col, ft, ran.HighVal[0])
+	if err != nil {
+		return 0, errors.Trace(err)
+	}
+	count := highLess - lowLess
+	if !highExclude {
+		count += highEqual
+	}
+	if lowExclude {
+		count -= lowEqual
+	}
+	return math.Max(count, 0), nil
+}
+
+// boundRowCount returns the number of rows less than the range bound value and the number
+// of rows equal to it.
+func boundRowCount(stats *statistics.Table, col *statistics.Column, ft *types.FieldType, val interface{}) (less float64, equal float64, err error) {
+	switch val {
+	case nil:
+		return 0, float64(col.NullCount), nil
+	case MinNotNullVal:
+		return float64(col.NullCount), 0, nil
+	case MaxVal:
+		return float64(stats.Count), 0, nil
+	}
+	val, err = types.Convert(val, ft)
+	if err != nil {
+		return 0, 0, errors.Trace(err)
+	}
+	less, err = col.LessRowCount(val)
+	if err != nil {
+		return 0, 0, errors.Trace(err)
+	}
+	equal, err = col.EqualRowCount(val)
+	if err != nil {
+		return 0, 0, errors.Trace(err)
+	}
+	return float64(col.NullCount) + less, equal, nil
+}
+
+// filterRowCount estimates the row count of the filter by the selectivity of the
+// conditions if the source is a scan of an analyzed table.
+func (c *costEstimator) filterRowCount(v *Filter) float64 {
+	var stats *statistics.Table
+	switch x := v.Src().(type) {
+	case *TableScan:
+		stats = x.Stats
+	case *IndexScan:
+		stats = x.Stats
+	}
+	if stats == nil || stats.Count == 0 {
+		return v.Src().RowCount() * FilterRate
+	}
+	// The conditions used to build the index ranges are still in the filter, so the
+	// selectivity applies to the whole table instead of the source rows.
+	sel := 1.0
+	for _, cond := range v.Conditions {
+		sel *= selectivity(stats, cond)
+	}
+	return math.Min(v.Src().RowCount(), float64(stats.Count)*sel)
+}
+
+// selectivity estimates the fraction of the rows in the table which satisfy the condition.
+// It returns FilterRate if the condition can't be estimated by the statistics.
+func selectivity(stats *statistics.Table, cond ast.ExprNode) float64 {
+	var count float64
+	var err error
+	switch x := cond.(type) {
+	case *ast.BinaryOperationExpr:
+		cn, value, op, ok := columnComparison(x)
+		if !ok {
+			return FilterRate
+		}
+		col := columnStats(stats, cn)
+		if col == nil {
+			return FilterRate
+		}
+		if value == nil {
+			// Comparing with null is never true.
+			return 0
+		}
+		value, err = types.Convert(value, &cn.Refer.Column.FieldType)
+		if err != nil {
+			return FilterRate
+		}
+		count, err = comparisonRowCount(stats, col, op, value)
+	case *ast.IsNullExpr:
+		cn, ok := x.Expr.(*ast.ColumnNameExpr)
+		if !ok {
+			return FilterRate
+		}
+		col := columnStats(stats, cn)
+		if col == nil {
+			return FilterRate
+		}
+		count = float64(col.NullCount)
+		if x.Not {
+			count = float64(stats.Count) - count
+		}
+	default:
+		return FilterRate
+	}
+	if err != nil {
+		return FilterRate
+	}
+	return math.Min(math.Max(count/float64(stats.Count), 0), 1)
+}
+
+// columnComparison extracts the column, the value and the operator from a comparison
+// like 'a < 1' or '1 > a', the operator is reversed if the value is on the left.
+func columnComparison(x *ast.BinaryOperationExpr) (*ast.ColumnNameExpr, interface{}, opcode.Op, bool) {
+	switch x.Op {
+	case opcode.EQ, opcode.NE, opcode.LT, opcode.LE, opcode.GT, opcode.GE:
+	default:
+		return nil, nil, x.Op, false
+	}
+	if cn, ok := x.L.(*ast.ColumnNameExpr); ok {
+		if v, ok := x.R.(*ast.ValueExpr); ok {
+			return cn, v.GetValue(), x.Op, true
+		}
+		return nil, nil, x.Op, false
+	}
+	cn, ok := x.R.(*ast.ColumnNameExpr)
+	if !ok {
+		return nil, nil, x.Op, false
+	}
+	v, ok := x.L.(*ast.ValueExpr)
+	if !ok {
+		return nil, nil, x.Op, false
+	}
+	op := x.Op
+	switch x.Op {
+	case opcode.LT:
+		op = opcode.GT
+	case opcode.LE:
+		op = opcode.GE
+	case opcode.GT:
+		op = opcode.LT
+	case opcode.GE:
+		op = opcode.LE
+	}
+	return cn, v.GetValue(), op, true
+}
+
+// columnStats returns the statistics of the column if it belongs to the analyzed table.
+func columnStats(stats *statistics.Table, cn *ast.ColumnNameExpr) *statistics.Column {
+	if cn.Refer == nil || cn.Refer.Table == nil || cn.Refer.Table.ID != stats.TableID {
+		return nil
+	}
+	return stats.Column(cn.Refer.Column.ID)
+}
+
+func comparisonRowCount(stats *statistics.Table, col *statistics.Column, op opcode.Op, value interface{}) (float64, error) {
+	switch op {
+	case opcode.EQ:
+		return col.EqualRowCount(value)
+	case opcode.NE:
+		equal, err := col.EqualRowCount(value)
+		return float64(stats.Count-col.NullCount) - equal, errors.Trace(err)
+	case opcode.LT:
+		return col.LessRowCount(value)
+	case opcode.GT:
+		return col.GreaterRowCount(value)
+	}
+	equal, err := col.EqualRowCount(value)
+	if err != nil {
+		return 0, errors.Trace(err)
+	}
+	var count float64
+	if op == opcode.LE {
+		count, err = col.LessRowCount(value)
+	} else {
+		count, err = col.GreaterRowCount(value)
+	}
+	return count + equal, errors.Trace(err)
+}
+
 // EstimateCost estimates the cost of the plan.
 func EstimateCost(p Plan) float64 {
 	var estimator costEstimator
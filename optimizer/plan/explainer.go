@@ -16,9 +16,11 @@ package plan
 import (
 	"fmt"
 	"strings"
+
+	"github.com/pingcap/tidb/ast"
 )
 
-// Explain explains a Plan, returns description string.
+// Explain explains a Plan, returns a compact description string.
 func Explain(p Plan) (string, error) {
 	var e explainer
 	p.Accept(&e)
@@ -31,6 +33,10 @@ type explainer struct {
 }
 
 func (e *explainer) Enter(in Plan) (Plan, bool) {
+	if _, ok := in.(*Join); ok {
+		// Sides of join are explained separately in Leave.
+		return in, true
+	}
 	return in, false
 }
 
@@ -54,6 +60,10 @@ func (e *explainer) Leave(in Plan) (Plan, bool) {
 		str = "Lock"
 	case *Limit:
 		str = "Limit"
+	case *Join:
+		str = e.explainJoin(x)
+	case *Aggregate:
+		str = x.Strategy.String()
 	default:
 		e.err = ErrUnsupportedType.Gen("Unknown plan type %T", in)
 		return in, false
@@ -61,3 +71,250 @@ func (e *explainer) Leave(in Plan) (Plan, bool) {
 	e.strs = append(e.strs, str)
 	return in, true
 }
+
+func (e *explainer) explainJoin(p *Join) string {
+	left, err := Explain(p.Left)
+	if err != nil {
+		e.err = err
+	}
+	right, err := Explain(p.Right)
+	if err != nil {
+		e.err = err
+	}
+	return fmt.Sprintf("%s{%s,%s}", explainJoinOperator(p), left, right)
+}
+
+// ExplainNode describes an operator of a plan with its estimated cost.
+type ExplainNode struct {
+	Operator    string         `json:"operator"`
+	RowCount    float64        `json:"row_count"`
+	StartupCost float64        `json:"startup_cost"`
+	TotalCost   float64        `json:"total_cost"`
+	Info        string         `json:"info,omitempty"`
+	Children    []*ExplainNode `json:"children,omitempty"`
+}
+
+// ExplainTree builds the ExplainNode tree of a plan whose cost has been estimated.
+// The bypassed sort is not an operator, so it is not in the tree.
+func ExplainTree(p Plan) (*ExplainNode, error) {
+	if x, ok := p.(*Sort); ok && x.Bypass {
+		return ExplainTree(x.Src())
+	}
+	node := &ExplainNode{
+		RowCount:    p.RowCount(),
+		StartupCost: p.StartupCost(),
+		TotalCost:   p.TotalCost(),
+	}
+	var children []Plan
+	switch x := p.(type) {
+	case *TableScan:
+		node.Operator = "Table"
+		node.Info = fmt.Sprintf("table:%s", x.Table.Name.L)
+		if x.Desc {
+			node.Info += ", desc"
+		}
+	case *IndexScan:
+		node.Operator = "Index"
+		node.Info = explainIndexScan(x)
+	case *Filter:
+		node.Operator = "Filter"
+		node.Info = exprListString(x.Conditions)
+	case *SelectFields:
+		node.Operator = "Fields"
+		names := make([]string, 0, len(x.Fields()))
+		for _, f := range x.Fields() {
+			names = append(names, resultFieldName(f))
+		}
+		node.Info = strings.Join(names, ", ")
+	case *Sort:
+		node.Operator = "Sort"
+		items := make([]string, 0, len(x.ByItems))
+		for _, item := range x.ByItems {
+			str := exprString(item.Expr)
+			if item.Desc {
+				str += " desc"
+			}
+			items = append(items, str)
+		}
+		node.Info = strings.Join(items, ", ")
+	case *SelectLock:
+		node.Operator = "Lock"
+		if x.Lock == ast.SelectLockForUpdate {
+			node.Info = "for update"
+		} else if x.Lock == ast.SelectLockInShareMode {
+			node.Info = "in share mode"
+		}
+	case *Limit:
+		node.Operator = "Limit"
+		node.Info = fmt.Sprintf("offset:%d, count:%d", x.Offset, x.Count)
+	case *Join:
+		node.Operator = explainJoinOperator(x)
+		node.Info = explainJoinInfo(x)
+		children = append(children, x.Left, x.Right)
+	case *Aggregate:
+		node.Operator = x.Strategy.String()
+		var infos []string
+		if len(x.GroupByItems) > 0 {
+			infos = append(infos, fmt.Sprintf("group by:[%s]", exprListString(x.GroupByItems)))
+		}
+		if len(x.AggFuncs) > 0 {
+			funcs := make([]ast.ExprNode, len(x.AggFuncs))
+			for i, f := range x.AggFuncs {
+				funcs[i] = f
+			}
+			infos = append(infos, fmt.Sprintf("funcs:[%s]", exprListString(funcs)))
+		}
+		node.Info = strings.Join(infos, ", ")
+	default:
+		return nil, ErrUnsupportedType.Gen("Unknown plan type %T", p)
+	}
+	if x, ok := p.(WithSrcPlan); ok && x.Src() != nil {
+		children = append(children, x.Src())
+	}
+	for _, child := range children {
+		childNode, err := ExplainTree(child)
+		if err != nil {
+			return nil, err
+		}
+		node.Children = append(node.Children, childNode)
+	}
+	return node, nil
+}
+
+func explainIndexScan(p *IndexScan) string {
+	cols := make([]string, len(p.Index.Columns))
+	for i, col := range p.Index.Columns {
+		cols[i] = col.Name.L
+	}
+	ranges := make([]string, len(p.Ranges))
+	for i, ran := range p.Ranges {
+		ranges[i] = ran.String()
+	}
+	info := fmt.Sprintf("table:%s, index:%s(%s), ranges:%s", p.Table.Name.L, p.Index.Name.L,
+		strings.Join(cols, ","), strings.Join(ranges, " "))
+	if p.Desc {
+		info += ", desc"
+	}
+	return info
+}
+
+func explainJoinOperator(p *Join) string {
+	var kind string
+	switch p.Kind {
+	case JoinLeft:
+		kind = "Left"
+	case JoinRight:
+		kind = "Right"
+	case JoinSemi:
+		kind = "Semi"
+	case JoinAntiSemi:
+		kind = "AntiSemi"
+	}
+	return kind + p.Strategy.String()
+}
+
+func explainJoinInfo(p *Join) string {
+	var infos []string
+	if len(p.LeftKeys) > 0 {
+		keys := make([]string, len(p.LeftKeys))
+		for i := range p.LeftKeys {
+			keys[i] = fmt.Sprintf("%s = %s", exprString(p.LeftKeys[i]), exprString(p.RightKeys[i]))
+		}
+		infos = append(infos, fmt.Sprintf("equal:[%s]", strings.Join(keys, ", ")))
+	}
+	if len(p.Conditions) > 0 {
+		infos = append(infos, fmt.Sprintf("other:[%s]", exprListString(p.Conditions)))
+	}
+	return strings.Join(infos, ", ")
+}
+
+func resultFieldName(f *ast.ResultField) string {
+	if f.ColumnAsName.L != "" {
+		return f.ColumnAsName.L
+	}
+	if f.Column != nil {
+		return f.Column.Name.L
+	}
+	return ""
+}
+
+func exprListString(exprs []ast.ExprNode) string {
+	strs := make([]string, len(exprs))
+	for i, expr := range exprs {
+		strs[i] = exprString(expr)
+	}
+	return strings.Join(strs, ", ")
+}
+
+// exprString returns a readable string of the expression for explain.
+func exprString(expr ast.ExprNode) string {
+	switch x := expr.(type) {
+	case *ast.ValueExpr:
+		return valueString(x.GetValue())
+	case *ast.ColumnNameExpr:
+		if x.Refer != nil && x.Refer.Column != nil {
+			if x.Refer.TableAsName.L != "" {
+				return x.Refer.TableAsName.L + "." + x.Refer.Column.Name.L
+			}
+			if x.Refer.Table != nil && x.Refer.Table.Name.L != "" {
+				return x.Refer.Table.Name.L + "." + x.Refer.Column.Name.L
+			}
+		}
+		return x.Name.Name.L
+	case *ast.BinaryOperationExpr:
+		return fmt.Sprintf("%s %s %s", exprString(x.L), x.Op, exprString(x.R))
+	case *ast.UnaryOperationExpr:
+		return fmt.Sprintf("%s%s", x.Op, exprString(x.V))
+	case *ast.ParenthesesExpr:
+		return fmt.Sprintf("(%s)", exprString(x.Expr))
+	case *ast.IsNullExpr:
+		if x.Not {
+			return fmt.Sprintf("%s is not null", exprString(x.Expr))
+		}
+		return fmt.Sprintf("%s is null", exprString(x.Expr))
+	case *ast.BetweenExpr:
+		not := ""
+		if x.Not {
+			not = "not "
+		}
+		return fmt.Sprintf("%s %sbetween %s and %s", exprString(x.Expr), not, exprString(x.Left), exprString(x.Right))
+	case *ast.PatternInExpr:
+		not := ""
+		if x.Not {
+			not = "not "
+		}
+		if x.Sel != nil {
+			return fmt.Sprintf("%s %sin (subquery)", exprString(x.Expr), not)
+		}
+		return fmt.Sprintf("%s %sin (%s)", exprString(x.Expr), not, exprListString(x.List))
+	case *ast.PatternLikeExpr:
+		not := ""
+		if x.Not {
+			not = "not "
+		}
+		return fmt.Sprintf("%s %slike %s", exprString(x.Expr), not, exprString(x.Pattern))
+	case *ast.FuncCallExpr:
+		return fmt.Sprintf("%s(%s)", x.FnName.L, exprListString(x.Args))
+	case *ast.AggregateFuncExpr:
+		distinct := ""
+		if x.Distinct {
+			distinct = "distinct "
+		}
+		return fmt.Sprintf("%s(%s%s)", strings.ToLower(x.F), distinct, exprListString(x.Args))
+	case *ast.SubqueryExpr, *ast.ExistsSubqueryExpr, *ast.CompareSubqueryExpr:
+		return "(subquery)"
+	}
+	return "?"
+}
+
+func valueString(v interface{}) string {
+	switch x := v.(type) {
+	case nil:
+		return "NULL"
+	case string:
+		return fmt.Sprintf("%q", x)
+	case []byte:
+		return fmt.Sprintf("%q", x)
+	}
+	return fmt.Sprintf("%v", v)
+}
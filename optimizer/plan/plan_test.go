@@ -20,6 +20,7 @@ import (
 	. "github.com/pingcap/check"
 	"github.com/pingcap/tidb/ast"
 	"github.com/pingcap/tidb/model"
+	"github.com/pingcap/tidb/mysql"
 	"github.com/pingcap/tidb/parser"
 )
 
@@ -231,6 +232,30 @@ func (s *testPlanSuite) TestBuilder(c *C) {
 			sqlStr:  "select a from t where a = 1 limit 1 for update",
 			planStr: "Table(t)->Filter->Lock->Fields->Limit",
 		},
+		{
+			sqlStr:  "select count(*) from t where a = 1 group by b having count(*) > 1 order by b",
+			planStr: "Table(t)->Filter->HashAgg->Fields->Filter->Sort",
+		},
+		{
+			sqlStr:  "select 1 from t1, t2 where t1.a = t2.a",
+			planStr: "HashJoin{Table(t1),Table(t2)}->Fields",
+		},
+		{
+			sqlStr:  "select 1 from t1 join t2 on t1.a = t2.b where t1.c = 1 and t2.d > t1.d",
+			planStr: "HashJoin{Table(t1)->Filter,Table(t2)}->Fields",
+		},
+		{
+			sqlStr:  "select 1 from t1 left join t2 on t1.a = t2.a and t1.b = 1 and t2.b = 1 where t1.c = 1 and t2.c = 1",
+			planStr: "LeftHashJoin{Table(t1)->Filter,Table(t2)->Filter}->Filter->Fields",
+		},
+		{
+			sqlStr:  "select 1 from t1 right join t2 on t1.a = t2.a and t1.b = 1 where t1.c = 1 and t2.c = 1",
+			planStr: "RightHashJoin{Table(t1)->Filter,Table(t2)->Filter}->Filter->Fields",
+		},
+		{
+			sqlStr:  "select 1 from t1 join t2 on t1.a = t2.a join t3 on t2.b = t3.b where t1.c = t3.c",
+			planStr: "HashJoin{HashJoin{Table(t1),Table(t2)},Table(t3)}->Fields",
+		},
 	}
 	for _, ca := range cases {
 		lexer := parser.NewLexer(ca.sqlStr)
@@ -238,7 +263,7 @@ func (s *testPlanSuite) TestBuilder(c *C) {
 		c.Assert(rc, Equals, 0, Commentf("error %v for expr %s", lexer.Errors(), ca.sqlStr))
 		stmt := lexer.Stmts()[0].(*ast.SelectStmt)
 		mockResolve(stmt)
-		p, err := BuildPlan(stmt)
+		p, err := BuildPlan(stmt, nil, nil)
 		c.Assert(err, IsNil)
 		explainStr, err := Explain(p)
 		c.Assert(err, IsNil)
@@ -263,6 +288,18 @@ func (s *testPlanSuite) TestBestPlan(c *C) {
 			sql:  "select * from t where b = 1 order by a",
 			best: "Index(t.b)->Filter->Fields->Sort",
 		},
+		{
+			sql:  "select * from t order by a desc limit 10",
+			best: "Index(t.a)->Fields->Limit",
+		},
+		{
+			sql:  "select * from t order by c desc, d desc",
+			best: "Index(t.c_d)->Fields",
+		},
+		{
+			sql:  "select * from t order by c desc, d",
+			best: "Table(t)->Fields->Sort",
+		},
 		{
 			sql:  "select * from t where (a between 1 and 2) and (b = 3)",
 			best: "Index(t.b)->Filter->Fields",
@@ -291,6 +328,46 @@ func (s *testPlanSuite) TestBestPlan(c *C) {
 			sql:  "select * from t where a is null",
 			best: "Index(t.a)->Filter->Fields",
 		},
+		{
+			sql:  "select a, count(*) from t group by a",
+			best: "Index(t.a)->StreamAgg->Fields",
+		},
+		{
+			sql:  "select c, sum(d) from t group by c order by c",
+			best: "Index(t.c_d)->StreamAgg->Fields",
+		},
+		{
+			sql:  "select d, count(*) from t group by d order by d",
+			best: "Table(t)->HashAgg->Fields->Sort",
+		},
+		{
+			sql:  "select count(*) from t where b = 1",
+			best: "Index(t.b)->Filter->HashAgg->Fields",
+		},
+		{
+			sql:  "select a from t group by a having count(*) > 1 limit 1",
+			best: "Index(t.a)->StreamAgg->Fields->Filter->Limit",
+		},
+		{
+			sql:  "select * from t1 join t2 on t1.a = t2.a",
+			best: "MergeJoin{Index(t1.a),Index(t2.a)}->Fields",
+		},
+		{
+			sql:  "select * from t1 join t2 on t1.d = t2.d",
+			best: "HashJoin{Table(t1),Table(t2)}->Fields",
+		},
+		{
+			sql:  "select * from t1 join t2 on t1.d = t2.d where t1.a = 1",
+			best: "HashJoin{Index(t1.a)->Filter,Table(t2)}->Fields",
+		},
+		{
+			sql:  "select * from t1 left join t2 on t1.d = t2.d and t2.b = 1",
+			best: "LeftHashJoin{Table(t1),Index(t2.b)->Filter}->Fields",
+		},
+		{
+			sql:  "select * from t1 join t2 on t1.a = t2.a join t3 on t1.d = t3.d where t3.b = 1",
+			best: "HashJoin{MergeJoin{Index(t1.a),Index(t2.a)},Index(t3.b)->Filter}->Fields",
+		},
 	}
 	for _, ca := range cases {
 		lexer := parser.NewLexer(ca.sql)
@@ -299,7 +376,7 @@ func (s *testPlanSuite) TestBestPlan(c *C) {
 		stmt := lexer.Stmts()[0].(*ast.SelectStmt)
 		ast.SetFlag(stmt)
 		mockResolve(stmt)
-		p, err := BuildPlan(stmt)
+		p, err := BuildPlan(stmt, nil, nil)
 		c.Assert(err, IsNil)
 		bestCost := EstimateCost(p)
 		bestPlan := p
@@ -321,7 +398,8 @@ func (s *testPlanSuite) TestBestPlan(c *C) {
 func mockResolve(node ast.Node) {
 	indices := []*model.IndexInfo{
 		{
-			Name: model.NewCIStr("a"),
+			Name:  model.NewCIStr("a"),
+			State: model.StatePublic,
 			Columns: []*model.IndexColumn{
 				{
 					Name: model.NewCIStr("a"),
@@ -329,7 +407,8 @@ func mockResolve(node ast.Node) {
 			},
 		},
 		{
-			Name: model.NewCIStr("b"),
+			Name:  model.NewCIStr("b"),
+			State: model.StatePublic,
 			Columns: []*model.IndexColumn{
 				{
 					Name: model.NewCIStr("b"),
@@ -337,7 +416,8 @@ func mockResolve(node ast.Node) {
 			},
 		},
 		{
-			Name: model.NewCIStr("c_d"),
+			Name:  model.NewCIStr("c_d"),
+			State: model.StatePublic,
 			Columns: []*model.IndexColumn{
 				{
 					Name: model.NewCIStr("c"),
@@ -352,12 +432,14 @@ func mockResolve(node ast.Node) {
 		Indices: indices,
 		Name:    model.NewCIStr("t"),
 	}
-	resolver := mockResolver{table: table}
+	resolver := mockResolver{table: table, joinFields: map[string][]*ast.ResultField{}}
 	node.Accept(&resolver)
 }
 
 type mockResolver struct {
 	table *model.TableInfo
+	// joinFields stores result fields for tables other than "t", they are used to test join.
+	joinFields map[string][]*ast.ResultField
 }
 
 func (b *mockResolver) Enter(in ast.Node) (ast.Node, bool) {
@@ -367,6 +449,15 @@ func (b *mockResolver) Enter(in ast.Node) (ast.Node, bool) {
 func (b *mockResolver) Leave(in ast.Node) (ast.Node, bool) {
 	switch x := in.(type) {
 	case *ast.ColumnNameExpr:
+		if fields, ok := b.joinFields[x.Name.Table.L]; ok {
+			for _, f := range fields {
+				if f.Column.Name.L == x.Name.Name.L {
+					x.Refer = f
+					x.SetType(&f.Column.FieldType)
+				}
+			}
+			break
+		}
 		x.Refer = &ast.ResultField{
 			Column: &model.ColumnInfo{
 				Name: x.Name.Name,
@@ -374,7 +465,28 @@ func (b *mockResolver) Leave(in ast.Node) (ast.Node, bool) {
 			Table: b.table,
 		}
 	case *ast.TableName:
-		x.TableInfo = b.table
+		if x.Name.L == b.table.Name.L {
+			x.TableInfo = b.table
+			break
+		}
+		table := *b.table
+		table.Name = x.Name
+		x.TableInfo = &table
+		var fields []*ast.ResultField
+		for _, name := range []string{"a", "b", "c", "d"} {
+			column := &model.ColumnInfo{Name: model.NewCIStr(name)}
+			column.Tp = mysql.TypeLonglong
+			fields = append(fields, &ast.ResultField{Column: column, Table: &table})
+		}
+		x.SetResultFields(fields)
+		b.joinFields[x.Name.L] = fields
+	case *ast.Join:
+		if x.Right == nil {
+			x.SetResultFields(x.Left.GetResultFields())
+			break
+		}
+		fields := append([]*ast.ResultField{}, x.Left.GetResultFields()...)
+		x.SetResultFields(append(fields, x.Right.GetResultFields()...))
 	}
 	return in, true
 }
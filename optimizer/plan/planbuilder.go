@@ -15,28 +15,50 @@ package plan
 
 import (
 	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/mysql"
 	"github.com/pingcap/tidb/parser/opcode"
+	"github.com/pingcap/tidb/statistics"
 	"github.com/pingcap/tidb/terror"
+	"github.com/pingcap/tidb/util/types"
 )
 
 // Error instances.
 var (
 	ErrUnsupportedType = terror.ClassOptimizerPlan.New(CodeUnsupportedType, "Unsupported type")
+	ErrWrongGroupField = terror.ClassOptimizerPlan.New(CodeWrongGroupField, "Can't group on aggregate function")
 )
 
 // Error codes.
 const (
 	CodeUnsupportedType = iota + 1
+	CodeWrongGroupField
 )
 
+// SubQueryBuilder builds the executor of a subquery plan,
+// it is implemented in executor to avoid import cycle.
+type SubQueryBuilder interface {
+	Build(p Plan) ast.SubqueryExec
+}
+
 // BuildPlan builds a plan from a node.
 // returns ErrUnsupportedType if ast.Node type is not supported yet.
-func BuildPlan(node ast.Node) (Plan, error) {
-	var builder planBuilder
+// The subqueries which are not converted to semi joins are evaluated by executors
+// built from sb, if sb is nil, the executors are not built.
+// The statistics in stats are used to estimate the cost, stats can be nil.
+func BuildPlan(node ast.Node, sb SubQueryBuilder, stats *statistics.Handle) (Plan, error) {
+	builder := &planBuilder{
+		sb:         sb,
+		stats:      stats,
+		semiJoined: map[*ast.SubqueryExpr]bool{},
+	}
 	p := builder.build(node)
 	if builder.err != nil {
 		return nil, builder.err
 	}
+	builder.buildSubqueries(node)
+	if builder.err != nil {
+		return nil, builder.err
+	}
 	err := refine(p)
 	return p, err
 }
@@ -44,48 +66,106 @@ func BuildPlan(node ast.Node) (Plan, error) {
 // planBuilder builds Plan from an ast.Node.
 // It just build the ast node straightforwardly.
 type planBuilder struct {
-	err error
+	err   error
+	sb    SubQueryBuilder
+	stats *statistics.Handle
+	// semiJoined holds the subqueries which have been converted to semi joins.
+	semiJoined map[*ast.SubqueryExpr]bool
 }
 
 func (b *planBuilder) build(node ast.Node) Plan {
 	switch x := node.(type) {
 	case *ast.SelectStmt:
 		return b.buildSelect(x)
+	case *ast.AnalyzeTableStmt:
+		return b.buildAnalyze(x)
+	case *ast.AdminStmt:
+		return b.buildAdmin(x)
 	}
 	b.err = ErrUnsupportedType.Gen("Unsupported type %T", node)
 	return nil
 }
 
+func (b *planBuilder) buildAnalyze(stmt *ast.AnalyzeTableStmt) Plan {
+	p := &Analyze{}
+	for _, tn := range stmt.TableNames {
+		p.Tables = append(p.Tables, tn.TableInfo)
+	}
+	return p
+}
+
+func (b *planBuilder) buildAdmin(stmt *ast.AdminStmt) Plan {
+	p := &Admin{
+		Tp:     stmt.Tp,
+		JobIDs: stmt.JobIDs,
+		Index:  stmt.Index,
+		Repair: stmt.Repair,
+	}
+	for _, tn := range stmt.Tables {
+		p.Schemas = append(p.Schemas, tn.DBInfo)
+		p.Tables = append(p.Tables, tn.TableInfo)
+	}
+	return p
+}
+
 func (b *planBuilder) buildSelect(sel *ast.SelectStmt) Plan {
 	var p Plan
 	if sel.From != nil {
-		p = b.buildJoin(sel.From.TableRefs)
+		var conditions []ast.ExprNode
+		if sel.Where != nil {
+			conditions = b.splitWhere(sel.Where)
+		}
+		p, conditions = b.buildResultSet(sel.From.TableRefs, conditions)
 		if b.err != nil {
 			return nil
 		}
-		if sel.Where != nil {
-			p = b.buildFilter(p, sel.Where)
+		var semiJoins []*Join
+		semiJoins, conditions = b.extractSemiJoins(conditions, p.Fields())
+		if b.err != nil {
+			return nil
+		}
+		if len(conditions) > 0 {
+			p = b.buildFilter(p, conditions)
 			if b.err != nil {
 				return nil
 			}
 		}
+		for _, semiJoin := range semiJoins {
+			semiJoin.Left = p
+			semiJoin.SetFields(p.Fields())
+			p = semiJoin
+		}
 		if sel.LockTp != ast.SelectLockNone {
 			p = b.buildSelectLock(p, sel.LockTp)
 			if b.err != nil {
 				return nil
 			}
 		}
+		aggFuncs := extractAggFuncs(sel)
+		if sel.GroupBy != nil || len(aggFuncs) > 0 {
+			p = b.buildAggregate(p, aggFuncs, sel.GroupBy)
+			if b.err != nil {
+				return nil
+			}
+		}
 		p = b.buildSelectFields(p, sel.GetResultFields())
 		if b.err != nil {
 			return nil
 		}
+		if sel.Having != nil {
+			// Having condition may refer to the select fields, so it is evaluated after them.
+			p = b.buildFilter(p, b.splitWhere(sel.Having.Expr))
+			if b.err != nil {
+				return nil
+			}
+		}
 	} else {
 		p = b.buildSelectFields(p, sel.GetResultFields())
 		if b.err != nil {
 			return nil
 		}
 		if sel.Where != nil {
-			p = b.buildFilter(p, sel.Where)
+			p = b.buildFilter(p, b.splitWhere(sel.Where))
 			if b.err != nil {
 				return nil
 			}
@@ -106,23 +186,98 @@ func (b *planBuilder) buildSelect(sel *ast.SelectStmt) Plan {
 	return p
 }
 
-func (b *planBuilder) buildJoin(from *ast.Join) Plan {
-	// Only support single table for now.
-	ts, ok := from.Left.(*ast.TableSource)
-	if !ok {
-		b.err = ErrUnsupportedType.Gen("Unsupported type %T", from.Left)
-		return nil
+// buildResultSet builds plan for a table source or a join node.
+// The conditions are pushed down as deep as possible, conditions
+// which can not be pushed into the plan are returned.
+func (b *planBuilder) buildResultSet(node ast.ResultSetNode, conditions []ast.ExprNode) (Plan, []ast.ExprNode) {
+	switch x := node.(type) {
+	case *ast.Join:
+		if x.Right == nil {
+			return b.buildResultSet(x.Left, conditions)
+		}
+		return b.buildJoin(x, conditions)
+	case *ast.TableSource:
+		tn, ok := x.Source.(*ast.TableName)
+		if !ok {
+			b.err = ErrUnsupportedType.Gen("Unsupported type %T", x.Source)
+			return nil, nil
+		}
+		p := &TableScan{
+			Table: tn.TableInfo,
+			Stats: b.stats.Get(tn.TableInfo.ID),
+		}
+		p.SetFields(tn.GetResultFields())
+		return p, conditions
 	}
-	tn, ok := ts.Source.(*ast.TableName)
-	if !ok {
-		b.err = ErrUnsupportedType.Gen("Unsupported type %T", ts.Source)
-		return nil
+	b.err = ErrUnsupportedType.Gen("Unsupported type %T", node)
+	return nil, nil
+}
+
+func (b *planBuilder) buildJoin(join *ast.Join, conditions []ast.ExprNode) (Plan, []ast.ExprNode) {
+	p := &Join{}
+	switch join.Tp {
+	case ast.LeftJoin:
+		p.Kind = JoinLeft
+	case ast.RightJoin:
+		p.Kind = JoinRight
 	}
-	p := &TableScan{
-		Table: tn.TableInfo,
+	var onConditions []ast.ExprNode
+	if join.On != nil {
+		onConditions = b.splitWhere(join.On.Expr)
 	}
-	p.SetFields(tn.GetResultFields())
-	return p
+	leftFields := join.Left.GetResultFields()
+	rightFields := join.Right.GetResultFields()
+
+	// Conditions from upper level are evaluated after join, so they can only be pushed
+	// to the outer side, or be treated as on conditions for inner join.
+	var leftConditions, rightConditions, remained []ast.ExprNode
+	for _, cond := range conditions {
+		side := conditionSide(cond, leftFields, rightFields)
+		switch {
+		case side == sideLeft && p.Kind != JoinRight:
+			leftConditions = append(leftConditions, cond)
+		case side == sideRight && p.Kind != JoinLeft:
+			rightConditions = append(rightConditions, cond)
+		case side == sideBoth && p.Kind == JoinInner:
+			onConditions = append(onConditions, cond)
+		default:
+			remained = append(remained, cond)
+		}
+	}
+	// On conditions decide whether two rows match, so they can only be pushed to the inner side.
+	for _, cond := range onConditions {
+		side := conditionSide(cond, leftFields, rightFields)
+		switch {
+		case side == sideLeft && p.Kind != JoinLeft:
+			leftConditions = append(leftConditions, cond)
+		case side == sideRight && p.Kind != JoinRight:
+			rightConditions = append(rightConditions, cond)
+		default:
+			if l, r, ok := joinKey(cond, leftFields, rightFields); ok {
+				p.LeftKeys = append(p.LeftKeys, l)
+				p.RightKeys = append(p.RightKeys, r)
+			} else {
+				p.Conditions = append(p.Conditions, cond)
+			}
+		}
+	}
+
+	p.Left, leftConditions = b.buildResultSet(join.Left, leftConditions)
+	if b.err != nil {
+		return nil, nil
+	}
+	if len(leftConditions) > 0 {
+		p.Left = b.buildFilter(p.Left, leftConditions)
+	}
+	p.Right, rightConditions = b.buildResultSet(join.Right, rightConditions)
+	if b.err != nil {
+		return nil, nil
+	}
+	if len(rightConditions) > 0 {
+		p.Right = b.buildFilter(p.Right, rightConditions)
+	}
+	p.SetFields(join.GetResultFields())
+	return p, remained
 }
 
 // splitWhere split a where expression to a list of AND conditions.
@@ -131,7 +286,7 @@ func (b *planBuilder) splitWhere(where ast.ExprNode) []ast.ExprNode {
 	switch x := where.(type) {
 	case *ast.BinaryOperationExpr:
 		if x.Op == opcode.AndAnd {
-			conditions = append(conditions, x.L)
+			conditions = append(conditions, b.splitWhere(x.L)...)
 			conditions = append(conditions, b.splitWhere(x.R)...)
 		} else {
 			conditions = append(conditions, x)
@@ -142,9 +297,9 @@ func (b *planBuilder) splitWhere(where ast.ExprNode) []ast.ExprNode {
 	return conditions
 }
 
-func (b *planBuilder) buildFilter(src Plan, where ast.ExprNode) *Filter {
+func (b *planBuilder) buildFilter(src Plan, conditions []ast.ExprNode) *Filter {
 	filter := &Filter{
-		Conditions: b.splitWhere(where),
+		Conditions: conditions,
 	}
 	filter.SetSrc(src)
 	filter.SetFields(src.Fields())
@@ -167,6 +322,50 @@ func (b *planBuilder) buildSelectFields(src Plan, fields []*ast.ResultField) Pla
 	return selectFields
 }
 
+func (b *planBuilder) buildAggregate(src Plan, aggFuncs []*ast.AggregateFuncExpr, groupBy *ast.GroupByClause) Plan {
+	aggregate := &Aggregate{
+		AggFuncs: aggFuncs,
+	}
+	if groupBy != nil {
+		for _, item := range groupBy.Items {
+			node, _ := item.Expr.Accept(&groupByRewriter{})
+			expr := node.(ast.ExprNode)
+			var collector aggFuncCollector
+			expr.Accept(&collector)
+			if len(collector.aggFuncs) > 0 {
+				b.err = ErrWrongGroupField.Gen("Can't group on '%s'", item.Text())
+				return nil
+			}
+			aggregate.GroupByItems = append(aggregate.GroupByItems, expr)
+		}
+	}
+	aggregate.SetSrc(src)
+	aggregate.SetFields(src.Fields())
+	return aggregate
+}
+
+// groupByRewriter rewrites a group by item to the expression to group the rows by.
+// A group by item may refer to a select field by position or alias, but select fields
+// are evaluated after aggregation, so the expression of the select field is used.
+type groupByRewriter struct{}
+
+func (r *groupByRewriter) Enter(in ast.Node) (ast.Node, bool) {
+	return in, false
+}
+
+func (r *groupByRewriter) Leave(in ast.Node) (ast.Node, bool) {
+	switch x := in.(type) {
+	case *ast.PositionExpr:
+		return x.Refer.Expr, true
+	case *ast.ColumnNameExpr:
+		if x.Refer.Column.Name.L == "" {
+			// The column name is an alias of a select field which is not a table column.
+			return x.Refer.Expr, true
+		}
+	}
+	return in, true
+}
+
 func (b *planBuilder) buildSort(src Plan, byItems []*ast.ByItem) Plan {
 	sort := &Sort{
 		ByItems: byItems,
@@ -185,3 +384,360 @@ func (b *planBuilder) buildLimit(src Plan, limit *ast.Limit) Plan {
 	li.SetFields(src.Fields())
 	return li
 }
+
+// aggFuncCollector collects aggregate functions in an expression.
+type aggFuncCollector struct {
+	aggFuncs []*ast.AggregateFuncExpr
+}
+
+func (c *aggFuncCollector) Enter(in ast.Node) (ast.Node, bool) {
+	switch x := in.(type) {
+	case *ast.AggregateFuncExpr:
+		c.aggFuncs = append(c.aggFuncs, x)
+		return in, true
+	case *ast.SubqueryExpr:
+		// Aggregate functions in subquery belong to the subquery.
+		return in, true
+	}
+	return in, false
+}
+
+func (c *aggFuncCollector) Leave(in ast.Node) (ast.Node, bool) {
+	return in, true
+}
+
+// extractAggFuncs extracts aggregate functions in select fields, having and order by clause.
+func extractAggFuncs(sel *ast.SelectStmt) []*ast.AggregateFuncExpr {
+	var collector aggFuncCollector
+	for _, field := range sel.Fields.Fields {
+		if field.Expr != nil {
+			field.Expr.Accept(&collector)
+		}
+	}
+	if sel.Having != nil {
+		sel.Having.Expr.Accept(&collector)
+	}
+	if sel.OrderBy != nil {
+		for _, item := range sel.OrderBy.Items {
+			item.Expr.Accept(&collector)
+		}
+	}
+	return collector.aggFuncs
+}
+
+// Sides of a join which a condition refers to.
+const (
+	sideNone  = 0
+	sideLeft  = 1 << 0
+	sideRight = 1 << 1
+	sideBoth  = sideLeft | sideRight
+	// sideOther means the condition refers to a column which is not in the join.
+	sideOther = 1 << 2
+)
+
+// conditionSide returns which sides of a join the condition refers to.
+func conditionSide(cond ast.ExprNode, leftFields, rightFields []*ast.ResultField) int {
+	c := &sideChecker{leftFields: leftFields, rightFields: rightFields}
+	cond.Accept(c)
+	return c.side
+}
+
+// sideChecker collects the join sides that column names refer to.
+type sideChecker struct {
+	leftFields  []*ast.ResultField
+	rightFields []*ast.ResultField
+	side        int
+}
+
+func (c *sideChecker) Enter(in ast.Node) (ast.Node, bool) {
+	if x, ok := in.(*ast.SubqueryExpr); ok && !x.Correlated {
+		// Uncorrelated subquery doesn't refer to any side.
+		return in, true
+	}
+	return in, false
+}
+
+func (c *sideChecker) Leave(in ast.Node) (ast.Node, bool) {
+	if cn, ok := in.(*ast.ColumnNameExpr); ok {
+		switch {
+		case containsField(c.leftFields, cn.Refer):
+			c.side |= sideLeft
+		case containsField(c.rightFields, cn.Refer):
+			c.side |= sideRight
+		default:
+			c.side |= sideOther
+		}
+	}
+	return in, true
+}
+
+func containsField(fields []*ast.ResultField, rf *ast.ResultField) bool {
+	for _, f := range fields {
+		if f == rf {
+			return true
+		}
+	}
+	return false
+}
+
+// joinKey checks if the condition is an equal condition between two sides of a join,
+// if it is, returns the left side key and right side key.
+func joinKey(cond ast.ExprNode, leftFields, rightFields []*ast.ResultField) (l, r ast.ExprNode, ok bool) {
+	binop, ok := cond.(*ast.BinaryOperationExpr)
+	if !ok || binop.Op != opcode.EQ {
+		return nil, nil, false
+	}
+	lSide := conditionSide(binop.L, leftFields, rightFields)
+	rSide := conditionSide(binop.R, leftFields, rightFields)
+	if lSide == sideLeft && rSide == sideRight {
+		l, r = binop.L, binop.R
+	} else if lSide == sideRight && rSide == sideLeft {
+		l, r = binop.R, binop.L
+	} else {
+		return nil, nil, false
+	}
+	if !keyComparable(l.GetType(), r.GetType()) {
+		return nil, nil, false
+	}
+	return l, r, true
+}
+
+// keyComparable checks if values of the two types can be compared in their encoded form,
+// so they can be used as hash join keys and merge join keys.
+func keyComparable(a, b *types.FieldType) bool {
+	if a == nil || b == nil {
+		return false
+	}
+	ac, bc := keyTypeClass(a.Tp), keyTypeClass(b.Tp)
+	if ac == keyClassNone || bc == keyClassNone {
+		return false
+	}
+	if ac == keyClassOther {
+		// Time types with different type may have different string forms for the same value.
+		return a.Tp == b.Tp
+	}
+	return ac == bc
+}
+
+// Type classes for join keys.
+const (
+	keyClassNone = iota
+	keyClassNumber
+	keyClassString
+	keyClassOther
+)
+
+func keyTypeClass(tp byte) int {
+	switch tp {
+	case mysql.TypeTiny, mysql.TypeShort, mysql.TypeInt24, mysql.TypeLong, mysql.TypeLonglong,
+		mysql.TypeYear, mysql.TypeFloat, mysql.TypeDouble, mysql.TypeDecimal, mysql.TypeNewDecimal:
+		return keyClassNumber
+	case mysql.TypeVarchar, mysql.TypeString, mysql.TypeVarString, mysql.TypeTinyBlob,
+		mysql.TypeMediumBlob, mysql.TypeBlob, mysql.TypeLongBlob:
+		return keyClassString
+	case mysql.TypeDate, mysql.TypeDatetime, mysql.TypeTimestamp, mysql.TypeDuration:
+		return keyClassOther
+	}
+	return keyClassNone
+}
+
+// extractSemiJoins converts the in and exists subquery conditions to semi joins,
+// the left side of the returned joins are not set. Conditions which are not
+// converted are returned.
+func (b *planBuilder) extractSemiJoins(conditions []ast.ExprNode, outerFields []*ast.ResultField) ([]*Join, []ast.ExprNode) {
+	var semiJoins []*Join
+	var remained []ast.ExprNode
+	for _, cond := range conditions {
+		semiJoin := b.buildSemiJoin(cond, outerFields)
+		if b.err != nil {
+			return nil, nil
+		}
+		if semiJoin == nil {
+			remained = append(remained, cond)
+		} else {
+			semiJoins = append(semiJoins, semiJoin)
+		}
+	}
+	return semiJoins, remained
+}
+
+// buildSemiJoin builds a semi join from "expr [not] in (subquery)" or "[not] exists (subquery)",
+// returns nil if the condition can not be converted.
+func (b *planBuilder) buildSemiJoin(cond ast.ExprNode, outerFields []*ast.ResultField) *Join {
+	p := &Join{Kind: JoinSemi}
+	var sel ast.ExprNode
+	// outerKey is the left expression of in.
+	var outerKey ast.ExprNode
+	switch x := cond.(type) {
+	case *ast.PatternInExpr:
+		if _, ok := x.Expr.(*ast.RowExpr); ok {
+			return nil
+		}
+		sel, outerKey = x.Sel, x.Expr
+		if x.Not {
+			p.Kind = JoinAntiSemi
+			p.NullAware = true
+		}
+	case *ast.ExistsSubqueryExpr:
+		sel = x.Sel
+	case *ast.UnaryOperationExpr:
+		exists, ok := x.V.(*ast.ExistsSubqueryExpr)
+		if !ok || x.Op != opcode.Not {
+			return nil
+		}
+		sel = exists.Sel
+		p.Kind = JoinAntiSemi
+	}
+	sub, ok := sel.(*ast.SubqueryExpr)
+	if !ok {
+		return nil
+	}
+	inner, ok := sub.Query.(*ast.SelectStmt)
+	if !ok || inner.From == nil {
+		return nil
+	}
+	if outerKey != nil && len(inner.GetResultFields()) != 1 {
+		return nil
+	}
+	if sub.Correlated {
+		if !b.decorrelate(p, inner, outerKey, outerFields) {
+			return nil
+		}
+	} else {
+		var innerKey ast.ExprNode
+		if outerKey != nil {
+			innerKey = fieldExpr(inner.GetResultFields()[0])
+			if !keyComparable(outerKey.GetType(), innerKey.GetType()) {
+				return nil
+			}
+			p.LeftKeys = []ast.ExprNode{outerKey}
+			p.RightKeys = []ast.ExprNode{innerKey}
+		}
+		p.Right = b.buildSelect(inner)
+	}
+	b.semiJoined[sub] = true
+	return p
+}
+
+// decorrelate builds the right side of a semi join from a correlated subquery, the where conditions
+// which refer to the outer query become join keys and join conditions. It returns false if the subquery
+// can not be decorrelated, then it is evaluated for every outer row.
+func (b *planBuilder) decorrelate(p *Join, inner *ast.SelectStmt, outerKey ast.ExprNode, outerFields []*ast.ResultField) bool {
+	// The null aware "not in" needs to know whether the matched right rows have NULL values,
+	// it is not supported with join conditions.
+	if p.NullAware || inner.GroupBy != nil || inner.Having != nil || inner.Limit != nil {
+		return false
+	}
+	if len(extractAggFuncs(inner)) > 0 {
+		return false
+	}
+	innerFields := inner.From.TableRefs.GetResultFields()
+	if outerKey != nil {
+		var innerKey ast.ExprNode
+		field := inner.Fields.Fields[0]
+		if field.WildCard != nil {
+			innerKey = fieldExpr(inner.GetResultFields()[0])
+		} else {
+			innerKey = field.Expr
+			if side := conditionSide(innerKey, nil, innerFields); side != sideNone && side != sideRight {
+				return false
+			}
+		}
+		if !keyComparable(outerKey.GetType(), innerKey.GetType()) {
+			return false
+		}
+		p.LeftKeys = append(p.LeftKeys, outerKey)
+		p.RightKeys = append(p.RightKeys, innerKey)
+	}
+	var innerConditions []ast.ExprNode
+	if inner.Where != nil {
+		for _, cond := range b.splitWhere(inner.Where) {
+			switch conditionSide(cond, outerFields, innerFields) {
+			case sideNone, sideRight:
+				innerConditions = append(innerConditions, cond)
+			case sideLeft, sideBoth:
+				if l, r, ok := joinKey(cond, outerFields, innerFields); ok {
+					p.LeftKeys = append(p.LeftKeys, l)
+					p.RightKeys = append(p.RightKeys, r)
+				} else {
+					p.Conditions = append(p.Conditions, cond)
+				}
+			default:
+				// The condition refers to a query outside of the outer query.
+				return false
+			}
+		}
+	}
+	p.Right, innerConditions = b.buildResultSet(inner.From.TableRefs, innerConditions)
+	if b.err != nil {
+		return false
+	}
+	if len(innerConditions) > 0 {
+		p.Right = b.buildFilter(p.Right, innerConditions)
+	}
+	return true
+}
+
+// fieldExpr returns an expression which is evaluated to the value of a result field.
+func fieldExpr(rf *ast.ResultField) ast.ExprNode {
+	expr := &ast.ColumnNameExpr{
+		Name:  &ast.ColumnName{Name: rf.ColumnAsName},
+		Refer: rf,
+	}
+	expr.SetType(rf.Expr.GetType())
+	return expr
+}
+
+// buildSubqueries builds plans for the subqueries which are not converted to semi joins,
+// they are evaluated by the evaluator with the executors built from the plans.
+func (b *planBuilder) buildSubqueries(node ast.Node) {
+	node.Accept(&subqueryVisitor{b: b})
+}
+
+func (b *planBuilder) buildSubquery(sub *ast.SubqueryExpr) {
+	p := b.build(sub.Query)
+	if b.err != nil {
+		return
+	}
+	if b.err = refine(p); b.err != nil {
+		return
+	}
+	p, b.err = bestAlternative(p)
+	if b.err != nil {
+		return
+	}
+	if b.sb != nil {
+		sub.SubqueryExec = b.sb.Build(p)
+	}
+}
+
+// subqueryVisitor sets how subqueries are evaluated and builds plans for them.
+type subqueryVisitor struct {
+	b *planBuilder
+}
+
+func (v *subqueryVisitor) Enter(in ast.Node) (ast.Node, bool) {
+	switch x := in.(type) {
+	case *ast.ExistsSubqueryExpr:
+		if sub, ok := x.Sel.(*ast.SubqueryExpr); ok {
+			sub.Exists = true
+		}
+	case *ast.PatternInExpr:
+		if sub, ok := x.Sel.(*ast.SubqueryExpr); ok {
+			sub.MultiRows = true
+		}
+	case *ast.CompareSubqueryExpr:
+		if sub, ok := x.R.(*ast.SubqueryExpr); ok {
+			sub.MultiRows = true
+		}
+	case *ast.SubqueryExpr:
+		if !v.b.semiJoined[x] {
+			v.b.buildSubquery(x)
+		}
+	}
+	return in, v.b.err != nil
+}
+
+func (v *subqueryVisitor) Leave(in ast.Node) (ast.Node, bool) {
+	return in, v.b.err == nil
+}
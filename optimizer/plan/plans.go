@@ -14,8 +14,11 @@
 package plan
 
 import (
+	"strings"
+
 	"github.com/pingcap/tidb/ast"
 	"github.com/pingcap/tidb/model"
+	"github.com/pingcap/tidb/statistics"
 )
 
 // TableScan represents a table scan plan.
@@ -24,6 +27,9 @@ type TableScan struct {
 
 	Table *model.TableInfo
 	Desc  bool
+
+	// Stats is the statistics of the table, it is nil if the table is not analyzed.
+	Stats *statistics.Table
 }
 
 // Accept implements Plan Accept interface.
@@ -58,6 +64,26 @@ type IndexRange struct {
 	HighExclude bool
 }
 
+// String implements fmt.Stringer interface.
+func (ir *IndexRange) String() string {
+	lowVals := make([]string, len(ir.LowVal))
+	for i, v := range ir.LowVal {
+		lowVals[i] = valueString(v)
+	}
+	highVals := make([]string, len(ir.HighVal))
+	for i, v := range ir.HighVal {
+		highVals[i] = valueString(v)
+	}
+	l, r := "[", "]"
+	if ir.LowExclude {
+		l = "("
+	}
+	if ir.HighExclude {
+		r = ")"
+	}
+	return l + strings.Join(lowVals, " ") + "," + strings.Join(highVals, " ") + r
+}
+
 // IsPoint returns if the index range is a point.
 func (ir *IndexRange) IsPoint() bool {
 	if len(ir.LowVal) != len(ir.HighVal) {
@@ -86,6 +112,9 @@ type IndexScan struct {
 
 	// Desc indicates whether the index should be scanned in descending order.
 	Desc bool
+
+	// Stats is the statistics of the table, it is nil if the table is not analyzed.
+	Stats *statistics.Table
 }
 
 // Accept implements Plan Accept interface.
@@ -250,3 +279,203 @@ func (p *Limit) SetLimit(limit float64) {
 	p.limit = float64(p.Offset + p.Count)
 	p.src.SetLimit(p.limit)
 }
+
+// JoinType is the type of a Join plan.
+type JoinType int
+
+// Join types.
+const (
+	// JoinInner returns the rows matched on both sides.
+	JoinInner JoinType = iota
+	// JoinLeft returns all the left rows, unmatched rows are joined with NULL.
+	JoinLeft
+	// JoinRight returns all the right rows, unmatched rows are joined with NULL.
+	JoinRight
+	// JoinSemi returns the left rows which match any right row, only left fields are returned.
+	JoinSemi
+	// JoinAntiSemi returns the left rows which don't match any right row, only left fields are returned.
+	JoinAntiSemi
+)
+
+// JoinStrategy is the algorithm used to execute a Join plan.
+type JoinStrategy int
+
+// Join strategies.
+const (
+	// JoinHash builds a hash table from one side and probes it with the other side.
+	JoinHash JoinStrategy = iota
+	// JoinMerge merges the two sides which are sorted by the join keys.
+	JoinMerge
+)
+
+// String implements fmt.Stringer interface.
+func (s JoinStrategy) String() string {
+	if s == JoinMerge {
+		return "MergeJoin"
+	}
+	return "HashJoin"
+}
+
+// Join represents a join plan.
+type Join struct {
+	basePlan
+
+	Left     Plan
+	Right    Plan
+	Kind     JoinType
+	Strategy JoinStrategy
+
+	// LeftKeys and RightKeys are built from the equal conditions between the two sides,
+	// a joined row must satisfy LeftKeys[i] = RightKeys[i].
+	LeftKeys  []ast.ExprNode
+	RightKeys []ast.ExprNode
+	// Conditions are evaluated on the joined row to decide whether the two rows match.
+	Conditions []ast.ExprNode
+	// NullAware is set for anti semi join built from "not in", a left row is not returned
+	// if it is compared with NULL, because the "not in" result is NULL.
+	NullAware bool
+}
+
+// Accept implements Plan Accept interface.
+func (p *Join) Accept(v Visitor) (Plan, bool) {
+	np, skip := v.Enter(p)
+	if skip {
+		return v.Leave(np)
+	}
+	p = np.(*Join)
+	var ok bool
+	p.Left, ok = p.Left.Accept(v)
+	if !ok {
+		return p, false
+	}
+	p.Right, ok = p.Right.Accept(v)
+	if !ok {
+		return p, false
+	}
+	return v.Leave(p)
+}
+
+// SetLimit implements Plan SetLimit interface.
+// The limit is not pushed to the sources, because we don't know how many
+// source rows are needed to produce the joined rows.
+func (p *Join) SetLimit(limit float64) {
+	p.limit = limit
+}
+
+// BuildLeft returns whether the hash table of a hash join should be built from the left side.
+// For outer join, the hash table is built from the inner side, for inner join, it is built
+// from the side with less rows. For semi join, it is always built from the right side.
+func (p *Join) BuildLeft() bool {
+	switch p.Kind {
+	case JoinLeft, JoinSemi, JoinAntiSemi:
+		return false
+	case JoinRight:
+		return true
+	}
+	return p.Left.RowCount() < p.Right.RowCount()
+}
+
+// AggregateStrategy is the algorithm used to execute an Aggregate plan.
+type AggregateStrategy int
+
+// Aggregate strategies.
+const (
+	// AggregateHash puts the source rows into groups by a hash table.
+	AggregateHash AggregateStrategy = iota
+	// AggregateStream aggregates the source rows which are sorted by the group by items.
+	AggregateStream
+)
+
+// String implements fmt.Stringer interface.
+func (s AggregateStrategy) String() string {
+	if s == AggregateStream {
+		return "StreamAgg"
+	}
+	return "HashAgg"
+}
+
+// Aggregate represents a group by and aggregate plan.
+type Aggregate struct {
+	planWithSrc
+
+	// AggFuncs are all the aggregate functions in the select fields, having and order by clause,
+	// their values are set when a group is returned.
+	AggFuncs []*ast.AggregateFuncExpr
+	// GroupByItems are the expressions to group the source rows, if it is empty,
+	// all the source rows are in a single group.
+	GroupByItems []ast.ExprNode
+	Strategy     AggregateStrategy
+}
+
+// Accept implements Plan Accept interface.
+func (p *Aggregate) Accept(v Visitor) (Plan, bool) {
+	np, skip := v.Enter(p)
+	if skip {
+		return v.Leave(np)
+	}
+	p = np.(*Aggregate)
+	var ok bool
+	p.src, ok = p.src.Accept(v)
+	if !ok {
+		return p, false
+	}
+	return v.Leave(p)
+}
+
+// SetLimit implements Plan SetLimit interface.
+// The limit is not pushed to the source, because all the source rows are needed
+// to produce the aggregated rows.
+func (p *Aggregate) SetLimit(limit float64) {
+	p.limit = limit
+}
+
+// Analyze represents an analyze plan, it collects the statistics of the tables.
+type Analyze struct {
+	basePlan
+
+	Tables []*model.TableInfo
+}
+
+// Accept implements Plan Accept interface.
+func (p *Analyze) Accept(v Visitor) (Plan, bool) {
+	np, _ := v.Enter(p)
+	return v.Leave(np)
+}
+
+// Admin represents an admin plan, it inspects or cancels the DDL jobs, or checks the
+// indices of the tables.
+type Admin struct {
+	basePlan
+
+	Tp     ast.AdminStmtType
+	JobIDs []int64
+	// Schemas are the schemas of the tables.
+	Schemas []*model.DBInfo
+	Tables  []*model.TableInfo
+	Index   string
+	Repair  bool
+}
+
+// Accept implements Plan Accept interface.
+func (p *Admin) Accept(v Visitor) (Plan, bool) {
+	np, _ := v.Enter(p)
+	return v.Leave(np)
+}
+
+// ExplainPlan represents an explain plan, it describes the chosen plan of a statement and
+// the alternatives which are rejected because of higher cost.
+type ExplainPlan struct {
+	basePlan
+
+	StmtPlan     Plan
+	Alternatives []Plan
+	// Format is the output format, ast.ExplainFormatTraditional or ast.ExplainFormatJSON.
+	Format string
+}
+
+// Accept implements Plan Accept interface.
+// The explained plans have been optimized, so they are not visited.
+func (p *ExplainPlan) Accept(v Visitor) (Plan, bool) {
+	np, _ := v.Enter(p)
+	return v.Leave(np)
+}
@@ -19,7 +19,7 @@ import (
 
 	"github.com/pingcap/tidb/ast"
 	"github.com/pingcap/tidb/parser/opcode"
-	"github.com/pingcap/tidb/util/types"
+	"github.com/pingcap/tidb/util/collate"
 )
 
 type rangePoint struct {
@@ -45,7 +45,9 @@ func (rp rangePoint) String() string {
 
 type rangePointSorter struct {
 	points []rangePoint
-	err    error
+	// collation is the collation of the indexed column.
+	collation string
+	err       error
 }
 
 func (r *rangePointSorter) Len() int {
@@ -81,7 +83,7 @@ func (r *rangePointSorter) Less(i, j int) bool {
 		return true
 	}
 
-	n, err := types.Compare(a.value, b.value)
+	n, err := collate.Compare(r.collation, a.value, b.value)
 	if err != nil {
 		r.err = err
 		return true
@@ -108,7 +110,9 @@ func (r *rangePointSorter) Swap(i, j int) {
 }
 
 type rangeBuilder struct {
-	err error
+	// collation is the collation of the indexed column which the ranges are built for.
+	collation string
+	err       error
 }
 
 func (r *rangeBuilder) build(expr ast.ExprNode) []rangePoint {
@@ -203,7 +207,7 @@ func (r *rangeBuilder) buildFromIn(x *ast.PatternInExpr) []rangePoint {
 		endPoint := rangePoint{value: v.GetValue()}
 		rangePoints = append(rangePoints, startPoint, endPoint)
 	}
-	sorter := rangePointSorter{points: rangePoints}
+	sorter := rangePointSorter{points: rangePoints, collation: r.collation}
 	sort.Sort(&sorter)
 	if sorter.err != nil {
 		r.err = sorter.err
@@ -300,6 +304,12 @@ func (r *rangeBuilder) buildFromPatternLike(x *ast.PatternLikeExpr) []rangePoint
 	copy(highValue, lowValue)
 
 	endPoint := rangePoint{excl: true}
+	if collate.IsCaseInsensitive(r.collation) {
+		// The strings matching the pattern case insensitively may be larger than the
+		// incremented prefix, so the range is not bounded.
+		endPoint.value = MaxVal
+		return []rangePoint{startPoint, endPoint}
+	}
 	for i := len(highValue) - 1; i >= 0; i-- {
 		highValue[i]++
 		if highValue[i] != 0 {
@@ -332,7 +342,7 @@ func (r *rangeBuilder) union(a, b []rangePoint) []rangePoint {
 }
 
 func (r *rangeBuilder) merge(a, b []rangePoint, union bool) []rangePoint {
-	sorter := rangePointSorter{points: append(a, b...)}
+	sorter := rangePointSorter{points: append(a, b...), collation: r.collation}
 	sort.Sort(&sorter)
 	if sorter.err != nil {
 		r.err = sorter.err
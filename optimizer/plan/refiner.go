@@ -40,6 +40,18 @@ func (r *refiner) Enter(in Plan) (Plan, bool) {
 		r.conditions = x.Conditions
 	case *IndexScan:
 		r.indexScan = x
+	case *Aggregate:
+		// Conditions above aggregation are evaluated on the aggregated rows,
+		// they can not be used to build the index range.
+		r.conditions = nil
+	case *Join:
+		// Each side of the join has its own conditions and index scan,
+		// so they are refined separately.
+		if r.err = refine(x.Left); r.err != nil {
+			return in, true
+		}
+		r.err = refine(x.Right)
+		return in, true
 	}
 	return in, false
 }
@@ -50,6 +62,11 @@ func (r *refiner) Leave(in Plan) (Plan, bool) {
 		r.buildIndexRange(x)
 	case *Sort:
 		r.sortBypass(x)
+	case *Aggregate:
+		if x.Strategy == AggregateHash {
+			// Hash aggregation doesn't keep the order of the index scan.
+			r.indexScan = nil
+		}
 	case *Limit:
 		x.SetLimit(0)
 	}
@@ -64,8 +81,11 @@ func (r *refiner) sortBypass(p *Sort) {
 		}
 		var desc bool
 		for i, val := range p.ByItems {
-			if val.Desc {
-				desc = true
+			if i == 0 {
+				desc = val.Desc
+			} else if val.Desc != desc {
+				// The index can only be scanned in one direction.
+				return
 			}
 			cn, ok := val.Expr.(*ast.ColumnNameExpr)
 			if !ok {
@@ -79,11 +99,7 @@ func (r *refiner) sortBypass(p *Sort) {
 				return
 			}
 		}
-		if desc {
-			// TODO: support desc when index reverse iterator is supported.
-			r.indexScan.Desc = true
-			return
-		}
+		r.indexScan.Desc = desc
 		p.Bypass = true
 	}
 }
@@ -97,6 +113,9 @@ func (r *refiner) buildIndexRange(p *IndexScan) {
 	rb := rangeBuilder{}
 	for i := 0; i < len(p.Index.Columns); i++ {
 		checker := conditionChecker{idx: p.Index, tableName: p.Table.Name, columnOffset: i}
+		if offset := p.Index.Columns[i].Offset; offset < len(p.Table.Columns) {
+			rb.collation = p.Table.Columns[offset].Collate
+		}
 		rangePoints := fullRange
 		var columnUsed bool
 		for _, cond := range r.conditions {
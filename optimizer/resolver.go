@@ -47,6 +47,9 @@ type nameResolver struct {
 	Err           error
 
 	contextStack []*resolverContext
+	// correlated is set when leaving a select statement which refers to outer query columns,
+	// then it is passed to the subquery expression.
+	correlated bool
 }
 
 // resolverContext stores information in a single level of select statement
@@ -78,10 +81,14 @@ type resolverContext struct {
 	inGroupBy bool
 	// When visiting having, only fieldList and groupBy fields are available.
 	inHaving bool
+	// When visiting aggregate function arguments in having, columns in tables are available.
+	inHavingAgg bool
 	// OrderBy clause has different resolving rule than group by.
 	inOrderBy bool
 	// When visiting column name in ByItem, we should know if the column name is in an expression.
 	inByItemExpression bool
+	// useOuterContext is true if a column in this context refers to the outer context.
+	useOuterContext bool
 }
 
 // currentContext gets the current resolverContext.
@@ -134,6 +141,11 @@ func (nr *nameResolver) Enter(inNode ast.Node) (outNode ast.Node, skipChildren b
 		nr.currentContext().inGroupBy = true
 	case *ast.HavingClause:
 		nr.currentContext().inHaving = true
+	case *ast.AggregateFuncExpr:
+		ctx := nr.currentContext()
+		if ctx.inHaving {
+			ctx.inHavingAgg = true
+		}
 	case *ast.OrderByClause:
 		nr.currentContext().inOrderBy = true
 	case *ast.ByItem:
@@ -172,9 +184,12 @@ func (nr *nameResolver) Leave(inNode ast.Node) (node ast.Node, ok bool) {
 		nr.handleFieldList(v)
 		nr.currentContext().inFieldList = false
 	case *ast.GroupByClause:
+		nr.handleGroupBy(v)
 		nr.currentContext().inGroupBy = false
 	case *ast.HavingClause:
 		nr.currentContext().inHaving = false
+	case *ast.AggregateFuncExpr:
+		nr.currentContext().inHavingAgg = false
 	case *ast.OrderByClause:
 		nr.currentContext().inOrderBy = false
 	case *ast.ByItem:
@@ -182,8 +197,13 @@ func (nr *nameResolver) Leave(inNode ast.Node) (node ast.Node, ok bool) {
 	case *ast.PositionExpr:
 		nr.handlePosition(v)
 	case *ast.SelectStmt:
-		v.SetResultFields(nr.currentContext().fieldList)
+		ctx := nr.currentContext()
+		v.SetResultFields(ctx.fieldList)
+		nr.correlated = ctx.useOuterContext
 		nr.popContext()
+	case *ast.SubqueryExpr:
+		v.Correlated = nr.correlated
+		nr.correlated = false
 	case *ast.InsertStmt:
 		nr.popContext()
 	case *ast.DeleteStmt:
@@ -209,16 +229,20 @@ func (nr *nameResolver) handleTableName(tn *ast.TableName) {
 	dbInfo, _ := nr.Info.SchemaByName(tn.Schema)
 	tn.DBInfo = dbInfo
 
-	rfs := make([]*ast.ResultField, len(tn.TableInfo.Columns))
-	for i, v := range tn.TableInfo.Columns {
+	rfs := make([]*ast.ResultField, 0, len(tn.TableInfo.Columns))
+	for _, v := range tn.TableInfo.Columns {
+		if v.State != model.StatePublic {
+			// Only the public columns can be read.
+			continue
+		}
 		expr := &ast.ValueExpr{}
 		expr.SetType(&v.FieldType)
-		rfs[i] = &ast.ResultField{
+		rfs = append(rfs, &ast.ResultField{
 			Column: v,
 			Table:  tn.TableInfo,
 			DBName: tn.Schema,
 			Expr:   expr,
-		}
+		})
 	}
 	tn.SetResultFields(rfs)
 	return
@@ -275,6 +299,11 @@ func (nr *nameResolver) handleColumnName(cn *ast.ColumnNameExpr) {
 	for i := len(nr.contextStack) - 1; i >= 0; i-- {
 		if nr.resolveColumnNameInContext(nr.contextStack[i], cn) {
 			// Column is already resolved or encountered an error.
+			// The contexts between the current one and the resolved one are
+			// subqueries that use the outer query.
+			for _, ctx := range nr.contextStack[i+1:] {
+				ctx.useOuterContext = true
+			}
 			return
 		}
 	}
@@ -313,14 +342,25 @@ func (nr *nameResolver) resolveColumnNameInContext(ctx *resolverContext, cn *ast
 			}
 			return true
 		}
-		return false
+		return nr.resolveColumnInTableSources(cn, ctx.tables)
+	}
+	if ctx.inHavingAgg {
+		// Aggregate function arguments are evaluated on table rows, from table first, then field list.
+		if nr.resolveColumnInTableSources(cn, ctx.tables) {
+			return true
+		}
+		return nr.resolveColumnInResultFields(cn, ctx.fieldList)
 	}
 	if ctx.inHaving {
 		// First group by, then field list.
 		if nr.resolveColumnInResultFields(cn, ctx.groupBy) {
 			return true
 		}
-		return nr.resolveColumnInResultFields(cn, ctx.fieldList)
+		if nr.resolveColumnInResultFields(cn, ctx.fieldList) {
+			return true
+		}
+		// Selected table columns can be referred by column name even if they have alias names.
+		return nr.resolveColumnInSelectedColumns(cn, ctx.fieldList)
 	}
 	if ctx.inOrderBy {
 		if nr.resolveColumnInResultFields(cn, ctx.groupBy) {
@@ -439,6 +479,31 @@ func (nr *nameResolver) resolveColumnInResultFields(cn *ast.ColumnNameExpr, rfs
 	return false
 }
 
+// resolveColumnInSelectedColumns resolves the column name in table columns of the field list
+// by their original table and column names.
+func (nr *nameResolver) resolveColumnInSelectedColumns(cn *ast.ColumnNameExpr, rfs []*ast.ResultField) bool {
+	var matched *ast.ResultField
+	for _, rf := range rfs {
+		if rf.Column.Name.L == "" || rf.Column.Name.L != cn.Name.Name.L {
+			continue
+		}
+		if cn.Name.Table.L != "" && cn.Name.Table.L != rf.TableAsName.L && cn.Name.Table.L != rf.Table.Name.L {
+			continue
+		}
+		if matched != nil && matched.Expr != rf.Expr {
+			// The columns are from different table sources.
+			nr.Err = errors.Errorf("column %s is ambiguous.", cn.Name.Name.O)
+			return true
+		}
+		matched = rf
+	}
+	if matched != nil {
+		cn.Refer = matched
+		return true
+	}
+	return false
+}
+
 // handleFieldList expands wild card field and set fieldList in current context.
 func (nr *nameResolver) handleFieldList(fieldList *ast.FieldList) {
 	var resultFields []*ast.ResultField
@@ -448,6 +513,17 @@ func (nr *nameResolver) handleFieldList(fieldList *ast.FieldList) {
 	nr.currentContext().fieldList = resultFields
 }
 
+// handleGroupBy sets groupBy in current context, having and order by clause
+// resolve column names in group by columns first.
+func (nr *nameResolver) handleGroupBy(groupBy *ast.GroupByClause) {
+	ctx := nr.currentContext()
+	for _, item := range groupBy.Items {
+		if cn, ok := item.Expr.(*ast.ColumnNameExpr); ok && cn.Refer != nil {
+			ctx.groupBy = append(ctx.groupBy, cn.Refer)
+		}
+	}
+}
+
 func getInnerFromParentheses(expr ast.ExprNode) ast.ExprNode {
 	if pexpr, ok := expr.(*ast.ParenthesesExpr); ok {
 		return getInnerFromParentheses(pexpr.Expr)
@@ -79,6 +79,7 @@ var resolverTestCases = []resolverTestCase{
 	{"select * from t1, t2 join t3 on t2.c1 = t3.c1", true},
 	{"select c1 from t1 group by c1 having c1 = 3", true},
 	{"select c1 from t1 group by c1 having c2 = 3", false},
+	{"select c1 from t1 group by c1 having max(c2) = 3", true},
 	{"select c1 from t1 where exists (select c2)", true},
 }
 
@@ -14,9 +14,14 @@
 package optimizer
 
 import (
+	"strings"
+
 	"github.com/pingcap/tidb/ast"
 	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/mysql"
 	"github.com/pingcap/tidb/optimizer/evaluator"
+	"github.com/pingcap/tidb/util/charset"
+	"github.com/pingcap/tidb/util/types"
 )
 
 // inferType infers result type for ast.ExprNode.
@@ -47,6 +52,8 @@ func (v *typeInferrer) Leave(in ast.Node) (out ast.Node, ok bool) {
 		x.SetType(&x.Refer.Column.FieldType)
 	case *ast.FuncCastExpr:
 		x.SetType(x.Tp)
+	case *ast.AggregateFuncExpr:
+		v.aggregateFunc(x)
 	case *ast.SelectStmt:
 		rf := x.GetResultFields()
 		for _, val := range rf {
@@ -58,6 +65,37 @@ func (v *typeInferrer) Leave(in ast.Node) (out ast.Node, ok bool) {
 	return in, true
 }
 
+func (v *typeInferrer) aggregateFunc(x *ast.AggregateFuncExpr) {
+	switch strings.ToLower(x.F) {
+	case "count":
+		ft := types.NewFieldType(mysql.TypeLonglong)
+		ft.Flen = 21
+		ft.Charset = charset.CharsetBin
+		ft.Collate = charset.CharsetBin
+		x.SetType(ft)
+	case "max", "min":
+		x.SetType(x.Args[0].GetType())
+	case "sum", "avg":
+		// The result is decimal for exact value arguments, double for others.
+		ft := types.NewFieldType(mysql.TypeDouble)
+		if tp := x.Args[0].GetType(); tp != nil {
+			switch tp.Tp {
+			case mysql.TypeTiny, mysql.TypeShort, mysql.TypeInt24, mysql.TypeLong, mysql.TypeLonglong,
+				mysql.TypeYear, mysql.TypeDecimal, mysql.TypeNewDecimal:
+				ft.Tp = mysql.TypeNewDecimal
+			}
+		}
+		ft.Charset = charset.CharsetBin
+		ft.Collate = charset.CharsetBin
+		x.SetType(ft)
+	case "group_concat":
+		ft := types.NewFieldType(mysql.TypeVarString)
+		ft.Charset = mysql.DefaultCharset
+		ft.Collate = mysql.DefaultCollationName
+		x.SetType(ft)
+	}
+}
+
 type preEvaluator struct {
 	ctx context.Context
 	err error
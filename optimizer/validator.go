@@ -26,6 +26,8 @@ const (
 	CodeSameColumns
 	CodeMultiWildCard
 	CodeUnsupported
+	CodeInvalidGroupFuncUse
+	CodeUnknownExplainFormat
 )
 
 // Optimizer base errors.
@@ -35,6 +37,10 @@ var (
 	ErrSameColumns   = terror.ClassOptimizer.New(CodeRowColumns, "Operands should contain same columns")
 	ErrMultiWildCard = terror.ClassOptimizer.New(CodeMultiWildCard, "wildcard field exist more than once")
 	ErrUnSupported   = terror.ClassOptimizer.New(CodeUnsupported, "unsupported")
+	// ErrInvalidGroupFuncUse is returned when aggregate function is used inside another one.
+	ErrInvalidGroupFuncUse = terror.ClassOptimizer.New(CodeInvalidGroupFuncUse, "Invalid use of group function")
+	// ErrUnknownExplainFormat is returned when the explain format is neither traditional nor json.
+	ErrUnknownExplainFormat = terror.ClassOptimizer.New(CodeUnknownExplainFormat, "Unknown EXPLAIN format name")
 )
 
 // validate checkes whether the node is valid.
@@ -49,9 +55,18 @@ func validate(node ast.Node) error {
 type validator struct {
 	err           error
 	wildCardCount int
+	inAggregate   bool
 }
 
 func (v *validator) Enter(in ast.Node) (out ast.Node, skipChildren bool) {
+	if _, ok := in.(*ast.AggregateFuncExpr); ok {
+		if v.inAggregate {
+			// Aggregate function can not be nested.
+			v.err = ErrInvalidGroupFuncUse
+			return in, true
+		}
+		v.inAggregate = true
+	}
 	return in, false
 }
 
@@ -75,6 +90,12 @@ func (v *validator) Leave(in ast.Node) (out ast.Node, ok bool) {
 		v.checkFieldList(x)
 	case *ast.ByItem:
 		v.checkAllOneColumn(x.Expr)
+	case *ast.AggregateFuncExpr:
+		v.inAggregate = false
+	case *ast.ExplainStmt:
+		if x.Format != ast.ExplainFormatTraditional && x.Format != ast.ExplainFormatJSON {
+			v.err = ErrUnknownExplainFormat.Gen("Unknown EXPLAIN format name: '%s'", x.Format)
+		}
 	}
 	return in, v.err == nil
 }
@@ -88,7 +109,7 @@ func (v *validator) checkAllOneColumn(exprs ...ast.ExprNode) {
 		case *ast.RowExpr:
 			v.err = ErrOneColumn
 		case *ast.SubqueryExpr:
-			if len(x.Query.GetResultFields()) != 1 {
+			if count := subqueryColumnCount(x); count >= 0 && count != 1 {
 				v.err = ErrOneColumn
 			}
 		}
@@ -111,19 +132,34 @@ func columnCount(ex ast.ExprNode) int {
 	case *ast.RowExpr:
 		return len(x.Values)
 	case *ast.SubqueryExpr:
-		return len(x.Query.GetResultFields())
+		return subqueryColumnCount(x)
 	default:
 		return 1
 	}
 }
 
+// subqueryColumnCount returns the column count of a subquery, it returns -1 if the count
+// is unknown, because wildcards are not expanded before names are resolved.
+func subqueryColumnCount(x *ast.SubqueryExpr) int {
+	sel, ok := x.Query.(*ast.SelectStmt)
+	if !ok {
+		return -1
+	}
+	for _, field := range sel.Fields.Fields {
+		if field.WildCard != nil {
+			return -1
+		}
+	}
+	return len(sel.Fields.Fields)
+}
+
 func (v *validator) checkSameColumns(exprs ...ast.ExprNode) {
 	if len(exprs) == 0 {
 		return
 	}
 	count := columnCount(exprs[0])
 	for i := 1; i < len(exprs); i++ {
-		if columnCount(exprs[i]) != count {
+		if n := columnCount(exprs[i]); count >= 0 && n >= 0 && n != count {
 			v.err = ErrSameColumns
 			return
 		}
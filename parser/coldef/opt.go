@@ -136,6 +136,7 @@ const (
 	TblOptMaxRows
 	TblOptMinRows
 	TblOptDelayKeyWrite
+	TblOptRowFormat
 )
 
 // TableOpt is used for parsing table option from SQL.
@@ -249,6 +250,15 @@ type UserSpecification struct {
 	AuthOpt *AuthOption
 }
 
+const (
+	// RequireUnspecified means there is no REQUIRE clause, the requirement is not changed.
+	RequireUnspecified = iota
+	// RequireNone means the account doesn't require TLS connections.
+	RequireNone
+	// RequireSSL means the account must connect with TLS.
+	RequireSSL
+)
+
 // PrivElem is the privilege type and optional column list.
 type PrivElem struct {
 	Priv mysql.PrivilegeType
@@ -0,0 +1,256 @@
+// Copyright 2015 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"strings"
+
+	"github.com/pingcap/tidb/ast"
+)
+
+// Normalize returns the normalized text of the statement node returned by the parser, it must
+// be called before the node is rewritten by the optimizer. The literals in the ast are replaced
+// by "?", the lists of literals like "in (1, 2, 3)" or "values (1, 2), (3, 4)" are folded to
+// "( ... )", the comments are removed, the text out of the quotes is lower-cased and the tokens
+// are separated by one space. The statements only different in the literals have the same
+// normalized text.
+func Normalize(node ast.StmtNode) string {
+	text := node.Text()
+	v := &literalCollector{text: text}
+	node.Accept(v)
+	sort.Sort(bySpanStart(v.spans))
+	var (
+		tokens []string
+		pos    int
+	)
+	for _, span := range v.spans {
+		if span.start < pos {
+			// The literal is in a folded list.
+			continue
+		}
+		tokens = appendTokens(tokens, text[pos:span.start])
+		tokens = append(tokens, span.replacement)
+		pos = span.end
+	}
+	tokens = appendTokens(tokens, text[pos:])
+	if n := len(tokens); n > 0 && tokens[n-1] == ";" {
+		tokens = tokens[:n-1]
+	}
+	return strings.Join(tokens, " ")
+}
+
+// Digest returns the digest of the normalized text, it's the hex encoded SHA256.
+func Digest(normalized string) string {
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
+
+type literalSpan struct {
+	start int
+	end   int
+	// replacement is "?" for a literal and "..." for a folded list of literals.
+	replacement string
+}
+
+type bySpanStart []literalSpan
+
+func (b bySpanStart) Len() int      { return len(b) }
+func (b bySpanStart) Swap(i, j int) { b[i], b[j] = b[j], b[i] }
+func (b bySpanStart) Less(i, j int) bool {
+	if b[i].start != b[j].start {
+		return b[i].start < b[j].start
+	}
+	// The folded list goes before its first literal.
+	return b[i].end > b[j].end
+}
+
+// literalCollector collects the spans of the literals and the lists of literals in the
+// statement text.
+type literalCollector struct {
+	text  string
+	spans []literalSpan
+}
+
+func (v *literalCollector) Enter(in ast.Node) (ast.Node, bool) {
+	switch x := in.(type) {
+	case *ast.ValueExpr:
+		if start, end, ok := v.literal(x); ok {
+			v.spans = append(v.spans, literalSpan{start: start, end: end, replacement: "?"})
+		}
+	case *ast.PatternInExpr:
+		if start, end, ok := v.literalList(x.List); ok {
+			v.spans = append(v.spans, literalSpan{start: start, end: end, replacement: "..."})
+		}
+	case *ast.InsertStmt:
+		v.foldRows(x.Lists)
+	case *ast.Limit:
+		for _, lit := range x.Literals {
+			if start, end, ok := v.literal(lit); ok {
+				v.spans = append(v.spans, literalSpan{start: start, end: end, replacement: "?"})
+			}
+		}
+	}
+	return in, false
+}
+
+func (v *literalCollector) Leave(in ast.Node) (ast.Node, bool) {
+	return in, true
+}
+
+// literal returns the span of the expression if it's a literal in the statement text.
+func (v *literalCollector) literal(expr ast.ExprNode) (int, int, bool) {
+	x, ok := expr.(*ast.ValueExpr)
+	if !ok {
+		return 0, 0, false
+	}
+	lit := x.Text()
+	start, end := x.Offset, x.Offset+len(lit)
+	if lit == "" || start < 0 || end > len(v.text) || v.text[start:end] != lit {
+		return 0, 0, false
+	}
+	return start, end, true
+}
+
+// literalList returns the span from the first to the last expression if they are all literals.
+func (v *literalCollector) literalList(list []ast.ExprNode) (int, int, bool) {
+	if len(list) == 0 {
+		return 0, 0, false
+	}
+	var start, end int
+	for i, expr := range list {
+		s, e, ok := v.literal(expr)
+		if !ok || s < end {
+			return 0, 0, false
+		}
+		if i == 0 {
+			start = s
+		}
+		end = e
+	}
+	return start, end, true
+}
+
+// foldRows folds the rows of the insert statement if they are all lists of literals.
+func (v *literalCollector) foldRows(rows [][]ast.ExprNode) {
+	var start, end int
+	for i, row := range rows {
+		s, e, ok := v.literalList(row)
+		if !ok || s < end {
+			return
+		}
+		if i == 0 {
+			start = s
+		}
+		end = e
+	}
+	if len(rows) > 0 {
+		v.spans = append(v.spans, literalSpan{start: start, end: end, replacement: "..."})
+	}
+}
+
+// appendTokens appends the tokens of the text between the literals, the comments are skipped.
+func appendTokens(tokens []string, s string) []string {
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case isSpace(c):
+			i++
+		case strings.HasPrefix(s[i:], "/*"):
+			end := strings.Index(s[i+2:], "*/")
+			if end < 0 {
+				return tokens
+			}
+			i += end + 4
+		case c == '#' || (strings.HasPrefix(s[i:], "--") && (i+2 == len(s) || isSpace(s[i+2]))):
+			end := strings.IndexByte(s[i:], '\n')
+			if end < 0 {
+				return tokens
+			}
+			i += end + 1
+		case c == '\'' || c == '"' || c == '`':
+			end := quotedEnd(s, i)
+			token := s[i:end]
+			if c == '`' && isWord(token[1:len(token)-1]) {
+				token = strings.ToLower(token[1 : len(token)-1])
+			}
+			tokens = append(tokens, token)
+			i = end
+		case isWordChar(c):
+			end := i + 1
+			for end < len(s) && isWordChar(s[end]) {
+				end++
+			}
+			tokens = append(tokens, strings.ToLower(s[i:end]))
+			i = end
+		default:
+			end := i + operatorLen(s[i:])
+			tokens = append(tokens, s[i:end])
+			i = end
+		}
+	}
+	return tokens
+}
+
+// quotedEnd returns the end of the quoted string starting at start.
+func quotedEnd(s string, start int) int {
+	quote := s[start]
+	for i := start + 1; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && quote != '`':
+			i++
+		case s[i] == quote:
+			if i+1 < len(s) && s[i+1] == quote {
+				i++
+				continue
+			}
+			return i + 1
+		}
+	}
+	return len(s)
+}
+
+var multiCharOperators = []string{"<=>", "<=", ">=", "<>", "!=", "||", "&&", ":=", "<<", ">>"}
+
+func operatorLen(s string) int {
+	for _, op := range multiCharOperators {
+		if strings.HasPrefix(s, op) {
+			return len(op)
+		}
+	}
+	return 1
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f' || c == '\v'
+}
+
+func isWordChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' ||
+		c == '_' || c == '$' || c == '@' || c >= 0x80
+}
+
+func isWord(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if !isWordChar(s[i]) || s[i] == '@' {
+			return false
+		}
+	}
+	return true
+}
@@ -60,9 +60,11 @@ import (
 	abs		"ABS"
 	add		"ADD"
 	addDate		"ADDDATE"
+	admin		"ADMIN"
 	after		"AFTER"
 	all 		"ALL"
 	alter		"ALTER"
+	analyze		"ANALYZE"
 	and		"AND"
 	andand		"&&"
 	andnot		"&^"
@@ -78,8 +80,10 @@ import (
 	both		"BOTH"
 	by		"BY"
 	byteType	"BYTE"
+	cancel		"CANCEL"
 	caseKwd		"CASE"
 	cast		"CAST"
+	change		"CHANGE"
 	character	"CHARACTER"
 	charsetKwd	"CHARSET"
 	check 		"CHECK"
@@ -113,6 +117,7 @@ import (
 	dayofmonth	"DAYOFMONTH"
 	dayofweek	"DAYOFWEEK"
 	dayofyear	"DAYOFYEAR"
+	ddl		"DDL"
 	deallocate	"DEALLOCATE"
 	defaultKwd	"DEFAULT"
 	delayed		"DELAYED"
@@ -142,6 +147,7 @@ import (
 	first		"FIRST"
 	foreign		"FOREIGN"
 	forKwd		"FOR"
+	format		"FORMAT"
 	foundRows	"FOUND_ROWS"
 	from		"FROM"
 	full		"FULL"
@@ -167,9 +173,11 @@ import (
 	into		"INTO"
 	is		"IS"
 	isolation	"ISOLATION"
+	jobs		"JOBS"
 	join		"JOIN"
 	key		"KEY"
 	keyBlockSize	"KEY_BLOCK_SIZE"
+	kill		"KILL"
 	le		"<="
 	leading		"LEADING"
 	left		"LEFT"
@@ -191,11 +199,13 @@ import (
 	minRows		"MIN_ROWS"
 	mod 		"MOD"
 	mode		"MODE"
+	modify		"MODIFY"
 	month		"MONTH"
 	names		"NAMES"
 	national	"NATIONAL"
 	neq		"!="
 	neqSynonym	"<>"
+	none		"NONE"
 	not		"NOT"
 	null		"NULL"
 	nulleq		"<=>"
@@ -212,19 +222,25 @@ import (
 	placeholder	"PLACEHOLDER"
 	prepare		"PREPARE"
 	primary		"PRIMARY"
+	processlist	"PROCESSLIST"
 	quarter		"QUARTER"
 	quick		"QUICK"
+	query		"QUERY"
 	rand		"RAND"
 	read		"READ"
 	references	"REFERENCES"
 	regexp		"REGEXP"
+	rename		"RENAME"
+	repair		"REPAIR"
 	repeat		"REPEAT"
 	repeatable	"REPEATABLE"
 	replace		"REPLACE"
+	require		"REQUIRE"
 	right		"RIGHT"
 	rlike		"RLIKE"
 	rollback	"ROLLBACK"
 	row 		"ROW"
+	rowFormat	"ROW_FORMAT"
 	rsh		">>"
 	schema		"SCHEMA"
 	schemas		"SCHEMAS"
@@ -237,6 +253,7 @@ import (
 	show		"SHOW"
 	signed		"SIGNED"
 	some 		"SOME"
+	ssl		"SSL"
 	start		"START"
 	status		"STATUS"
 	stringType	"string"
@@ -354,7 +371,10 @@ import (
 	yearMonth		"YEAR_MONTH"
 
 %type   <item>
+	AdminStmt		"Admin statement"
+	AdminCheckOrRepair	"Admin check or repair"
 	AlterTableStmt		"Alter table statement"
+	AnalyzeTableStmt	"Analyze table statement"
 	AlterTableSpec	"Alter table specification"
 	AlterTableSpecList	"Alter table specification list"
 	AnyOrAll		"Any or All for subquery"
@@ -449,6 +469,8 @@ import (
 	IsolationLevel		"Isolation level"
 	JoinTable 		"join table"
 	JoinType		"join type"
+	KillStmt		"Kill statement"
+	KillTypeOpt		"Kill type, CONNECTION or QUERY"
 	KeyOrIndex		"{KEY|INDEX}"
 	LikeEscapeOpt 		"like escape option"
 	LimitClause		"LIMIT clause"
@@ -490,6 +512,7 @@ import (
 	RegexpSym		"REGEXP or RLIKE"
 	ReplaceIntoStmt		"REPLACE INTO statement"
 	ReplacePriority		"replace statement priority"
+	RequireClauseOpt	"Require TLS clause of account"
 	RollbackStmt		"ROLLBACK statement"
 	SelectLockOpt		"FOR UPDATE or LOCK IN SHARE MODE,"
 	SelectStmt		"SELECT statement"
@@ -533,6 +556,10 @@ import (
 	TransactionChars	"Transaction characteristic list"
 	TrimDirection		"Trim string direction"
 	TruncateTableStmt	"TRANSACTION TABLE statement"
+	RenameTableStmt		"RENAME TABLE statement"
+	TableToTable		"rename table to table"
+	TableToTableList	"rename table to table by list"
+	RenameToOpt		"optional TO or AS of ALTER TABLE RENAME"
 	UnionOpt		"Union Option(empty/ALL/DISTINCT)"
 	UnionStmt		"Union select state ment"
 	UnionClauseList		"Union select clause list"
@@ -587,6 +614,7 @@ import (
 	OptCollate		"Optional Collate setting"
 	NUM			"numbers"
 	LengthNum		"Field length num(uint64)"
+	NumList			"Some numbers"
 
 %token	tableRefPriority
 
@@ -634,11 +662,83 @@ import (
 
 Start:
 	StatementList
+	{
+		l := yylex.(*lexer)
+		l.endStmt(len(l.src) + 1)
+	}
 |	parseExpression Expression
 	{
 		yylex.(*lexer).expr = $2.(ast.ExprNode)
 	}
 
+/*************************************AdminStmt**************************************
+ * ADMIN SHOW DDL, ADMIN SHOW DDL JOBS and ADMIN CANCEL DDL JOBS are used to inspect and
+ * cancel the DDL jobs. ADMIN CHECK TABLE and ADMIN CHECK INDEX check whether the indices
+ * agree with the records, ADMIN REPAIR TABLE and ADMIN REPAIR INDEX fix them.
+ *******************************************************************************************/
+AdminStmt:
+	"ADMIN" "SHOW" "DDL"
+	{
+		$$ = &ast.AdminStmt{Tp: ast.AdminShowDDL}
+	}
+|	"ADMIN" "SHOW" "DDL" "JOBS"
+	{
+		$$ = &ast.AdminStmt{Tp: ast.AdminShowDDLJobs}
+	}
+|	"ADMIN" "CANCEL" "DDL" "JOBS" NumList
+	{
+		$$ = &ast.AdminStmt{
+			Tp:	ast.AdminCancelDDLJobs,
+			JobIDs:	$5.([]int64),
+		}
+	}
+|	"ADMIN" AdminCheckOrRepair "TABLE" TableNameList
+	{
+		$$ = &ast.AdminStmt{
+			Tp:	ast.AdminCheckTable,
+			Tables:	$4.([]*ast.TableName),
+			Repair:	$2.(bool),
+		}
+	}
+|	"ADMIN" AdminCheckOrRepair "INDEX" TableName Identifier
+	{
+		$$ = &ast.AdminStmt{
+			Tp:	ast.AdminCheckIndex,
+			Tables:	[]*ast.TableName{$4.(*ast.TableName)},
+			Index:	$5.(string),
+			Repair:	$2.(bool),
+		}
+	}
+
+AdminCheckOrRepair:
+	"CHECK"
+	{
+		$$ = false
+	}
+|	"REPAIR"
+	{
+		$$ = true
+	}
+
+NumList:
+	LengthNum
+	{
+		$$ = []int64{int64($1.(uint64))}
+	}
+|	NumList ',' LengthNum
+	{
+		$$ = append($1.([]int64), int64($3.(uint64)))
+	}
+
+/*************************************AnalyzeTableStmt**************************************
+ * See: https://dev.mysql.com/doc/refman/5.7/en/analyze-table.html
+ *******************************************************************************************/
+AnalyzeTableStmt:
+	"ANALYZE" "TABLE" TableNameList
+	{
+		$$ = &ast.AnalyzeTableStmt{TableNames: $3.([]*ast.TableName)}
+	}
+
 /**************************************AlterTableStmt***************************************
  * See: https://dev.mysql.com/doc/refman/5.7/en/alter-table.html
  *******************************************************************************************/
@@ -700,6 +800,35 @@ AlterTableSpec:
 			Name: $4.(string),
 		}
 	}
+|	"MODIFY" ColumnKeywordOpt ColumnDef ColumnPosition
+	{
+		$$ = &ast.AlterTableSpec{
+			Tp:		ast.AlterTableModifyColumn,
+			Column:		$3.(*ast.ColumnDef),
+			Position:	$4.(*ast.ColumnPosition),
+		}
+	}
+|	"CHANGE" ColumnKeywordOpt ColumnName ColumnDef ColumnPosition
+	{
+		$$ = &ast.AlterTableSpec{
+			Tp:		ast.AlterTableChangeColumn,
+			OldColumnName:	$3.(*ast.ColumnName),
+			Column:		$4.(*ast.ColumnDef),
+			Position:	$5.(*ast.ColumnPosition),
+		}
+	}
+|	"RENAME" RenameToOpt TableName
+	{
+		$$ = &ast.AlterTableSpec{
+			Tp: ast.AlterTableRenameTable,
+			NewTable: $3.(*ast.TableName),
+		}
+	}
+
+RenameToOpt:
+	{}
+|	"TO"
+|	"AS"
 
 KeyOrIndex:
 	"KEY"|"INDEX"
@@ -991,15 +1120,15 @@ NowSym:
 SignedLiteral:
 	Literal
 	{
-		$$ = ast.NewValueExpr($1)
+		$$ = yylex.(*lexer).newLiteral($1, yyS[yypt].offset)
 	}
 |	'+' NumLiteral
 	{
-		$$ = &ast.UnaryOperationExpr{Op: opcode.Plus, V: ast.NewValueExpr($2)}
+		$$ = &ast.UnaryOperationExpr{Op: opcode.Plus, V: yylex.(*lexer).newLiteral($2, yyS[yypt].offset)}
 	}
 |	'-' NumLiteral
 	{
-		$$ = &ast.UnaryOperationExpr{Op: opcode.Minus, V: ast.NewValueExpr($2)}
+		$$ = &ast.UnaryOperationExpr{Op: opcode.Minus, V: yylex.(*lexer).newLiteral($2, yyS[yypt].offset)}
 	}
 
 // TODO: support decimal literal
@@ -1325,7 +1454,17 @@ ExplainStmt:
 	}
 |	ExplainSym ExplainableStmt
 	{
-		$$ = &ast.ExplainStmt{Stmt: $2.(ast.StmtNode)}
+		$$ = &ast.ExplainStmt{
+			Stmt:	$2.(ast.StmtNode),
+			Format:	ast.ExplainFormatTraditional,
+		}
+	}
+|	ExplainSym "FORMAT" eq Identifier ExplainableStmt
+	{
+		$$ = &ast.ExplainStmt{
+			Stmt:	$5.(ast.StmtNode),
+			Format:	strings.ToLower($4.(string)),
+		}
 	}
 
 LengthNum:
@@ -1679,8 +1818,9 @@ UnReservedKeyword:
 |	"START" | "STATUS" | "GLOBAL" | "TABLES"| "TEXT" | "TIME" | "TIMESTAMP" | "TRANSACTION" | "TRUNCATE" | "UNKNOWN"
 |	"VALUE" | "WARNINGS" | "YEAR" |	"MODE" | "WEEK" | "ANY" | "SOME" | "USER" | "IDENTIFIED" | "COLLATION"
 |	"COMMENT" | "AVG_ROW_LENGTH" | "CONNECTION" | "CHECKSUM" | "COMPRESSION" | "KEY_BLOCK_SIZE" | "MAX_ROWS" | "MIN_ROWS"
-|	"NATIONAL" | "ROW" | "QUARTER" | "ESCAPE" | "GRANTS" | "FIELDS" | "TRIGGERS" | "DELAY_KEY_WRITE" | "ISOLATION"
-|	"REPEATABLE" | "COMMITTED" | "UNCOMMITTED" | "ONLY" | "SERIALIZABLE" | "LEVEL"
+|	"NATIONAL" | "ROW" | "QUARTER" | "ESCAPE" | "GRANTS" | "FIELDS" | "TRIGGERS" | "DELAY_KEY_WRITE" | "ISOLATION" | "ROW_FORMAT"
+|	"REPEATABLE" | "COMMITTED" | "UNCOMMITTED" | "ONLY" | "SERIALIZABLE" | "LEVEL" | "FORMAT" | "NONE" | "KILL" | "PROCESSLIST" | "QUERY"
+|	"MODIFY" | "ADMIN" | "CANCEL" | "DDL" | "JOBS" | "REPAIR"
 
 NotKeywordToken:
 	"ABS" | "ADDDATE" | "COALESCE" | "CONCAT" | "CONCAT_WS" | "COUNT" | "DAY" | "DATE_ADD" | "DATE_SUB" | "DAYOFMONTH"
@@ -1888,7 +2028,7 @@ Literal:
 Operand:
 	Literal
 	{
-		$$ = ast.NewValueExpr($1)
+		$$ = yylex.(*lexer).newLiteral($1, yyS[yypt].offset)
 	}
 |	ColumnName
 	{
@@ -2984,7 +3124,7 @@ LimitClause:
 	}
 |	"LIMIT" LengthNum
 	{
-		$$ = &ast.Limit{Count: $2.(uint64)}
+		$$ = &ast.Limit{Count: $2.(uint64), Literals: []*ast.ValueExpr{yylex.(*lexer).newLiteral($2, yyS[yypt].offset)}}
 	}
 
 SelectStmtLimit:
@@ -2993,15 +3133,25 @@ SelectStmtLimit:
 	}
 |	"LIMIT" LengthNum 
 	{
-		$$ = &ast.Limit{Count: $2.(uint64)}
+		$$ = &ast.Limit{Count: $2.(uint64), Literals: []*ast.ValueExpr{yylex.(*lexer).newLiteral($2, yyS[yypt].offset)}}
 	}
 |	"LIMIT" LengthNum ',' LengthNum 
 	{
-		$$ = &ast.Limit{Offset: $2.(uint64), Count: $4.(uint64)}
+		l := yylex.(*lexer)
+		$$ = &ast.Limit{
+			Offset:		$2.(uint64),
+			Count:		$4.(uint64),
+			Literals:	[]*ast.ValueExpr{l.newLiteral($2, yyS[yypt-2].offset), l.newLiteral($4, yyS[yypt].offset)},
+		}
 	}
 |	"LIMIT" LengthNum "OFFSET" LengthNum
 	{
-		$$ = &ast.Limit{Offset: $4.(uint64), Count: $2.(uint64)}
+		l := yylex.(*lexer)
+		$$ = &ast.Limit{
+			Offset:		$4.(uint64),
+			Count:		$2.(uint64),
+			Literals:	[]*ast.ValueExpr{l.newLiteral($2, yyS[yypt-2].offset), l.newLiteral($4, yyS[yypt].offset)},
+		}
 	}
 
 SelectStmtDistinct:
@@ -3355,6 +3505,14 @@ ShowStmt:
 			User:	$4.(string),
 		}
 	}
+|	"SHOW" OptFull "PROCESSLIST"
+	{
+		// See: https://dev.mysql.com/doc/refman/5.7/en/show-processlist.html
+		$$ = &ast.ShowStmt{
+			Tp:	ast.ShowProcessList,
+			Full:	$2.(bool),
+		}
+	}
 
 ShowTargetFilterable:
 	"ENGINES"
@@ -3499,7 +3657,9 @@ ShowTableAliasOpt:
 
 Statement:
 	EmptyStmt
+|	AdminStmt
 |	AlterTableStmt
+|	AnalyzeTableStmt
 |	BeginTransactionStmt
 |	CommitStmt
 |	DeallocateStmt
@@ -3516,11 +3676,13 @@ Statement:
 |	DropTableStmt
 |	GrantStmt
 |	InsertIntoStmt
+|	KillStmt
 |	PreparedStmt
 |	RollbackStmt
 |	ReplaceIntoStmt
 |	SelectStmt
 |	UnionStmt
+|	RenameTableStmt
 |	SetStmt
 |	ShowStmt
 |	TruncateTableStmt
@@ -3546,17 +3708,15 @@ StatementList:
 	Statement
 	{
 		if $1 != nil {
-			s := $1.(ast.StmtNode)
-			s.SetText(yylex.(*lexer).stmtText())
-			yylex.(*lexer).list = append(yylex.(*lexer).list, s)
+			yylex.(*lexer).appendStmt($1.(ast.StmtNode), yyS[yypt].offset)
 		}
 	}
 |	StatementList ';' Statement
 	{
+		l := yylex.(*lexer)
+		l.endStmt(yyS[yypt-1].offset)
 		if $3 != nil {
-			s := $3.(ast.StmtNode)
-			s.SetText(yylex.(*lexer).stmtText())
-			yylex.(*lexer).list = append(yylex.(*lexer).list, s)
+			l.appendStmt($3.(ast.StmtNode), yyS[yypt].offset)
 		}
 	}
 
@@ -3664,6 +3824,14 @@ TableOption:
 	{
 		$$ = &ast.TableOption{Tp: ast.TableOptionDelayKeyWrite, UintValue: $3.(uint64)} 
 	}
+|	"ROW_FORMAT" EqOpt "DEFAULT"
+	{
+		$$ = &ast.TableOption{Tp: ast.TableOptionRowFormat, StrValue: "DEFAULT"} 
+	}
+|	"ROW_FORMAT" EqOpt Identifier
+	{
+		$$ = &ast.TableOption{Tp: ast.TableOptionRowFormat, StrValue: $3.(string)} 
+	}
 
 
 TableOptionListOpt:
@@ -3693,6 +3861,31 @@ TruncateTableStmt:
 		$$ = &ast.TruncateTableStmt{Table: $3.(*ast.TableName)}
 	}
 
+RenameTableStmt:
+	"RENAME" "TABLE" TableToTableList
+	{
+		$$ = &ast.RenameTableStmt{TableToTables: $3.([]*ast.TableToTable)}
+	}
+
+TableToTableList:
+	TableToTable
+	{
+		$$ = []*ast.TableToTable{$1.(*ast.TableToTable)}
+	}
+|	TableToTableList ',' TableToTable
+	{
+		$$ = append($1.([]*ast.TableToTable), $3.(*ast.TableToTable))
+	}
+
+TableToTable:
+	TableName "TO" TableName
+	{
+		$$ = &ast.TableToTable{
+			OldTable: $1.(*ast.TableName),
+			NewTable: $3.(*ast.TableName),
+		}
+	}
+
 /*************************************Type Begin***************************************/
 Type:
 	NumericType
@@ -3887,6 +4080,8 @@ StringType:
 		if $4.(bool) {
 			x.Flag |= mysql.BinaryFlag
 		}
+		x.Charset = $5.(string)
+		x.Collate = $6.(string)
 		$$ = x
 	}
 |	NationalOpt "CHAR" OptBinary OptCharset OptCollate
@@ -3895,6 +4090,8 @@ StringType:
 		if $3.(bool) {
 			x.Flag |= mysql.BinaryFlag
 		}
+		x.Charset = $4.(string)
+		x.Collate = $5.(string)
 		$$ = x
 	}
 |	NationalOpt "VARCHAR" FieldLen OptBinary OptCharset OptCollate
@@ -4240,12 +4437,13 @@ CommaOpt:
  *  https://dev.mysql.com/doc/refman/5.7/en/account-management-sql.html
  ************************************************************************************/
 CreateUserStmt:
-	"CREATE" "USER" IfNotExists UserSpecList
+	"CREATE" "USER" IfNotExists UserSpecList RequireClauseOpt
 	{
  		// See: https://dev.mysql.com/doc/refman/5.7/en/create-user.html
 		$$ = &ast.CreateUserStmt{
 			IfNotExists: $3.(bool),
 			Specs: $4.([]*ast.UserSpec),
+			Require: $5.(ast.RequireType),
 		}
 	}
 
@@ -4297,16 +4495,61 @@ HashString:
  * See: https://dev.mysql.com/doc/refman/5.7/en/grant.html
  *************************************************************************************/
 GrantStmt:
-	 "GRANT" PrivElemList "ON" ObjectType PrivLevel "TO" UserSpecList
+	 "GRANT" PrivElemList "ON" ObjectType PrivLevel "TO" UserSpecList RequireClauseOpt
 	 {
 		$$ = &ast.GrantStmt{
 			Privs: $2.([]*ast.PrivElem),
 			ObjectType: $4.(ast.ObjectTypeType),
 			Level: $5.(*ast.GrantLevel),
 			Users: $7.([]*ast.UserSpec),
+			Require: $8.(ast.RequireType),
 		}
 	 }
 
+/*
+ * See: https://dev.mysql.com/doc/refman/5.7/en/create-user.html#create-user-tls
+ */
+/*******************************************************************
+ *
+ *  Kill Statement
+ *  See: https://dev.mysql.com/doc/refman/5.7/en/kill.html
+ *
+ *******************************************************************/
+KillStmt:
+	"KILL" KillTypeOpt LengthNum
+	{
+		$$ = &ast.KillStmt{
+			Query:		$2.(bool),
+			ConnectionID:	$3.(uint64),
+		}
+	}
+
+KillTypeOpt:
+	{
+		$$ = false
+	}
+|	"CONNECTION"
+	{
+		$$ = false
+	}
+|	"QUERY"
+	{
+		$$ = true
+	}
+
+RequireClauseOpt:
+	{
+		$$ = ast.RequireUnspecified
+	}
+|	"REQUIRE" "NONE"
+	{
+		$$ = ast.RequireNone
+	}
+|	"REQUIRE" "SSL"
+	{
+		$$ = ast.RequireSSL
+	}
+
 PrivElem:
 	PrivType
 	{
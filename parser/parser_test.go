@@ -41,6 +41,8 @@ func (s *testParserSuite) TestSimple(c *C) {
 		"collation", "comment", "avg_row_length", "checksum", "compression", "connection", "key_block_size",
 		"max_rows", "min_rows", "national", "row", "quarter", "escape", "grants", "status", "fields", "triggers",
 		"delay_key_write", "isolation", "repeatable", "committed", "uncommitted", "only", "serializable", "level",
+		"row_format", "format", "kill", "processlist", "query", "modify",
+		"admin", "cancel", "ddl", "jobs", "repair",
 	}
 	for _, kw := range unreservedKws {
 		src := fmt.Sprintf("SELECT %s FROM tbl;", kw)
@@ -262,6 +264,30 @@ func (s *testParserSuite) TestDMLStmt(c *C) {
 		{`SHOW FIELDS FROM City;`, true},
 		{`SHOW TRIGGERS LIKE 't'`, true},
 		{"SHOW DATABASES LIKE 'test2'", true},
+		{"SHOW PROCESSLIST", true},
+		{"SHOW FULL PROCESSLIST", true},
+
+		// For kill statement
+		{"KILL 1", true},
+		{"KILL CONNECTION 1", true},
+		{"KILL QUERY 1", true},
+		{"KILL", false},
+		{"KILL QUERY", false},
+
+		// For admin statement
+		{"ADMIN SHOW DDL", true},
+		{"ADMIN SHOW DDL JOBS", true},
+		{"ADMIN CANCEL DDL JOBS 1", true},
+		{"ADMIN CANCEL DDL JOBS 1, 2", true},
+		{"ADMIN CANCEL DDL JOBS", false},
+		{"ADMIN SHOW", false},
+		{"ADMIN CHECK TABLE t", true},
+		{"ADMIN CHECK TABLE t1, db.t2", true},
+		{"ADMIN CHECK INDEX t idx", true},
+		{"ADMIN REPAIR TABLE t", true},
+		{"ADMIN REPAIR INDEX db.t idx", true},
+		{"ADMIN CHECK INDEX t", false},
+		{"ADMIN CHECK t", false},
 
 		// For default value
 		{"CREATE TABLE sbtest (id INTEGER UNSIGNED NOT NULL AUTO_INCREMENT, k integer UNSIGNED DEFAULT '0' NOT NULL, c char(120) DEFAULT '' NOT NULL, pad char(60) DEFAULT '' NOT NULL, PRIMARY KEY  (id) )", true},
@@ -588,6 +614,11 @@ func (s *testParserSuite) TestDDL(c *C) {
 		{"create table t (c int) password 'abc'", true},
 		{"create table t (c int) DELAY_KEY_WRITE=1", true},
 		{"create table t (c int) DELAY_KEY_WRITE 1", true},
+		{"create table t (c int) ROW_FORMAT = default", true},
+		{"create table t (c int) ROW_FORMAT = compact", true},
+		{"create table t (c int) ROW_FORMAT redundant", true},
+		{"alter table t ROW_FORMAT = compact", true},
+		{"alter table t ROW_FORMAT = 1", false},
 		// For check clause
 		{"create table t (c1 bool, c2 bool, check (c1 in (0, 1)), check (c2 in (0, 1)))", true},
 		{"CREATE TABLE Customer (SD integer CHECK (SD > 0), First_Name varchar(30));", true},
@@ -611,6 +642,25 @@ func (s *testParserSuite) TestDDL(c *C) {
 		{"drop tables xxx, yyy", true},
 		{"drop table if exists xxx", true},
 		{"drop table if not exists xxx", false},
+		// For rename table
+		{"rename table t1 to t2", true},
+		{"rename table t1 to t2, t2 to t3, t3 to t1", true},
+		{"rename table db1.t1 to db2.t1", true},
+		{"rename table t1", false},
+		{"rename table t1 to t2,", false},
+		{"alter table t rename t1", true},
+		{"alter table t rename to db1.t1", true},
+		{"alter table t rename as t1", true},
+		{"alter table t rename to", false},
+		// For modify/change column
+		{"alter table t modify a bigint", true},
+		{"alter table t modify column a varchar(20) not null default 'x' comment 'c'", true},
+		{"alter table t modify a int first", true},
+		{"alter table t modify column a int after b", true},
+		{"alter table t modify a", false},
+		{"alter table t change a b bigint", true},
+		{"alter table t change column a b varchar(20) after c", true},
+		{"alter table t change a b", false},
 	}
 	s.RunTest(c, table)
 }
@@ -658,6 +708,9 @@ func (s *testParserSuite) TestPrivilege(c *C) {
 		{`CREATE USER 'root'@'localhost' IDENTIFIED BY 'new-password'`, true},
 		{`CREATE USER 'root'@'localhost' IDENTIFIED BY PASSWORD 'hashstring'`, true},
 		{`CREATE USER 'root'@'localhost' IDENTIFIED BY 'new-password', 'root'@'127.0.0.1' IDENTIFIED BY PASSWORD 'hashstring'`, true},
+		{`CREATE USER 'root'@'localhost' IDENTIFIED BY 'new-password' REQUIRE SSL`, true},
+		{`CREATE USER 'root'@'localhost' REQUIRE NONE`, true},
+		{`CREATE USER 'root'@'localhost' REQUIRE`, false},
 
 		// For grant statement
 		{"GRANT ALL ON db1.* TO 'jeffrey'@'localhost';", true},
@@ -669,10 +722,44 @@ func (s *testParserSuite) TestPrivilege(c *C) {
 		{"GRANT ALL ON mydb.mytbl TO 'someuser'@'somehost';", true},
 		{"GRANT SELECT, INSERT ON mydb.mytbl TO 'someuser'@'somehost';", true},
 		{"GRANT SELECT (col1), INSERT (col1,col2) ON mydb.mytbl TO 'someuser'@'somehost';", true},
+		{"GRANT ALL ON *.* TO 'someuser'@'somehost' REQUIRE SSL;", true},
+		{"GRANT ALL ON *.* TO 'someuser'@'somehost' REQUIRE NONE;", true},
 	}
 	s.RunTest(c, table)
 }
 
+func (s *testParserSuite) TestAnalyze(c *C) {
+	table := []testCase{
+		{"analyze table t", true},
+		{"analyze table t, db.t1", true},
+		{"analyze table", false},
+		{"analyze t", false},
+	}
+	s.RunTest(c, table)
+}
+
+func (s *testParserSuite) TestExplain(c *C) {
+	table := []testCase{
+		{"explain t", true},
+		{"explain t c", true},
+		{"explain select * from t", true},
+		{"describe select * from t", true},
+		{"explain format = json select * from t", true},
+		{"explain format = traditional delete from t", true},
+		{"explain format = 'json' select * from t", false},
+		{"explain format select * from t", false},
+		{"explain format", true},
+	}
+	s.RunTest(c, table)
+
+	l := NewLexer("explain format = JSON select 1")
+	c.Assert(yyParse(l), Equals, 0)
+	c.Assert(l.Stmts()[0].(*ast.ExplainStmt).Format, Equals, ast.ExplainFormatJSON)
+	l = NewLexer("explain select 1")
+	c.Assert(yyParse(l), Equals, 0)
+	c.Assert(l.Stmts()[0].(*ast.ExplainStmt).Format, Equals, ast.ExplainFormatTraditional)
+}
+
 func (s *testParserSuite) TestComment(c *C) {
 	table := []testCase{
 		{"create table t (c int comment 'comment')", true},
@@ -748,3 +835,66 @@ func (s *testParserSuite) TestMysqlDump(c *C) {
 	}
 	s.RunTest(c, table)
 }
+
+func (s *testParserSuite) TestStmtText(c *C) {
+	table := []struct {
+		src   string
+		texts []string
+	}{
+		{"select 1", []string{"select 1"}},
+		{"select 1;\n", []string{"select 1"}},
+		{"  select 1 ; ; select 'a;b'", []string{"select 1", "select 'a;b'"}},
+		{"select 1 /* a;b */;select 2 -- c\n", []string{"select 1", "select 2 -- c"}},
+		{"(select 1) union (select 2);\nbegin", []string{"(select 1) union (select 2)", "begin"}},
+	}
+	for _, t := range table {
+		l := NewLexer(t.src)
+		c.Assert(yyParse(l), Equals, 0)
+		var texts []string
+		for _, st := range l.Stmts() {
+			texts = append(texts, st.Text())
+		}
+		c.Assert(texts, DeepEquals, t.texts, Commentf("src: %q", t.src))
+	}
+}
+
+func (s *testParserSuite) TestNormalize(c *C) {
+	table := []struct {
+		src        string
+		normalized string
+	}{
+		{"SELECT * FROM t WHERE a = 1 AND b = 'x';", "select * from t where a = ? and b = ?"},
+		{"select  *  from T where a=2.5 /* c */ and b = \"y\" -- d\n", "select * from t where a = ? and b = ?"},
+		{"select a from `t` where a in (1, 2, 3) and b in (x'0f') and c in (1, d)", "select a from t where a in ( ... ) and b in ( ... ) and c in ( ? , d )"},
+		{"insert into t values (1, 'a'), (2, 'b'), (3, 'c')", "insert into t values ( ... )"},
+		{"insert into t (a, b) values (1, now())", "insert into t ( a , b ) values ( ? , now ( ) )"},
+		{"select a, -1, _utf8'x', null from t where b >= +2.5 limit 10", "select a , - ? , ? , ? from t where b >= + ? limit ?"},
+		{"select a from t where b = 1 limit 10, 20", "select a from t where b = ? limit ? , ?"},
+		{"select a from t limit 10 offset 20", "select a from t limit ? offset ?"},
+		{"(select a from t) union (select b from t2) limit 1", "( select a from t ) union ( select b from t2 ) limit ?"},
+		{"delete from t where a = 'x' limit 5", "delete from t where a = ? limit ?"},
+		{"update t set a = 1 limit 5", "update t set a = ? limit ?"},
+		{"select 1; select `a b`, 'x' from t", "select ?"},
+	}
+	for _, t := range table {
+		l := NewLexer(t.src)
+		c.Assert(yyParse(l), Equals, 0, Commentf("src: %q", t.src))
+		c.Assert(Normalize(l.Stmts()[0]), Equals, t.normalized, Commentf("src: %q", t.src))
+	}
+	l := NewLexer("select 1; select `a b`, 'x' from t where a = 1")
+	c.Assert(yyParse(l), Equals, 0)
+	c.Assert(Normalize(l.Stmts()[1]), Equals, "select `a b` , ? from t where a = ?")
+
+	digest := func(src string) string {
+		l := NewLexer(src)
+		c.Assert(yyParse(l), Equals, 0)
+		return Digest(Normalize(l.Stmts()[0]))
+	}
+	d1 := digest("select * from t where a = 1")
+	d2 := digest("SELECT * FROM t WHERE a=100")
+	d3 := digest("select * from t where b = 1")
+	c.Assert(d1, Equals, d2)
+	c.Assert(digest("select * from t limit 10"), Equals, digest("select * from t limit 20"))
+	c.Assert(d1, Not(Equals), d3)
+	c.Assert(d1, HasLen, 64)
+}
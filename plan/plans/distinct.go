@@ -23,6 +23,7 @@ import (
 	"github.com/pingcap/tidb/expression"
 	"github.com/pingcap/tidb/kv/memkv"
 	"github.com/pingcap/tidb/plan"
+	"github.com/pingcap/tidb/util/collate"
 	"github.com/pingcap/tidb/util/format"
 )
 
@@ -82,8 +83,14 @@ func (r *DistinctDefaultPlan) fetchAll(ctx context.Context) error {
 			return errors.Trace(err)
 		}
 		var v []interface{}
-		// get distinct key
-		key := row.Data[0:r.HiddenFieldOffset]
+		// get distinct key, the values equal under the collations are the same.
+		key := make([]interface{}, r.HiddenFieldOffset)
+		for i := range key {
+			key[i] = row.Data[i]
+			if i < len(r.ResultFields) {
+				key[i] = collate.SortKey(r.ResultFields[i].Collate, key[i])
+			}
+		}
 		v, err = t.Get(key)
 		if err != nil {
 			return errors.Trace(err)
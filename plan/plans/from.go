@@ -28,6 +28,7 @@ import (
 	"github.com/pingcap/tidb/kv"
 	"github.com/pingcap/tidb/parser/opcode"
 	"github.com/pingcap/tidb/plan"
+	"github.com/pingcap/tidb/sessionctx/variable"
 	"github.com/pingcap/tidb/table"
 	"github.com/pingcap/tidb/table/tables"
 	"github.com/pingcap/tidb/util"
@@ -138,7 +139,7 @@ func (r *TableDefaultPlan) filterBinOp(ctx context.Context, x *expression.Binary
 	}
 
 	ix := t.FindIndexByColName(cn)
-	if ix == nil { // Column cn has no index.
+	if ix == nil || !canCompareByIndex(c, ix.Collated) { // Column cn has no usable index.
 		return r, false, nil
 	}
 
@@ -147,12 +148,13 @@ func (r *TableDefaultPlan) filterBinOp(ctx context.Context, x *expression.Binary
 		return nil, false, err
 	}
 	return &indexPlan{
-		src:     t,
-		col:     c,
-		unique:  ix.Unique,
-		idxName: ix.Name.O,
-		idx:     ix.X,
-		spans:   toSpans(x.Op, rval, seekVal),
+		src:      t,
+		col:      c,
+		unique:   ix.Unique,
+		idxName:  ix.Name.O,
+		idx:      ix.X,
+		collated: ix.Collated,
+		spans:    toSpans(x.Op, spanValue(c, rval, seekVal), seekVal),
 	}, true, nil
 }
 
@@ -179,12 +181,13 @@ func (r *TableDefaultPlan) filterIdent(ctx context.Context, x *expression.Ident,
 			spans = toSpans(opcode.EQ, 0, 0)
 		}
 		return &indexPlan{
-			src:     t,
-			col:     v,
-			unique:  ix.Unique,
-			idxName: ix.Name.L,
-			idx:     ix.X,
-			spans:   spans,
+			src:      t,
+			col:      v,
+			unique:   ix.Unique,
+			idxName:  ix.Name.L,
+			idx:      ix.X,
+			collated: ix.Collated,
+			spans:    spans,
 		}, true, nil
 	}
 	return r, false, nil
@@ -216,12 +219,13 @@ func (r *TableDefaultPlan) filterIsNull(ctx context.Context, x *expression.IsNul
 		spans = toSpans(opcode.EQ, nil, nil)
 	}
 	return &indexPlan{
-		src:     t,
-		col:     col,
-		unique:  ix.Unique,
-		idxName: ix.Name.L,
-		idx:     ix.X,
-		spans:   spans,
+		src:      t,
+		col:      col,
+		unique:   ix.Unique,
+		idxName:  ix.Name.L,
+		idx:      ix.X,
+		collated: ix.Collated,
+		spans:    spans,
 	}, true, nil
 }
 
@@ -272,6 +276,9 @@ func (r *TableDefaultPlan) GetFields() []*field.ResultField {
 
 // Next implements plan.Plan Next interface.
 func (r *TableDefaultPlan) Next(ctx context.Context) (row *plan.Row, err error) {
+	if err = variable.CheckKilled(ctx); err != nil {
+		return nil, errors.Trace(err)
+	}
 	if r.iter == nil {
 		var txn kv.Transaction
 		txn, err = ctx.GetTxn(false)
@@ -295,6 +302,10 @@ func (r *TableDefaultPlan) Next(ctx context.Context) (row *plan.Row, err error)
 	// r2_col1 -> r2 col1 value
 	// r2_col2 -> r2 col2 value
 	// ...
+	// or in single kv row format:
+	// r1 -> r1 row data
+	// r2 -> r2 row data
+	// ...
 	rowKey := r.iter.Key()
 	handle, err := tables.DecodeRecordKeyHandle(rowKey)
 	if err != nil {
@@ -313,10 +324,11 @@ func (r *TableDefaultPlan) Next(ctx context.Context) (row *plan.Row, err error)
 
 	// TODO: we could just fetch mentioned columns' values
 	row = &plan.Row{}
-	row.Data, err = r.T.Row(ctx, handle)
+	row.Data, err = r.T.DecodeRow(txn, handle, r.iter.Value(), r.T.Cols())
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
+	variable.AddRowsExamined(ctx, 1)
 	// Put rowKey to the tail of record row
 	rke := &plan.RowKeyEntry{
 		Tbl: r.T,
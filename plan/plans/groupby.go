@@ -25,6 +25,7 @@ import (
 	"github.com/pingcap/tidb/field"
 	"github.com/pingcap/tidb/kv/memkv"
 	"github.com/pingcap/tidb/plan"
+	"github.com/pingcap/tidb/util/collate"
 	"github.com/pingcap/tidb/util/format"
 )
 
@@ -112,7 +113,8 @@ func (r *GroupByDefaultPlan) evalGroupKey(ctx context.Context, k []interface{},
 		if err != nil {
 			return err
 		}
-		k[i] = val
+		// The values equal under the collation are in the same group.
+		k[i] = collate.SortKey(r.collation(v), val)
 	}
 	return nil
 }
@@ -24,8 +24,10 @@ import (
 	"github.com/pingcap/tidb/kv"
 	"github.com/pingcap/tidb/parser/opcode"
 	"github.com/pingcap/tidb/plan"
+	"github.com/pingcap/tidb/sessionctx/variable"
 	"github.com/pingcap/tidb/table"
 	"github.com/pingcap/tidb/terror"
+	"github.com/pingcap/tidb/util/collate"
 	"github.com/pingcap/tidb/util/format"
 	"github.com/pingcap/tidb/util/types"
 )
@@ -123,11 +125,13 @@ func (span *indexSpan) cutOffHigh(val interface{}, exclude bool) *indexSpan {
 }
 
 type indexPlan struct {
-	src        table.Table
-	col        *column.Col
-	unique     bool
-	idxName    string
-	idx        kv.Index
+	src     table.Table
+	col     *column.Col
+	unique  bool
+	idxName string
+	idx     kv.Index
+	// collated is true if the strings are encoded under the collation of the column.
+	collated   bool
 	spans      []*indexSpan // multiple spans are ordered by their values and without overlapping.
 	cursor     int
 	skipLowCmp bool
@@ -210,14 +214,14 @@ func (r *indexPlan) Filter(ctx context.Context, expr expression.Expression) (pla
 		if tname != "" && r.src.TableName().L != tname {
 			break
 		}
-		if r.col.ColumnInfo.Name.L != cname {
+		if r.col.ColumnInfo.Name.L != cname || !canCompareByIndex(r.col, r.collated) {
 			break
 		}
 		seekVal, err := types.Convert(val, &r.col.FieldType)
 		if err != nil {
 			return nil, false, errors.Trace(err)
 		}
-		spans = filterSpans(r.spans, toSpans(x.Op, val, seekVal))
+		spans = filterSpans(r.spans, toSpans(x.Op, spanValue(r.col, val, seekVal), seekVal))
 	case *expression.Ident:
 		if r.col.Name.L != x.L {
 			break
@@ -243,15 +247,31 @@ func (r *indexPlan) Filter(ctx context.Context, expr expression.Expression) (pla
 	}
 
 	return &indexPlan{
-		src:     r.src,
-		col:     r.col,
-		unique:  r.unique,
-		idxName: r.idxName,
-		idx:     r.idx,
-		spans:   spans,
+		src:      r.src,
+		col:      r.col,
+		unique:   r.unique,
+		idxName:  r.idxName,
+		idx:      r.idx,
+		collated: r.collated,
+		spans:    spans,
 	}, true, nil
 }
 
+// canCompareByIndex returns false if the strings are encoded by bytes in the index while the
+// column is case insensitive, the index was created before the collations are supported.
+func canCompareByIndex(col *column.Col, collated bool) bool {
+	return collated || !collate.IsCaseInsensitive(col.Collate)
+}
+
+// spanValue returns the value to compare with the index values of the column.
+func spanValue(col *column.Col, val, seekVal interface{}) interface{} {
+	if collate.IsCaseInsensitive(col.Collate) {
+		// The index values are the weight keys under the case insensitive collation.
+		return collate.SortKey(col.Collate, seekVal)
+	}
+	return val
+}
+
 // return the intersection range between origin and filter.
 func filterSpans(origin []*indexSpan, filter []*indexSpan) []*indexSpan {
 	newSpans := make([]*indexSpan, 0, len(filter))
@@ -329,6 +349,9 @@ func toSpans(op opcode.Op, val, seekVal interface{}) []*indexSpan {
 
 // Next implements plan.Plan Next interface.
 func (r *indexPlan) Next(ctx context.Context) (*plan.Row, error) {
+	if err := variable.CheckKilled(ctx); err != nil {
+		return nil, errors.Trace(err)
+	}
 	for {
 		if r.cursor == len(r.spans) {
 			return nil, nil
@@ -406,7 +429,7 @@ func (r *indexPlan) isPointLookup(span *indexSpan) bool {
 	if !equalOp || !r.unique || span.lowVal == nil {
 		return false
 	}
-	n, err := types.Compare(span.seekVal, span.lowVal)
+	n, err := types.Compare(collate.SortKey(r.col.Collate, span.seekVal), span.lowVal)
 	if err != nil {
 		return false
 	}
@@ -420,6 +443,7 @@ func (r *indexPlan) lookupRow(ctx context.Context, h int64) (*plan.Row, error) {
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
+	variable.AddRowsExamined(ctx, 1)
 	rowKey := &plan.RowKeyEntry{
 		Tbl: r.src,
 		Key: string(r.src.RecordKey(h, nil)),
@@ -17,6 +17,7 @@ import (
 	"fmt"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/juju/errors"
 	"github.com/pingcap/tidb/column"
@@ -28,8 +29,11 @@ import (
 	"github.com/pingcap/tidb/mysql"
 	"github.com/pingcap/tidb/plan"
 	"github.com/pingcap/tidb/sessionctx"
+	"github.com/pingcap/tidb/sessionctx/processlist"
+	"github.com/pingcap/tidb/statistics"
 	"github.com/pingcap/tidb/util/charset"
 	"github.com/pingcap/tidb/util/format"
+	"github.com/pingcap/tidb/util/stmtsummary"
 	"github.com/pingcap/tidb/util/types"
 )
 
@@ -52,6 +56,8 @@ var (
 	collationsFields     []*field.ResultField
 	filesFields          []*field.ResultField
 	profilingFields      []*field.ResultField
+	processListFields    []*field.ResultField
+	stmtSummaryFields    []*field.ResultField
 	characterSetsRecords [][]interface{}
 	collationsRecords    [][]interface{}
 	filesRecords         [][]interface{}
@@ -67,6 +73,8 @@ const (
 	tableFiles         = "FILES"
 	catalogVal         = "def"
 	tableProfiling     = "PROFILING"
+	tableProcessList   = "PROCESSLIST"
+	tableStmtSummary   = "STATEMENTS_SUMMARY"
 )
 
 // NewInfoSchemaPlan returns new InfoSchemaPlan instance, and checks if the
@@ -81,6 +89,8 @@ func NewInfoSchemaPlan(tableName string) (isp *InfoSchemaPlan, err error) {
 	case tableCollations:
 	case tableFiles:
 	case tableProfiling:
+	case tableProcessList:
+	case tableStmtSummary:
 	default:
 		return nil, errors.Errorf("table INFORMATION_SCHEMA.%s does not exist", tableName)
 	}
@@ -207,6 +217,137 @@ func buildResultFieldsForProfiling() (rfs []*field.ResultField) {
 	return
 }
 
+func buildResultFieldsForProcessList() (rfs []*field.ResultField) {
+	tbName := tableProcessList
+	rfs = append(rfs, buildResultField(tbName, "ID", mysql.TypeLonglong, 21))
+	rfs = append(rfs, buildResultField(tbName, "USER", mysql.TypeVarchar, 16))
+	rfs = append(rfs, buildResultField(tbName, "HOST", mysql.TypeVarchar, 64))
+	rfs = append(rfs, buildResultField(tbName, "DB", mysql.TypeVarchar, 64))
+	rfs = append(rfs, buildResultField(tbName, "COMMAND", mysql.TypeVarchar, 16))
+	rfs = append(rfs, buildResultField(tbName, "TIME", mysql.TypeLong, 7))
+	rfs = append(rfs, buildResultField(tbName, "STATE", mysql.TypeVarchar, 64))
+	rfs = append(rfs, buildResultField(tbName, "INFO", mysql.TypeLongBlob, 0))
+	for i, f := range rfs {
+		f.Offset = i
+	}
+	return
+}
+
+// processListRecords returns the records of the connections of the server sorted by id,
+// it's empty if the context doesn't belong to a server connection. The connections of the
+// other users are shown only to a user with all the global privileges.
+func processListRecords(ctx context.Context) ([][]interface{}, error) {
+	m := processlist.GetManager(ctx)
+	if m == nil {
+		return nil, nil
+	}
+	var records [][]interface{}
+	pl := m.ShowProcessList()
+	sort.Sort(byProcessID(pl))
+	for _, pi := range pl {
+		ok, err := processlist.CanAccess(ctx, pi.User)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if !ok {
+			continue
+		}
+		var db, info interface{}
+		if len(pi.DB) > 0 {
+			db = pi.DB
+		}
+		if len(pi.Info) > 0 {
+			info = pi.Info
+		}
+		seconds := int64(time.Since(pi.Time) / time.Second)
+		record := []interface{}{
+			pi.ID,      // ID
+			pi.User,    // USER
+			pi.Host,    // HOST
+			db,         // DB
+			pi.Command, // COMMAND
+			seconds,    // TIME
+			"",         // STATE
+			info,       // INFO
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+type byProcessID []processlist.ProcessInfo
+
+func (p byProcessID) Len() int           { return len(p) }
+func (p byProcessID) Less(i, j int) bool { return p[i].ID < p[j].ID }
+func (p byProcessID) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
+
+func buildResultFieldsForStmtSummary() (rfs []*field.ResultField) {
+	tbName := tableStmtSummary
+	rfs = append(rfs, buildResultField(tbName, "SUMMARY_BEGIN_TIME", mysql.TypeDatetime, 19))
+	rfs = append(rfs, buildResultField(tbName, "SCHEMA_NAME", mysql.TypeVarchar, 64))
+	rfs = append(rfs, buildResultField(tbName, "USER", mysql.TypeVarchar, 16))
+	rfs = append(rfs, buildResultField(tbName, "DIGEST", mysql.TypeVarchar, 64))
+	rfs = append(rfs, buildResultField(tbName, "DIGEST_TEXT", mysql.TypeBlob, 65535))
+	rfs = append(rfs, buildResultField(tbName, "EXEC_COUNT", mysql.TypeLonglong, 20))
+	rfs = append(rfs, buildResultField(tbName, "SUM_ERRORS", mysql.TypeLonglong, 20))
+	rfs = append(rfs, buildResultField(tbName, "SUM_LATENCY", mysql.TypeLonglong, 20))
+	rfs = append(rfs, buildResultField(tbName, "MAX_LATENCY", mysql.TypeLonglong, 20))
+	rfs = append(rfs, buildResultField(tbName, "AVG_LATENCY", mysql.TypeLonglong, 20))
+	rfs = append(rfs, buildResultField(tbName, "SUM_AFFECTED_ROWS", mysql.TypeLonglong, 20))
+	rfs = append(rfs, buildResultField(tbName, "FIRST_SEEN", mysql.TypeDatetime, 19))
+	rfs = append(rfs, buildResultField(tbName, "LAST_SEEN", mysql.TypeDatetime, 19))
+	rfs = append(rfs, buildResultField(tbName, "QUERY_SAMPLE_TEXT", mysql.TypeBlob, 65535))
+	for i, f := range rfs {
+		f.Offset = i
+	}
+	return
+}
+
+// stmtSummaryRecords returns the summaries of the statements executed in the current window
+// sorted by the sum latency, the latencies are in nanoseconds. Like the process list, the
+// statements of the other users are shown only to a user with all the global privileges.
+func stmtSummaryRecords(ctx context.Context) ([][]interface{}, error) {
+	windowBegin, summaries := stmtsummary.Global().Summaries()
+	toDatetime := func(t time.Time) mysql.Time {
+		return mysql.Time{Time: t, Type: mysql.TypeDatetime}
+	}
+	var records [][]interface{}
+	for _, s := range summaries {
+		ok, err := processlist.CanAccess(ctx, s.User)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if !ok {
+			continue
+		}
+		var schema, user interface{}
+		if len(s.SchemaName) > 0 {
+			schema = s.SchemaName
+		}
+		if len(s.User) > 0 {
+			user = s.User
+		}
+		record := []interface{}{
+			toDatetime(windowBegin), // SUMMARY_BEGIN_TIME
+			schema,                  // SCHEMA_NAME
+			user,                    // USER
+			s.Digest,                // DIGEST
+			s.NormalizedSQL,         // DIGEST_TEXT
+			s.ExecCount,             // EXEC_COUNT
+			s.SumErrors,             // SUM_ERRORS
+			uint64(s.SumLatency),    // SUM_LATENCY
+			uint64(s.MaxLatency),    // MAX_LATENCY
+			uint64(s.AvgLatency()),  // AVG_LATENCY
+			s.SumAffectedRows,       // SUM_AFFECTED_ROWS
+			toDatetime(s.FirstSeen), // FIRST_SEEN
+			toDatetime(s.LastSeen),  // LAST_SEEN
+			s.SampleSQL,             // QUERY_SAMPLE_TEXT
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
 func buildResultFieldsForCharacterSets() (rfs []*field.ResultField) {
 	tbName := tableCharacterSets
 	rfs = append(rfs, buildResultField(tbName, "CHARACTER_SET_NAME", mysql.TypeVarchar, 32))
@@ -320,6 +461,10 @@ func (isp *InfoSchemaPlan) GetFields() []*field.ResultField {
 		return filesFields
 	case tableProfiling:
 		return profilingFields
+	case tableProcessList:
+		return processListFields
+	case tableStmtSummary:
+		return stmtSummaryFields
 	}
 	return nil
 }
@@ -356,7 +501,9 @@ func buildResultField(tableName, name string, tp byte, size int) *field.ResultFi
 // Next implements plan.Plan Next interface.
 func (isp *InfoSchemaPlan) Next(ctx context.Context) (row *plan.Row, err error) {
 	if isp.rows == nil {
-		isp.fetchAll(ctx)
+		if err = isp.fetchAll(ctx); err != nil {
+			return nil, errors.Trace(err)
+		}
 	}
 	if isp.cursor == len(isp.rows) {
 		return
@@ -366,14 +513,15 @@ func (isp *InfoSchemaPlan) Next(ctx context.Context) (row *plan.Row, err error)
 	return
 }
 
-func (isp *InfoSchemaPlan) fetchAll(ctx context.Context) {
-	is := sessionctx.GetDomain(ctx).InfoSchema()
+func (isp *InfoSchemaPlan) fetchAll(ctx context.Context) error {
+	do := sessionctx.GetDomain(ctx)
+	is := do.InfoSchema()
 	schemas := is.AllSchemas()
 	switch isp.TableName {
 	case tableSchemata:
 		isp.fetchSchemata(is.AllSchemaNames())
 	case tableTables:
-		isp.fetchTables(schemas)
+		isp.fetchTables(schemas, do.StatsHandle())
 	case tableColumns:
 		isp.fetchColumns(schemas)
 	case tableStatistics:
@@ -384,7 +532,12 @@ func (isp *InfoSchemaPlan) fetchAll(ctx context.Context) {
 		isp.fetchCollations()
 	case tableFiles:
 		isp.fetchFiles()
+	case tableProcessList:
+		return isp.fetchProcessList(ctx)
+	case tableStmtSummary:
+		return isp.fetchStmtSummary(ctx)
 	}
+	return nil
 }
 
 func (isp *InfoSchemaPlan) fetchSchemata(schemas []string) {
@@ -401,9 +554,14 @@ func (isp *InfoSchemaPlan) fetchSchemata(schemas []string) {
 	}
 }
 
-func (isp *InfoSchemaPlan) fetchTables(schemas []*model.DBInfo) {
+func (isp *InfoSchemaPlan) fetchTables(schemas []*model.DBInfo, stats *statistics.Handle) {
 	for _, schema := range schemas {
 		for _, table := range schema.Tables {
+			// The row count is known only if the table is analyzed.
+			var rowCount uint64
+			if tblStats := stats.Get(table.ID); tblStats != nil {
+				rowCount = uint64(tblStats.Count)
+			}
 			record := []interface{}{
 				catalogVal,          // TABLE_CATALOG
 				schema.Name.O,       // TABLE_SCHEMA
@@ -412,7 +570,7 @@ func (isp *InfoSchemaPlan) fetchTables(schemas []*model.DBInfo) {
 				"InnoDB",            // ENGINE
 				uint64(10),          // VERSION
 				"Compact",           // ROW_FORMAT
-				uint64(0),           // TABLE_ROWS
+				rowCount,            // TABLE_ROWS
 				uint64(0),           // AVG_ROW_LENGTH
 				uint64(16384),       // DATA_LENGTH
 				uint64(0),           // MAX_DATA_LENGTH
@@ -537,6 +695,28 @@ func (isp *InfoSchemaPlan) fetchFiles() error {
 	return nil
 }
 
+func (isp *InfoSchemaPlan) fetchProcessList(ctx context.Context) error {
+	records, err := processListRecords(ctx)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	for _, record := range records {
+		isp.rows = append(isp.rows, &plan.Row{Data: record})
+	}
+	return nil
+}
+
+func (isp *InfoSchemaPlan) fetchStmtSummary(ctx context.Context) error {
+	records, err := stmtSummaryRecords(ctx)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	for _, record := range records {
+		isp.rows = append(isp.rows, &plan.Row{Data: record})
+	}
+	return nil
+}
+
 // Close implements plan.Plan Close interface.
 func (isp *InfoSchemaPlan) Close() error {
 	isp.rows = nil
@@ -556,4 +736,6 @@ func init() {
 	collationsRecords = buildColltionsRecords()
 	filesRecords = buildFilesRecords()
 	profilingFields = buildResultFieldsForProfiling()
+	processListFields = buildResultFieldsForProcessList()
+	stmtSummaryFields = buildResultFieldsForStmtSummary()
 }
@@ -18,9 +18,12 @@ import (
 	"github.com/pingcap/tidb/context"
 	"github.com/pingcap/tidb/expression"
 	"github.com/pingcap/tidb/field"
+	"github.com/pingcap/tidb/kv"
 	"github.com/pingcap/tidb/parser/coldef"
 	"github.com/pingcap/tidb/plan"
 	"github.com/pingcap/tidb/sessionctx/forupdate"
+	"github.com/pingcap/tidb/sessionctx/variable"
+	"github.com/pingcap/tidb/terror"
 	"github.com/pingcap/tidb/util/format"
 )
 
@@ -28,11 +31,24 @@ var (
 	_ plan.Plan = (*SelectLockPlan)(nil)
 )
 
+// maxSelectLockRetry is the max times SelectLockPlan reads the rows again when the locked
+// rows are changed by other transactions.
+const maxSelectLockRetry = 10
+
 // SelectLockPlan handles SELECT ... FOR UPDATE, recording selected rows
 // for acquiring locks explicitly.
+// In a pessimistic transaction, all the rows are locked before the first one is returned,
+// if a locked row is changed after it is read, the rows are read again from the latest
+// snapshot. Otherwise the rows are locked one by one when they are returned.
 type SelectLockPlan struct {
 	Src  plan.Plan
 	Lock coldef.LockType
+
+	rows   []*plan.Row
+	cursor int
+	locked bool
+	// buffered is true if the rows are read and locked before the first one is returned.
+	buffered bool
 }
 
 // Explain implements plan.Plan Explain interface.
@@ -58,27 +74,107 @@ func (r *SelectLockPlan) Filter(ctx context.Context, expr expression.Expression)
 
 // Next implements plan.Plan Next interface.
 func (r *SelectLockPlan) Next(ctx context.Context) (row *plan.Row, err error) {
-	row, err = r.Src.Next(ctx)
-	if row == nil || err != nil {
-		return nil, errors.Trace(err)
+	if r.Lock != coldef.SelectLockForUpdate {
+		return r.Src.Next(ctx)
 	}
-	if len(row.RowKeys) != 0 && r.Lock == coldef.SelectLockForUpdate {
-		forupdate.SetForUpdate(ctx)
-		txn, err := ctx.GetTxn(false)
-		if err != nil {
+	if !r.locked {
+		r.locked = true
+		if _, err = ctx.GetTxn(false); err != nil {
 			return nil, errors.Trace(err)
 		}
-		for _, k := range row.RowKeys {
-			err = txn.LockKeys([]byte(k.Key))
+		r.buffered = variable.GetSessionVars(ctx).PessimisticTxn
+		if r.buffered {
+			err = r.lockRows(ctx)
 			if err != nil {
 				return nil, errors.Trace(err)
 			}
 		}
 	}
-	return
+	if !r.buffered {
+		row, err = r.Src.Next(ctx)
+		if row == nil || err != nil {
+			return nil, errors.Trace(err)
+		}
+		return row, errors.Trace(lockRowKeys(ctx, row))
+	}
+	if r.cursor >= len(r.rows) {
+		return nil, nil
+	}
+	row = r.rows[r.cursor]
+	r.cursor++
+	return row, nil
+}
+
+func (r *SelectLockPlan) lockRows(ctx context.Context) error {
+	for i := 0; ; i++ {
+		err := r.fetchAndLock(ctx)
+		if err == nil {
+			return nil
+		}
+		if !terror.ErrorEqual(err, kv.ErrWriteConflict) || i >= maxSelectLockRetry {
+			return errors.Trace(err)
+		}
+		// The source plan reads the rows from the beginning after it is closed.
+		err = r.Src.Close()
+		if err != nil {
+			return errors.Trace(err)
+		}
+	}
+}
+
+func (r *SelectLockPlan) fetchAndLock(ctx context.Context) error {
+	r.rows = r.rows[:0]
+	var conflict error
+	for {
+		row, err := r.Src.Next(ctx)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if row == nil {
+			return errors.Trace(conflict)
+		}
+		r.rows = append(r.rows, row)
+		err = lockRowKeys(ctx, row)
+		if terror.ErrorEqual(err, kv.ErrWriteConflict) {
+			// Lock all the rows before reading them again, so they are not changed again.
+			conflict = err
+			continue
+		}
+		if err != nil {
+			return errors.Trace(err)
+		}
+	}
+}
+
+// lockRowKeys locks the keys of the row, all the keys are locked even if some of them
+// return ErrWriteConflict.
+func lockRowKeys(ctx context.Context, row *plan.Row) error {
+	if len(row.RowKeys) == 0 {
+		return nil
+	}
+	forupdate.SetForUpdate(ctx)
+	txn, err := ctx.GetTxn(false)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	var conflict error
+	for _, k := range row.RowKeys {
+		err = txn.LockKeys([]byte(k.Key))
+		if terror.ErrorEqual(err, kv.ErrWriteConflict) {
+			conflict = err
+			continue
+		}
+		if err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return errors.Trace(conflict)
 }
 
 // Close implements plan.Plan Close interface.
 func (r *SelectLockPlan) Close() error {
+	r.rows = nil
+	r.cursor = 0
+	r.locked = false
 	return r.Src.Close()
 }
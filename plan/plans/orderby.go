@@ -28,6 +28,7 @@ import (
 	"github.com/pingcap/tidb/expression"
 	"github.com/pingcap/tidb/field"
 	"github.com/pingcap/tidb/plan"
+	"github.com/pingcap/tidb/util/collate"
 	"github.com/pingcap/tidb/util/format"
 	"github.com/pingcap/tidb/util/types"
 )
@@ -191,7 +192,7 @@ func (r *OrderByDefaultPlan) fetchAll(ctx context.Context) error {
 				}
 			}
 
-			ordRow.Key = append(ordRow.Key, val)
+			ordRow.Key = append(ordRow.Key, collate.SortKey(r.collation(by), val))
 		}
 		r.ordTable.Rows = append(r.ordTable.Rows, ordRow)
 	}
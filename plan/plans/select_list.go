@@ -57,6 +57,18 @@ func (s *SelectList) updateFields(table string, resultFields []*field.ResultFiel
 	}
 }
 
+// collation returns the collation of the group by or order by expression, the expression
+// may be a position of the select fields.
+func (s *SelectList) collation(e expression.Expression) string {
+	if p, ok := e.(*expression.Position); ok {
+		if p.N > 0 && p.N <= len(s.ResultFields) {
+			return s.ResultFields[p.N-1].Collate
+		}
+		return ""
+	}
+	return expression.Collation(e)
+}
+
 func createEmptyResultField(f *field.Field) *field.ResultField {
 	result := &field.ResultField{}
 	// Set origin name
@@ -123,6 +123,10 @@ func (s *ShowPlan) GetFields() []*field.ResultField {
 		names = []string{"Table", "Create Table"}
 	case stmt.ShowGrants:
 		names = []string{fmt.Sprintf("Grants for %s", s.User)}
+	case stmt.ShowProcessList:
+		names = []string{"Id", "User", "Host", "db", "Command", "Time", "State", "Info"}
+		types = []byte{mysql.TypeLonglong, mysql.TypeVarchar, mysql.TypeVarchar, mysql.TypeVarchar,
+			mysql.TypeVarchar, mysql.TypeLong, mysql.TypeVarchar, mysql.TypeVarchar}
 	case stmt.ShowTriggers:
 		names = []string{"Trigger", "Event", "Table", "Statement", "Timing", "Created",
 			"sql_mode", "Definer", "character_set_client", "collation_connection", "Database Collation"}
@@ -193,6 +197,8 @@ func (s *ShowPlan) fetchAll(ctx context.Context) error {
 		return s.fetchShowGrants(ctx)
 	case stmt.ShowTriggers:
 		return s.fetchShowTriggers(ctx)
+	case stmt.ShowProcessList:
+		return s.fetchShowProcessList(ctx)
 	}
 	return nil
 }
@@ -582,7 +588,9 @@ func (s *ShowPlan) fetchShowCreateTable(ctx context.Context) error {
 			}
 			switch col.DefaultValue {
 			case nil:
-				buf.WriteString(" DEFAULT NULL")
+				if !mysql.HasNotNullFlag(col.Flag) {
+					buf.WriteString(" DEFAULT NULL")
+				}
 			case "CURRENT_TIMESTAMP":
 				buf.WriteString(" DEFAULT CURRENT_TIMESTAMP")
 			default:
@@ -660,3 +668,20 @@ func (s *ShowPlan) fetchShowGrants(ctx context.Context) error {
 func (s *ShowPlan) fetchShowTriggers(ctx context.Context) error {
 	return nil
 }
+
+// maxShowProcessListInfoLen is the max length of the statement shown by SHOW PROCESSLIST without FULL.
+const maxShowProcessListInfoLen = 100
+
+func (s *ShowPlan) fetchShowProcessList(ctx context.Context) error {
+	records, err := processListRecords(ctx)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	for _, record := range records {
+		if info, ok := record[len(record)-1].(string); ok && !s.Full && len(info) > maxShowProcessListInfoLen {
+			record[len(record)-1] = info[:maxShowProcessListInfoLen]
+		}
+		s.rows = append(s.rows, &plan.Row{Data: record})
+	}
+	return nil
+}
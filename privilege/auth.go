@@ -0,0 +1,66 @@
+// Copyright 2015 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package privilege
+
+import (
+	"sync"
+
+	"github.com/juju/errors"
+)
+
+// AuthConn is the client connection an AuthPlugin exchanges authentication data with.
+type AuthConn interface {
+	// ReadPacket reads the next packet sent by the client.
+	ReadPacket() ([]byte, error)
+	// WriteAuthMoreData sends the data to the client in an AuthMoreData packet.
+	WriteAuthMoreData(data []byte) error
+	// IsSecure returns whether the password can be sent in plain text, i.e. the connection is
+	// encrypted by TLS or it's a Unix socket connection.
+	IsSecure() bool
+}
+
+// AuthPlugin is an authentication method of the connection phase.
+// See: https://dev.mysql.com/doc/internals/en/authentication-method.html
+type AuthPlugin interface {
+	// Name returns the plugin name used in the handshake and the auth switch request.
+	Name() string
+	// Authenticate checks the auth response of the client against the password hash
+	// stored in mysql.user and the salt sent in the handshake. The plugin may exchange
+	// more data with the client by conn.
+	Authenticate(conn AuthConn, pwdHash []byte, salt []byte, authResp []byte) (bool, error)
+}
+
+var (
+	authPluginsMu sync.RWMutex
+	authPlugins   = make(map[string]AuthPlugin)
+)
+
+// RegisterAuthPlugin registers an AuthPlugin by its name.
+func RegisterAuthPlugin(p AuthPlugin) error {
+	authPluginsMu.Lock()
+	defer authPluginsMu.Unlock()
+	if _, ok := authPlugins[p.Name()]; ok {
+		return errors.Errorf("auth plugin %s is already registered", p.Name())
+	}
+	authPlugins[p.Name()] = p
+	return nil
+}
+
+// GetAuthPlugin gets the AuthPlugin registered with name, it returns nil if the plugin
+// is not registered.
+func GetAuthPlugin(name string) AuthPlugin {
+	authPluginsMu.RLock()
+	defer authPluginsMu.RUnlock()
+	return authPlugins[name]
+}
@@ -28,8 +28,10 @@ func (k keyType) String() string {
 // Checker is the interface for check privileges.
 type Checker interface {
 	// Check checks privilege.
+	// If db is nil, only check global scope privileges.
 	// If tbl is nil, only check global/db scope privileges.
 	// If tbl is not nil, check global/db/table scope privileges.
+	// AllPriv means all the privileges of a scope.
 	Check(ctx context.Context, db *model.DBInfo, tbl *model.TableInfo, privilege mysql.PrivilegeType) (bool, error)
 	// Show granted privileges for user.
 	ShowGrants(ctx context.Context, user string) ([]string, error)
@@ -0,0 +1,201 @@
+// Copyright 2015 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package privileges
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
+	"sync"
+
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/privilege"
+	"github.com/pingcap/tidb/util"
+)
+
+var (
+	_ privilege.AuthPlugin = (*nativePassword)(nil)
+	_ privilege.AuthPlugin = (*cachingSha2Password)(nil)
+)
+
+func init() {
+	privilege.RegisterAuthPlugin(&nativePassword{})
+	privilege.RegisterAuthPlugin(newCachingSha2Password(nil))
+}
+
+// nativePassword implements the mysql_native_password authentication.
+// See: https://dev.mysql.com/doc/internals/en/secure-password-authentication.html
+type nativePassword struct{}
+
+// Name implements the privilege.AuthPlugin Name interface.
+func (p *nativePassword) Name() string {
+	return mysql.AuthNativePassword
+}
+
+// Authenticate implements the privilege.AuthPlugin Authenticate interface.
+func (p *nativePassword) Authenticate(conn privilege.AuthConn, pwdHash []byte, salt []byte, authResp []byte) (bool, error) {
+	return bytes.Equal(authResp, util.CalcPassword(salt, pwdHash)), nil
+}
+
+// The status sent in the AuthMoreData packets of caching_sha2_password.
+const (
+	cachingSha2RequestPublicKey byte = 2
+	cachingSha2FastAuthSuccess  byte = 3
+	cachingSha2PerformFullAuth  byte = 4
+)
+
+// cachingSha2Password implements the caching_sha2_password authentication.
+// The client sends XOR(SHA256(password), SHA256(SHA256(SHA256(password)), salt)), it's
+// verified by the SHA256(SHA256(password)) cached for the password. If the password
+// isn't cached, the client is asked to send the password in plain text over TLS or
+// encrypted by the RSA public key of the server, then the password is cached.
+// See: https://dev.mysql.com/doc/dev/mysql-server/latest/page_caching_sha2_authentication_exchanges.html
+type cachingSha2Password struct {
+	keyOnce sync.Once
+	key     *rsa.PrivateKey
+	keyErr  error
+	// publicKey is the PEM encoded public key sent to the client.
+	publicKey []byte
+
+	mu sync.RWMutex
+	// cache maps the password hash stored in mysql.user to SHA256(SHA256(password)),
+	// the entry of the old password is not used after the password is changed.
+	cache map[string][]byte
+}
+
+// newCachingSha2Password creates a cachingSha2Password with the RSA key, a key is
+// generated when it's needed if key is nil.
+func newCachingSha2Password(key *rsa.PrivateKey) *cachingSha2Password {
+	return &cachingSha2Password{
+		key:   key,
+		cache: make(map[string][]byte),
+	}
+}
+
+// Name implements the privilege.AuthPlugin Name interface.
+func (p *cachingSha2Password) Name() string {
+	return mysql.AuthCachingSha2Password
+}
+
+// Authenticate implements the privilege.AuthPlugin Authenticate interface.
+func (p *cachingSha2Password) Authenticate(conn privilege.AuthConn, pwdHash []byte, salt []byte, authResp []byte) (bool, error) {
+	if len(pwdHash) == 0 {
+		return len(authResp) == 0, nil
+	}
+	if len(salt) > 20 {
+		// The nonce doesn't contain the terminating NUL.
+		salt = salt[:20]
+	}
+	p.mu.RLock()
+	digest, ok := p.cache[string(pwdHash)]
+	p.mu.RUnlock()
+	if ok && len(authResp) == sha256.Size {
+		if !checkSha2Scramble(authResp, digest, salt) {
+			return false, nil
+		}
+		err := conn.WriteAuthMoreData([]byte{cachingSha2FastAuthSuccess})
+		return err == nil, errors.Trace(err)
+	}
+
+	err := conn.WriteAuthMoreData([]byte{cachingSha2PerformFullAuth})
+	if err != nil {
+		return false, errors.Trace(err)
+	}
+	data, err := conn.ReadPacket()
+	if err != nil {
+		return false, errors.Trace(err)
+	}
+	var password []byte
+	if conn.IsSecure() {
+		password = data
+	} else {
+		if len(data) != 1 || data[0] != cachingSha2RequestPublicKey {
+			// The password can't be sent in plain text without TLS.
+			return false, nil
+		}
+		if password, err = p.readEncryptedPassword(conn, salt); err != nil {
+			return false, errors.Trace(err)
+		}
+	}
+	password = bytes.TrimRight(password, "\x00")
+	if !bytes.Equal(util.Sha1Hash(password), pwdHash) {
+		return false, nil
+	}
+	stage1 := sha256.Sum256(password)
+	stage2 := sha256.Sum256(stage1[:])
+	p.mu.Lock()
+	p.cache[string(pwdHash)] = stage2[:]
+	p.mu.Unlock()
+	return true, nil
+}
+
+// readEncryptedPassword sends the public key to the client and reads the password, it's
+// XORed with the salt and encrypted by the public key.
+func (p *cachingSha2Password) readEncryptedPassword(conn privilege.AuthConn, salt []byte) ([]byte, error) {
+	if err := p.loadKey(); err != nil {
+		return nil, errors.Trace(err)
+	}
+	if err := conn.WriteAuthMoreData(p.publicKey); err != nil {
+		return nil, errors.Trace(err)
+	}
+	data, err := conn.ReadPacket()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	password, err := rsa.DecryptOAEP(sha1.New(), rand.Reader, p.key, data, nil)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	for i := range password {
+		password[i] ^= salt[i%len(salt)]
+	}
+	return password, nil
+}
+
+func (p *cachingSha2Password) loadKey() error {
+	p.keyOnce.Do(func() {
+		if p.key == nil {
+			p.key, p.keyErr = rsa.GenerateKey(rand.Reader, 2048)
+			if p.keyErr != nil {
+				return
+			}
+		}
+		der, err := x509.MarshalPKIXPublicKey(&p.key.PublicKey)
+		if err != nil {
+			p.keyErr = err
+			return
+		}
+		p.publicKey = pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+	})
+	return errors.Trace(p.keyErr)
+}
+
+// checkSha2Scramble checks the scramble sent by the client for the cached digest,
+// SHA256(scramble XOR SHA256(digest, salt)) should be equal to digest.
+func checkSha2Scramble(scramble, digest, salt []byte) bool {
+	h := sha256.New()
+	h.Write(digest)
+	h.Write(salt)
+	stage1 := h.Sum(nil)
+	for i := range stage1 {
+		stage1[i] ^= scramble[i]
+	}
+	stage2 := sha256.Sum256(stage1)
+	return bytes.Equal(stage2[:], digest)
+}
@@ -0,0 +1,182 @@
+// Copyright 2015 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package privileges
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
+	"io"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/privilege"
+	"github.com/pingcap/tidb/util"
+)
+
+var _ = Suite(&testAuthSuite{})
+
+type testAuthSuite struct {
+	salt []byte
+}
+
+func (s *testAuthSuite) SetUpSuite(c *C) {
+	s.salt = []byte("0123456789abcdefghij")
+}
+
+// mockAuthConn plays the client, reply returns the packet sent by the client for the
+// AuthMoreData sent by the server.
+type mockAuthConn struct {
+	secure  bool
+	reply   func(data []byte) []byte
+	packets [][]byte
+	written [][]byte
+}
+
+func (m *mockAuthConn) ReadPacket() ([]byte, error) {
+	if len(m.packets) == 0 {
+		return nil, io.EOF
+	}
+	data := m.packets[0]
+	m.packets = m.packets[1:]
+	return data, nil
+}
+
+func (m *mockAuthConn) WriteAuthMoreData(data []byte) error {
+	m.written = append(m.written, data)
+	if m.reply != nil {
+		if p := m.reply(data); p != nil {
+			m.packets = append(m.packets, p)
+		}
+	}
+	return nil
+}
+
+func (m *mockAuthConn) IsSecure() bool {
+	return m.secure
+}
+
+// scrambleSha256 is the auth response of caching_sha2_password computed by the client.
+func scrambleSha256(password string, salt []byte) []byte {
+	stage1 := sha256.Sum256([]byte(password))
+	stage2 := sha256.Sum256(stage1[:])
+	h := sha256.New()
+	h.Write(stage2[:])
+	h.Write(salt)
+	scramble := h.Sum(nil)
+	for i := range scramble {
+		scramble[i] ^= stage1[i]
+	}
+	return scramble
+}
+
+func (s *testAuthSuite) TestRegister(c *C) {
+	c.Assert(privilege.GetAuthPlugin(mysql.AuthNativePassword), NotNil)
+	c.Assert(privilege.GetAuthPlugin(mysql.AuthCachingSha2Password), NotNil)
+	c.Assert(privilege.GetAuthPlugin("sha256_password"), IsNil)
+	c.Assert(privilege.RegisterAuthPlugin(&nativePassword{}), NotNil)
+}
+
+func (s *testAuthSuite) TestNativePassword(c *C) {
+	p := &nativePassword{}
+	pwdHash := util.Sha1Hash([]byte("pwd"))
+	ok, err := p.Authenticate(nil, pwdHash, s.salt, util.CalcPassword(s.salt, pwdHash))
+	c.Assert(err, IsNil)
+	c.Assert(ok, IsTrue)
+	ok, err = p.Authenticate(nil, pwdHash, s.salt, util.CalcPassword(s.salt, util.Sha1Hash([]byte("wrong"))))
+	c.Assert(err, IsNil)
+	c.Assert(ok, IsFalse)
+	// The empty password.
+	ok, err = p.Authenticate(nil, nil, s.salt, nil)
+	c.Assert(err, IsNil)
+	c.Assert(ok, IsTrue)
+}
+
+func (s *testAuthSuite) TestCachingSha2Password(c *C) {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	c.Assert(err, IsNil)
+	p := newCachingSha2Password(key)
+	pwdHash := util.Sha1Hash([]byte("pwd"))
+
+	// The password is not cached, the client sends the plain text password over TLS.
+	conn := &mockAuthConn{
+		secure: true,
+		reply: func(data []byte) []byte {
+			c.Assert(data, DeepEquals, []byte{cachingSha2PerformFullAuth})
+			return []byte("pwd\x00")
+		},
+	}
+	ok, err := p.Authenticate(conn, pwdHash, s.salt, scrambleSha256("pwd", s.salt))
+	c.Assert(err, IsNil)
+	c.Assert(ok, IsTrue)
+
+	// The password is cached.
+	conn = &mockAuthConn{}
+	ok, err = p.Authenticate(conn, pwdHash, s.salt, scrambleSha256("pwd", s.salt))
+	c.Assert(err, IsNil)
+	c.Assert(ok, IsTrue)
+	c.Assert(conn.written, DeepEquals, [][]byte{{cachingSha2FastAuthSuccess}})
+	ok, err = p.Authenticate(&mockAuthConn{}, pwdHash, s.salt, scrambleSha256("wrong", s.salt))
+	c.Assert(err, IsNil)
+	c.Assert(ok, IsFalse)
+
+	// The password is changed, the client requests the public key and sends the encrypted password.
+	pwdHash = util.Sha1Hash([]byte("new"))
+	encrypt := func(password string) func(data []byte) []byte {
+		return func(data []byte) []byte {
+			if len(data) == 1 {
+				c.Assert(data[0], Equals, cachingSha2PerformFullAuth)
+				return []byte{cachingSha2RequestPublicKey}
+			}
+			block, _ := pem.Decode(data)
+			c.Assert(block, NotNil)
+			pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+			c.Assert(err, IsNil)
+			plain := []byte(password + "\x00")
+			for i := range plain {
+				plain[i] ^= s.salt[i%len(s.salt)]
+			}
+			enc, err := rsa.EncryptOAEP(sha1.New(), rand.Reader, pub.(*rsa.PublicKey), plain, nil)
+			c.Assert(err, IsNil)
+			return enc
+		}
+	}
+	ok, err = p.Authenticate(&mockAuthConn{reply: encrypt("pwd")}, pwdHash, s.salt, scrambleSha256("pwd", s.salt))
+	c.Assert(err, IsNil)
+	c.Assert(ok, IsFalse)
+	ok, err = p.Authenticate(&mockAuthConn{reply: encrypt("new")}, pwdHash, s.salt, scrambleSha256("new", s.salt))
+	c.Assert(err, IsNil)
+	c.Assert(ok, IsTrue)
+	ok, err = p.Authenticate(&mockAuthConn{}, pwdHash, s.salt, scrambleSha256("new", s.salt))
+	c.Assert(err, IsNil)
+	c.Assert(ok, IsTrue)
+
+	// The password can't be sent in plain text without TLS.
+	conn = &mockAuthConn{
+		reply: func(data []byte) []byte {
+			return []byte("other\x00")
+		},
+	}
+	ok, err = p.Authenticate(conn, util.Sha1Hash([]byte("other")), s.salt, scrambleSha256("other", s.salt))
+	c.Assert(err, IsNil)
+	c.Assert(ok, IsFalse)
+
+	// The empty password.
+	ok, err = p.Authenticate(&mockAuthConn{}, nil, s.salt, nil)
+	c.Assert(err, IsNil)
+	c.Assert(ok, IsTrue)
+}
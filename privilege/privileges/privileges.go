@@ -38,10 +38,31 @@ func (ps *privileges) contain(p mysql.PrivilegeType) bool {
 	if ps.privs == nil {
 		return false
 	}
+	if p == mysql.AllPriv {
+		// All the privileges of the level are granted by GRANT ALL.
+		for _, priv := range ps.levelPrivs() {
+			if _, ok := ps.privs[priv]; !ok {
+				return false
+			}
+		}
+		return true
+	}
 	_, ok := ps.privs[p]
 	return ok
 }
 
+func (ps *privileges) levelPrivs() []mysql.PrivilegeType {
+	switch ps.Level {
+	case coldef.GrantLevelGlobal:
+		return mysql.AllGlobalPrivs
+	case coldef.GrantLevelDB:
+		return mysql.AllDBPrivs
+	case coldef.GrantLevelTable:
+		return mysql.AllTablePrivs
+	}
+	return nil
+}
+
 func (ps *privileges) add(p mysql.PrivilegeType) {
 	if ps.privs == nil {
 		ps.privs = make(map[mysql.PrivilegeType]bool)
@@ -182,6 +203,9 @@ func (p *UserPrivileges) Check(ctx context.Context, db *model.DBInfo, tbl *model
 	if ok {
 		return true, nil
 	}
+	if db == nil {
+		return false, nil
+	}
 	// Check db scope privileges.
 	dbp, ok := p.privs.DBPrivs[db.Name.O]
 	if ok {
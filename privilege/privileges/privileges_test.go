@@ -156,6 +156,27 @@ func (t *testPrivilegeSuite) TestCheckTablePrivilege(c *C) {
 	c.Assert(r, IsTrue)
 }
 
+func (t *testPrivilegeSuite) TestCheckAllPrivilege(c *C) {
+	se := newSession(c, t.store, t.dbName)
+	mustExec(c, se, `CREATE USER 'all'@'localhost' identified by '123';`)
+	ctx, _ := se.(context.Context)
+	variable.GetSessionVars(ctx).User = "all@localhost"
+	mustExec(c, se, `GRANT Select, Update ON *.* TO  'all'@'localhost';`)
+	pc := &privileges.UserPrivileges{}
+	r, err := pc.Check(ctx, nil, nil, mysql.SelectPriv)
+	c.Assert(err, IsNil)
+	c.Assert(r, IsTrue)
+	r, err = pc.Check(ctx, nil, nil, mysql.AllPriv)
+	c.Assert(err, IsNil)
+	c.Assert(r, IsFalse)
+
+	mustExec(c, se, `GRANT ALL ON *.* TO  'all'@'localhost';`)
+	pc = &privileges.UserPrivileges{}
+	r, err = pc.Check(ctx, nil, nil, mysql.AllPriv)
+	c.Assert(err, IsNil)
+	c.Assert(r, IsTrue)
+}
+
 func (t *testPrivilegeSuite) TestShowGrants(c *C) {
 	se := newSession(c, t.store, t.dbName)
 	ctx, _ := se.(context.Context)
@@ -70,6 +70,7 @@ func (v *groupByVisitor) VisitIdent(i *expression.Ident) (expression.Expression,
 	if len(idx) > 0 {
 		i.ReferScope = expression.IdentReferFromTable
 		i.ReferIndex = idx[0]
+		i.Collation = fieldCollation(v.selectList.FromFields, idx[0])
 		return i, nil
 	}
 
@@ -91,6 +92,7 @@ func (v *groupByVisitor) VisitIdent(i *expression.Ident) (expression.Expression,
 		// find in select list
 		i.ReferScope = expression.IdentReferSelectList
 		i.ReferIndex = index
+		i.Collation = fieldCollation(v.selectList.ResultFields, index)
 		return i, nil
 	}
 
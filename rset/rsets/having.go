@@ -70,6 +70,7 @@ func (v *havingVisitor) visitIdentInAggregate(i *expression.Ident) (expression.E
 	if len(idx) > 0 {
 		i.ReferScope = expression.IdentReferFromTable
 		i.ReferIndex = idx[0]
+		i.Collation = fieldCollation(v.selectList.FromFields, idx[0])
 		return i, nil
 	}
 
@@ -125,6 +125,14 @@ func checkIdentAmbiguous(i *expression.Ident, selectList *plans.SelectList, clau
 	return index, nil
 }
 
+// fieldCollation returns the collation of the field at the index.
+func fieldCollation(fields []*field.ResultField, index int) string {
+	if index < 0 || index >= len(fields) {
+		return ""
+	}
+	return fields[index].Collate
+}
+
 // FromIdentVisitor can only handle identifier which reference FROM table or outer query.
 // like in common select list, where or join on condition.
 type FromIdentVisitor struct {
@@ -139,6 +147,7 @@ func (v *FromIdentVisitor) VisitIdent(i *expression.Ident) (expression.Expressio
 	if len(idx) == 1 {
 		i.ReferScope = expression.IdentReferFromTable
 		i.ReferIndex = idx[0]
+		i.Collation = fieldCollation(v.FromFields, idx[0])
 		return i, nil
 	} else if len(idx) > 1 {
 		return nil, errors.Errorf("Column '%s' in %s is ambiguous", i, v.Clause)
@@ -121,6 +121,7 @@ func (v *orderByVisitor) VisitIdent(i *expression.Ident) (expression.Expression,
 			// e,g. select c1 as c2 from t order by c2, here c2 references c1.
 			i.ReferScope = expression.IdentReferSelectList
 			i.ReferIndex = index
+			i.Collation = fieldCollation(v.selectList.ResultFields, index)
 			return i, nil
 		}
 	}
@@ -130,6 +131,7 @@ func (v *orderByVisitor) VisitIdent(i *expression.Ident) (expression.Expression,
 	if len(idx) > 0 {
 		i.ReferScope = expression.IdentReferFromTable
 		i.ReferIndex = idx[0]
+		i.Collation = fieldCollation(v.selectList.FromFields, idx[0])
 		return i, nil
 	}
 
@@ -146,6 +148,7 @@ func (v *orderByVisitor) VisitIdent(i *expression.Ident) (expression.Expression,
 		// find in select list
 		i.ReferScope = expression.IdentReferSelectList
 		i.ReferIndex = index
+		i.Collation = fieldCollation(v.selectList.ResultFields, index)
 		return i, nil
 	}
 
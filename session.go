@@ -18,9 +18,9 @@
 package tidb
 
 import (
-	"bytes"
 	"encoding/json"
 	"fmt"
+	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -33,6 +33,7 @@ import (
 	"github.com/pingcap/tidb/field"
 	"github.com/pingcap/tidb/kv"
 	"github.com/pingcap/tidb/meta"
+	"github.com/pingcap/tidb/metric"
 	"github.com/pingcap/tidb/mysql"
 	"github.com/pingcap/tidb/privilege"
 	"github.com/pingcap/tidb/privilege/privileges"
@@ -41,6 +42,7 @@ import (
 	"github.com/pingcap/tidb/sessionctx/autocommit"
 	"github.com/pingcap/tidb/sessionctx/db"
 	"github.com/pingcap/tidb/sessionctx/forupdate"
+	"github.com/pingcap/tidb/sessionctx/processlist"
 	"github.com/pingcap/tidb/sessionctx/variable"
 	"github.com/pingcap/tidb/stmt"
 	"github.com/pingcap/tidb/stmt/stmts"
@@ -57,6 +59,7 @@ type Session interface {
 	LastInsertID() uint64                         // Last inserted auto_increment id
 	AffectedRows() uint64                         // Affected rows by lastest executed stmt
 	Execute(sql string) ([]rset.Recordset, error) // Execute a sql statement
+	SplitStatements(sql string) ([]string, error) // Split a multi-statement query into statements
 	String() string                               // For debug
 	FinishTxn(rollback bool) error
 	// For execute prepare statement in binary protocol
@@ -68,6 +71,12 @@ type Session interface {
 	Close() error
 	Retry() error
 	Auth(user string, auth []byte, salt []byte) bool
+	// Authenticate the user by the auth plugin
+	AuthWithPlugin(user string, plugin privilege.AuthPlugin, conn privilege.AuthConn, auth []byte, salt []byte) bool
+	RequireSSL(user string) (bool, error) // Whether the user must connect with TLS
+	SetConnectionID(id uint64)            // Set the connection id returned by CONNECTION_ID()
+	SetProcessManager(m processlist.Manager)
+	Cancel() // Interrupt the executing statement
 }
 
 var (
@@ -120,6 +129,13 @@ type session struct {
 	initing     bool // Running bootstrap using this session.
 	retrying    bool
 	maxRetryCnt int // Max retry times. If maxRetryCnt <=0, there is no limitation for retry times.
+	// sessionVars is the SessionVars bound to the session, Cancel uses it to set the kill flag
+	// because values can't be accessed by other goroutines.
+	sessionVars *variable.SessionVars
+	// txnStartTS and retryCount are the start version of the last transaction and the retry
+	// count of the current statement, they are written to the slow query log.
+	txnStartTS uint64
+	retryCount int
 
 	debugInfos map[string]interface{} // Vars for debug and unit tests.
 }
@@ -193,7 +209,7 @@ func (s *session) String() string {
 
 func needRetry(st stmt.Statement) bool {
 	switch st.(type) {
-	case *stmts.PreparedStmt, *stmts.ShowStmt, *stmts.DoStmt, *stmts.CommitStmt:
+	case *stmts.PreparedStmt, *stmts.ShowStmt, *stmts.DoStmt, *stmts.CommitStmt, *stmts.KillStmt:
 		return false
 	default:
 		return true
@@ -219,7 +235,16 @@ func (s *session) Retry() error {
 	}
 	var err error
 	retryCnt := 0
+	defer func() {
+		result := "OK"
+		if err != nil {
+			result = "Error"
+		}
+		metric.Inc(metric.Label("tidb_txn_retry_total", "result", result), 1)
+	}()
 	for {
+		metric.Inc("tidb_txn_retry_attempts_total", 1)
+		s.retryCount++
 		s.resetHistory()
 		s.FinishTxn(true)
 		success := true
@@ -377,6 +402,46 @@ func (s *session) isAutocommit(ctx context.Context) bool {
 	return false
 }
 
+// sysVar returns the session value of the system variable, the global value is cached in the
+// session on first use.
+func (s *session) sysVar(name string) (string, bool) {
+	sessVars := variable.GetSessionVars(s)
+	if val, ok := sessVars.Systems[name]; ok {
+		return val, true
+	}
+	if s.initing {
+		return "", false
+	}
+	val, err := s.GetGlobalSysVar(s, name)
+	if err != nil {
+		log.Errorf("Get global sys var %s error: %v", name, err)
+		return "", false
+	}
+	sessVars.Systems[name] = val
+	return val, true
+}
+
+// setTxnMode makes the new transaction pessimistic if tidb_txn_mode is "pessimistic",
+// the locks are waited for at most innodb_lock_wait_timeout seconds.
+func (s *session) setTxnMode(txn kv.Transaction) {
+	s.sessionVars.PessimisticTxn = false
+	if s.Value(&sqlexec.RestrictedSQLExecutorKeyType{}) != nil {
+		return
+	}
+	mode, ok := s.sysVar(variable.TiDBTxnMode)
+	if !ok || !strings.EqualFold(mode, variable.TxnModePessimistic) {
+		return
+	}
+	s.sessionVars.PessimisticTxn = true
+	timeout := kv.DefaultLockWaitTimeout
+	if val, ok := s.sysVar(variable.InnodbLockWaitTimeout); ok {
+		if seconds, err := strconv.ParseInt(val, 10, 64); err == nil && seconds > 0 {
+			timeout = time.Duration(seconds) * time.Second
+		}
+	}
+	txn.SetOption(kv.PessimisticLockWaitTimeout, timeout)
+}
+
 func (s *session) ShouldAutocommit(ctx context.Context) bool {
 	if ctx.Value(&sqlexec.RestrictedSQLExecutorKeyType{}) != nil {
 		return false
@@ -390,7 +455,17 @@ func (s *session) ShouldAutocommit(ctx context.Context) bool {
 }
 
 func (s *session) Execute(sql string) ([]rset.Recordset, error) {
-	statements, err := Compile(s, sql)
+	s.resetKilled()
+	var (
+		statements []stmt.Statement
+		normalized []string
+	)
+	rawStmts, err := Parse(s, sql)
+	if err == nil {
+		// The statements are normalized before they are compiled since the ast is rewritten.
+		normalized = s.normalizeStmts(rawStmts)
+		statements, err = compileStmts(s, rawStmts)
+	}
 	if err != nil {
 		log.Errorf("Syntax error: %s", sql)
 		log.Errorf("Error occurs at %s.", err)
@@ -399,8 +474,10 @@ func (s *session) Execute(sql string) ([]rset.Recordset, error) {
 
 	var rs []rset.Recordset
 
-	for _, st := range statements {
+	for i, st := range statements {
+		e := s.startStmt(st.OriginText(), normalized[i])
 		r, err := runStmt(s, st)
+		r = s.stmtExecuted(e, r, err)
 		if err != nil {
 			log.Warnf("session:%v, err:%v", s, err)
 			return nil, errors.Trace(err)
@@ -412,6 +489,38 @@ func (s *session) Execute(sql string) ([]rset.Recordset, error) {
 	return rs, nil
 }
 
+// SplitStatements parses the sql and returns the text of every statement, the server uses
+// it to execute a multi-statement query one by one and send the results in order.
+func (s *session) SplitStatements(sql string) ([]string, error) {
+	nodes, err := Parse(s, sql)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	texts := make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		texts = append(texts, node.Text())
+	}
+	return texts, nil
+}
+
+// resetKilled clears the kill flag set for the previous statement.
+func (s *session) resetKilled() {
+	atomic.StoreUint32(&s.sessionVars.Killed, 0)
+}
+
+// Cancel interrupts the statement being executed, it's called by KILL QUERY from another session.
+func (s *session) Cancel() {
+	atomic.StoreUint32(&s.sessionVars.Killed, 1)
+}
+
+func (s *session) SetConnectionID(id uint64) {
+	s.SetValue(builtin.ConnectionIDKey, int64(id))
+}
+
+func (s *session) SetProcessManager(m processlist.Manager) {
+	processlist.BindManager(s, m)
+}
+
 // For execute prepare statement in binary protocol
 func (s *session) PrepareStmt(sql string) (stmtID uint32, paramCount int, fields []*field.ResultField, err error) {
 	return prepareStmt(s, sql)
@@ -465,12 +574,18 @@ func checkArgs(args ...interface{}) error {
 
 // Execute a prepared statement
 func (s *session) ExecutePreparedStmt(stmtID uint32, args ...interface{}) (rset.Recordset, error) {
+	s.resetKilled()
 	err := checkArgs(args...)
 	if err != nil {
 		return nil, err
 	}
+	var e *stmtExecution
+	if ps, ok := stmts.GetPreparedStmt(s, stmtID); ok {
+		e = s.startStmt(ps.SQLText, ps.Normalized)
+	}
 	st := &stmts.ExecuteStmt{ID: stmtID}
 	r, err := runStmt(s, st, args...)
+	r = s.stmtExecuted(e, r, err)
 	return r, errors.Trace(err)
 }
 
@@ -492,6 +607,8 @@ func (s *session) GetTxn(forceNew bool) (kv.Transaction, error) {
 		if !s.isAutocommit(s) {
 			variable.GetSessionVars(s).SetStatusFlag(mysql.ServerStatusInTrans, true)
 		}
+		s.setTxnMode(s.txn)
+		s.txnStartTS = s.txn.StartTS()
 		log.Infof("New txn:%s in session:%d", s.txn, s.sid)
 		return s.txn, nil
 	}
@@ -507,6 +624,8 @@ func (s *session) GetTxn(forceNew bool) (kv.Transaction, error) {
 		if !s.isAutocommit(s) {
 			variable.GetSessionVars(s).SetStatusFlag(mysql.ServerStatusInTrans, true)
 		}
+		s.setTxnMode(s.txn)
+		s.txnStartTS = s.txn.StartTS()
 		log.Warnf("Force new txn:%s in session:%d", s.txn, s.sid)
 	}
 	return s.txn, nil
@@ -531,21 +650,32 @@ func (s *session) Close() error {
 }
 
 func (s *session) getPassword(name, host string) (string, error) {
-	// Get password for name and host.
-	authSQL := fmt.Sprintf("SELECT Password FROM %s.%s WHERE User='%s' and Host='%s';", mysql.SystemDB, mysql.UserTable, name, host)
-	pwd, err := s.getExecRet(s, authSQL)
+	return s.getUserColumn(name, host, "Password")
+}
+
+// getUserColumn gets the column of mysql.user for name and host, the row for name with
+// any host(%) is used if there is no row for the host.
+func (s *session) getUserColumn(name, host, column string) (string, error) {
+	authSQL := fmt.Sprintf("SELECT %s FROM %s.%s WHERE User='%s' and Host='%s';", column, mysql.SystemDB, mysql.UserTable, name, host)
+	val, err := s.getExecRet(s, authSQL)
 	if err == nil {
-		return pwd, nil
+		return val, nil
 	} else if !terror.ExecResultIsEmpty.Equal(err) {
 		return "", errors.Trace(err)
 	}
-	//Try to get user password for name with any host(%).
-	authSQL = fmt.Sprintf("SELECT Password FROM %s.%s WHERE User='%s' and Host='%%';", mysql.SystemDB, mysql.UserTable, name)
-	pwd, err = s.getExecRet(s, authSQL)
-	return pwd, errors.Trace(err)
+	//Try to get the column for name with any host(%).
+	authSQL = fmt.Sprintf("SELECT %s FROM %s.%s WHERE User='%s' and Host='%%';", column, mysql.SystemDB, mysql.UserTable, name)
+	val, err = s.getExecRet(s, authSQL)
+	return val, errors.Trace(err)
 }
 
 func (s *session) Auth(user string, auth []byte, salt []byte) bool {
+	return s.AuthWithPlugin(user, privilege.GetAuthPlugin(mysql.AuthNativePassword), nil, auth, salt)
+}
+
+// AuthWithPlugin authenticates the user by the auth plugin, conn is used by the plugin to
+// exchange more data with the client.
+func (s *session) AuthWithPlugin(user string, plugin privilege.AuthPlugin, conn privilege.AuthConn, auth []byte, salt []byte) bool {
 	strs := strings.Split(user, "@")
 	if len(strs) != 2 {
 		log.Warnf("Invalid format for user: %s", user)
@@ -555,19 +685,41 @@ func (s *session) Auth(user string, auth []byte, salt []byte) bool {
 	name := strs[0]
 	host := strs[1]
 	pwd, err := s.getPassword(name, host)
+	if err != nil {
+		log.Warnf("Get password of user %s error %v", user, err)
+		return false
+	}
 	hpwd, err := util.DecodePassword(pwd)
 	if err != nil {
 		log.Errorf("Decode password string error %v", err)
 		return false
 	}
-	checkAuth := util.CalcPassword(salt, hpwd)
-	if !bytes.Equal(auth, checkAuth) {
+	ok, err := plugin.Authenticate(conn, hpwd, salt, auth)
+	if err != nil {
+		log.Warnf("Authenticate user %s by %s error %v", user, plugin.Name(), err)
+		return false
+	}
+	if !ok {
 		return false
 	}
 	variable.GetSessionVars(s).SetCurrentUser(user)
 	return true
 }
 
+// RequireSSL checks whether the user must connect with TLS, it's set by the REQUIRE SSL
+// clause of CREATE USER and GRANT.
+func (s *session) RequireSSL(user string) (bool, error) {
+	strs := strings.Split(user, "@")
+	if len(strs) != 2 {
+		return false, errors.Errorf("invalid format for user: %s", user)
+	}
+	sslType, err := s.getUserColumn(strs[0], strs[1], "Ssl_type")
+	if err != nil {
+		return false, errors.Trace(err)
+	}
+	return sslType != "", nil
+}
+
 // Some vars name for debug.
 const (
 	retryEmptyHistoryList = "RetryEmptyHistoryList"
@@ -590,7 +742,8 @@ func CreateSession(store kv.Storage) (Session, error) {
 	sessionctx.BindDomain(s, domain)
 
 	variable.BindSessionVars(s)
-	variable.GetSessionVars(s).SetStatusFlag(mysql.ServerStatusAutocommit, true)
+	s.sessionVars = variable.GetSessionVars(s)
+	s.sessionVars.SetStatusFlag(mysql.ServerStatusAutocommit, true)
 
 	// set connection id
 	s.SetValue(builtin.ConnectionIDKey, s.sid)
@@ -603,8 +756,8 @@ func CreateSession(store kv.Storage) (Session, error) {
 	sessionMu.Lock()
 	defer sessionMu.Unlock()
 
-	ok := isBoostrapped(store)
-	if !ok {
+	ver := getStoreBootstrapVersion(store)
+	if ver == notBootstrapped {
 		// if no bootstrap and storage is remote, we must use a little lease time to
 		// bootstrap quickly, after bootstrapped, we will reset the lease time.
 		// TODO: Using a bootstap tool for doing this may be better later.
@@ -620,6 +773,12 @@ func CreateSession(store kv.Storage) (Session, error) {
 			sessionctx.GetDomain(s).SetLease(schemaLease)
 		}
 
+		finishBoostrap(store)
+	} else if ver < currentBootstrapVersion {
+		s.initing = true
+		upgrade(s, ver)
+		s.initing = false
+
 		finishBoostrap(store)
 	}
 
@@ -629,18 +788,21 @@ func CreateSession(store kv.Storage) (Session, error) {
 	return s, nil
 }
 
-func isBoostrapped(store kv.Storage) bool {
+// getStoreBootstrapVersion returns the bootstrap version of the store, notBootstrapped means
+// the store isn't bootstrapped.
+func getStoreBootstrapVersion(store kv.Storage) int64 {
 	// check in memory
 	_, ok := storeBootstrapped[store.UUID()]
 	if ok {
-		return true
+		return currentBootstrapVersion
 	}
 
 	// check in kv store
+	var ver int64
 	err := kv.RunInNewTxn(store, false, func(txn kv.Transaction) error {
 		var err error
 		t := meta.NewMeta(txn)
-		ok, err = t.IsBootstrapped()
+		ver, err = t.GetBootstrapVersion()
 		return errors.Trace(err)
 	})
 
@@ -648,12 +810,12 @@ func isBoostrapped(store kv.Storage) bool {
 		log.Fatalf("check bootstrapped err %v", err)
 	}
 
-	if ok {
+	if ver >= currentBootstrapVersion {
 		// here mean memory is not ok, but other server has already finished it
 		storeBootstrapped[store.UUID()] = true
 	}
 
-	return ok
+	return ver
 }
 
 func finishBoostrap(store kv.Storage) {
@@ -661,7 +823,7 @@ func finishBoostrap(store kv.Storage) {
 
 	err := kv.RunInNewTxn(store, true, func(txn kv.Transaction) error {
 		t := meta.NewMeta(txn)
-		err := t.FinishBootstrap()
+		err := t.FinishBootstrap(currentBootstrapVersion)
 		return errors.Trace(err)
 	})
 	if err != nil {
@@ -14,7 +14,10 @@
 package tidb
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -22,12 +25,19 @@ import (
 	. "github.com/pingcap/check"
 	"github.com/pingcap/tidb/context"
 	"github.com/pingcap/tidb/kv"
+	"github.com/pingcap/tidb/meta"
+	"github.com/pingcap/tidb/metric"
+	"github.com/pingcap/tidb/model"
 	"github.com/pingcap/tidb/mysql"
 	"github.com/pingcap/tidb/optimizer"
 	"github.com/pingcap/tidb/optimizer/plan"
+	"github.com/pingcap/tidb/parser"
 	"github.com/pingcap/tidb/sessionctx"
 	"github.com/pingcap/tidb/sessionctx/autocommit"
+	"github.com/pingcap/tidb/sessionctx/processlist"
 	"github.com/pingcap/tidb/sessionctx/variable"
+	"github.com/pingcap/tidb/terror"
+	"github.com/pingcap/tidb/util/stmtsummary"
 )
 
 var _ = Suite(&testSessionSuite{})
@@ -732,9 +742,11 @@ func (s *testSessionSuite) TestBootstrap(c *C) {
 	row, err := r.Next()
 	c.Assert(err, IsNil)
 	c.Assert(row, NotNil)
-	match(c, row.Data, []byte("%"), []byte("root"), []byte(""), "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y")
+	match(c, row.Data, []byte("%"), []byte("root"), []byte(""), "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "")
 
 	c.Assert(se.Auth("root@anyhost", []byte(""), []byte("")), IsTrue)
+	// The user names are case sensitive.
+	c.Assert(se.Auth("ROOT@anyhost", []byte(""), []byte("")), IsFalse)
 	mustExecSQL(c, se, "USE test;")
 	// Check privilege tables.
 	mustExecSQL(c, se, "SELECT * from mysql.db;")
@@ -780,7 +792,8 @@ func (s *testSessionSuite) bootstrapWithError(store kv.Storage, c *C) {
 	c.Assert(err, IsNil)
 	sessionctx.BindDomain(ss, domain)
 	variable.BindSessionVars(ss)
-	variable.GetSessionVars(ss).SetStatusFlag(mysql.ServerStatusAutocommit, true)
+	ss.sessionVars = variable.GetSessionVars(ss)
+	ss.sessionVars.SetStatusFlag(mysql.ServerStatusAutocommit, true)
 	// session implements autocommit.Checker. Bind it to ctx
 	autocommit.BindAutocommitChecker(ss, ss)
 	sessionMu.Lock()
@@ -801,7 +814,7 @@ func (s *testSessionSuite) TestBootstrapWithError(c *C) {
 	row, err := r.Next()
 	c.Assert(err, IsNil)
 	c.Assert(row, NotNil)
-	match(c, row.Data, []byte("%"), []byte("root"), []byte(""), "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y")
+	match(c, row.Data, []byte("%"), []byte("root"), []byte(""), "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "Y", "")
 	mustExecSQL(c, se, "USE test;")
 	// Check privilege tables.
 	mustExecSQL(c, se, "SELECT * from mysql.db;")
@@ -821,6 +834,40 @@ func (s *testSessionSuite) TestBootstrapWithError(c *C) {
 	c.Assert(row.Data[0], BytesEquals, []byte("True"))
 }
 
+func (s *testSessionSuite) TestBootstrapUpgrade(c *C) {
+	dbName := "test_main_db_upgrade"
+	defer removeStore(c, dbName)
+	store := newStore(c, dbName)
+	se := newSession(c, store, dbName)
+
+	// Make the store look like one bootstrapped by the version without Ssl_type.
+	mustExecSQL(c, se, "alter table mysql.user drop column Ssl_type")
+	err := kv.RunInNewTxn(store, true, func(txn kv.Transaction) error {
+		return meta.NewMeta(txn).FinishBootstrap(version1)
+	})
+	c.Assert(err, IsNil)
+	delete(storeBootstrapped, store.UUID())
+	se.Close()
+
+	se, err = CreateSession(store)
+	c.Assert(err, IsNil)
+	mustExecMatch(c, se, "select Ssl_type from mysql.user", [][]interface{}{{""}})
+	requireSSL, err := se.RequireSSL("root@%")
+	c.Assert(err, IsNil)
+	c.Assert(requireSSL, IsFalse)
+	err = kv.RunInNewTxn(store, false, func(txn kv.Transaction) error {
+		ver, err1 := meta.NewMeta(txn).GetBootstrapVersion()
+		c.Assert(ver, Equals, int64(currentBootstrapVersion))
+		return err1
+	})
+	c.Assert(err, IsNil)
+
+	// The upgrade can be run again.
+	upgrade(se, version1)
+	mustExecMatch(c, se, "select Ssl_type from mysql.user", [][]interface{}{{""}})
+	se.Close()
+}
+
 func (s *testSessionSuite) TestEnum(c *C) {
 	store := newStore(c, s.dbName)
 	se := newSession(c, store, s.dbName)
@@ -1368,6 +1415,853 @@ func (s *testSessionSuite) TestMultiColumnIndex(c *C) {
 	c.Assert(err, IsNil)
 }
 
+func (s *testSessionSuite) TestJoin(c *C) {
+	store := newStore(c, s.dbName)
+	se := newSession(c, store, s.dbName)
+	mustExecSQL(c, se, "drop table if exists t1, t2, t3")
+	mustExecSQL(c, se, `
+		create table t1 (c1 int, c11 int, index(c1));
+		create table t2 (c2 int, c22 int, index(c2));
+		create table t3 (c3 int, c33 int);
+		insert into t1 values (1, 1), (2, 2), (3, 3), (null, 4);
+		insert into t2 values (1, 1), (1, 2), (2, 3), (4, 4), (null, 5);
+		insert into t3 values (1, 1), (3, 3), (null, 5);`)
+
+	sql := "select c1, c2 from t1 join t2 on t1.c1 = t2.c2 order by c1"
+	checkPlan(c, se, sql, "MergeJoin{Index(t1.c1),Index(t2.c2)}->Fields->Sort")
+	mustExecMatch(c, se, sql, [][]interface{}{{1, 1}, {1, 1}, {2, 2}})
+
+	sql = "select c11, c33 from t1 join t3 on t1.c11 = t3.c33 order by c11"
+	checkPlan(c, se, sql, "HashJoin{Table(t1),Table(t3)}->Fields->Sort")
+	mustExecMatch(c, se, sql, [][]interface{}{{1, 1}, {3, 3}})
+
+	sql = "select c11, c22 from t1 join t2 on t1.c11 = t2.c22 where t1.c1 = 2"
+	checkPlan(c, se, sql, "HashJoin{Index(t1.c1)->Filter,Table(t2)}->Fields")
+	mustExecMatch(c, se, sql, [][]interface{}{{2, 2}})
+
+	sql = "select c1, c2 from t1 right join t2 on t1.c1 = t2.c2 and t1.c11 > 1 order by c22"
+	mustExecMatch(c, se, sql, [][]interface{}{{nil, 1}, {nil, 1}, {2, 2}, {nil, 4}, {nil, nil}})
+
+	sql = "select c11, c22 from t1 left join t2 on t1.c11 = t2.c22 where t2.c22 is null order by c11"
+	mustExecMatch(c, se, sql, [][]interface{}{})
+	sql = "select c11, c22 from t1 left join t2 on t1.c11 = t2.c22 and t2.c22 < 3 order by c11"
+	mustExecMatch(c, se, sql, [][]interface{}{{1, 1}, {2, 2}, {3, nil}, {4, nil}})
+
+	sql = "select c11, c22, c33 from t1, t2, t3 where t1.c11 = t2.c22 and t2.c22 = t3.c33 and t1.c11 < t2.c22 + 1"
+	mustExecMatch(c, se, sql, [][]interface{}{{1, 1, 1}, {3, 3, 3}})
+
+	sql = "select c11, c33 from t1 join t3 on t1.c11 > t3.c33 order by c11, c33"
+	mustExecMatch(c, se, sql, [][]interface{}{{2, 1}, {3, 1}, {4, 1}, {4, 3}})
+
+	err := se.Close()
+	c.Assert(err, IsNil)
+}
+
+func (s *testSessionSuite) TestAggregation(c *C) {
+	store := newStore(c, s.dbName)
+	se := newSession(c, store, s.dbName)
+	mustExecSQL(c, se, "drop table if exists t")
+	mustExecSQL(c, se, `
+		create table t (c1 int, c2 int, c3 varchar(10), index(c1));
+		insert into t values (1, 1, "a"), (1, 2, "b"), (1, 2, "b"), (2, 3, "c"), (3, null, null), (null, 5, "e");`)
+
+	sql := "select c1, count(*), count(c2), sum(c2), max(c3) from t group by c1 order by c1"
+	checkPlan(c, se, sql, "Index(t.c1)->StreamAgg->Fields")
+	mustExecMatch(c, se, sql, [][]interface{}{
+		{nil, 1, 1, 5, []byte("e")},
+		{1, 3, 3, 5, []byte("b")},
+		{2, 1, 1, 3, []byte("c")},
+		{3, 1, 0, nil, nil},
+	})
+
+	sql = "select c2, count(*) from t group by c2 order by c2"
+	checkPlan(c, se, sql, "Table(t)->HashAgg->Fields->Sort")
+	mustExecMatch(c, se, sql, [][]interface{}{{nil, 1}, {1, 1}, {2, 2}, {3, 1}, {5, 1}})
+
+	sql = "select count(*), count(distinct c2), sum(distinct c2), avg(c2), min(c3) from t"
+	checkPlan(c, se, sql, "Table(t)->HashAgg->Fields")
+	mustExecMatch(c, se, sql, [][]interface{}{{6, 4, 11, "2.6000", []byte("a")}})
+
+	sql = "select count(*), sum(c2), max(c2) from t where c1 > 10"
+	mustExecMatch(c, se, sql, [][]interface{}{{0, nil, nil}})
+	sql = "select c1, count(*) from t where c1 > 10 group by c1"
+	mustExecMatch(c, se, sql, [][]interface{}{})
+
+	sql = "select c1, group_concat(c3), group_concat(distinct c3) from t where c1 = 1 group by c1"
+	mustExecMatch(c, se, sql, [][]interface{}{{1, "a,b,b", "a,b"}})
+
+	sql = "select c1 as a, count(*) as cnt from t group by a having cnt > 1 or a = 2 order by count(*) desc, a"
+	mustExecMatch(c, se, sql, [][]interface{}{{1, 3}, {2, 1}})
+	sql = "select c1 from t group by c1 having max(c2) > 2 order by 1"
+	mustExecMatch(c, se, sql, [][]interface{}{{nil}, {2}})
+	sql = "select c1 + 1, count(*) from t group by 1 order by 1"
+	mustExecMatch(c, se, sql, [][]interface{}{{nil, 1}, {2, 3}, {3, 1}, {4, 1}})
+
+	err := se.Close()
+	c.Assert(err, IsNil)
+}
+
+func (s *testSessionSuite) TestSubquery(c *C) {
+	store := newStore(c, s.dbName)
+	se := newSession(c, store, s.dbName)
+	mustExecSQL(c, se, "drop table if exists t1, t2")
+	mustExecSQL(c, se, `
+		create table t1 (a int, b int);
+		create table t2 (c int, d int, index(c));
+		insert into t1 values (1, 1), (2, 2), (3, null), (null, 4);
+		insert into t2 values (1, 10), (2, 20), (2, 21), (5, null);`)
+
+	sql := "select a from t1 where a in (select c from t2) order by a"
+	checkPlan(c, se, sql, "SemiHashJoin{Table(t1),Table(t2)->Fields}->Fields->Sort")
+	mustExecMatch(c, se, sql, [][]interface{}{{1}, {2}})
+	sql = "select a from t1 where a not in (select c from t2) order by a"
+	checkPlan(c, se, sql, "AntiSemiHashJoin{Table(t1),Table(t2)->Fields}->Fields->Sort")
+	mustExecMatch(c, se, sql, [][]interface{}{{3}})
+	sql = "select a from t1 where a not in (select d from t2)"
+	mustExecMatch(c, se, sql, [][]interface{}{})
+
+	sql = "select a from t1 where exists (select * from t2 where t2.c = t1.a) order by a"
+	checkPlan(c, se, sql, "SemiHashJoin{Table(t1),Table(t2)}->Fields->Sort")
+	mustExecMatch(c, se, sql, [][]interface{}{{1}, {2}})
+	sql = "select a from t1 where not exists (select * from t2 where t2.c = t1.a) order by a"
+	checkPlan(c, se, sql, "AntiSemiHashJoin{Table(t1),Table(t2)}->Fields->Sort")
+	mustExecMatch(c, se, sql, [][]interface{}{{nil}, {3}})
+	sql = "select a from t1 where exists (select * from t2 where t2.c > t1.a and t2.d is null) order by a"
+	mustExecMatch(c, se, sql, [][]interface{}{{1}, {2}, {3}})
+	sql = "select a from t1 where a in (select c from t2 where t2.d > t1.b * 5) order by a"
+	mustExecMatch(c, se, sql, [][]interface{}{{1}, {2}})
+	sql = "select count(*) from t1 where exists (select * from t2 where d is null)"
+	mustExecMatch(c, se, sql, [][]interface{}{{4}})
+	sql = "select a from t1 where exists (select * from t2 where t2.c = t1.a and t2.d in (select d from t2 where d > 20))"
+	mustExecMatch(c, se, sql, [][]interface{}{{2}})
+	sql = `select a from t1 where a in (select c from t2 where exists (
+		select * from t1 as t3 where t3.b = t2.c and t3.a = t1.a)) order by a`
+	mustExecMatch(c, se, sql, [][]interface{}{{1}, {2}})
+
+	// Subqueries which are not converted to semi joins are evaluated for every row.
+	sql = "select a, (select max(d) from t2 where t2.c = t1.a) from t1 order by a"
+	checkPlan(c, se, sql, "Table(t1)->Fields->Sort")
+	mustExecMatch(c, se, sql, [][]interface{}{{nil, nil}, {1, 10}, {2, 21}, {3, nil}})
+	sql = "select a, a in (select c from t2) from t1 order by a"
+	mustExecMatch(c, se, sql, [][]interface{}{{nil, nil}, {1, 1}, {2, 1}, {3, 0}})
+	sql = "select a from t1 where b > (select min(c) from t2) order by a"
+	mustExecMatch(c, se, sql, [][]interface{}{{nil}, {2}})
+	sql = "select a from t1 where a > any (select c from t2) order by a"
+	mustExecMatch(c, se, sql, [][]interface{}{{2}, {3}})
+	sql = "select a from t1 where a >= all (select c from t2)"
+	mustExecMatch(c, se, sql, [][]interface{}{})
+	sql = "select a from t1 where a < all (select c from t2 where c > 10) order by a"
+	mustExecMatch(c, se, sql, [][]interface{}{{nil}, {1}, {2}, {3}})
+	mustExecFailed(c, se, "select a from t1 where a = (select c from t2)")
+
+	err := se.Close()
+	c.Assert(err, IsNil)
+}
+
+func (s *testSessionSuite) TestAnalyze(c *C) {
+	store := newStore(c, s.dbName)
+	se := newSession(c, store, s.dbName)
+	mustExecSQL(c, se, "drop table if exists t")
+	mustExecSQL(c, se, "create table t (a int, b int, index idx_a (a), index idx_b (b))")
+	for i := 0; i < 100; i++ {
+		mustExecSQL(c, se, fmt.Sprintf("insert into t values (%d, %d)", i, i%2))
+	}
+
+	rowsSQL := fmt.Sprintf("select table_rows from information_schema.tables where table_schema = '%s' and table_name = 't'", s.dbName)
+	mustExecMatch(c, se, rowsSQL, [][]interface{}{{0}})
+
+	// Without statistics, the point range on idx_b looks cheaper.
+	sql := "select a from t where a < 5 and b = 0"
+	checkPlan(c, se, sql, "Index(t.idx_b)->Filter->Fields")
+
+	mustExecSQL(c, se, "analyze table t")
+	mustExecMatch(c, se, rowsSQL, [][]interface{}{{100}})
+
+	// Half of the rows have b = 0, but only 5 rows have a < 5.
+	checkPlan(c, se, sql, "Index(t.idx_a)->Filter->Fields")
+	mustExecMatch(c, se, sql, [][]interface{}{{0}, {2}, {4}})
+	sql = "select a from t where a > 95 and b = 1"
+	checkPlan(c, se, sql, "Index(t.idx_a)->Filter->Fields")
+	mustExecMatch(c, se, sql, [][]interface{}{{97}, {99}})
+
+	mustExecFailed(c, se, "analyze table not_exist")
+
+	err := se.Close()
+	c.Assert(err, IsNil)
+}
+
+func (s *testSessionSuite) TestExplain(c *C) {
+	store := newStore(c, s.dbName)
+	se := newSession(c, store, s.dbName)
+	mustExecSQL(c, se, "drop table if exists t")
+	mustExecSQL(c, se, "create table t (a int, b int, index idx_a (a))")
+
+	sql := "explain select b from t where a > 1 order by a"
+	mustExecMatch(c, se, sql, [][]interface{}{
+		{"Fields", "2050.00", "4100.00", "b"},
+		{"└─Filter", "2050.00", "4100.00", "t.a > 1"},
+		{"  └─Index", "4100.00", "4100.00", "table:t, index:idx_a(a), ranges:(1,+inf]"},
+		{"Rejected", "5000.00", "25000.00", "Table(t)->Filter->Fields->Sort"},
+	})
+
+	r := mustExecSQL(c, se, "explain format=json select b from t where a > 1 order by a")
+	row, err := r.FirstRow()
+	c.Assert(err, IsNil)
+	var doc struct {
+		Plan                 *plan.ExplainNode   `json:"plan"`
+		RejectedAlternatives []*plan.ExplainNode `json:"rejected_alternatives"`
+	}
+	err = json.Unmarshal([]byte(row[0].(string)), &doc)
+	c.Assert(err, IsNil)
+	c.Assert(doc.Plan.Operator, Equals, "Fields")
+	c.Assert(doc.Plan.Children[0].Children[0].Info, Equals, "table:t, index:idx_a(a), ranges:(1,+inf]")
+	c.Assert(doc.RejectedAlternatives, HasLen, 1)
+	c.Assert(doc.RejectedAlternatives[0].Operator, Equals, "Sort")
+	c.Assert(doc.RejectedAlternatives[0].TotalCost, Equals, float64(25000))
+
+	mustExecFailed(c, se, "explain format=xml select b from t")
+
+	err = se.Close()
+	c.Assert(err, IsNil)
+}
+
+func (s *testSessionSuite) TestIndexDescScan(c *C) {
+	store := newStore(c, s.dbName)
+	se := newSession(c, store, s.dbName)
+	mustExecSQL(c, se, "drop table if exists t")
+	mustExecSQL(c, se, "create table t (id int primary key, a int, b int, index idx_a (a), index idx_a_b (a, b))")
+	for i := 0; i < 10; i++ {
+		mustExecSQL(c, se, fmt.Sprintf("insert into t values (%d, %d, %d)", i, i/2, i))
+	}
+	mustExecSQL(c, se, "insert into t values (10, null, 10)")
+
+	sql := "select id from t order by a desc limit 3"
+	checkPlan(c, se, sql, "Index(t.idx_a)->Fields->Limit")
+	r := mustExecSQL(c, se, sql)
+	rows, err := r.Rows(-1, 0)
+	c.Assert(err, IsNil)
+	c.Assert(rows, HasLen, 3)
+	match(c, rows[2], 7)
+
+	sql = "select a, b from t where a >= 1 and a < 3 order by a desc, b desc"
+	checkPlan(c, se, sql, "Index(t.idx_a_b)->Filter->Fields")
+	mustExecMatch(c, se, sql, [][]interface{}{{2, 5}, {2, 4}, {1, 3}, {1, 2}})
+
+	sql = "select a, b from t where a = 1 or a > 3 order by a desc, b desc"
+	mustExecMatch(c, se, sql, [][]interface{}{{4, 9}, {4, 8}, {1, 3}, {1, 2}})
+
+	sql = "select b from t where a = 2 and b < 5 order by a desc, b desc"
+	mustExecMatch(c, se, sql, [][]interface{}{{4}})
+
+	// Null values are the smallest.
+	sql = "select b from t where a < 1 or a is null order by a desc"
+	mustExecMatch(c, se, sql, [][]interface{}{{1}, {0}, {10}})
+
+	// Uncommitted changes are merged.
+	mustExecSQL(c, se, "begin")
+	mustExecSQL(c, se, "delete from t where id = 9")
+	mustExecSQL(c, se, "insert into t values (11, 5, 11)")
+	mustExecMatch(c, se, "select b from t where a > 3 order by a desc, b desc", [][]interface{}{{11}, {8}})
+	mustExecSQL(c, se, "rollback")
+
+	err = se.Close()
+	c.Assert(err, IsNil)
+}
+
+func (s *testSessionSuite) TestPessimisticSelectForUpdate(c *C) {
+	store := newStore(c, s.dbName)
+	se := newSession(c, store, s.dbName)
+	se1 := newSession(c, store, s.dbName)
+	se2 := newSession(c, store, s.dbName)
+
+	mustExecSQL(c, se, "drop table if exists t")
+	mustExecSQL(c, se, "create table t (c1 int primary key, c2 int)")
+	mustExecSQL(c, se, "insert t values (11, 1), (12, 1)")
+	for _, se := range []Session{se1, se2} {
+		mustExecSQL(c, se, "set tidb_txn_mode = 'pessimistic'")
+		mustExecSQL(c, se, "set innodb_lock_wait_timeout = 1")
+	}
+
+	// se2 waits for the lock of se1 and reads the value committed by se1.
+	mustExecSQL(c, se1, "begin")
+	mustExecMatch(c, se1, "select c2 from t where c1 = 11 for update", [][]interface{}{{1}})
+	mustExecSQL(c, se2, "begin")
+	done := make(chan [][]interface{}, 1)
+	go func() {
+		r := mustExecSQL(c, se2, "select c2 from t where c1 = 11 for update")
+		rows, err := r.Rows(-1, 0)
+		c.Assert(err, IsNil)
+		done <- rows
+	}()
+	time.Sleep(50 * time.Millisecond)
+	mustExecSQL(c, se1, "update t set c2 = c2 + 1 where c1 = 11")
+	mustExecSQL(c, se1, "commit")
+	matches(c, <-done, [][]interface{}{{2}})
+	mustExecSQL(c, se2, "update t set c2 = c2 + 1 where c1 = 11")
+	mustExecSQL(c, se2, "commit")
+	mustExecMatch(c, se, "select c2 from t where c1 = 11", [][]interface{}{{3}})
+
+	// Lock wait timeout.
+	mustExecSQL(c, se1, "begin")
+	mustExecMatch(c, se1, "select c2 from t where c1 = 11 for update", [][]interface{}{{3}})
+	mustExecSQL(c, se2, "begin")
+	r := mustExecSQL(c, se2, "select * from t where c1 = 11 for update")
+	_, err := r.Rows(-1, 0)
+	c.Assert(terror.ErrorEqual(err, kv.ErrLockWaitTimeout), IsTrue)
+	mustExecSQL(c, se2, "rollback")
+
+	// Deadlock.
+	mustExecSQL(c, se2, "begin")
+	mustExecMatch(c, se2, "select c2 from t where c1 = 12 for update", [][]interface{}{{1}})
+	go func() {
+		r := mustExecSQL(c, se1, "select * from t where c1 = 12 for update")
+		_, err := r.Rows(-1, 0)
+		c.Assert(err, IsNil)
+		done <- nil
+	}()
+	time.Sleep(50 * time.Millisecond)
+	r = mustExecSQL(c, se2, "select * from t where c1 = 11 for update")
+	_, err = r.Rows(-1, 0)
+	c.Assert(terror.ErrorEqual(err, kv.ErrDeadlock), IsTrue)
+	mustExecSQL(c, se2, "rollback")
+	<-done
+	mustExecSQL(c, se1, "commit")
+
+	// The prepared statement runs with the legacy plans, it waits for the lock and reads
+	// the value committed by se1 too.
+	mustExecSQL(c, se1, "begin")
+	mustExecMatch(c, se1, "select c2 from t where c1 = 11 for update", [][]interface{}{{3}})
+	mustExecSQL(c, se2, "begin")
+	go func() {
+		r := mustExecSQL(c, se2, "select c2 from t where c1 = ? for update", 11)
+		rows, err := r.Rows(-1, 0)
+		c.Assert(err, IsNil)
+		done <- rows
+	}()
+	time.Sleep(50 * time.Millisecond)
+	mustExecSQL(c, se1, "update t set c2 = c2 + 1 where c1 = 11")
+	mustExecSQL(c, se1, "commit")
+	matches(c, <-done, [][]interface{}{{4}})
+	mustExecSQL(c, se2, "commit")
+
+	mustExecSQL(c, se, s.dropDBSQL)
+}
+
+func (s *testSessionSuite) TestSplitStatements(c *C) {
+	store := newStore(c, s.dbName)
+	se := newSession(c, store, s.dbName)
+
+	cases := []struct {
+		sql   string
+		stmts []string
+	}{
+		{"select 1", []string{"select 1"}},
+		{"select 1; select 'a;b';\ninsert t values (1)", []string{"select 1", "select 'a;b'", "insert t values (1)"}},
+		{"", []string{}},
+	}
+	for _, ca := range cases {
+		stmts, err := se.SplitStatements(ca.sql)
+		c.Assert(err, IsNil)
+		c.Assert(stmts, DeepEquals, ca.stmts, Commentf("%q", ca.sql))
+	}
+	_, err := se.SplitStatements("select 1; select")
+	c.Assert(err, NotNil)
+
+	mustExecSQL(c, se, s.dropDBSQL)
+}
+
+func (s *testSessionSuite) TestProcessList(c *C) {
+	store := newStore(c, s.dbName)
+	se := newSession(c, store, s.dbName)
+
+	mustExecSQL(c, se, "drop table if exists t")
+	mustExecSQL(c, se, "create table t (c int)")
+	mustExecSQL(c, se, "insert t values (1), (2)")
+
+	// The session doesn't belong to a server.
+	mustExecMatch(c, se, "show processlist", [][]interface{}{})
+	mustExecMatch(c, se, "select * from information_schema.processlist", [][]interface{}{})
+	_, err := se.Execute("kill 1")
+	c.Assert(terror.ErrorEqual(err, mysql.NewErrf(mysql.ErrNoSuchThread, "Unknown thread id: %d", 1)), IsTrue)
+
+	m := &mockProcessManager{
+		sessions: map[uint64]Session{1: se},
+		infos: []processlist.ProcessInfo{
+			{ID: 2, User: "u2", Host: "h2", Command: "Sleep", Time: time.Now()},
+			{ID: 1, User: "u1", Host: "h1", DB: s.dbName, Command: "Query", Time: time.Now(), Info: strings.Repeat("a", 200)},
+		},
+	}
+	se.SetProcessManager(m)
+	mustExecMatch(c, se, "show processlist", [][]interface{}{
+		{1, "u1", "h1", s.dbName, "Query", 0, "", strings.Repeat("a", 100)},
+		{2, "u2", "h2", nil, "Sleep", 0, "", nil},
+	})
+	mustExecMatch(c, se, "show full processlist", [][]interface{}{
+		{1, "u1", "h1", s.dbName, "Query", 0, "", strings.Repeat("a", 200)},
+		{2, "u2", "h2", nil, "Sleep", 0, "", nil},
+	})
+	mustExecMatch(c, se, "select id, user from information_schema.processlist where command = 'Sleep'", [][]interface{}{{2, "u2"}})
+
+	mustExecSQL(c, se, "kill query 1")
+	c.Assert(m.killed, DeepEquals, []string{"query 1"})
+	mustExecSQL(c, se, "kill connection 1")
+	c.Assert(m.killed, DeepEquals, []string{"query 1", "connection 1"})
+	_, err = se.Execute("kill 3")
+	c.Assert(terror.ErrorEqual(err, mysql.NewErrf(mysql.ErrNoSuchThread, "Unknown thread id: %d", 3)), IsTrue)
+
+	// A user can only see and kill the connections of its own, unless it has all the privileges.
+	mustExecSQL(c, se, "create user 'u1'@'%' identified by ''")
+	mustExecSQL(c, se, "create user 'u3'@'%' identified by ''")
+	mustExecSQL(c, se, "grant all on *.* to 'u3'@'%'")
+	se1 := newSession(c, store, s.dbName)
+	variable.GetSessionVars(se1.(context.Context)).User = "u1@h1"
+	se1.SetProcessManager(m)
+	mustExecMatch(c, se1, "select id, user from information_schema.processlist", [][]interface{}{{1, "u1"}})
+	mustExecMatch(c, se1, "show processlist", [][]interface{}{
+		{1, "u1", "h1", s.dbName, "Query", 0, "", strings.Repeat("a", 100)},
+	})
+	m.killed = nil
+	_, err = se1.Execute("kill 2")
+	c.Assert(terror.ErrorEqual(err, mysql.NewErrf(mysql.ErrKillDenied, "You are not owner of thread %d", 2)), IsTrue)
+	mustExecSQL(c, se1, "kill query 1")
+	c.Assert(m.killed, DeepEquals, []string{"query 1"})
+	se3 := newSession(c, store, s.dbName)
+	variable.GetSessionVars(se3.(context.Context)).User = "u3@h3"
+	se3.SetProcessManager(m)
+	m.sessions[2] = se3
+	mustExecMatch(c, se3, "select id, user from information_schema.processlist", [][]interface{}{{1, "u1"}, {2, "u2"}})
+	mustExecSQL(c, se3, "kill query 2")
+	c.Assert(m.killed, DeepEquals, []string{"query 1", "query 2"})
+
+	// The statement being executed is interrupted.
+	r := mustExecSQL(c, se, "select * from t")
+	se.Cancel()
+	_, err = r.Rows(-1, 0)
+	c.Assert(terror.ErrorEqual(err, variable.ErrQueryInterrupted), IsTrue)
+	// The next statement isn't affected.
+	mustExecMatch(c, se, "select * from t", [][]interface{}{{1}, {2}})
+
+	mustExecSQL(c, se, s.dropDBSQL)
+}
+
+type mockProcessManager struct {
+	sessions map[uint64]Session
+	infos    []processlist.ProcessInfo
+	killed   []string
+}
+
+func (m *mockProcessManager) ShowProcessList() []processlist.ProcessInfo {
+	return m.infos
+}
+
+func (m *mockProcessManager) Kill(connectionID uint64, query bool) bool {
+	se, ok := m.sessions[connectionID]
+	if !ok {
+		return false
+	}
+	if query {
+		m.killed = append(m.killed, fmt.Sprintf("query %d", connectionID))
+	} else {
+		m.killed = append(m.killed, fmt.Sprintf("connection %d", connectionID))
+	}
+	se.Cancel()
+	return true
+}
+
+// queryCount returns the value of tidb_query_total of the type and the result.
+func queryCount(c *C, typ, result string) string {
+	var buf bytes.Buffer
+	c.Assert(metric.WritePrometheus(&buf), IsNil)
+	prefix := fmt.Sprintf("tidb_query_total{type=%q,result=%q} ", typ, result)
+	for _, line := range strings.Split(buf.String(), "\n") {
+		if strings.HasPrefix(line, prefix) {
+			return line[len(prefix):]
+		}
+	}
+	return "0"
+}
+
+func (s *testSessionSuite) TestQueryMetrics(c *C) {
+	store := newStore(c, s.dbName)
+	se := newSession(c, store, s.dbName)
+	mustExecSQL(c, se, "drop table if exists t")
+	mustExecSQL(c, se, "create table t (a int)")
+	mustExecSQL(c, se, "insert t values (1), (2)")
+
+	// The query is recorded after the rows are fetched.
+	ok := queryCount(c, "Select", "OK")
+	r := mustExecSQL(c, se, "select a from t")
+	c.Assert(queryCount(c, "Select", "OK"), Equals, ok)
+	rows, err := r.Rows(-1, 0)
+	c.Assert(err, IsNil)
+	c.Assert(rows, HasLen, 2)
+	c.Assert(r.Close(), IsNil)
+	c.Assert(queryCount(c, "Select", "OK"), Not(Equals), ok)
+
+	// The error returned when the rows are fetched is recorded.
+	ok, failed := queryCount(c, "Select", "OK"), queryCount(c, "Select", "Error")
+	r = mustExecSQL(c, se, "select a from t")
+	se.(*session).Cancel()
+	_, err = r.Rows(-1, 0)
+	c.Assert(err, NotNil)
+	c.Assert(r.Close(), IsNil)
+	c.Assert(queryCount(c, "Select", "OK"), Equals, ok)
+	c.Assert(queryCount(c, "Select", "Error"), Not(Equals), failed)
+}
+
+// normalizeDigest returns the normalized text and the digest of the statement sql.
+func normalizeDigest(c *C, se Session, sql string) (string, string) {
+	nodes, err := Parse(se.(context.Context), sql)
+	c.Assert(err, IsNil)
+	normalized := parser.Normalize(nodes[0])
+	return normalized, parser.Digest(normalized)
+}
+
+func (s *testSessionSuite) TestSlowQueryLog(c *C) {
+	store := newStore(c, s.dbName)
+	se := newSession(c, store, s.dbName)
+	var buf bytes.Buffer
+	setSlowQueryWriter(&buf, time.Hour)
+	defer setSlowQueryWriter(nil, 0)
+
+	mustExecSQL(c, se, "drop table if exists t")
+	mustExecSQL(c, se, "create table t (a int)")
+	mustExecSQL(c, se, "insert t values (1), (2), (3)")
+	c.Assert(buf.Len(), Equals, 0)
+
+	// The statements are logged after the rows are fetched.
+	mustExecSQL(c, se, "set @@session.tidb_slow_log_threshold = '0'")
+	r := mustExecSQL(c, se, "select a from t where a > 1")
+	c.Assert(buf.Len(), Equals, 0)
+	rows, err := r.Rows(-1, 0)
+	c.Assert(err, IsNil)
+	c.Assert(rows, HasLen, 2)
+	c.Assert(r.Close(), IsNil)
+	_, digest := normalizeDigest(c, se, "select a from t where a > 100")
+	entry := buf.String()
+	for _, line := range []string{
+		"# Time: ",
+		"# Query_time: ",
+		"Rows_sent: 2  Rows_examined: 3\n",
+		"# Txn_start_ts: ",
+		"# Digest: " + digest + "\n",
+		"use " + s.dbName + ";\nSET timestamp=",
+		"\nselect a from t where a > 1;\n",
+	} {
+		c.Assert(strings.Contains(entry, line), IsTrue, Commentf("%s not in %s", line, entry))
+	}
+	c.Assert(strings.HasPrefix(entry, "# Time: "), IsTrue)
+
+	// The prepared statements executed with the binary protocol are logged with the same digest.
+	buf.Reset()
+	r = mustExecSQL(c, se, "select a from t where a > ?", 1)
+	c.Assert(buf.Len(), Equals, 0)
+	rows, err = r.Rows(-1, 0)
+	c.Assert(err, IsNil)
+	c.Assert(rows, HasLen, 2)
+	c.Assert(r.Close(), IsNil)
+	entry = buf.String()
+	for _, line := range []string{
+		"Rows_sent: 2  Rows_examined: 3\n",
+		"# Digest: " + digest + "\n",
+		"\nselect a from t where a > ?;\n",
+	} {
+		c.Assert(strings.Contains(entry, line), IsTrue, Commentf("%s not in %s", line, entry))
+	}
+
+	mustExecSQL(c, se, "set @@session.tidb_slow_log_threshold = '100000'")
+	buf.Reset()
+	mustExecSQL(c, se, "insert t values (4)")
+	c.Assert(buf.Len(), Equals, 0)
+}
+
+func (s *testSessionSuite) TestStmtSummary(c *C) {
+	store := newStore(c, s.dbName)
+	se := newSession(c, store, s.dbName)
+	stmtsummary.Global().Clear()
+	stmtsummary.Global().SetEnabled(true)
+	defer func() {
+		stmtsummary.Global().SetEnabled(false)
+		stmtsummary.Global().Clear()
+	}()
+
+	mustExecSQL(c, se, "drop table if exists t")
+	mustExecSQL(c, se, "create table t (a int primary key)")
+	mustExecSQL(c, se, "insert t values (1), (2)")
+	mustExecSQL(c, se, "insert t values (3)")
+	_, err := se.Execute("insert t values (3)")
+	c.Assert(err, NotNil)
+
+	normalized, digest := normalizeDigest(c, se, "insert t values (1)")
+	sql := "select exec_count, sum_errors, sum_affected_rows, digest_text, query_sample_text " +
+		"from information_schema.statements_summary where digest = '" + digest + "'"
+	mustExecMatch(c, se, sql, [][]interface{}{{3, 1, 3, normalized, "insert t values (3)"}})
+
+	// The queries are added after the rows are fetched.
+	r := mustExecSQL(c, se, "select a from t where a > 1")
+	rows, err := r.Rows(-1, 0)
+	c.Assert(err, IsNil)
+	c.Assert(rows, HasLen, 2)
+	c.Assert(r.Close(), IsNil)
+	_, digest = normalizeDigest(c, se, "select a from t where a > 1")
+	sql = "select schema_name, exec_count, sum_latency >= max_latency, max_latency >= avg_latency " +
+		"from information_schema.statements_summary where digest = '" + digest + "'"
+	mustExecMatch(c, se, sql, [][]interface{}{{s.dbName, 1, 1, 1}})
+
+	// The prepared statements executed with the binary protocol are added with the same digest.
+	r = mustExecSQL(c, se, "select a from t where a > ?", 1)
+	rows, err = r.Rows(-1, 0)
+	c.Assert(err, IsNil)
+	c.Assert(rows, HasLen, 2)
+	c.Assert(r.Close(), IsNil)
+	sql = "select exec_count, query_sample_text from information_schema.statements_summary where digest = '" + digest + "'"
+	mustExecMatch(c, se, sql, [][]interface{}{{2, "select a from t where a > ?"}})
+
+	// The statements of the other users are only shown to a user with all the privileges.
+	mustExecSQL(c, se, "create user 'sum1'@'%' identified by ''")
+	mustExecSQL(c, se, "create user 'sum2'@'%' identified by ''")
+	mustExecSQL(c, se, "grant all on *.* to 'sum2'@'%'")
+	se1 := newSession(c, store, s.dbName)
+	variable.GetSessionVars(se1.(context.Context)).User = "sum1@localhost"
+	r = mustExecSQL(c, se1, "select 'secret'")
+	_, err = r.Rows(-1, 0)
+	c.Assert(err, IsNil)
+	c.Assert(r.Close(), IsNil)
+	// The query of the root user is not shown.
+	sql = "select count(*) from information_schema.statements_summary where digest = '" + digest + "'"
+	mustExecMatch(c, se1, sql, [][]interface{}{{0}})
+	mustExecMatch(c, se, sql, [][]interface{}{{1}})
+	_, digest = normalizeDigest(c, se, "select 'secret'")
+	sql = "select user, query_sample_text from information_schema.statements_summary where digest = '" + digest + "' order by user"
+	mustExecMatch(c, se1, sql, [][]interface{}{{"sum1", "select 'secret'"}})
+	se2 := newSession(c, store, s.dbName)
+	variable.GetSessionVars(se2.(context.Context)).User = "sum2@localhost"
+	r = mustExecSQL(c, se2, "select 'other'")
+	_, err = r.Rows(-1, 0)
+	c.Assert(err, IsNil)
+	c.Assert(r.Close(), IsNil)
+	mustExecMatch(c, se1, sql, [][]interface{}{{"sum1", "select 'secret'"}})
+	mustExecMatch(c, se2, sql, [][]interface{}{{"sum1", "select 'secret'"}, {"sum2", "select 'other'"}})
+
+	// The executions are not added if the summary is disabled.
+	stmtsummary.Global().SetEnabled(false)
+	mustExecSQL(c, se, "insert t values (4)")
+	stmtsummary.Global().SetEnabled(true)
+	_, digest = normalizeDigest(c, se, "insert t values (1)")
+	sql = "select exec_count from information_schema.statements_summary where digest = '" + digest + "'"
+	mustExecMatch(c, se, sql, [][]interface{}{{3}})
+}
+
+func (s *testSessionSuite) TestCollation(c *C) {
+	store := newStore(c, s.dbName)
+	se := newSession(c, store, s.dbName)
+
+	mustExecSQL(c, se, "drop table if exists t, t2, t3")
+	mustExecSQL(c, se, `create table t (id int primary key, name varchar(20) collate utf8_general_ci,
+		b varchar(20) collate utf8_bin, unique key (name))`)
+	mustExecSQL(c, se, "insert t values (1, 'abc', 'abc'), (2, 'Bcd', 'Bcd')")
+	_, err := se.Execute("insert t values (3, 'ABC', 'ABC')")
+	c.Assert(err, NotNil)
+	_, err = se.Execute("insert t values (3, 'bcd  ', 'bcd')")
+	c.Assert(err, NotNil)
+	mustExecSQL(c, se, "insert t values (3, 'aaa', 'aaa'), (4, 'BBB', 'BBB'), (5, 'Ccc', 'Ccc')")
+
+	mustExecMatch(c, se, "select id from t where name = 'ABC'", [][]interface{}{{1}})
+	mustExecMatch(c, se, "select id from t where name in ('ABC', 'abc', 'bcd')", [][]interface{}{{1}, {2}})
+	mustExecMatch(c, se, "select id from t where name like 'AB%'", [][]interface{}{{1}})
+	mustExecMatch(c, se, "select id from t where name > 'abz' and name < 'bCe'", [][]interface{}{{4}, {2}})
+	mustExecMatch(c, se, "select id from t where b = 'ABC'", [][]interface{}{})
+	mustExecMatch(c, se, "select name from t order by name", [][]interface{}{{[]byte("aaa")}, {[]byte("abc")}, {[]byte("BBB")}, {[]byte("Bcd")}, {[]byte("Ccc")}})
+	mustExecMatch(c, se, "select b from t order by b", [][]interface{}{{[]byte("BBB")}, {[]byte("Bcd")}, {[]byte("Ccc")}, {[]byte("aaa")}, {[]byte("abc")}})
+	mustExecMatch(c, se, "select name from t order by name desc limit 2", [][]interface{}{{[]byte("Ccc")}, {[]byte("Bcd")}})
+
+	// The default collation utf8_unicode_ci compares strings by bytes.
+	mustExecSQL(c, se, "create table t3 (a varchar(10), unique key (a))")
+	mustExecSQL(c, se, "insert t3 values ('abc'), ('ABC')")
+	mustExecMatch(c, se, "select a from t3 where a = 'ABC'", [][]interface{}{{[]byte("ABC")}})
+
+	mustExecSQL(c, se, "create table t2 (id int, c char(10) collate utf8_general_ci, key (c))")
+	mustExecSQL(c, se, "insert t2 values (1, 'a'), (2, 'A'), (3, 'b'), (4, 'B '), (5, 'Á')")
+	mustExecMatch(c, se, "select count(*) from t2 group by c order by c", [][]interface{}{{3}, {2}})
+	mustExecMatch(c, se, "select count(distinct c) from t2", [][]interface{}{{2}})
+	mustExecMatch(c, se, "select count(*) from (select distinct c from t2) x", [][]interface{}{{2}})
+	mustExecMatch(c, se, "select id from t2 where c = 'á' order by id", [][]interface{}{{1}, {2}, {5}})
+	r := mustExecSQL(c, se, "update t2 set id = id + 10 where c = 'B'")
+	c.Assert(r, IsNil)
+	c.Assert(se.AffectedRows(), Equals, uint64(2))
+	mustExecMatch(c, se, "select id from t2 where id > 10 order by id", [][]interface{}{{13}, {14}})
+	mustExecSQL(c, se, "delete from t2 where id > 10 and c like 'B%'")
+	c.Assert(se.AffectedRows(), Equals, uint64(2))
+
+	err = store.Close()
+	c.Assert(err, IsNil)
+}
+
+func (s *testSessionSuite) TestModifyColumn(c *C) {
+	store := newStore(c, s.dbName)
+	se := newSession(c, store, s.dbName)
+
+	mustExecSQL(c, se, "drop table if exists t")
+	mustExecSQL(c, se, "create table t (id int primary key, a int, b varchar(10) collate utf8_bin, c int default 1, key (a), unique key (b))")
+	mustExecSQL(c, se, "insert t values (1, 10, 'x', 1), (2, 20, 'y', 2), (3, 30, '33', 3)")
+
+	// The column info is changed directly.
+	mustExecSQL(c, se, "alter table t modify a bigint")
+	mustExecSQL(c, se, "alter table t modify column b varchar(20) collate utf8_bin")
+	mustExecSQL(c, se, "alter table t change c d int not null default 5 after id")
+	mustExecSQL(c, se, "insert t (id, a, b) values (4, 40, 'zzzzzzzzzzzzzzz')")
+	mustExecMatch(c, se, "select * from t where id = 4", [][]interface{}{{4, 5, 40, []byte("zzzzzzzzzzzzzzz")}})
+	mustExecMatch(c, se, "select id from t where a = 20", [][]interface{}{{2}})
+	mustExecMatch(c, se, "select column_name, column_type from information_schema.columns where table_name = 't'",
+		[][]interface{}{{"id", "int(11)"}, {"d", "int(11)"}, {"a", "bigint(21)"}, {"b", "varchar(20)"}})
+
+	// The column data is converted and the indices are rebuilt.
+	mustExecSQL(c, se, "alter table t change a a2 varchar(10) first")
+	mustExecMatch(c, se, "select * from t where a2 = '30'", [][]interface{}{{[]byte("30"), 3, 3, []byte("33")}})
+	mustExecSQL(c, se, "insert t values ('50', 5, 5, 'w')")
+	mustExecMatch(c, se, "select id from t where a2 > '3' order by a2", [][]interface{}{{3}, {4}, {5}})
+
+	// The job is rolled back if the column data can't be converted.
+	_, err := se.Execute("alter table t modify b int")
+	c.Assert(err, NotNil)
+	mustExecSQL(c, se, "insert t values ('60', 6, 6, 'v'), ('70', 7, 7, 'X')")
+	mustExecMatch(c, se, "select id from t where b = 'y'", [][]interface{}{{2}})
+	_, err = se.Execute("alter table t modify b varchar(10) collate utf8_general_ci")
+	c.Assert(err, NotNil)
+	mustExecSQL(c, se, "delete from t where id = 7")
+	mustExecSQL(c, se, "alter table t modify b varchar(10) collate utf8_general_ci")
+	mustExecMatch(c, se, "select id from t where b = 'Y'", [][]interface{}{{2}})
+	_, err = se.Execute("insert t values ('80', 8, 8, 'V')")
+	c.Assert(err, NotNil)
+
+	mustExecSQL(c, se, "alter table t modify a2 int after id")
+	mustExecSQL(c, se, "alter table t modify d varchar(10) default 'a' first")
+	mustExecSQL(c, se, "update t set a2 = 11 where id = 1")
+	mustExecSQL(c, se, "delete from t where b = 'w'")
+	mustExecMatch(c, se, "select * from t order by id", [][]interface{}{
+		{[]byte("1"), 1, 11, []byte("x")},
+		{[]byte("2"), 2, 20, []byte("y")},
+		{[]byte("3"), 3, 30, []byte("33")},
+		{[]byte("5"), 4, 40, []byte("zzzzzzzzzz")},
+		{[]byte("6"), 6, 60, []byte("v")},
+	})
+	mustExecMatch(c, se, "select id from t where a2 > 15 and a2 < 35", [][]interface{}{{2}, {3}})
+	mustExecMatch(c, se, "select id from t where a2 = 10", [][]interface{}{})
+
+	// The column can't be NOT NULL if it has NULL values.
+	mustExecSQL(c, se, "drop table if exists t2")
+	mustExecSQL(c, se, "create table t2 (a int, b int)")
+	mustExecSQL(c, se, "insert t2 values (1, null), (2, 2)")
+	_, err = se.Execute("alter table t2 modify b int not null")
+	c.Assert(err, ErrorMatches, ".*Invalid use of NULL value.*")
+	mustExecMatch(c, se, "select is_nullable from information_schema.columns where table_name = 't2' and column_name = 'b'",
+		[][]interface{}{{"YES"}})
+	mustExecMatch(c, se, "select a from t2 where b is null", [][]interface{}{{1}})
+	mustExecSQL(c, se, "update t2 set b = 1 where a = 1")
+	mustExecSQL(c, se, "alter table t2 modify b int not null")
+	_, err = se.Execute("insert t2 values (3, null)")
+	c.Assert(err, NotNil)
+	r := mustExecSQL(c, se, "show create table t2")
+	row, err := r.FirstRow()
+	c.Assert(err, IsNil)
+	c.Assert(row[1], Matches, "(?s).*`b` int\\(11\\) NOT NULL\n.*")
+
+	err = store.Close()
+	c.Assert(err, IsNil)
+}
+
+func (s *testSessionSuite) TestAdminDDL(c *C) {
+	store := newStore(c, s.dbName)
+	se := newSession(c, store, s.dbName)
+
+	mustExecSQL(c, se, "drop table if exists t")
+	mustExecSQL(c, se, "create table t (c int)")
+
+	r := mustExecSQL(c, se, "admin show ddl")
+	row, err := r.FirstRow()
+	c.Assert(err, IsNil)
+	c.Assert(row, HasLen, 4)
+	c.Assert(row[1], Not(Equals), "")
+	c.Assert(row[2], Equals, "")
+
+	// The latest history job is the first one when no job is in the queue.
+	r = mustExecSQL(c, se, "admin show ddl jobs")
+	row, err = r.FirstRow()
+	c.Assert(err, IsNil)
+	c.Assert(row[1], Equals, "create table")
+	c.Assert(row[4], Equals, "done")
+	c.Assert(row[5], Equals, "public")
+	jobID := row[0].(string)
+
+	mustExecMatch(c, se, "admin cancel ddl jobs "+jobID+", 0", [][]interface{}{
+		{jobID, fmt.Sprintf("DDL job %s is already cancelled or finished", jobID)},
+		{"0", "DDL job 0 not found"},
+	})
+
+	// Only a user with all the privileges can cancel the jobs.
+	mustExecSQL(c, se, "create user 'ddl1'@'%' identified by ''")
+	mustExecSQL(c, se, "create user 'ddl2'@'%' identified by ''")
+	mustExecSQL(c, se, "grant all on *.* to 'ddl2'@'%'")
+	se1 := newSession(c, store, s.dbName)
+	variable.GetSessionVars(se1.(context.Context)).User = "ddl1@localhost"
+	r = mustExecSQL(c, se1, "admin cancel ddl jobs 0")
+	_, err = r.Rows(-1, 0)
+	c.Assert(err, ErrorMatches, ".*privilege to cancel DDL jobs.*")
+	se2 := newSession(c, store, s.dbName)
+	variable.GetSessionVars(se2.(context.Context)).User = "ddl2@localhost"
+	mustExecMatch(c, se2, "admin cancel ddl jobs 0", [][]interface{}{{"0", "DDL job 0 not found"}})
+
+	err = se.Close()
+	c.Assert(err, IsNil)
+}
+
+func (s *testSessionSuite) TestAdminCheck(c *C) {
+	store := newStore(c, s.dbName)
+	se := newSession(c, store, s.dbName)
+
+	mustExecSQL(c, se, "drop table if exists t")
+	mustExecSQL(c, se, "create table t (c int, d varchar(10), index idx_c (c), unique index idx_d (d))")
+	mustExecSQL(c, se, "insert into t values (1, 'a'), (2, 'B'), (3, null), (null, null)")
+	mustExecMatch(c, se, "admin check table t", [][]interface{}{})
+	mustExecMatch(c, se, "admin check index t idx_d", [][]interface{}{})
+	mustExecFailed(c, se, "admin check index t idx_e")
+
+	// Remove the index entry of the second row.
+	is := sessionctx.GetDomain(se.(context.Context)).InfoSchema()
+	tbl, err := is.TableByName(model.NewCIStr(s.dbName), model.NewCIStr("t"))
+	c.Assert(err, IsNil)
+	txn, err := store.Begin()
+	c.Assert(err, IsNil)
+	err = tbl.Indices()[0].X.Delete(txn, []interface{}{int64(2)}, 2)
+	c.Assert(err, IsNil)
+	err = txn.Commit()
+	c.Assert(err, IsNil)
+
+	expected := [][]interface{}{{"t", "idx_c", "2", "missing index entry"}}
+	mustExecMatch(c, se, "admin check table t", expected)
+	mustExecMatch(c, se, "admin repair index t idx_c", expected)
+	mustExecMatch(c, se, "admin check table t", [][]interface{}{})
+	mustExecMatch(c, se, "select c from t where c = 2", [][]interface{}{{2}})
+
+	// The repair needs the privileges to insert and delete on the table.
+	mustExecSQL(c, se, "create user 'chk1'@'%' identified by ''")
+	mustExecSQL(c, se, "create user 'chk2'@'%' identified by ''")
+	mustExecSQL(c, se, "grant select, insert on "+s.dbName+".t to 'chk1'@'%'")
+	mustExecSQL(c, se, "grant select, insert, delete on "+s.dbName+".t to 'chk2'@'%'")
+	se1 := newSession(c, store, s.dbName)
+	variable.GetSessionVars(se1.(context.Context)).User = "chk1@localhost"
+	mustExecMatch(c, se1, "admin check table t", [][]interface{}{})
+	r := mustExecSQL(c, se1, "admin repair table t")
+	_, err = r.Rows(-1, 0)
+	c.Assert(err, ErrorMatches, ".*privilege to repair table.*")
+	se2 := newSession(c, store, s.dbName)
+	variable.GetSessionVars(se2.(context.Context)).User = "chk2@localhost"
+	mustExecMatch(c, se2, "admin repair table t", [][]interface{}{})
+
+	err = se.Close()
+	c.Assert(err, IsNil)
+}
+
 func (s *testSessionSuite) TestGlobalVarAccessor(c *C) {
 	store := newStore(c, s.dbName)
 	se := newSession(c, store, s.dbName).(*session)
@@ -1393,7 +2287,7 @@ func checkPlan(c *C, se Session, sql, explain string) {
 	stmt := stmts[0]
 	c.Assert(optimizer.IsSupported(stmt), IsTrue)
 	is := sessionctx.GetDomain(ctx).InfoSchema()
-	p, err := optimizer.Optimize(is, ctx, stmt)
+	p, err := optimizer.Optimize(is, ctx, stmt, nil)
 	c.Assert(err, IsNil)
 	planStr, err := plan.Explain(p)
 	c.Assert(err, IsNil)
@@ -0,0 +1,91 @@
+// Copyright 2015 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processlist
+
+import (
+	"strings"
+	"time"
+
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/privilege"
+	"github.com/pingcap/tidb/sessionctx/variable"
+)
+
+// ProcessInfo is the information of a connection shown by SHOW PROCESSLIST.
+type ProcessInfo struct {
+	ID      uint64
+	User    string
+	Host    string
+	DB      string
+	Command string
+	// Time is the time when the connection enters the current command.
+	Time time.Time
+	// Info is the statement being executed, it's empty if the connection is idle.
+	Info string
+}
+
+// Manager is the interface manages the connections of a server.
+type Manager interface {
+	// ShowProcessList returns the information of all the connections.
+	ShowProcessList() []ProcessInfo
+	// Kill interrupts the statement being executed by the connection if query is true,
+	// otherwise it closes the connection. It returns false if the connection doesn't exist.
+	Kill(connectionID uint64, query bool) bool
+}
+
+// keyType is a dummy type to avoid naming collision in context.
+type keyType int
+
+// String defines a Stringer function for debugging and pretty printing.
+func (k keyType) String() string {
+	return "process_manager"
+}
+
+const key keyType = 0
+
+// BindManager binds the process manager to context.
+func BindManager(ctx context.Context, m Manager) {
+	ctx.SetValue(key, m)
+}
+
+// GetManager gets the process manager from context, it returns nil if the context
+// doesn't belong to a server connection.
+func GetManager(ctx context.Context) Manager {
+	m, ok := ctx.Value(key).(Manager)
+	if !ok {
+		return nil
+	}
+	return m
+}
+
+// CanAccess returns whether the user of the context can see the statements of or kill the
+// connections of the user, it's allowed for the same user or a user with all the global privileges.
+func CanAccess(ctx context.Context, user string) (bool, error) {
+	current := variable.GetSessionVars(ctx).User
+	if len(current) == 0 {
+		// In embedded db mode, user does not need to login.
+		return true, nil
+	}
+	if strings.SplitN(current, "@", 2)[0] == user {
+		return true, nil
+	}
+	checker := privilege.GetPrivilegeChecker(ctx)
+	if checker == nil {
+		return false, nil
+	}
+	ok, err := checker.Check(ctx, nil, nil, mysql.AllPriv)
+	return ok, errors.Trace(err)
+}
@@ -14,9 +14,16 @@
 package variable
 
 import (
+	"sync/atomic"
+
+	"github.com/juju/errors"
 	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/mysql"
 )
 
+// ErrQueryInterrupted is returned when the executing statement is killed by KILL QUERY.
+var ErrQueryInterrupted = mysql.NewErr(mysql.ErrQueryInterrupted)
+
 // SessionVars is to handle user-defined or global variables in current session.
 type SessionVars struct {
 	// user-defined variables
@@ -41,6 +48,17 @@ type SessionVars struct {
 
 	// Current user
 	User string
+
+	// RowsExamined is the number of the rows read from the tables by the current statement.
+	RowsExamined uint64
+
+	// PessimisticTxn is true if the current transaction locks the rows of SELECT FOR UPDATE
+	// at once, it's set when the transaction begins.
+	PessimisticTxn bool
+
+	// Killed is set to 1 by KILL QUERY from another connection, the executing
+	// statement checks it and stops. It's accessed atomically.
+	Killed uint32
 }
 
 // sessionVarsKeyType is a dummy type to avoid naming collision in context.
@@ -115,3 +133,19 @@ func (s *SessionVars) GetNextPreparedStmtID() uint32 {
 func (s *SessionVars) SetCurrentUser(user string) {
 	s.User = user
 }
+
+// AddRowsExamined adds the rows read from the tables by the statement executing in the context.
+func AddRowsExamined(ctx context.Context, rows uint64) {
+	if s := GetSessionVars(ctx); s != nil {
+		s.RowsExamined += rows
+	}
+}
+
+// CheckKilled returns ErrQueryInterrupted if the statement executing in the context is killed.
+func CheckKilled(ctx context.Context) error {
+	s := GetSessionVars(ctx)
+	if s != nil && atomic.LoadUint32(&s.Killed) == 1 {
+		return errors.Trace(ErrQueryInterrupted)
+	}
+	return nil
+}
@@ -494,7 +494,7 @@ var defaultSysVars = []*SysVar{
 	{ScopeNone, "basedir", "/usr/local/mysql"},
 	{ScopeGlobal, "innodb_old_blocks_time", "1000"},
 	{ScopeGlobal, "innodb_stats_method", "nulls_equal"},
-	{ScopeGlobal | ScopeSession, "innodb_lock_wait_timeout", "50"},
+	{ScopeGlobal | ScopeSession, InnodbLockWaitTimeout, "50"},
 	{ScopeGlobal, "local_infile", "ON"},
 	{ScopeGlobal | ScopeSession, "myisam_stats_method", "nulls_unequal"},
 	{ScopeNone, "version_compile_os", "osx10.8"},
@@ -560,6 +560,8 @@ var defaultSysVars = []*SysVar{
 	{ScopeGlobal | ScopeSession, "min_examined_row_limit", "0"},
 	{ScopeGlobal, "sync_frm", "ON"},
 	{ScopeGlobal, "innodb_online_alter_log_max_size", "134217728"},
+	{ScopeGlobal | ScopeSession, TiDBTxnMode, ""},
+	{ScopeGlobal | ScopeSession, TiDBSlowLogThreshold, ""},
 }
 
 // SetNamesVariables is the system variable names related to set names statements.
@@ -576,8 +578,21 @@ const (
 	CharsetDatabase = "character_set_database"
 	// CollationDatabase is the name for collation_database system variable.
 	CollationDatabase = "collation_database"
+	// InnodbLockWaitTimeout is the name for innodb_lock_wait_timeout system variable,
+	// it is the seconds to wait for a pessimistic lock.
+	InnodbLockWaitTimeout = "innodb_lock_wait_timeout"
+	// TiDBTxnMode is the name for tidb_txn_mode system variable, the transactions are
+	// pessimistic if it is TxnModePessimistic, otherwise they are optimistic.
+	TiDBTxnMode = "tidb_txn_mode"
+	// TiDBSlowLogThreshold is the name for tidb_slow_log_threshold system variable, the statements
+	// running longer than it in milliseconds are written to the slow query log. The threshold of
+	// the server is used if it is empty.
+	TiDBSlowLogThreshold = "tidb_slow_log_threshold"
 )
 
+// TxnModePessimistic is the value of tidb_txn_mode for pessimistic transactions.
+const TxnModePessimistic = "pessimistic"
+
 // GlobalVarAccessor is the interface for accessing global scope system and status variables.
 type GlobalVarAccessor interface {
 	// GetGlobalSysVar gets the global system variable value for name.
@@ -0,0 +1,300 @@
+// Copyright 2015 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tidb
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/juju/errors"
+	"github.com/ngaut/log"
+	"github.com/pingcap/tidb/ast"
+	"github.com/pingcap/tidb/expression/builtin"
+	"github.com/pingcap/tidb/parser"
+	"github.com/pingcap/tidb/plan"
+	"github.com/pingcap/tidb/rset"
+	"github.com/pingcap/tidb/sessionctx/db"
+	"github.com/pingcap/tidb/sessionctx/variable"
+	"github.com/pingcap/tidb/util/stmtsummary"
+)
+
+// slowQueryLog is where the slow queries are written, it's disabled if w is nil.
+var slowQueryLog struct {
+	sync.Mutex
+	w io.Writer
+	// threshold is used if tidb_slow_log_threshold is empty.
+	threshold time.Duration
+}
+
+// SetSlowQueryLog makes the statements running longer than threshold written to the file at
+// path in the MySQL slow query log format, so it can be analyzed by pt-query-digest. The
+// threshold can be changed by the system variable tidb_slow_log_threshold.
+func SetSlowQueryLog(path string, threshold time.Duration) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	setSlowQueryWriter(f, threshold)
+	return nil
+}
+
+func setSlowQueryWriter(w io.Writer, threshold time.Duration) {
+	slowQueryLog.Lock()
+	defer slowQueryLog.Unlock()
+	if c, ok := slowQueryLog.w.(io.Closer); ok {
+		c.Close()
+	}
+	slowQueryLog.w = w
+	slowQueryLog.threshold = threshold
+}
+
+// stmtExecution is an execution of a statement, it's added to the statement summary and
+// written to the slow query log if it's slow when it's finished.
+type stmtExecution struct {
+	sql        string
+	normalized string
+	schema     string
+	start      time.Time
+	// slowThreshold is used if slowLog is true.
+	slowLog       bool
+	slowThreshold time.Duration
+	rowsSent      uint64
+	affectedRows  uint64
+	failed        bool
+}
+
+// normalizeStmts returns the normalized texts of the statements, they are empty if neither the
+// slow query log nor the statement summary is enabled.
+func (s *session) normalizeStmts(nodes []ast.StmtNode) []string {
+	normalized := make([]string, len(nodes))
+	slowQueryLog.Lock()
+	slowLog := slowQueryLog.w != nil
+	slowQueryLog.Unlock()
+	if s.initing || !slowLog && !stmtsummary.Global().Enabled() {
+		return normalized
+	}
+	for i, node := range nodes {
+		normalized[i] = parser.Normalize(node)
+	}
+	return normalized
+}
+
+// startStmt starts tracking the execution of the statement sql, it returns nil for the
+// statements run by bootstrap.
+func (s *session) startStmt(sql, normalized string) *stmtExecution {
+	if s.initing {
+		return nil
+	}
+	slowQueryLog.Lock()
+	slowLog, threshold := slowQueryLog.w != nil, slowQueryLog.threshold
+	slowQueryLog.Unlock()
+	if slowLog {
+		if val, ok := s.sysVar(variable.TiDBSlowLogThreshold); ok && val != "" {
+			ms, err := strconv.ParseInt(val, 10, 64)
+			if err == nil && ms >= 0 {
+				threshold = time.Duration(ms) * time.Millisecond
+			}
+		}
+	}
+	s.retryCount = 0
+	s.txnStartTS = 0
+	if s.txn != nil {
+		s.txnStartTS = s.txn.StartTS()
+	}
+	return &stmtExecution{
+		sql:           sql,
+		normalized:    normalized,
+		schema:        db.GetCurrentSchema(s),
+		start:         time.Now(),
+		slowLog:       slowLog,
+		slowThreshold: threshold,
+	}
+}
+
+// stmtExecuted is called after the statement of the execution e is run by runStmt, r and err
+// are returned by runStmt. The execution is finished if the statement doesn't return a
+// recordset, otherwise the returned recordset finishes it when it's closed.
+func (s *session) stmtExecuted(e *stmtExecution, r rset.Recordset, err error) rset.Recordset {
+	if e == nil {
+		return r
+	}
+	if err != nil {
+		e.failed = true
+		s.finishStmt(e)
+		return r
+	}
+	e.affectedRows = variable.GetSessionVars(s).AffectedRows
+	if r == nil {
+		s.finishStmt(e)
+		return nil
+	}
+	// The rows are fetched after the statement is executed.
+	return &stmtRecordset{Recordset: r, se: s, exec: e}
+}
+
+// finishStmt adds the execution to the statement summary, and writes it to the slow query
+// log if it runs longer than the threshold.
+func (s *session) finishStmt(e *stmtExecution) {
+	if e == nil {
+		return
+	}
+	queryTime := time.Since(e.start)
+	vars := variable.GetSessionVars(s)
+	// The digest is only computed if the execution is summarized or written to the log.
+	var digest string
+	if e.normalized != "" && stmtsummary.Global().Enabled() {
+		digest = parser.Digest(e.normalized)
+		stmtsummary.Global().Add(&stmtsummary.Execution{
+			SchemaName:    e.schema,
+			User:          strings.SplitN(vars.User, "@", 2)[0],
+			Digest:        digest,
+			NormalizedSQL: e.normalized,
+			SQL:           e.sql,
+			StartTime:     e.start,
+			Latency:       queryTime,
+			AffectedRows:  e.affectedRows,
+			Failed:        e.failed,
+		})
+	}
+	if !e.slowLog || queryTime < e.slowThreshold {
+		return
+	}
+	if digest == "" {
+		digest = parser.Digest(e.normalized)
+	}
+	connectionID, _ := s.Value(builtin.ConnectionIDKey).(int64)
+	entry := formatSlowQuery(&slowQueryEntry{
+		sql:          e.sql,
+		start:        e.start,
+		queryTime:    queryTime,
+		user:         vars.User,
+		db:           db.GetCurrentSchema(s),
+		connectionID: connectionID,
+		rowsSent:     e.rowsSent,
+		rowsExamined: vars.RowsExamined,
+		txnStartTS:   s.txnStartTS,
+		retryCount:   s.retryCount,
+		digest:       digest,
+	})
+
+	slowQueryLog.Lock()
+	defer slowQueryLog.Unlock()
+	if slowQueryLog.w == nil {
+		return
+	}
+	if _, err := slowQueryLog.w.Write(entry); err != nil {
+		log.Errorf("write slow query log error %v", err)
+	}
+}
+
+type slowQueryEntry struct {
+	sql          string
+	start        time.Time
+	queryTime    time.Duration
+	user         string
+	db           string
+	connectionID int64
+	rowsSent     uint64
+	rowsExamined uint64
+	txnStartTS   uint64
+	retryCount   int
+	digest       string
+}
+
+// formatSlowQuery formats the entry in the MySQL slow query log format, the fields not in
+// MySQL are written as the "# Name: value" comments like Percona Server.
+// See: https://dev.mysql.com/doc/refman/5.7/en/slow-query-log.html
+func formatSlowQuery(e *slowQueryEntry) []byte {
+	user, host := e.user, ""
+	if pos := strings.LastIndex(user, "@"); pos >= 0 {
+		user, host = user[:pos], user[pos+1:]
+	}
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "# Time: %s\n", e.start.UTC().Format("2006-01-02T15:04:05.000000Z"))
+	fmt.Fprintf(&buf, "# User@Host: %s[%s] @ %s []  Id: %d\n", user, user, host, e.connectionID)
+	fmt.Fprintf(&buf, "# Query_time: %.6f  Lock_time: 0.000000  Rows_sent: %d  Rows_examined: %d\n",
+		e.queryTime.Seconds(), e.rowsSent, e.rowsExamined)
+	fmt.Fprintf(&buf, "# Txn_start_ts: %d  Retry_count: %d\n", e.txnStartTS, e.retryCount)
+	fmt.Fprintf(&buf, "# Digest: %s\n", e.digest)
+	if e.db != "" {
+		fmt.Fprintf(&buf, "use %s;\n", e.db)
+	}
+	fmt.Fprintf(&buf, "SET timestamp=%d;\n", e.start.Unix())
+	buf.WriteString(strings.TrimRight(e.sql, "; \t\n"))
+	buf.WriteString(";\n")
+	return buf.Bytes()
+}
+
+// stmtRecordset counts the rows sent by the recordset and records the errors, the execution
+// is finished when it's closed since the rows are fetched after the statement is executed.
+type stmtRecordset struct {
+	rset.Recordset
+	se       *session
+	exec     *stmtExecution
+	finished bool
+}
+
+// Do implements rset.Recordset Do interface.
+func (rs *stmtRecordset) Do(f func(data []interface{}) (bool, error)) error {
+	err := rs.Recordset.Do(func(data []interface{}) (bool, error) {
+		rs.exec.rowsSent++
+		return f(data)
+	})
+	rs.exec.failed = rs.exec.failed || err != nil
+	return errors.Trace(err)
+}
+
+// FirstRow implements rset.Recordset FirstRow interface.
+func (rs *stmtRecordset) FirstRow() ([]interface{}, error) {
+	row, err := rs.Recordset.FirstRow()
+	if row != nil {
+		rs.exec.rowsSent++
+	}
+	rs.exec.failed = rs.exec.failed || err != nil
+	return row, errors.Trace(err)
+}
+
+// Rows implements rset.Recordset Rows interface.
+func (rs *stmtRecordset) Rows(limit, offset int) ([][]interface{}, error) {
+	rows, err := rs.Recordset.Rows(limit, offset)
+	rs.exec.rowsSent += uint64(len(rows))
+	rs.exec.failed = rs.exec.failed || err != nil
+	return rows, errors.Trace(err)
+}
+
+// Next implements rset.Recordset Next interface.
+func (rs *stmtRecordset) Next() (*plan.Row, error) {
+	row, err := rs.Recordset.Next()
+	if row != nil {
+		rs.exec.rowsSent++
+	}
+	rs.exec.failed = rs.exec.failed || err != nil
+	return row, errors.Trace(err)
+}
+
+// Close implements rset.Recordset Close interface.
+func (rs *stmtRecordset) Close() error {
+	err := rs.Recordset.Close()
+	if !rs.finished {
+		rs.se.finishStmt(rs.exec)
+		rs.finished = true
+	}
+	return errors.Trace(err)
+}
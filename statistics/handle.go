@@ -0,0 +1,87 @@
+// Copyright 2015 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statistics
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+type statsCache struct {
+	version int64
+	tables  map[int64]*Table
+}
+
+// Handle holds the statistics of the analyzed tables, it is safe for concurrent use.
+type Handle struct {
+	value atomic.Value
+	// mu serializes the updates.
+	mu sync.Mutex
+}
+
+// NewHandle creates a new Handle.
+func NewHandle() *Handle {
+	h := &Handle{}
+	h.value.Store(&statsCache{tables: map[int64]*Table{}})
+	return h
+}
+
+func (h *Handle) cache() *statsCache {
+	return h.value.Load().(*statsCache)
+}
+
+// Version returns the statistics version of the loaded statistics.
+func (h *Handle) Version() int64 {
+	return h.cache().version
+}
+
+// Set replaces all the statistics with the loaded ones of the version.
+func (h *Handle) Set(tables []*Table, version int64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	cache := &statsCache{
+		version: version,
+		tables:  make(map[int64]*Table, len(tables)),
+	}
+	for _, t := range tables {
+		cache.tables[t.TableID] = t
+	}
+	h.value.Store(cache)
+}
+
+// Update updates the statistics of a table.
+func (h *Handle) Update(t *Table) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	old := h.cache()
+	cache := &statsCache{
+		version: old.version,
+		tables:  make(map[int64]*Table, len(old.tables)+1),
+	}
+	for id, v := range old.tables {
+		cache.tables[id] = v
+	}
+	cache.tables[t.TableID] = t
+	h.value.Store(cache)
+}
+
+// Get gets the statistics of a table, returns nil if the table is not analyzed or h is nil.
+func (h *Handle) Get(tableID int64) *Table {
+	if h == nil {
+		return nil
+	}
+	return h.cache().tables[tableID]
+}
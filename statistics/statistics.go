@@ -0,0 +1,343 @@
+// Copyright 2015 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statistics
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"math/rand"
+	"sort"
+
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/util/codec"
+)
+
+// Default values used to build statistics.
+const (
+	DefaultSampleSize  = 10000
+	DefaultBucketCount = 256
+)
+
+// Table represents the statistics of a table.
+type Table struct {
+	TableID int64 `json:"table_id"`
+	// Count is the number of rows in the table.
+	Count   int64     `json:"count"`
+	Columns []*Column `json:"columns"`
+}
+
+// Column returns the statistics of the column with id, returns nil if the column is not analyzed.
+func (t *Table) Column(id int64) *Column {
+	if t == nil {
+		return nil
+	}
+	for _, col := range t.Columns {
+		if col.ID == id {
+			return col
+		}
+	}
+	return nil
+}
+
+// Marshal encodes the statistics to bytes to be stored.
+func (t *Table) Marshal() ([]byte, error) {
+	data, err := json.Marshal(t)
+	return data, errors.Trace(err)
+}
+
+// UnmarshalTable decodes the statistics stored by Table.Marshal.
+func UnmarshalTable(data []byte) (*Table, error) {
+	t := &Table{}
+	err := json.Unmarshal(data, t)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return t, nil
+}
+
+// Column represents the statistics of a column.
+// The not null values are described by an equal-depth histogram, every bucket holds
+// roughly the same number of values, and the values of a bucket are larger than
+// the values of the previous bucket.
+type Column struct {
+	ID int64 `json:"id"`
+	// NDV is the number of distinct not null values.
+	NDV       int64     `json:"ndv"`
+	NullCount int64     `json:"null_count"`
+	Buckets   []*Bucket `json:"buckets"`
+}
+
+// Bucket is a bucket of the histogram.
+type Bucket struct {
+	// Count is the number of values which are not larger than the upper bound,
+	// it is accumulated from the first bucket.
+	Count int64 `json:"count"`
+	// Repeats is the number of values which are equal to the upper bound.
+	Repeats int64 `json:"repeats"`
+	// LowerBound and UpperBound are the smallest and largest values in the bucket,
+	// encoded by codec.EncodeKey.
+	LowerBound []byte `json:"lower_bound"`
+	UpperBound []byte `json:"upper_bound"`
+}
+
+// notNullCount returns the number of the not null values.
+func (c *Column) notNullCount() float64 {
+	if len(c.Buckets) == 0 {
+		return 0
+	}
+	return float64(c.Buckets[len(c.Buckets)-1].Count)
+}
+
+// search returns the index of the first bucket whose upper bound is not less than the value.
+func (c *Column) search(value []byte) int {
+	return sort.Search(len(c.Buckets), func(i int) bool {
+		return bytes.Compare(c.Buckets[i].UpperBound, value) >= 0
+	})
+}
+
+// lowerCount returns the number of values in the buckets before the bucket at index i.
+func (c *Column) lowerCount(i int) float64 {
+	if i == 0 {
+		return 0
+	}
+	return float64(c.Buckets[i-1].Count)
+}
+
+// EqualRowCount estimates the number of rows whose column value equals to the value.
+// The value should have been converted to the type of the column.
+func (c *Column) EqualRowCount(value interface{}) (float64, error) {
+	if value == nil {
+		return float64(c.NullCount), nil
+	}
+	b, err := codec.EncodeKey(nil, value)
+	if err != nil {
+		return 0, errors.Trace(err)
+	}
+	i := c.search(b)
+	if i == len(c.Buckets) {
+		return 0, nil
+	}
+	bucket := c.Buckets[i]
+	if bytes.Equal(bucket.UpperBound, b) {
+		return float64(bucket.Repeats), nil
+	}
+	if bytes.Compare(b, bucket.LowerBound) < 0 {
+		// The value is between two buckets.
+		return 0, nil
+	}
+	// The value is inside the bucket, assume the values are distributed uniformly.
+	count := float64(bucket.Count-bucket.Repeats) - c.lowerCount(i)
+	if c.NDV > 0 {
+		count = math.Min(count, c.notNullCount()/float64(c.NDV))
+	}
+	return count, nil
+}
+
+// LessRowCount estimates the number of rows whose column value is less than the value.
+// The null values are not counted.
+func (c *Column) LessRowCount(value interface{}) (float64, error) {
+	if value == nil {
+		return 0, nil
+	}
+	b, err := codec.EncodeKey(nil, value)
+	if err != nil {
+		return 0, errors.Trace(err)
+	}
+	i := c.search(b)
+	if i == len(c.Buckets) {
+		return c.notNullCount(), nil
+	}
+	bucket := c.Buckets[i]
+	lower := c.lowerCount(i)
+	if bytes.Compare(b, bucket.LowerBound) <= 0 {
+		return lower, nil
+	}
+	inBucket := float64(bucket.Count-bucket.Repeats) - lower
+	if bytes.Equal(bucket.UpperBound, b) {
+		return lower + inBucket, nil
+	}
+	// The value is inside the bucket, assume half of the values are less than it.
+	return lower + inBucket/2, nil
+}
+
+// GreaterRowCount estimates the number of rows whose column value is greater than the value.
+func (c *Column) GreaterRowCount(value interface{}) (float64, error) {
+	less, err := c.LessRowCount(value)
+	if err != nil {
+		return 0, errors.Trace(err)
+	}
+	equal, err := c.EqualRowCount(value)
+	if err != nil {
+		return 0, errors.Trace(err)
+	}
+	if value == nil {
+		equal = 0
+	}
+	return math.Max(c.notNullCount()-less-equal, 0), nil
+}
+
+// BetweenRowCount estimates the number of rows whose column value is not less than a
+// and less than b.
+func (c *Column) BetweenRowCount(a, b interface{}) (float64, error) {
+	lessA, err := c.LessRowCount(a)
+	if err != nil {
+		return 0, errors.Trace(err)
+	}
+	lessB, err := c.LessRowCount(b)
+	if err != nil {
+		return 0, errors.Trace(err)
+	}
+	return math.Max(lessB-lessA, 0), nil
+}
+
+// SampleCollector collects a random sample of the rows with fixed size by reservoir sampling.
+type SampleCollector struct {
+	// Count is the number of the collected rows.
+	Count   int64
+	Samples [][]interface{}
+
+	maxSampleSize int
+	rand          *rand.Rand
+}
+
+// NewSampleCollector creates a SampleCollector which keeps at most maxSampleSize rows.
+func NewSampleCollector(maxSampleSize int, seed int64) *SampleCollector {
+	return &SampleCollector{
+		maxSampleSize: maxSampleSize,
+		rand:          rand.New(rand.NewSource(seed)),
+	}
+}
+
+// Collect collects a row, every collected row has the same chance to be in the sample.
+func (s *SampleCollector) Collect(row []interface{}) {
+	s.Count++
+	if len(s.Samples) < s.maxSampleSize {
+		s.Samples = append(s.Samples, row)
+		return
+	}
+	i := s.rand.Int63n(s.Count)
+	if i < int64(s.maxSampleSize) {
+		s.Samples[i] = row
+	}
+}
+
+// BuildTable builds the statistics of a table from the sampled rows,
+// the values of a sampled row are in the order of colIDs,
+// count is the number of rows in the table.
+func BuildTable(tableID int64, count int64, colIDs []int64, samples [][]interface{}, bucketCount int) (*Table, error) {
+	t := &Table{
+		TableID: tableID,
+		Count:   count,
+		Columns: make([]*Column, len(colIDs)),
+	}
+	for i, id := range colIDs {
+		values := make([]interface{}, len(samples))
+		for j, row := range samples {
+			values[j] = row[i]
+		}
+		col, err := buildColumn(id, count, values, bucketCount)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		t.Columns[i] = col
+	}
+	return t, nil
+}
+
+func buildColumn(id int64, count int64, values []interface{}, bucketCount int) (*Column, error) {
+	col := &Column{ID: id}
+	if len(values) == 0 {
+		return col, nil
+	}
+
+	var nullCount int64
+	keys := make([][]byte, 0, len(values))
+	for _, v := range values {
+		if v == nil {
+			nullCount++
+			continue
+		}
+		key, err := codec.EncodeKey(nil, v)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		keys = append(keys, key)
+	}
+	sort.Sort(byteSlices(keys))
+
+	// The counts in the sample are scaled to the whole table.
+	scale := float64(count) / float64(len(values))
+	col.NullCount = int64(math.Floor(float64(nullCount)*scale + 0.5))
+	col.NDV = estimateNDV(keys, count-col.NullCount)
+
+	depth := (len(keys) + bucketCount - 1) / bucketCount
+	for i, key := range keys {
+		last := len(col.Buckets) - 1
+		if last >= 0 && bytes.Equal(col.Buckets[last].UpperBound, key) {
+			col.Buckets[last].Count = int64(i + 1)
+			col.Buckets[last].Repeats++
+			continue
+		}
+		if last >= 0 && col.Buckets[last].Count-int64(col.lowerCount(last)) < int64(depth) {
+			col.Buckets[last].Count = int64(i + 1)
+			col.Buckets[last].Repeats = 1
+			col.Buckets[last].UpperBound = key
+			continue
+		}
+		col.Buckets = append(col.Buckets, &Bucket{
+			Count:      int64(i + 1),
+			Repeats:    1,
+			LowerBound: key,
+			UpperBound: key,
+		})
+	}
+	for _, bucket := range col.Buckets {
+		bucket.Count = int64(math.Floor(float64(bucket.Count)*scale + 0.5))
+		bucket.Repeats = int64(math.Floor(float64(bucket.Repeats)*scale + 0.5))
+	}
+	return col, nil
+}
+
+// estimateNDV estimates the number of distinct values of the column from the sorted
+// sampled keys with the Duj1 estimator, total is the number of not null values in the table.
+func estimateNDV(keys [][]byte, total int64) int64 {
+	var distinct, once int64
+	for i := 0; i < len(keys); {
+		j := i + 1
+		for j < len(keys) && bytes.Equal(keys[i], keys[j]) {
+			j++
+		}
+		distinct++
+		if j-i == 1 {
+			once++
+		}
+		i = j
+	}
+	n := int64(len(keys))
+	if n == 0 || n >= total {
+		return distinct
+	}
+	// Duj1: n*d / (n - f1 + f1*n/N), the values seen only once are likely
+	// to have more distinct values which are not sampled.
+	ndv := float64(n*distinct) / (float64(n-once) + float64(once*n)/float64(total))
+	ndv = math.Min(math.Max(ndv, float64(distinct)), float64(total))
+	return int64(ndv + 0.5)
+}
+
+type byteSlices [][]byte
+
+func (s byteSlices) Len() int           { return len(s) }
+func (s byteSlices) Less(i, j int) bool { return bytes.Compare(s[i], s[j]) < 0 }
+func (s byteSlices) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
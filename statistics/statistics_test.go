@@ -0,0 +1,137 @@
+// Copyright 2015 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package statistics
+
+import (
+	"testing"
+
+	. "github.com/pingcap/check"
+)
+
+func TestT(t *testing.T) {
+	TestingT(t)
+}
+
+var _ = Suite(&testStatisticsSuite{})
+
+type testStatisticsSuite struct {
+}
+
+func (s *testStatisticsSuite) TestBuildTable(c *C) {
+	// Column 1 is unique from 0 to 9999, column 2 has 100 distinct values and 1000 nulls.
+	collector := NewSampleCollector(DefaultSampleSize, 1)
+	for i := 0; i < 10000; i++ {
+		var v interface{}
+		if i%10 != 0 {
+			v = int64(i % 100)
+		}
+		collector.Collect([]interface{}{int64(i), v})
+	}
+	c.Assert(collector.Count, Equals, int64(10000))
+	c.Assert(collector.Samples, HasLen, DefaultSampleSize)
+
+	t, err := BuildTable(1, collector.Count, []int64{1, 2}, collector.Samples, 100)
+	c.Assert(err, IsNil)
+	c.Assert(t.Count, Equals, int64(10000))
+	c.Assert(t.Column(3), IsNil)
+
+	col := t.Column(1)
+	c.Assert(col.NDV, Equals, int64(10000))
+	c.Assert(col.NullCount, Equals, int64(0))
+	c.Assert(col.Buckets, HasLen, 100)
+	count, err := col.EqualRowCount(int64(5000))
+	c.Assert(err, IsNil)
+	c.Assert(count, Equals, float64(1))
+	count, err = col.LessRowCount(int64(5000))
+	c.Assert(err, IsNil)
+	c.Assert(count, Equals, float64(5000))
+	count, err = col.GreaterRowCount(int64(9000))
+	c.Assert(err, IsNil)
+	c.Assert(count, Equals, float64(999))
+	count, err = col.BetweenRowCount(int64(1000), int64(2000))
+	c.Assert(err, IsNil)
+	c.Assert(count, Equals, float64(1000))
+	count, err = col.LessRowCount(int64(-1))
+	c.Assert(err, IsNil)
+	c.Assert(count, Equals, float64(0))
+	count, err = col.EqualRowCount(int64(10000))
+	c.Assert(err, IsNil)
+	c.Assert(count, Equals, float64(0))
+
+	col = t.Column(2)
+	c.Assert(col.NDV, Equals, int64(90))
+	c.Assert(col.NullCount, Equals, int64(1000))
+	count, err = col.EqualRowCount(nil)
+	c.Assert(err, IsNil)
+	c.Assert(count, Equals, float64(1000))
+	count, err = col.EqualRowCount(int64(1))
+	c.Assert(err, IsNil)
+	c.Assert(count, Equals, float64(100))
+	count, err = col.LessRowCount(int64(50))
+	c.Assert(err, IsNil)
+	c.Assert(count, Equals, float64(4500))
+	count, err = col.GreaterRowCount(nil)
+	c.Assert(err, IsNil)
+	c.Assert(count, Equals, float64(9000))
+
+	data, err := t.Marshal()
+	c.Assert(err, IsNil)
+	t1, err := UnmarshalTable(data)
+	c.Assert(err, IsNil)
+	c.Assert(t1, DeepEquals, t)
+}
+
+func (s *testStatisticsSuite) TestSample(c *C) {
+	// The sample is scaled to the whole table.
+	collector := NewSampleCollector(1000, 1)
+	for i := 0; i < 100000; i++ {
+		collector.Collect([]interface{}{int64(i), int64(i % 10)})
+	}
+	c.Assert(collector.Samples, HasLen, 1000)
+
+	t, err := BuildTable(1, collector.Count, []int64{1, 2}, collector.Samples, 10)
+	c.Assert(err, IsNil)
+	col := t.Column(1)
+	c.Assert(col.NDV, Equals, int64(100000))
+	count, err := col.LessRowCount(int64(50000))
+	c.Assert(err, IsNil)
+	c.Assert(count > 40000 && count < 60000, IsTrue, Commentf("count %v", count))
+
+	col = t.Column(2)
+	c.Assert(col.NDV, Equals, int64(10))
+	count, err = col.EqualRowCount(int64(5))
+	c.Assert(err, IsNil)
+	c.Assert(count > 5000 && count < 15000, IsTrue, Commentf("count %v", count))
+}
+
+func (s *testStatisticsSuite) TestHandle(c *C) {
+	var h *Handle
+	c.Assert(h.Get(1), IsNil)
+
+	h = NewHandle()
+	t1 := &Table{TableID: 1, Count: 1}
+	t2 := &Table{TableID: 2, Count: 2}
+	h.Set([]*Table{t1}, 1)
+	c.Assert(h.Version(), Equals, int64(1))
+	c.Assert(h.Get(1), Equals, t1)
+	c.Assert(h.Get(2), IsNil)
+
+	h.Update(t2)
+	c.Assert(h.Version(), Equals, int64(1))
+	c.Assert(h.Get(1), Equals, t1)
+	c.Assert(h.Get(2), Equals, t2)
+
+	h.Set(nil, 2)
+	c.Assert(h.Get(1), IsNil)
+}
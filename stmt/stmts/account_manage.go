@@ -46,6 +46,7 @@ var (
 type CreateUserStmt struct {
 	IfNotExists bool
 	Specs       []*coldef.UserSpecification
+	Require     int
 
 	Text string
 }
@@ -84,6 +85,14 @@ func userExists(ctx context.Context, name string, host string) (bool, error) {
 	return row != nil, nil
 }
 
+// sslType returns the value of the Ssl_type column in mysql.user for the TLS requirement.
+func sslType(require int) string {
+	if require == coldef.RequireSSL {
+		return "ANY"
+	}
+	return ""
+}
+
 // parse user string into username and host
 // root@localhost -> roor, localhost
 func parseUser(user string) (string, string) {
@@ -112,13 +121,13 @@ func (s *CreateUserStmt) Exec(ctx context.Context) (rset.Recordset, error) {
 		} else {
 			pwd = util.EncodePassword(spec.AuthOpt.HashString)
 		}
-		user := fmt.Sprintf(`("%s", "%s", "%s")`, host, userName, pwd)
+		user := fmt.Sprintf(`("%s", "%s", "%s", "%s")`, host, userName, pwd, sslType(s.Require))
 		users = append(users, user)
 	}
 	if len(users) == 0 {
 		return nil, nil
 	}
-	sql := fmt.Sprintf(`INSERT INTO %s.%s (Host, User, Password) VALUES %s;`, mysql.SystemDB, mysql.UserTable, strings.Join(users, ", "))
+	sql := fmt.Sprintf(`INSERT INTO %s.%s (Host, User, Password, Ssl_type) VALUES %s;`, mysql.SystemDB, mysql.UserTable, strings.Join(users, ", "))
 	_, err := ctx.(sqlexec.RestrictedSQLExecutor).ExecRestrictedSQL(ctx, sql)
 	if err != nil {
 		return nil, errors.Trace(err)
@@ -51,6 +51,32 @@ func (s *testStmtSuite) TestCreateUserStmt(c *C) {
 	c.Assert(err, NotNil)
 }
 
+func (s *testStmtSuite) TestRequireSSL(c *C) {
+	getSSLType := func(user string) string {
+		tx := mustBegin(c, s.testDB)
+		rows, err := tx.Query(`SELECT Ssl_type FROM mysql.User WHERE User="` + user + `" and Host="localhost"`)
+		c.Assert(err, IsNil)
+		c.Assert(rows.Next(), IsTrue)
+		var sslType string
+		rows.Scan(&sslType)
+		rows.Close()
+		mustCommit(c, tx)
+		return sslType
+	}
+	mustExec(c, s.testDB, `CREATE USER 'testssl'@'localhost' IDENTIFIED BY '123' REQUIRE SSL;`)
+	c.Assert(getSSLType("testssl"), Equals, "ANY")
+	mustExec(c, s.testDB, `CREATE USER 'testnossl'@'localhost' IDENTIFIED BY '123';`)
+	c.Assert(getSSLType("testnossl"), Equals, "")
+
+	// GRANT without REQUIRE doesn't change the requirement.
+	mustExec(c, s.testDB, `GRANT SELECT ON *.* TO 'testssl'@'localhost';`)
+	c.Assert(getSSLType("testssl"), Equals, "ANY")
+	mustExec(c, s.testDB, `GRANT SELECT ON *.* TO 'testssl'@'localhost' REQUIRE NONE;`)
+	c.Assert(getSSLType("testssl"), Equals, "")
+	mustExec(c, s.testDB, `GRANT SELECT ON *.* TO 'testnossl'@'localhost' REQUIRE SSL;`)
+	c.Assert(getSSLType("testnossl"), Equals, "ANY")
+}
+
 func (s *testStmtSuite) TestSetPwdStmt(c *C) {
 	createUserSQL := `CREATE USER 'testpwd'@'localhost' IDENTIFIED BY '';`
 	tx := mustBegin(c, s.testDB)
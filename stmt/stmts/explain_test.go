@@ -22,7 +22,7 @@ import (
 )
 
 func (s *testStmtSuite) TestExplain(c *C) {
-	testSQL := "explain select 1"
+	testSQL := "explain insert into t values (1)"
 
 	stmtList, err := tidb.Compile(s.ctx, testSQL)
 	c.Assert(err, IsNil)
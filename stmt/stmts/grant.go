@@ -51,6 +51,7 @@ type GrantStmt struct {
 	ObjectType int
 	Level      *coldef.GrantLevel
 	Users      []*coldef.UserSpecification
+	Require    int
 	Text       string
 }
 
@@ -119,10 +120,23 @@ func (s *GrantStmt) Exec(ctx context.Context) (rset.Recordset, error) {
 				return nil, errors.Trace(err2)
 			}
 		}
+		if s.Require != coldef.RequireUnspecified {
+			err = s.setRequire(ctx, userName, host)
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+		}
 	}
 	return nil, nil
 }
 
+// Update the TLS requirement of the user in mysql.user.
+func (s *GrantStmt) setRequire(ctx context.Context, user string, host string) error {
+	sql := fmt.Sprintf(`UPDATE %s.%s SET Ssl_type="%s" WHERE User="%s" AND Host="%s"`, mysql.SystemDB, mysql.UserTable, sslType(s.Require), user, host)
+	_, err := ctx.(sqlexec.RestrictedSQLExecutor).ExecRestrictedSQL(ctx, sql)
+	return errors.Trace(err)
+}
+
 // Check if DB scope privilege entry exists in mysql.DB.
 // If unexists, insert a new one.
 func (s *GrantStmt) checkAndInitDBPriv(ctx context.Context, user string, host string) error {
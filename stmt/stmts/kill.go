@@ -0,0 +1,81 @@
+// Copyright 2015 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stmts
+
+import (
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/rset"
+	"github.com/pingcap/tidb/sessionctx/processlist"
+	"github.com/pingcap/tidb/stmt"
+	"github.com/pingcap/tidb/util/format"
+)
+
+var (
+	_ stmt.Statement = (*KillStmt)(nil)
+)
+
+// KillStmt is a statement to kill a query or connection.
+// See: https://dev.mysql.com/doc/refman/5.7/en/kill.html
+type KillStmt struct {
+	Query        bool
+	ConnectionID uint64
+
+	Text string
+}
+
+// Explain implements the stmt.Statement Explain interface.
+func (s *KillStmt) Explain(ctx context.Context, w format.Formatter) {
+	w.Format("%s\n", s.Text)
+}
+
+// IsDDL implements the stmt.Statement IsDDL interface.
+func (s *KillStmt) IsDDL() bool {
+	return false
+}
+
+// OriginText implements the stmt.Statement OriginText interface.
+func (s *KillStmt) OriginText() string {
+	return s.Text
+}
+
+// SetText implements the stmt.Statement SetText interface.
+func (s *KillStmt) SetText(text string) {
+	s.Text = text
+}
+
+// Exec implements the stmt.Statement Exec interface.
+func (s *KillStmt) Exec(ctx context.Context) (rset.Recordset, error) {
+	m := processlist.GetManager(ctx)
+	if m == nil {
+		return nil, errors.Trace(mysql.NewErrf(mysql.ErrNoSuchThread, "Unknown thread id: %d", s.ConnectionID))
+	}
+	for _, pi := range m.ShowProcessList() {
+		if pi.ID != s.ConnectionID {
+			continue
+		}
+		ok, err := processlist.CanAccess(ctx, pi.User)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		if !ok {
+			return nil, errors.Trace(mysql.NewErrf(mysql.ErrKillDenied, "You are not owner of thread %d", s.ConnectionID))
+		}
+	}
+	if !m.Kill(s.ConnectionID, s.Query) {
+		return nil, errors.Trace(mysql.NewErrf(mysql.ErrNoSuchThread, "Unknown thread id: %d", s.ConnectionID))
+	}
+	return nil, nil
+}
@@ -45,6 +45,9 @@ type PreparedStmt struct {
 	SQLStmt   stmt.Statement // The parsed statement from sql text with placeholder
 	Params    []*expression.ParamMarker
 	Fields    []*field.ResultField
+	// Normalized is the normalized text of the sql text for the slow query log and the
+	// statement summary, it's empty if neither of them is enabled.
+	Normalized string
 
 	Text string
 }
@@ -131,6 +134,16 @@ func (s *PreparedStmt) checkSelect(ctx context.Context, ss *SelectStmt) error {
 	return err
 }
 
+// GetPreparedStmt returns the prepared statement of the binary protocol with the id.
+func GetPreparedStmt(ctx context.Context, id uint32) (*PreparedStmt, bool) {
+	vs, ok := variable.GetSessionVars(ctx).PreparedStmts[getPreparedStmtIDKey(id)]
+	if !ok {
+		return nil, false
+	}
+	ps, ok := vs.(*PreparedStmt)
+	return ps, ok
+}
+
 // DeallocateStmt is a statement to release PreparedStmt.
 // See: https://dev.mysql.com/doc/refman/5.7/en/deallocate-prepare.html
 type DeallocateStmt struct {
@@ -0,0 +1,67 @@
+// Copyright 2015 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stmts
+
+import (
+	"github.com/pingcap/tidb/context"
+	"github.com/pingcap/tidb/rset"
+	"github.com/pingcap/tidb/sessionctx"
+	"github.com/pingcap/tidb/stmt"
+	"github.com/pingcap/tidb/table"
+	"github.com/pingcap/tidb/util/format"
+)
+
+var _ stmt.Statement = (*RenameTableStmt)(nil)
+
+// RenameTableStmt is a statement to rename one or more tables.
+// OldIdents[i] is renamed to NewIdents[i] in order.
+// See: https://dev.mysql.com/doc/refman/5.7/en/rename-table.html
+type RenameTableStmt struct {
+	OldIdents []table.Ident
+	NewIdents []table.Ident
+
+	Text string
+}
+
+// Explain implements the stmt.Statement Explain interface.
+func (s *RenameTableStmt) Explain(ctx context.Context, w format.Formatter) {
+	w.Format("%s\n", s.Text)
+}
+
+// IsDDL implements the stmt.Statement IsDDL interface.
+func (s *RenameTableStmt) IsDDL() bool {
+	return true
+}
+
+// OriginText implements the stmt.Statement OriginText interface.
+func (s *RenameTableStmt) OriginText() string {
+	return s.Text
+}
+
+// SetText implements the stmt.Statement SetText interface.
+func (s *RenameTableStmt) SetText(text string) {
+	s.Text = text
+}
+
+// Exec implements the stmt.Statement Exec interface.
+func (s *RenameTableStmt) Exec(ctx context.Context) (_ rset.Recordset, err error) {
+	oldIdents := make([]table.Ident, len(s.OldIdents))
+	newIdents := make([]table.Ident, len(s.NewIdents))
+	for i := range s.OldIdents {
+		oldIdents[i] = s.OldIdents[i].Full(ctx)
+		newIdents[i] = s.NewIdents[i].Full(ctx)
+	}
+	err = sessionctx.GetDomain(ctx).DDL().RenameTables(ctx, oldIdents, newIdents)
+	return nil, err
+}
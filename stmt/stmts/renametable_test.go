@@ -0,0 +1,43 @@
+// Copyright 2015 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stmts_test
+
+import (
+	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb"
+	"github.com/pingcap/tidb/stmt/stmts"
+)
+
+func (s *testStmtSuite) TestRenameTable(c *C) {
+	testSQL := "drop table if exists rename_t1, rename_t2; create table rename_t1 (c1 int);"
+	mustExec(c, s.testDB, testSQL)
+
+	testSQL = "rename table rename_t1 to rename_t2;"
+	stmtList, err := tidb.Compile(s.ctx, testSQL)
+	c.Assert(err, IsNil)
+	c.Assert(stmtList, HasLen, 1)
+
+	testStmt, ok := stmtList[0].(*stmts.RenameTableStmt)
+	c.Assert(ok, IsTrue)
+
+	c.Assert(testStmt.IsDDL(), IsTrue)
+	c.Assert(len(testStmt.OriginText()), Greater, 0)
+
+	mf := newMockFormatter()
+	testStmt.Explain(nil, mf)
+	c.Assert(mf.Len(), Greater, 0)
+
+	mustExec(c, s.testDB, testSQL)
+	mustExec(c, s.testDB, "drop table rename_t2;")
+}
@@ -14,8 +14,6 @@
 package stmts_test
 
 import (
-	"strings"
-
 	. "github.com/pingcap/check"
 )
 
@@ -44,11 +42,22 @@ func (s *testStmtSuite) TestSelectWithoutFrom(c *C) {
 func (s *testStmtSuite) TestSelectExplain(c *C) {
 	s.fillData(s.testDB, c)
 
-	strs := s.queryStrings(s.testDB, "explain select * from test where id = 1;", c)
-	// Must use index
-	if strings.Index(strs[0], "index") < 0 {
-		c.Fatalf("Should use index")
+	tx := mustBegin(c, s.testDB)
+	rows, err := tx.Query("explain select * from test where id = 1;")
+	c.Assert(err, IsNil)
+	var operators, infos []string
+	for rows.Next() {
+		var id, count, cost, info string
+		err = rows.Scan(&id, &count, &cost, &info)
+		c.Assert(err, IsNil)
+		operators = append(operators, id)
+		infos = append(infos, info)
 	}
+	rows.Close()
+	mustCommit(c, tx)
+	// Must use index, the rejected table scan follows the chosen plan.
+	c.Assert(operators, DeepEquals, []string{"Fields", "└─Filter", "  └─Index", "Rejected"})
+	c.Assert(infos[2], Equals, "table:test, index:primary(id), ranges:[1,1]")
 }
 
 func (s *testStmtSuite) TestSelectOrderBy(c *C) {
@@ -23,6 +23,7 @@ import (
 var (
 	_ kv.Snapshot = (*hbaseSnapshot)(nil)
 	_ kv.Iterator = (*hbaseIter)(nil)
+	_ kv.Iterator = (*hbaseReverseIter)(nil)
 )
 
 // hbaseBatchSize is used for go-themis Scanner.
@@ -120,6 +121,25 @@ func newInnerScanner(scanner *themis.ThemisScanner) kv.Iterator {
 	return it
 }
 
+// SeekReverse implements kv.Snapshot.SeekReverse interface.
+// Themis scanner only scans forward, so the rows before k are scanned and
+// buffered, then iterated in reverse order.
+func (s *hbaseSnapshot) SeekReverse(k kv.Key) (kv.Iterator, error) {
+	scanner := s.txn.GetScanner([]byte(s.storeName), nil, []byte(k), hbaseBatchSize)
+	defer scanner.Close()
+
+	it := &hbaseReverseIter{}
+	for {
+		r := scanner.Next()
+		if r == nil || len(r.Columns) == 0 {
+			break
+		}
+		it.rows = append(it.rows, r)
+	}
+	it.cursor = len(it.rows) - 1
+	return it, nil
+}
+
 func (s *hbaseSnapshot) Release() {
 	if s.txn != nil {
 		s.txn.Release()
@@ -162,3 +182,30 @@ func (it *hbaseIter) Close() {
 	}
 	it.rs = nil
 }
+
+type hbaseReverseIter struct {
+	rows   []*hbase.ResultRow
+	cursor int
+}
+
+func (it *hbaseReverseIter) Next() error {
+	it.cursor--
+	return nil
+}
+
+func (it *hbaseReverseIter) Valid() bool {
+	return it.cursor >= 0 && it.cursor < len(it.rows)
+}
+
+func (it *hbaseReverseIter) Key() string {
+	return string(it.rows[it.cursor].Row)
+}
+
+func (it *hbaseReverseIter) Value() []byte {
+	return it.rows[it.cursor].Columns[hbaseFmlAndQual].Value
+}
+
+func (it *hbaseReverseIter) Close() {
+	it.rows = nil
+	it.cursor = -1
+}
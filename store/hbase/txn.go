@@ -88,6 +88,10 @@ func (txn *hbaseTxn) String() string {
 	return fmt.Sprintf("%d", txn.tid)
 }
 
+func (txn *hbaseTxn) StartTS() uint64 {
+	return txn.tid
+}
+
 func (txn *hbaseTxn) Seek(k kv.Key) (kv.Iterator, error) {
 	log.Debugf("seek %q txn:%d", k, txn.tid)
 	iter, err := txn.UnionStore.Seek(k)
@@ -97,6 +101,15 @@ func (txn *hbaseTxn) Seek(k kv.Key) (kv.Iterator, error) {
 	return iter, nil
 }
 
+func (txn *hbaseTxn) SeekReverse(k kv.Key) (kv.Iterator, error) {
+	log.Debugf("seek reverse %q txn:%d", k, txn.tid)
+	iter, err := txn.UnionStore.SeekReverse(k)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	return iter, nil
+}
+
 func (txn *hbaseTxn) Delete(k kv.Key) error {
 	log.Debugf("delete %q txn:%d", k, txn.tid)
 	err := txn.UnionStore.Delete(k)
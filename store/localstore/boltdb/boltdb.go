@@ -77,6 +77,38 @@ func (d *db) Seek(startKey []byte) ([]byte, []byte, error) {
 	return key, value, nil
 }
 
+func (d *db) SeekReverse(key []byte) ([]byte, []byte, error) {
+	var k, v []byte
+	err := d.DB.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketName)
+		c := b.Cursor()
+		var ck, cv []byte
+		if key == nil {
+			ck, cv = c.Last()
+		} else {
+			// Seek positions on the first key >= key, the previous one is what we want.
+			ck, cv = c.Seek(key)
+			if ck == nil {
+				ck, cv = c.Last()
+			} else {
+				ck, cv = c.Prev()
+			}
+		}
+		if ck != nil {
+			k, v = bytes.CloneBytes(ck), bytes.CloneBytes(cv)
+		}
+		return nil
+	})
+
+	if err != nil {
+		return nil, nil, errors.Trace(err)
+	}
+	if k == nil {
+		return nil, nil, errors.Trace(engine.ErrNotFound)
+	}
+	return k, v, nil
+}
+
 func (d *db) NewBatch() engine.Batch {
 	return &batch{}
 }
@@ -21,6 +21,7 @@ import (
 	"github.com/ngaut/log"
 	"github.com/pingcap/tidb/kv"
 	"github.com/pingcap/tidb/store/localstore/engine"
+	"github.com/pingcap/tidb/store/localstore/tso"
 	"github.com/pingcap/tidb/terror"
 	"github.com/pingcap/tidb/util/bytes"
 )
@@ -150,9 +151,9 @@ func (gc *localstoreCompactor) filterExpiredKeys(keys []kv.EncodedKey) []kv.Enco
 			// Should not happen.
 			panic(err)
 		}
-		ts := localVersionToTimestamp(ver)
+		ts := tso.ExtractPhysical(ver.Ver)
 		// Check timeout keys.
-		if currentTS-int64(ts) >= int64(gc.policy.SafePoint) {
+		if currentTS-ts >= int64(gc.policy.SafePoint) {
 			// Skip first version.
 			if first {
 				first = false
@@ -74,8 +74,9 @@ func (s *localstoreCompactorTestSuite) TestCompactor(c *C) {
 	txn, _ = store.Begin()
 	txn.Set([]byte("a"), []byte("5"))
 	txn.Commit()
+	// The keys are the format version, the meta and 6 versions of a.
 	t := count(db)
-	c.Assert(t, Equals, 7)
+	c.Assert(t, Equals, 8)
 
 	// Simulating timeout
 	time.Sleep(1 * time.Second)
@@ -86,7 +87,7 @@ func (s *localstoreCompactorTestSuite) TestCompactor(c *C) {
 	time.Sleep(1 * time.Second)
 	// Do background GC
 	t = count(db)
-	c.Assert(t, Equals, 3)
+	c.Assert(t, Equals, 4)
 
 	compactor.Stop()
 }
@@ -32,6 +32,9 @@ type DB interface {
 	// Seek searches for the first key in the engine which is >= key in byte order, returns (nil, nil, ErrNotFound)
 	// if such key is not found.
 	Seek(key []byte) ([]byte, []byte, error)
+	// SeekReverse searches for the last key in the engine which is < key in byte order, if key is nil,
+	// it searches for the last key in the engine. Returns (nil, nil, ErrNotFound) if such key is not found.
+	SeekReverse(key []byte) ([]byte, []byte, error)
 	// NewBatch creates a Batch for writing.
 	NewBatch() Batch
 	// Commit writes the changed data in Batch.
@@ -63,6 +63,15 @@ func (d *db) Seek(startKey []byte) ([]byte, []byte, error) {
 	return iter.Key(), iter.Value(), nil
 }
 
+func (d *db) SeekReverse(key []byte) ([]byte, []byte, error) {
+	iter := d.DB.NewIterator(&util.Range{Limit: key}, nil)
+	defer iter.Release()
+	if ok := iter.Last(); !ok {
+		return nil, nil, errors.Trace(engine.ErrNotFound)
+	}
+	return iter.Key(), iter.Value(), nil
+}
+
 func (d *db) Commit(b engine.Batch) error {
 	batch, ok := b.(*leveldb.Batch)
 	if !ok {
@@ -95,4 +95,24 @@ func (s *testSuite) TestDB(c *C) {
 	c.Assert(err, NotNil)
 	c.Assert(k, IsNil)
 	c.Assert(v, IsNil)
+
+	k, v, err = db.SeekReverse(nil)
+	c.Assert(err, IsNil)
+	c.Assert(k, BytesEquals, []byte("b"))
+	c.Assert(v, BytesEquals, []byte("2"))
+
+	k, v, err = db.SeekReverse([]byte("c1"))
+	c.Assert(err, IsNil)
+	c.Assert(k, BytesEquals, []byte("b"))
+	c.Assert(v, BytesEquals, []byte("2"))
+
+	k, v, err = db.SeekReverse([]byte("b"))
+	c.Assert(err, IsNil)
+	c.Assert(k, BytesEquals, []byte("a"))
+	c.Assert(v, BytesEquals, []byte("2"))
+
+	k, v, err = db.SeekReverse([]byte("a"))
+	c.Assert(err, NotNil)
+	c.Assert(k, IsNil)
+	c.Assert(v, IsNil)
 }
@@ -14,14 +14,16 @@
 package localstore
 
 import (
+	"net/url"
+	"strings"
 	"sync"
 
 	"github.com/juju/errors"
 	"github.com/ngaut/log"
 	"github.com/pingcap/tidb/kv"
 	"github.com/pingcap/tidb/store/localstore/engine"
-	"github.com/pingcap/tidb/terror"
-	"github.com/pingcap/tidb/util/codec"
+	"github.com/pingcap/tidb/store/localstore/goleveldb"
+	"github.com/pingcap/tidb/store/localstore/tso"
 	"github.com/twinj/uuid"
 )
 
@@ -33,11 +35,16 @@ type dbStore struct {
 	mu sync.RWMutex
 	db engine.DB
 
-	txns       map[uint64]*dbTxn
-	keysLocked map[string]uint64
-	uuid       string
-	path       string
-	compactor  *localstoreCompactor
+	txns      map[uint64]*dbTxn
+	uuid      string
+	path      string
+	compactor *localstoreCompactor
+	// lockManager manages the pessimistic locks.
+	lockManager *lockManager
+
+	versionProvider kv.VersionProvider
+	// tsoClient is not nil if the versions are allocated by a remote oracle.
+	tsoClient *tso.Client
 
 	closed bool
 }
@@ -50,9 +57,7 @@ type storeCache struct {
 var (
 	globalID int64
 
-	providerMu            sync.RWMutex
-	globalVersionProvider kv.VersionProvider
-	mc                    storeCache
+	mc storeCache
 
 	// ErrDBClosed is the error meaning db is closed and we can use it anymore.
 	ErrDBClosed = errors.New("db is closed")
@@ -60,7 +65,6 @@ var (
 
 func init() {
 	mc.cache = make(map[string]*dbStore)
-	globalVersionProvider = &LocalVersionProvider{}
 }
 
 // Driver implements kv.Driver interface.
@@ -75,7 +79,27 @@ func IsLocalStore(s kv.Storage) bool {
 	return ok
 }
 
+// tsoFileSuffix is the suffix of the file which persists the high-water mark of the
+// in-process timestamp oracle, it is next to the storage path.
+const tsoFileSuffix = ".tso"
+
+// parseSchema parses the schema in the format path[?tso=host:port], the versions are
+// allocated by the timestamp oracle listening on host:port if tso is specified.
+func parseSchema(schema string) (path string, tsoAddr string, err error) {
+	pos := strings.Index(schema, "?")
+	if pos == -1 {
+		return schema, "", nil
+	}
+	path = schema[:pos]
+	params, err := url.ParseQuery(schema[pos+1:])
+	if err != nil {
+		return "", "", errors.Trace(err)
+	}
+	return path, params.Get("tso"), nil
+}
+
 // Open opens or creates a storage with specific format for a local engine Driver.
+// The schema is in the format path[?tso=host:port].
 func (d Driver) Open(schema string) (kv.Storage, error) {
 	mc.mu.Lock()
 	defer mc.mu.Unlock()
@@ -86,20 +110,52 @@ func (d Driver) Open(schema string) (kv.Storage, error) {
 		return store, nil
 	}
 
-	db, err := d.Driver.Open(schema)
+	path, tsoAddr, err := parseSchema(schema)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	var provider kv.VersionProvider
+	var client *tso.Client
+	if tsoAddr != "" {
+		client = tso.NewClient(tsoAddr)
+		provider = client
+	} else {
+		markPath := path + tsoFileSuffix
+		if _, ok := d.Driver.(goleveldb.MemoryDriver); ok {
+			// The data is not persisted, neither is the mark.
+			markPath = ""
+		}
+		provider, err = tso.NewOracle(markPath)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+
+	db, err := d.Driver.Open(path)
+	if err == nil {
+		if err = upgradeFormat(db); err != nil {
+			db.Close()
+		}
+	}
 	if err != nil {
+		if client != nil {
+			client.Close()
+		}
 		return nil, errors.Trace(err)
 	}
 
 	log.Info("New store", schema)
 	s := &dbStore{
-		txns:       make(map[uint64]*dbTxn),
-		keysLocked: make(map[string]uint64),
-		uuid:       uuid.NewV4().String(),
-		path:       schema,
-		db:         db,
-		compactor:  newLocalCompactor(localCompactDefaultPolicy, db),
-		closed:     false,
+		txns:            make(map[uint64]*dbTxn),
+		uuid:            uuid.NewV4().String(),
+		path:            schema,
+		db:              db,
+		compactor:       newLocalCompactor(localCompactDefaultPolicy, db),
+		lockManager:     newLockManager(),
+		versionProvider: provider,
+		tsoClient:       client,
+		closed:          false,
 	}
 	mc.cache[schema] = s
 	s.compactor.Start()
@@ -119,9 +175,7 @@ func (s *dbStore) GetSnapshot(ver kv.Version) (kv.Snapshot, error) {
 		return nil, errors.Trace(ErrDBClosed)
 	}
 
-	providerMu.RLock()
-	currentVer, err := globalVersionProvider.CurrentVersion()
-	providerMu.RUnlock()
+	currentVer, err := s.versionProvider.CurrentVersion()
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
@@ -138,14 +192,12 @@ func (s *dbStore) GetSnapshot(ver kv.Version) (kv.Snapshot, error) {
 }
 
 func (s *dbStore) CurrentVersion() (kv.Version, error) {
-	return globalVersionProvider.CurrentVersion()
+	return s.versionProvider.CurrentVersion()
 }
 
 // Begin transaction
 func (s *dbStore) Begin() (kv.Transaction, error) {
-	providerMu.RLock()
-	beginVer, err := globalVersionProvider.CurrentVersion()
-	providerMu.RUnlock()
+	beginVer, err := s.versionProvider.CurrentVersion()
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
@@ -159,6 +211,7 @@ func (s *dbStore) Begin() (kv.Transaction, error) {
 
 	txn := &dbTxn{
 		tid:          beginVer.Ver,
+		forUpdateTS:  beginVer.Ver,
 		valid:        true,
 		store:        s,
 		version:      kv.MinVersion,
@@ -182,94 +235,9 @@ func (s *dbStore) Close() error {
 	mc.mu.Lock()
 	defer mc.mu.Unlock()
 	s.compactor.Stop()
+	if s.tsoClient != nil {
+		s.tsoClient.Close()
+	}
 	delete(mc.cache, s.path)
 	return s.db.Close()
 }
-
-func (s *dbStore) writeBatch(b engine.Batch) error {
-	if b.Len() == 0 {
-		return nil
-	}
-
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	if s.closed {
-		return errors.Trace(ErrDBClosed)
-	}
-
-	err := s.db.Commit(b)
-	if err != nil {
-		log.Error(err)
-		return errors.Trace(err)
-	}
-
-	return nil
-}
-
-func (s *dbStore) newBatch() engine.Batch {
-	return s.db.NewBatch()
-}
-
-// Both lock and unlock are used for simulating scenario of percolator papers.
-func (s *dbStore) tryConditionLockKey(tid uint64, key string) error {
-	metaKey := codec.EncodeBytes(nil, []byte(key))
-
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	if s.closed {
-		return errors.Trace(ErrDBClosed)
-	}
-
-	if _, ok := s.keysLocked[key]; ok {
-		return errors.Trace(kv.ErrLockConflict)
-	}
-
-	currValue, err := s.db.Get(metaKey)
-	if terror.ErrorEqual(err, engine.ErrNotFound) {
-		s.keysLocked[key] = tid
-		return nil
-	}
-	if err != nil {
-		return errors.Trace(err)
-	}
-
-	// key not exist.
-	if currValue == nil {
-		s.keysLocked[key] = tid
-		return nil
-	}
-	_, ver, err := codec.DecodeUint(currValue)
-	if err != nil {
-		return errors.Trace(err)
-	}
-
-	// If there's newer version of this key, returns error.
-	if ver > tid {
-		log.Warnf("txn:%d, tryLockKey condition not match for key %s, currValue:%q", tid, key, currValue)
-		return errors.Trace(kv.ErrConditionNotMatch)
-	}
-
-	s.keysLocked[key] = tid
-	return nil
-}
-
-func (s *dbStore) unLockKeys(keys ...string) error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	if s.closed {
-		return errors.Trace(ErrDBClosed)
-	}
-
-	for _, key := range keys {
-		if _, ok := s.keysLocked[key]; !ok {
-			return errors.Trace(kv.ErrNotExist)
-		}
-
-		delete(s.keysLocked, key)
-	}
-
-	return nil
-}
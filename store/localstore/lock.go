@@ -0,0 +1,488 @@
+// Copyright 2015 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package localstore
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/juju/errors"
+	"github.com/ngaut/log"
+	"github.com/pingcap/tidb/kv"
+	"github.com/pingcap/tidb/store/localstore/engine"
+	"github.com/pingcap/tidb/terror"
+	"github.com/pingcap/tidb/util/bytes"
+	"github.com/pingcap/tidb/util/codec"
+)
+
+// Transactions are committed in the way of percolator:
+// In the prewrite phase, every key written or locked by the transaction is locked
+// with the transaction's start version, one of the keys is chosen as the primary and
+// the others are secondaries which point to the primary. The prewrite fails if a key
+// is locked by another transaction or committed after the start version.
+// In the commit phase, the primary is committed with the commit version first, which
+// commits the transaction, then the secondaries are committed.
+// The locks are stored in the meta keys, so a reader meets the lock before the data
+// of an unfinished transaction. The lock of a crashed transaction is cleaned up after
+// lockTTL since it is written, according to the state of its primary.
+
+// Operations of a lock.
+const (
+	opPut byte = iota + 1
+	opDelete
+	// opLock locks a key without writing it.
+	opLock
+)
+
+// lockTTL is the time in milliseconds after the prewrite when a lock can be cleaned up by
+// the other transactions, it must be less than the SafePoint of the compactor, so the
+// commit record of the primary is not compacted before the secondaries are resolved.
+const lockTTL = 3000
+
+// lockWaitInterval is the interval for a reader to wait for a lock to be released.
+const lockWaitInterval = 10 * time.Millisecond
+
+type mvccLock struct {
+	startTS uint64
+	// prewriteTime is the physical time in milliseconds when the lock is written, a
+	// transaction may run long before the prewrite, so it's not the time of startTS.
+	prewriteTime int64
+	primary      kv.Key
+	op           byte
+	value        []byte
+}
+
+func (l *mvccLock) expired() bool {
+	return physicalNow()-l.prewriteTime >= lockTTL
+}
+
+func physicalNow() int64 {
+	return time.Now().UnixNano() / int64(time.Millisecond)
+}
+
+// mvccMeta is the value of a meta key, which is the latest commit version of the key,
+// followed by the lock if the key is locked.
+type mvccMeta struct {
+	commitTS uint64
+	lock     *mvccLock
+}
+
+func encodeMeta(m mvccMeta) []byte {
+	b := codec.EncodeUint(nil, m.commitTS)
+	if m.lock == nil {
+		return b
+	}
+	b = codec.EncodeUint(b, m.lock.startTS)
+	b = codec.EncodeInt(b, m.lock.prewriteTime)
+	b = codec.EncodeBytes(b, m.lock.primary)
+	b = append(b, m.lock.op)
+	return append(b, m.lock.value...)
+}
+
+func decodeMeta(b []byte) (mvccMeta, error) {
+	var m mvccMeta
+	b, commitTS, err := codec.DecodeUint(b)
+	if err != nil {
+		return m, errors.Trace(err)
+	}
+	m.commitTS = commitTS
+	if len(b) == 0 {
+		return m, nil
+	}
+	l := &mvccLock{}
+	b, l.startTS, err = codec.DecodeUint(b)
+	if err != nil {
+		return m, errors.Trace(err)
+	}
+	b, l.prewriteTime, err = codec.DecodeInt(b)
+	if err != nil {
+		return m, errors.Trace(err)
+	}
+	b, l.primary, err = codec.DecodeBytes(b)
+	if err != nil {
+		return m, errors.Trace(err)
+	}
+	if len(b) == 0 {
+		return m, errors.Trace(ErrInvalidEncodedKey)
+	}
+	l.op, l.value = b[0], b[1:]
+	m.lock = l
+	return m, nil
+}
+
+// A committed value is prefixed with the start version of the transaction which
+// writes it, so the commit record of a primary can be found by the start version.
+func encodeValue(startTS uint64, v []byte) []byte {
+	return append(codec.EncodeUint(nil, startTS), v...)
+}
+
+func decodeValue(b []byte) (uint64, []byte, error) {
+	b, startTS, err := codec.DecodeUint(b)
+	return startTS, b, errors.Trace(err)
+}
+
+// formatVersion is the version of the data format, the committed values are prefixed with
+// the start versions since version 1.
+const formatVersion = 1
+
+// formatVersionKey is the key of the format version, it is less than all the encoded keys,
+// so the seeks for the encoded keys never meet it except seekReverse.
+var formatVersionKey = []byte{0}
+
+// ErrUnsupportedFormat is returned when the data is written in a newer format.
+var ErrUnsupportedFormat = errors.New("unsupported localstore data format")
+
+// upgradeBatchSize is the max number of the values upgraded in a batch.
+var upgradeBatchSize = 1024
+
+// upgradeFormat upgrades the data written in the old format when the db is opened. The values
+// written before the format version is stored are prefixed with start version 0. The values
+// are upgraded in batches, every batch stores version 0 followed by the last key it upgrades
+// as the format version, so an interrupted upgrade resumes after the key and no value is
+// prefixed twice. The format version is stored with the last batch.
+func upgradeFormat(db engine.DB) error {
+	v, err := db.Get(formatVersionKey)
+	if err != nil && !terror.ErrorEqual(err, engine.ErrNotFound) {
+		return errors.Trace(err)
+	}
+	k := formatVersionKey
+	if len(v) > 0 {
+		lastKey, ver, err1 := codec.DecodeUint(v)
+		if err1 != nil {
+			return errors.Trace(err1)
+		}
+		if ver > formatVersion {
+			return errors.Trace(ErrUnsupportedFormat)
+		}
+		if ver == formatVersion {
+			return nil
+		}
+		log.Warnf("resume the upgrade to localstore format %d", formatVersion)
+		k = lastKey
+	}
+
+	var total int
+	for k != nil {
+		var n int
+		k, n, err = upgradeFormatBatch(db, k)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		total += n
+	}
+	if total > 0 {
+		log.Warnf("upgrade %d values to localstore format %d", total, formatVersion)
+	}
+	return nil
+}
+
+// upgradeFormatBatch upgrades at most upgradeBatchSize values after the key k. It returns the
+// last key it upgrades and the number of the upgraded values, the key is nil if all the values
+// are upgraded.
+func upgradeFormatBatch(db engine.DB, k []byte) ([]byte, int, error) {
+	b := db.NewBatch()
+	for b.Len() < upgradeBatchSize {
+		next, v, err := db.Seek(kv.EncodedKey(k).Next())
+		if terror.ErrorEqual(err, engine.ErrNotFound) {
+			n := b.Len()
+			b.Put(formatVersionKey, codec.EncodeUint(nil, formatVersion))
+			return nil, n, errors.Trace(db.Commit(b))
+		}
+		if err != nil {
+			return nil, 0, errors.Trace(err)
+		}
+		k = bytes.CloneBytes(next)
+		_, ver, err := MvccDecode(k)
+		if err != nil {
+			return nil, 0, errors.Trace(err)
+		}
+		// The meta keys are compatible, they're the latest commit versions.
+		if ver.Ver != 0 {
+			b.Put(k, encodeValue(0, v))
+		}
+	}
+	n := b.Len()
+	b.Put(formatVersionKey, append(codec.EncodeUint(nil, 0), k...))
+	return k, n, errors.Trace(db.Commit(b))
+}
+
+// lockedError is returned by a read which meets a lock, the lock should be
+// resolved before the read is retried.
+type lockedError struct {
+	key  kv.Key
+	lock *mvccLock
+}
+
+func (e *lockedError) Error() string {
+	return fmt.Sprintf("key %q is locked by txn %d", e.key, e.lock.startTS)
+}
+
+// mutation is a key written or locked by a transaction.
+type mutation struct {
+	key   kv.Key
+	op    byte
+	value []byte
+}
+
+// getMeta gets the meta of the key, the caller must hold the store lock.
+func (s *dbStore) getMeta(k kv.Key) (mvccMeta, error) {
+	v, err := s.db.Get(codec.EncodeBytes(nil, k))
+	if terror.ErrorEqual(err, engine.ErrNotFound) || (err == nil && v == nil) {
+		return mvccMeta{}, nil
+	}
+	if err != nil {
+		return mvccMeta{}, errors.Trace(err)
+	}
+	m, err := decodeMeta(v)
+	return m, errors.Trace(err)
+}
+
+func putMeta(b engine.Batch, k kv.Key, m mvccMeta) {
+	metaKey := codec.EncodeBytes(nil, k)
+	if m.commitTS == 0 && m.lock == nil {
+		// The key has never been committed.
+		b.Delete(metaKey)
+		return
+	}
+	b.Put(metaKey, encodeMeta(m))
+}
+
+func commitLock(b engine.Batch, k kv.Key, m mvccMeta, commitTS uint64) {
+	if m.lock.op != opLock {
+		var v []byte
+		if m.lock.op == opPut {
+			v = m.lock.value
+		}
+		b.Put(MvccEncodeVersionKey(k, kv.NewVersion(commitTS)), encodeValue(m.lock.startTS, v))
+		if commitTS > m.commitTS {
+			m.commitTS = commitTS
+		}
+	}
+	putMeta(b, k, mvccMeta{commitTS: m.commitTS})
+}
+
+func rollbackLock(b engine.Batch, k kv.Key, m mvccMeta) {
+	putMeta(b, k, mvccMeta{commitTS: m.commitTS})
+}
+
+// prewrite locks the keys of the mutations for the transaction of startTS, it fails if
+// a key is committed after conflictTS or locked by another pessimistic transaction.
+func (s *dbStore) prewrite(startTS, conflictTS uint64, primary kv.Key, mutations []*mutation) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return errors.Trace(ErrDBClosed)
+	}
+
+	b := s.db.NewBatch()
+	now := physicalNow()
+	for _, m := range mutations {
+		if s.lockManager.isLockedByOthers(startTS, m.key) {
+			return errors.Trace(kv.ErrLockConflict)
+		}
+		meta, err := s.getMeta(m.key)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if meta.lock != nil && meta.lock.startTS != startTS {
+			// The lock of a crashed transaction is cleaned up, then the key is checked again.
+			err = s.resolveLockLocked(m.key, meta.lock)
+			if err != nil {
+				return errors.Trace(err)
+			}
+			meta, err = s.getMeta(m.key)
+			if err != nil {
+				return errors.Trace(err)
+			}
+		}
+		if meta.commitTS > conflictTS {
+			log.Warnf("txn:%d, key %q is committed at %d", startTS, m.key, meta.commitTS)
+			return errors.Trace(kv.ErrWriteConflict)
+		}
+		meta.lock = &mvccLock{
+			startTS:      startTS,
+			prewriteTime: now,
+			primary:      primary,
+			op:           m.op,
+			value:        m.value,
+		}
+		putMeta(b, m.key, meta)
+	}
+	return errors.Trace(s.db.Commit(b))
+}
+
+// commitKeys commits the locks of the transaction of startTS with commitTS. If the
+// lock of a key is not found, it is resolved by another transaction, it's an error for
+// the primary because the transaction may have been rolled back.
+func (s *dbStore) commitKeys(startTS, commitTS uint64, keys []kv.Key, primary bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return errors.Trace(ErrDBClosed)
+	}
+
+	b := s.db.NewBatch()
+	for _, k := range keys {
+		meta, err := s.getMeta(k)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if meta.lock == nil || meta.lock.startTS != startTS {
+			if primary {
+				log.Warnf("txn:%d, lock of the primary %q is not found", startTS, k)
+				return errors.Trace(kv.ErrRetryable)
+			}
+			continue
+		}
+		commitLock(b, k, meta, commitTS)
+	}
+	return errors.Trace(s.db.Commit(b))
+}
+
+// rollbackKeys removes the locks of the transaction of startTS.
+func (s *dbStore) rollbackKeys(startTS uint64, keys []kv.Key) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return errors.Trace(ErrDBClosed)
+	}
+
+	b := s.db.NewBatch()
+	for _, k := range keys {
+		meta, err := s.getMeta(k)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if meta.lock == nil || meta.lock.startTS != startTS {
+			continue
+		}
+		rollbackLock(b, k, meta)
+	}
+	return errors.Trace(s.db.Commit(b))
+}
+
+// latestCommit returns the latest commit version of the key, it waits for the lock of
+// another transaction to be resolved.
+func (s *dbStore) latestCommit(startTS uint64, k kv.Key) (uint64, error) {
+	for {
+		s.mu.RLock()
+		if s.closed {
+			s.mu.RUnlock()
+			return 0, errors.Trace(ErrDBClosed)
+		}
+		meta, err := s.getMeta(k)
+		s.mu.RUnlock()
+		if err != nil {
+			return 0, errors.Trace(err)
+		}
+		if meta.lock == nil || meta.lock.startTS == startTS {
+			return meta.commitTS, nil
+		}
+		err = s.resolveLock(k, meta.lock)
+		if terror.ErrorEqual(err, kv.ErrLockConflict) {
+			time.Sleep(lockWaitInterval)
+			continue
+		}
+		if err != nil {
+			return 0, errors.Trace(err)
+		}
+	}
+}
+
+// resolveLock cleans up the lock of the key, it returns kv.ErrLockConflict if the
+// transaction of the lock may be still running.
+func (s *dbStore) resolveLock(k kv.Key, lock *mvccLock) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return errors.Trace(ErrDBClosed)
+	}
+	return errors.Trace(s.resolveLockLocked(k, lock))
+}
+
+func (s *dbStore) resolveLockLocked(k kv.Key, lock *mvccLock) error {
+	meta, err := s.getMeta(k)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if meta.lock == nil || meta.lock.startTS != lock.startTS {
+		// Already resolved.
+		return nil
+	}
+
+	b := s.db.NewBatch()
+	primaryMeta, err := s.getMeta(lock.primary)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if primaryMeta.lock != nil && primaryMeta.lock.startTS == lock.startTS {
+		// The transaction is not committed.
+		if !lock.expired() {
+			return errors.Trace(kv.ErrLockConflict)
+		}
+		log.Warnf("rollback expired txn:%d, key %q", lock.startTS, k)
+		rollbackLock(b, lock.primary, primaryMeta)
+		if k.Cmp(lock.primary) != 0 {
+			rollbackLock(b, k, meta)
+		}
+		return errors.Trace(s.db.Commit(b))
+	}
+
+	// The primary is either committed or rolled back.
+	commitTS, err := s.findCommit(lock.primary, lock.startTS)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if commitTS > 0 {
+		commitLock(b, k, meta, commitTS)
+	} else {
+		rollbackLock(b, k, meta)
+	}
+	return errors.Trace(s.db.Commit(b))
+}
+
+// findCommit returns the commit version of the key written by the transaction of
+// startTS, it returns 0 if the key is not committed by the transaction.
+func (s *dbStore) findCommit(k kv.Key, startTS uint64) (uint64, error) {
+	mvccKey := MvccEncodeVersionKey(k, kv.MaxVersion)
+	for {
+		mvccK, v, err := s.db.Seek(mvccKey)
+		if terror.ErrorEqual(err, engine.ErrNotFound) {
+			return 0, nil
+		}
+		if err != nil {
+			return 0, errors.Trace(err)
+		}
+		key, ver, err := MvccDecode(mvccK)
+		if err != nil {
+			return 0, errors.Trace(err)
+		}
+		// The versions are in descending order.
+		if key.Cmp(k) != 0 || ver.Ver <= startTS {
+			return 0, nil
+		}
+		ts, _, err := decodeValue(v)
+		if err != nil {
+			return 0, errors.Trace(err)
+		}
+		if ts == startTS {
+			return ver.Ver, nil
+		}
+		mvccKey = kv.EncodedKey(mvccK).Next()
+	}
+}
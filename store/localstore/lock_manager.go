@@ -0,0 +1,123 @@
+// Copyright 2015 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package localstore
+
+import (
+	"sync"
+	"time"
+
+	"github.com/juju/errors"
+	"github.com/ngaut/log"
+	"github.com/pingcap/tidb/kv"
+)
+
+// keyLock is a pessimistic lock of a key, released is closed when the lock is released.
+type keyLock struct {
+	owner    uint64
+	released chan struct{}
+}
+
+// lockManager manages the pessimistic locks of the transactions. A transaction waits
+// for at most one lock at a time, so the wait-for graph is a set of chains, a deadlock
+// is found if the chain from the owner of the lock leads back to the waiter.
+type lockManager struct {
+	mu    sync.Mutex
+	locks map[string]*keyLock
+	// owned is the keys locked by every transaction.
+	owned map[uint64][]string
+	// waitFor maps a waiting transaction to the owner of the lock it waits for.
+	waitFor map[uint64]uint64
+}
+
+func newLockManager() *lockManager {
+	return &lockManager{
+		locks:   make(map[string]*keyLock),
+		owned:   make(map[uint64][]string),
+		waitFor: make(map[uint64]uint64),
+	}
+}
+
+// acquire locks the key for the transaction of startTS, it waits for the lock held by
+// another transaction for at most timeout.
+func (lm *lockManager) acquire(startTS uint64, key kv.Key, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		lm.mu.Lock()
+		l, ok := lm.locks[string(key)]
+		if !ok {
+			lm.locks[string(key)] = &keyLock{owner: startTS, released: make(chan struct{})}
+			lm.owned[startTS] = append(lm.owned[startTS], string(key))
+		}
+		if !ok || l.owner == startTS {
+			delete(lm.waitFor, startTS)
+			lm.mu.Unlock()
+			return nil
+		}
+		if lm.leadsTo(l.owner, startTS) {
+			delete(lm.waitFor, startTS)
+			lm.mu.Unlock()
+			log.Warnf("txn:%d, deadlock found when waiting for txn:%d, key %q", startTS, l.owner, key)
+			return errors.Trace(kv.ErrDeadlock)
+		}
+		lm.waitFor[startTS] = l.owner
+		lm.mu.Unlock()
+
+		timer := time.NewTimer(deadline.Sub(time.Now()))
+		select {
+		case <-l.released:
+			timer.Stop()
+		case <-timer.C:
+			lm.mu.Lock()
+			delete(lm.waitFor, startTS)
+			lm.mu.Unlock()
+			return errors.Trace(kv.ErrLockWaitTimeout)
+		}
+	}
+}
+
+// leadsTo checks whether the chain of waiting transactions from txn reaches target,
+// the caller must hold lm.mu.
+func (lm *lockManager) leadsTo(txn, target uint64) bool {
+	for {
+		if txn == target {
+			return true
+		}
+		next, ok := lm.waitFor[txn]
+		if !ok {
+			return false
+		}
+		txn = next
+	}
+}
+
+// isLockedByOthers checks whether the key is locked by a transaction other than startTS.
+func (lm *lockManager) isLockedByOthers(startTS uint64, key kv.Key) bool {
+	lm.mu.Lock()
+	defer lm.mu.Unlock()
+	l, ok := lm.locks[string(key)]
+	return ok && l.owner != startTS
+}
+
+// release releases all the locks of the transaction of startTS.
+func (lm *lockManager) release(startTS uint64) {
+	lm.mu.Lock()
+	defer lm.mu.Unlock()
+	for _, key := range lm.owned[startTS] {
+		l := lm.locks[key]
+		delete(lm.locks, key)
+		close(l.released)
+	}
+	delete(lm.owned, startTS)
+	delete(lm.waitFor, startTS)
+}
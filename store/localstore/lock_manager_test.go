@@ -0,0 +1,123 @@
+// Copyright 2015 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package localstore
+
+import (
+	"time"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/kv"
+	"github.com/pingcap/tidb/terror"
+)
+
+var _ = Suite(&testLockManagerSuite{})
+
+type testLockManagerSuite struct {
+}
+
+func (t *testLockManagerSuite) TestAcquire(c *C) {
+	lm := newLockManager()
+	c.Assert(lm.acquire(1, []byte("a"), time.Second), IsNil)
+	// The lock is reentrant.
+	c.Assert(lm.acquire(1, []byte("a"), time.Second), IsNil)
+	c.Assert(lm.isLockedByOthers(1, []byte("a")), IsFalse)
+	c.Assert(lm.isLockedByOthers(2, []byte("a")), IsTrue)
+
+	err := lm.acquire(2, []byte("a"), 10*time.Millisecond)
+	c.Assert(terror.ErrorEqual(err, kv.ErrLockWaitTimeout), IsTrue)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- lm.acquire(2, []byte("a"), time.Second)
+	}()
+	time.Sleep(10 * time.Millisecond)
+	select {
+	case <-done:
+		c.Fatal("acquire should wait for the lock")
+	default:
+	}
+	lm.release(1)
+	c.Assert(<-done, IsNil)
+	c.Assert(lm.isLockedByOthers(1, []byte("a")), IsTrue)
+	lm.release(2)
+	c.Assert(lm.isLockedByOthers(1, []byte("a")), IsFalse)
+}
+
+func (t *testLockManagerSuite) TestDeadlock(c *C) {
+	lm := newLockManager()
+	c.Assert(lm.acquire(1, []byte("a"), time.Second), IsNil)
+	c.Assert(lm.acquire(2, []byte("b"), time.Second), IsNil)
+	c.Assert(lm.acquire(3, []byte("c"), time.Second), IsNil)
+
+	done := make(chan error, 2)
+	go func() {
+		done <- lm.acquire(1, []byte("b"), time.Second)
+	}()
+	go func() {
+		done <- lm.acquire(2, []byte("c"), time.Second)
+	}()
+	time.Sleep(10 * time.Millisecond)
+	// 3 waits for 1, which waits for 2, which waits for 3.
+	err := lm.acquire(3, []byte("a"), time.Second)
+	c.Assert(terror.ErrorEqual(err, kv.ErrDeadlock), IsTrue)
+
+	lm.release(3)
+	c.Assert(<-done, IsNil)
+	lm.release(2)
+	c.Assert(<-done, IsNil)
+	lm.release(1)
+}
+
+func (t *testLockManagerSuite) TestPessimisticTxn(c *C) {
+	s := createMemStore().(*dbStore)
+	defer s.Close()
+
+	txn1, err := s.Begin()
+	c.Assert(err, IsNil)
+	txn1.SetOption(kv.PessimisticLockWaitTimeout, 10*time.Millisecond)
+	txn2, err := s.Begin()
+	c.Assert(err, IsNil)
+	txn2.SetOption(kv.PessimisticLockWaitTimeout, 10*time.Millisecond)
+
+	c.Assert(txn1.LockKeys([]byte("a")), IsNil)
+	err = txn2.LockKeys([]byte("a"))
+	c.Assert(terror.ErrorEqual(err, kv.ErrLockWaitTimeout), IsTrue)
+
+	// An optimistic transaction can't commit the keys locked by a pessimistic one.
+	txn3, err := s.Begin()
+	c.Assert(err, IsNil)
+	c.Assert(txn3.Set([]byte("a"), []byte("a3")), IsNil)
+	err = txn3.Commit()
+	c.Assert(terror.ErrorEqual(err, kv.ErrLockConflict), IsTrue)
+
+	c.Assert(txn1.Set([]byte("a"), []byte("a1")), IsNil)
+	c.Assert(txn1.Commit(), IsNil)
+
+	// The key is committed after txn2 starts, txn2 locks it and reads the latest value.
+	err = txn2.LockKeys([]byte("a"))
+	c.Assert(terror.ErrorEqual(err, kv.ErrWriteConflict), IsTrue)
+	v, err := txn2.Get([]byte("a"))
+	c.Assert(err, IsNil)
+	c.Assert(string(v), Equals, "a1")
+	c.Assert(txn2.LockKeys([]byte("a")), IsNil)
+	c.Assert(txn2.Set([]byte("a"), []byte("a2")), IsNil)
+	c.Assert(txn2.Commit(), IsNil)
+
+	txn, err := s.Begin()
+	c.Assert(err, IsNil)
+	v, err = txn.Get([]byte("a"))
+	c.Assert(err, IsNil)
+	c.Assert(string(v), Equals, "a2")
+	c.Assert(txn.Commit(), IsNil)
+}
@@ -0,0 +1,279 @@
+// Copyright 2015 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package localstore
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb/kv"
+	"github.com/pingcap/tidb/store/localstore/goleveldb"
+	"github.com/pingcap/tidb/store/localstore/tso"
+	"github.com/pingcap/tidb/terror"
+	"github.com/pingcap/tidb/util/codec"
+)
+
+var _ = Suite(&testLockSuite{})
+
+type testLockSuite struct {
+	s *dbStore
+}
+
+func (t *testLockSuite) SetUpTest(c *C) {
+	t.s = createMemStore().(*dbStore)
+	txn, err := t.s.Begin()
+	c.Assert(err, IsNil)
+	c.Assert(txn.Set([]byte("a"), []byte("a0")), IsNil)
+	c.Assert(txn.Set([]byte("b"), []byte("b0")), IsNil)
+	c.Assert(txn.Commit(), IsNil)
+}
+
+func (t *testLockSuite) TearDownTest(c *C) {
+	t.s.Close()
+}
+
+func (t *testLockSuite) get(c *C, k string) string {
+	snap, err := t.s.GetSnapshot(kv.MaxVersion)
+	c.Assert(err, IsNil)
+	defer snap.Release()
+	v, err := snap.Get([]byte(k))
+	c.Assert(err, IsNil)
+	return string(v)
+}
+
+// expiredTS returns a start version of a transaction which has run longer than lockTTL.
+func expiredTS() uint64 {
+	physical := time.Now().Add(-2*lockTTL*time.Millisecond).UnixNano() / int64(time.Millisecond)
+	return tso.ComposeTS(physical, 0)
+}
+
+// expireLocks makes the locks of the keys written longer than lockTTL ago, as if the
+// transaction crashed after the prewrite.
+func (t *testLockSuite) expireLocks(c *C, keys ...string) {
+	b := t.s.db.NewBatch()
+	for _, k := range keys {
+		meta, err := t.s.getMeta([]byte(k))
+		c.Assert(err, IsNil)
+		c.Assert(meta.lock, NotNil)
+		meta.lock.prewriteTime -= 2 * lockTTL
+		putMeta(b, []byte(k), meta)
+	}
+	c.Assert(t.s.db.Commit(b), IsNil)
+}
+
+func (t *testLockSuite) TestWriteConflict(c *C) {
+	txn1, err := t.s.Begin()
+	c.Assert(err, IsNil)
+	txn2, err := t.s.Begin()
+	c.Assert(err, IsNil)
+	c.Assert(txn1.Set([]byte("a"), []byte("a1")), IsNil)
+	c.Assert(txn2.Set([]byte("a"), []byte("a2")), IsNil)
+	c.Assert(txn1.Commit(), IsNil)
+
+	err = txn2.Commit()
+	c.Assert(terror.ErrorEqual(err, kv.ErrWriteConflict), IsTrue)
+	c.Assert(kv.IsRetryableError(err), IsTrue)
+	c.Assert(t.get(c, "a"), Equals, "a1")
+
+	// The keys locked for update conflict too.
+	txn1, err = t.s.Begin()
+	c.Assert(err, IsNil)
+	txn2, err = t.s.Begin()
+	c.Assert(err, IsNil)
+	c.Assert(txn1.LockKeys([]byte("b")), IsNil)
+	c.Assert(txn1.Set([]byte("c"), []byte("c1")), IsNil)
+	c.Assert(txn2.Set([]byte("b"), []byte("b2")), IsNil)
+	c.Assert(txn2.Commit(), IsNil)
+	err = txn1.Commit()
+	c.Assert(terror.ErrorEqual(err, kv.ErrWriteConflict), IsTrue)
+
+	// The locks of the failed transaction are removed.
+	meta, err := t.s.getMeta([]byte("c"))
+	c.Assert(err, IsNil)
+	c.Assert(meta.lock, IsNil)
+	c.Assert(meta.commitTS, Equals, uint64(0))
+}
+
+func (t *testLockSuite) TestLockConflict(c *C) {
+	startTS, err := t.s.CurrentVersion()
+	c.Assert(err, IsNil)
+	err = t.s.prewrite(startTS.Ver, startTS.Ver, []byte("a"), []*mutation{
+		{key: []byte("a"), op: opPut, value: []byte("a1")},
+	})
+	c.Assert(err, IsNil)
+
+	// The lock of a running transaction can't be resolved.
+	txn, err := t.s.Begin()
+	c.Assert(err, IsNil)
+	c.Assert(txn.Set([]byte("a"), []byte("a2")), IsNil)
+	err = txn.Commit()
+	c.Assert(terror.ErrorEqual(err, kv.ErrLockConflict), IsTrue)
+
+	// A reader which starts before the lock is not blocked.
+	snap, err := t.s.GetSnapshot(kv.NewVersion(startTS.Ver - 1))
+	c.Assert(err, IsNil)
+	v, err := snap.Get([]byte("a"))
+	c.Assert(err, IsNil)
+	c.Assert(string(v), Equals, "a0")
+
+	// A reader which starts after the lock waits for the commit.
+	commitTS, err := t.s.CurrentVersion()
+	c.Assert(err, IsNil)
+	go func() {
+		time.Sleep(5 * lockWaitInterval)
+		t.s.commitKeys(startTS.Ver, commitTS.Ver, []kv.Key{[]byte("a")}, true)
+	}()
+	c.Assert(t.get(c, "a"), Equals, "a1")
+}
+
+func (t *testLockSuite) TestResolveLock(c *C) {
+	// The keys are not committed before, so an expired start version doesn't conflict.
+	// The transaction crashes after prewrite, it is rolled back.
+	startTS := expiredTS()
+	err := t.s.prewrite(startTS, startTS, []byte("x"), []*mutation{
+		{key: []byte("x"), op: opPut, value: []byte("x1")},
+		{key: []byte("y"), op: opPut, value: []byte("y1")},
+		{key: []byte("w"), op: opLock},
+	})
+	c.Assert(err, IsNil)
+	// The lock of a long running transaction isn't expired until lockTTL after the prewrite.
+	txn, err := t.s.Begin()
+	c.Assert(err, IsNil)
+	c.Assert(txn.Set([]byte("x"), []byte("x0")), IsNil)
+	err = txn.Commit()
+	c.Assert(terror.ErrorEqual(err, kv.ErrLockConflict), IsTrue)
+
+	t.expireLocks(c, "x", "y", "w")
+	snap, err := t.s.GetSnapshot(kv.MaxVersion)
+	c.Assert(err, IsNil)
+	_, err = snap.Get([]byte("y"))
+	c.Assert(terror.ErrorEqual(err, kv.ErrNotExist), IsTrue)
+	_, err = snap.Get([]byte("x"))
+	c.Assert(terror.ErrorEqual(err, kv.ErrNotExist), IsTrue)
+	_, err = snap.Get([]byte("w"))
+	c.Assert(terror.ErrorEqual(err, kv.ErrNotExist), IsTrue)
+	// The locks are resolved by the reads.
+	for _, k := range []string{"x", "y", "w"} {
+		meta, err := t.s.getMeta([]byte(k))
+		c.Assert(err, IsNil)
+		c.Assert(meta.lock, IsNil)
+	}
+	// The primary can't be committed after it is rolled back.
+	err = t.s.commitKeys(startTS, startTS+1, []kv.Key{[]byte("x")}, true)
+	c.Assert(err, NotNil)
+
+	// The transaction crashes after the primary is committed, the secondaries are
+	// committed by the readers.
+	startTS = expiredTS() + 1
+	err = t.s.prewrite(startTS, startTS, []byte("x"), []*mutation{
+		{key: []byte("x"), op: opPut, value: []byte("x2")},
+		{key: []byte("y"), op: opPut, value: []byte("y2")},
+		{key: []byte("z"), op: opPut, value: []byte("z2")},
+	})
+	c.Assert(err, IsNil)
+	t.expireLocks(c, "x", "y", "z")
+	commitTS, err := t.s.CurrentVersion()
+	c.Assert(err, IsNil)
+	err = t.s.commitKeys(startTS, commitTS.Ver, []kv.Key{[]byte("x")}, true)
+	c.Assert(err, IsNil)
+
+	txn, err = t.s.Begin()
+	c.Assert(err, IsNil)
+	it, err := txn.SeekReverse(nil)
+	c.Assert(err, IsNil)
+	var vals []string
+	for it.Valid() {
+		vals = append(vals, string(it.Value()))
+		c.Assert(it.Next(), IsNil)
+	}
+	c.Assert(vals, DeepEquals, []string{"z2", "y2", "x2", "b0", "a0"})
+	c.Assert(txn.Commit(), IsNil)
+	for _, k := range []string{"y", "z"} {
+		meta, err := t.s.getMeta([]byte(k))
+		c.Assert(err, IsNil)
+		c.Assert(meta.lock, IsNil)
+		c.Assert(meta.commitTS, Equals, commitTS.Ver)
+	}
+}
+
+func (t *testLockSuite) TestUpgradeFormat(c *C) {
+	dir, err := ioutil.TempDir("", "localstore")
+	c.Assert(err, IsNil)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "data")
+
+	// Write the data in the old format, the committed values are not prefixed, and a
+	// deleted value is empty.
+	db, err := goleveldb.Driver{}.Open(path)
+	c.Assert(err, IsNil)
+	b := db.NewBatch()
+	put := func(k string, ver uint64, v []byte) {
+		b.Put(MvccEncodeVersionKey([]byte(k), kv.NewVersion(ver)), v)
+		b.Put(codec.EncodeBytes(nil, []byte(k)), codec.EncodeUint(nil, ver))
+	}
+	put("a", 10, []byte("a1"))
+	put("b", 10, []byte("b1"))
+	put("b", 20, nil)
+	put("c", 10, []byte("12345678"))
+	c.Assert(db.Commit(b), IsNil)
+	// The upgrade is interrupted after the first batch.
+	defer func(n int) { upgradeBatchSize = n }(upgradeBatchSize)
+	upgradeBatchSize = 2
+	k, n, err := upgradeFormatBatch(db, formatVersionKey)
+	c.Assert(err, IsNil)
+	c.Assert(k, NotNil)
+	c.Assert(n, Equals, 2)
+	c.Assert(db.Close(), IsNil)
+
+	// The upgrade is resumed when the store is opened.
+	store, err := Driver{goleveldb.Driver{}}.Open(path)
+	c.Assert(err, IsNil)
+	s := store.(*dbStore)
+	// The format is upgraded only once.
+	c.Assert(upgradeFormat(s.db), IsNil)
+
+	txn, err := s.Begin()
+	c.Assert(err, IsNil)
+	v, err := txn.Get([]byte("c"))
+	c.Assert(err, IsNil)
+	c.Assert(string(v), Equals, "12345678")
+	_, err = txn.Get([]byte("b"))
+	c.Assert(terror.ErrorEqual(err, kv.ErrNotExist), IsTrue)
+	it, err := txn.SeekReverse(nil)
+	c.Assert(err, IsNil)
+	var vals []string
+	for it.Valid() {
+		vals = append(vals, string(it.Value()))
+		c.Assert(it.Next(), IsNil)
+	}
+	c.Assert(vals, DeepEquals, []string{"12345678", "a1"})
+	c.Assert(txn.Set([]byte("a"), []byte("a2")), IsNil)
+	c.Assert(txn.Commit(), IsNil)
+	snap, err := s.GetSnapshot(kv.MaxVersion)
+	c.Assert(err, IsNil)
+	v, err = snap.Get([]byte("a"))
+	c.Assert(err, IsNil)
+	c.Assert(string(v), Equals, "a2")
+	snap.Release()
+
+	// The data in a newer format can't be opened.
+	b = s.db.NewBatch()
+	b.Put(formatVersionKey, codec.EncodeUint(nil, formatVersion+1))
+	c.Assert(s.db.Commit(b), IsNil)
+	c.Assert(terror.ErrorEqual(upgradeFormat(s.db), ErrUnsupportedFormat), IsTrue)
+	c.Assert(store.Close(), IsNil)
+}
@@ -0,0 +1,29 @@
+// Copyright 2015 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package localstore
+
+import "github.com/pingcap/tidb/metric"
+
+// The names of the latency metrics of the KV operations.
+var (
+	metricGet         = kvDurationMetric("get")
+	metricSeek        = kvDurationMetric("seek")
+	metricSeekReverse = kvDurationMetric("seek_reverse")
+	metricCommit      = kvDurationMetric("commit")
+	metricLockKeys    = kvDurationMetric("lock_keys")
+)
+
+func kvDurationMetric(op string) string {
+	return metric.Label("tidb_kv_duration_seconds", "store", "localstore", "type", op)
+}
@@ -16,11 +16,13 @@ package localstore
 import (
 	"bytes"
 	"fmt"
+	"net"
 	"time"
 
 	. "github.com/pingcap/check"
 	"github.com/pingcap/tidb/kv"
 	"github.com/pingcap/tidb/store/localstore/goleveldb"
+	"github.com/pingcap/tidb/store/localstore/tso"
 )
 
 var _ = Suite(&testMvccSuite{})
@@ -255,6 +257,78 @@ func (t *testMvccSuite) TestMvccSeek(c *C) {
 	c.Assert(v, BytesEquals, encodeInt(2))
 }
 
+func (t *testMvccSuite) TestMvccSeekReverse(c *C) {
+	s := t.getSnapshot(c, kv.MaxVersion)
+	k, v, err := s.mvccSeekReverse(encodeInt(2))
+	c.Assert(err, IsNil)
+	c.Assert([]byte(k), BytesEquals, encodeInt(1))
+	c.Assert(v, BytesEquals, encodeInt(1))
+
+	k, v, err = s.mvccSeekReverse(append(encodeInt(1), byte(0)))
+	c.Assert(err, IsNil)
+	c.Assert([]byte(k), BytesEquals, encodeInt(1))
+
+	k, v, err = s.mvccSeekReverse(encodeInt(1024))
+	c.Assert(err, IsNil)
+	c.Assert([]byte(k), BytesEquals, encodeInt(4))
+
+	k, v, err = s.mvccSeekReverse(encodeInt(0))
+	c.Assert(err, NotNil)
+
+	s = t.getSnapshot(c, kv.Version{Ver: 1})
+	k, v, err = s.mvccSeekReverse(encodeInt(1024))
+	c.Assert(err, NotNil)
+
+	txn, err := t.s.Begin()
+	c.Assert(err, IsNil)
+	err = txn.Set(encodeInt(1), encodeInt(1001))
+	c.Assert(err, IsNil)
+	err = txn.Commit()
+	c.Assert(err, IsNil)
+	v1, err := txn.CommittedVersion()
+	c.Assert(err, IsNil)
+
+	txn, err = t.s.Begin()
+	c.Assert(err, IsNil)
+	err = txn.Delete(encodeInt(2))
+	c.Assert(err, IsNil)
+	err = txn.Commit()
+	c.Assert(err, IsNil)
+	v2, err := txn.CommittedVersion()
+	c.Assert(err, IsNil)
+
+	s = t.getSnapshot(c, v2)
+	k, v, err = s.mvccSeekReverse(encodeInt(3))
+	c.Assert(err, IsNil)
+	c.Assert([]byte(k), BytesEquals, encodeInt(1))
+	c.Assert(v, BytesEquals, encodeInt(1001))
+
+	s = t.getSnapshot(c, v1)
+	k, v, err = s.mvccSeekReverse(encodeInt(3))
+	c.Assert(err, IsNil)
+	c.Assert([]byte(k), BytesEquals, encodeInt(2))
+	c.Assert(v, BytesEquals, encodeInt(2))
+
+	// Iterate in a transaction with the buffered changes.
+	txn, err = t.s.Begin()
+	c.Assert(err, IsNil)
+	err = txn.Set(encodeInt(2), encodeInt(1002))
+	c.Assert(err, IsNil)
+	err = txn.Delete(encodeInt(3))
+	c.Assert(err, IsNil)
+	it, err := txn.SeekReverse(encodeInt(5))
+	c.Assert(err, IsNil)
+	var keys []int
+	for it.Valid() {
+		keys = append(keys, decodeInt([]byte(it.Key())))
+		err = it.Next()
+		c.Assert(err, IsNil)
+	}
+	it.Close()
+	c.Assert(keys, DeepEquals, []int{4, 2, 1, 0})
+	txn.Commit()
+}
+
 func (t *testMvccSuite) TestMvccSuiteGetLatest(c *C) {
 	// update some new data
 	for i := 0; i < 10; i++ {
@@ -337,6 +411,53 @@ func (t *testMvccSuite) TestBufferedIterator(c *C) {
 	tx.Commit()
 }
 
+func (t *testMvccSuite) TestRemoteOracle(c *C) {
+	path, tsoAddr, err := parseSchema("/tmp/test?tso=127.0.0.1:1234")
+	c.Assert(err, IsNil)
+	c.Assert(path, Equals, "/tmp/test")
+	c.Assert(tsoAddr, Equals, "127.0.0.1:1234")
+	_, _, err = parseSchema("/tmp/test?tso=%zz")
+	c.Assert(err, NotNil)
+
+	o, err := tso.NewOracle("")
+	c.Assert(err, IsNil)
+	server := tso.NewServer(o)
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	c.Assert(err, IsNil)
+	go server.Serve(l)
+	defer server.Close()
+
+	d := Driver{
+		goleveldb.MemoryDriver{},
+	}
+	s, err := d.Open(fmt.Sprintf("%d?tso=%s", time.Now().UnixNano(), l.Addr()))
+	c.Assert(err, IsNil)
+	defer s.Close()
+	c.Assert(s.(*dbStore).tsoClient, NotNil)
+
+	txn, err := s.Begin()
+	c.Assert(err, IsNil)
+	err = txn.Set([]byte("a"), []byte("1"))
+	c.Assert(err, IsNil)
+	err = txn.Commit()
+	c.Assert(err, IsNil)
+
+	// The versions are allocated by the oracle.
+	ts, err := o.GetTimestamps(1)
+	c.Assert(err, IsNil)
+	ver, err := s.CurrentVersion()
+	c.Assert(err, IsNil)
+	c.Assert(ver.Ver > ts, IsTrue)
+	c.Assert(txn.(*dbTxn).version.Ver < ts, IsTrue)
+
+	snap, err := s.GetSnapshot(kv.MaxVersion)
+	c.Assert(err, IsNil)
+	defer snap.Release()
+	val, err := snap.Get([]byte("a"))
+	c.Assert(err, IsNil)
+	c.Assert(val, DeepEquals, []byte("1"))
+}
+
 func encodeInt(n int) []byte {
 	return []byte(fmt.Sprintf("%010d", n))
 }
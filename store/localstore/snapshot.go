@@ -14,11 +14,15 @@
 package localstore
 
 import (
+	"time"
+
 	"github.com/juju/errors"
 	"github.com/pingcap/tidb/kv"
+	"github.com/pingcap/tidb/metric"
 	"github.com/pingcap/tidb/store/localstore/engine"
 	"github.com/pingcap/tidb/terror"
 	"github.com/pingcap/tidb/util/bytes"
+	"github.com/pingcap/tidb/util/codec"
 )
 
 var (
@@ -43,10 +47,49 @@ func newSnapshot(store *dbStore, db engine.DB, ver kv.Version) *dbSnapshot {
 	return ss
 }
 
+// resolveLocks calls f until it doesn't meet a lock, the locks are resolved or
+// waited to be released.
+func (s *dbSnapshot) resolveLocks(f func() (kv.Key, []byte, error)) (kv.Key, []byte, error) {
+	for {
+		k, v, err := f()
+		locked, ok := err.(*lockedError)
+		if !ok {
+			return k, v, errors.Trace(err)
+		}
+		err = s.store.resolveLock(locked.key, locked.lock)
+		if terror.ErrorEqual(err, kv.ErrLockConflict) {
+			time.Sleep(lockWaitInterval)
+			continue
+		}
+		if err != nil {
+			return nil, nil, errors.Trace(err)
+		}
+	}
+}
+
+// checkLock returns a lockedError if the key is locked by a transaction which
+// starts before the snapshot, the caller must hold the store lock.
+func (s *dbSnapshot) checkLock(key kv.Key) error {
+	meta, err := s.store.getMeta(key)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if meta.lock != nil && meta.lock.startTS <= s.version.Ver {
+		return &lockedError{key: key, lock: meta.lock}
+	}
+	return nil
+}
+
 // mvccSeek seeks for the first key in db which has a k >= key and a version <=
 // snapshot's version, returns kv.ErrNotExist if such key is not found. If exact
 // is true, only k == key can be returned.
 func (s *dbSnapshot) mvccSeek(key kv.Key, exact bool) (kv.Key, []byte, error) {
+	return s.resolveLocks(func() (kv.Key, []byte, error) {
+		return s.seek(key, exact)
+	})
+}
+
+func (s *dbSnapshot) seek(key kv.Key, exact bool) (kv.Key, []byte, error) {
 	s.store.mu.RLock()
 	defer s.store.mu.RUnlock()
 
@@ -68,6 +111,11 @@ func (s *dbSnapshot) mvccSeek(key kv.Key, exact bool) (kv.Key, []byte, error) {
 	// ...
 	// EOF
 	for {
+		// The lock is in the meta key.
+		err := s.checkLock(key)
+		if err != nil {
+			return nil, nil, err
+		}
 		mvccKey := MvccEncodeVersionKey(key, s.version)
 		mvccK, v, err := s.db.Seek(mvccKey) // search for [4...EOF)
 		if err != nil {
@@ -88,6 +136,10 @@ func (s *dbSnapshot) mvccSeek(key kv.Key, exact bool) (kv.Key, []byte, error) {
 			key = k
 			continue
 		}
+		_, v, err = decodeValue(v)
+		if err != nil {
+			return nil, nil, errors.Trace(err)
+		}
 		if isTombstone(v) { // current key is deleted
 			if exact {
 				return nil, nil, errors.Trace(kv.ErrNotExist)
@@ -100,7 +152,77 @@ func (s *dbSnapshot) mvccSeek(key kv.Key, exact bool) (kv.Key, []byte, error) {
 	}
 }
 
+// mvccSeekReverse seeks for the last key in db which has a k < key and a version <=
+// snapshot's version, returns kv.ErrNotExist if such key is not found. If key is nil,
+// it seeks from the last key in db.
+func (s *dbSnapshot) mvccSeekReverse(key kv.Key) (kv.Key, []byte, error) {
+	return s.resolveLocks(func() (kv.Key, []byte, error) {
+		return s.seekReverse(key)
+	})
+}
+
+func (s *dbSnapshot) seekReverse(key kv.Key) (kv.Key, []byte, error) {
+	s.store.mu.RLock()
+	defer s.store.mu.RUnlock()
+
+	if s.store.closed {
+		return nil, nil, errors.Trace(ErrDBClosed)
+	}
+
+	// The encoded keys of the smaller keys are all less than Key (Meta) in the
+	// key layout described in mvccSeek.
+	var metaKey []byte
+	if key != nil {
+		metaKey = codec.EncodeBytes(nil, key)
+	}
+	for {
+		mvccK, _, err := s.db.SeekReverse(metaKey) // search for [...PrevKey_0]
+		if err == nil && kv.Key(mvccK).Cmp(formatVersionKey) == 0 {
+			// The format version is before all the keys.
+			err = engine.ErrNotFound
+		}
+		if err != nil {
+			if terror.ErrorEqual(err, engine.ErrNotFound) { // BOF
+				err = errors.Wrap(err, kv.ErrNotExist)
+			}
+			return nil, nil, errors.Trace(err)
+		}
+		k, _, err := MvccDecode(mvccK)
+		if err != nil {
+			return nil, nil, errors.Trace(err)
+		}
+		err = s.checkLock(k)
+		if err != nil {
+			return nil, nil, err
+		}
+		// search for the version of PrevKey visible to the snapshot
+		mvccK, v, err := s.db.Seek(MvccEncodeVersionKey(k, s.version))
+		if err != nil && !terror.ErrorEqual(err, engine.ErrNotFound) {
+			return nil, nil, errors.Trace(err)
+		}
+		if err == nil {
+			var visibleK kv.Key
+			visibleK, _, err = MvccDecode(mvccK)
+			if err != nil {
+				return nil, nil, errors.Trace(err)
+			}
+			if k.Cmp(visibleK) == 0 {
+				_, v, err = decodeValue(v)
+				if err != nil {
+					return nil, nil, errors.Trace(err)
+				}
+				if !isTombstone(v) {
+					return k, v, nil
+				}
+			}
+		}
+		// PrevKey is not visible or deleted, search for the key before its meta
+		metaKey = codec.EncodeBytes(nil, k)
+	}
+}
+
 func (s *dbSnapshot) Get(key kv.Key) ([]byte, error) {
+	defer metric.ObserveDuration(metricGet, time.Now())
 	_, v, err := s.mvccSeek(key, true)
 	if err != nil {
 		return nil, errors.Trace(err)
@@ -147,7 +269,14 @@ func (s *dbSnapshot) RangeGet(start, end kv.Key, limit int) (map[string][]byte,
 }
 
 func (s *dbSnapshot) Seek(k kv.Key) (kv.Iterator, error) {
-	it, err := newDBIter(s, k)
+	defer metric.ObserveDuration(metricSeek, time.Now())
+	it, err := newDBIter(s, k, false)
+	return it, errors.Trace(err)
+}
+
+func (s *dbSnapshot) SeekReverse(k kv.Key) (kv.Iterator, error) {
+	defer metric.ObserveDuration(metricSeekReverse, time.Now())
+	it, err := newDBIter(s, k, true)
 	return it, errors.Trace(err)
 }
 
@@ -155,14 +284,22 @@ func (s *dbSnapshot) Release() {
 }
 
 type dbIter struct {
-	s     *dbSnapshot
-	valid bool
-	k     kv.Key
-	v     []byte
+	s       *dbSnapshot
+	valid   bool
+	k       kv.Key
+	v       []byte
+	reverse bool
 }
 
-func newDBIter(s *dbSnapshot, startKey kv.Key) (*dbIter, error) {
-	k, v, err := s.mvccSeek(startKey, false)
+func newDBIter(s *dbSnapshot, startKey kv.Key, reverse bool) (*dbIter, error) {
+	var k kv.Key
+	var v []byte
+	var err error
+	if reverse {
+		k, v, err = s.mvccSeekReverse(startKey)
+	} else {
+		k, v, err = s.mvccSeek(startKey, false)
+	}
 	if err != nil {
 		if terror.ErrorEqual(err, kv.ErrNotExist) {
 			err = nil
@@ -171,15 +308,23 @@ func newDBIter(s *dbSnapshot, startKey kv.Key) (*dbIter, error) {
 	}
 
 	return &dbIter{
-		s:     s,
-		valid: true,
-		k:     k,
-		v:     v,
+		s:       s,
+		valid:   true,
+		k:       k,
+		v:       v,
+		reverse: reverse,
 	}, nil
 }
 
 func (it *dbIter) Next() error {
-	k, v, err := it.s.mvccSeek(it.k.Next(), false)
+	var k kv.Key
+	var v []byte
+	var err error
+	if it.reverse {
+		k, v, err = it.s.mvccSeekReverse(it.k)
+	} else {
+		k, v, err = it.s.mvccSeek(it.k.Next(), false)
+	}
 	if err != nil {
 		it.valid = false
 		if !terror.ErrorEqual(err, kv.ErrNotExist) {
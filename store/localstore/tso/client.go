@@ -0,0 +1,141 @@
+// Copyright 2015 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tso
+
+import (
+	"net/rpc"
+	"sync"
+
+	"github.com/juju/errors"
+	"github.com/ngaut/log"
+	"github.com/pingcap/tidb/kv"
+)
+
+// maxBatchSize is the max number of the requests merged into one rpc.
+const maxBatchSize = 10000
+
+var (
+	// ErrClientClosed is returned when the Client is closed.
+	ErrClientClosed = errors.New("tso client is closed")
+)
+
+type request struct {
+	ts   uint64
+	done chan error
+}
+
+// Client gets timestamps from a remote Server, it implements kv.VersionProvider.
+// The concurrent requests are merged into one rpc which allocates the timestamps in batch.
+type Client struct {
+	addr     string
+	requests chan *request
+	quit     chan struct{}
+	quitOnce sync.Once
+	wg       sync.WaitGroup
+	// rpcClient is only used in the loop goroutine.
+	rpcClient *rpc.Client
+}
+
+// NewClient creates a Client for the Server listening on addr, the connection is
+// established on the first request and re-established after an error.
+func NewClient(addr string) *Client {
+	c := &Client{
+		addr:     addr,
+		requests: make(chan *request, maxBatchSize),
+		quit:     make(chan struct{}),
+	}
+	c.wg.Add(1)
+	go c.loop()
+	return c
+}
+
+// CurrentVersion implements the kv.VersionProvider interface.
+func (c *Client) CurrentVersion() (kv.Version, error) {
+	req := &request{done: make(chan error, 1)}
+	select {
+	case c.requests <- req:
+	case <-c.quit:
+		return kv.Version{}, errors.Trace(ErrClientClosed)
+	}
+	select {
+	case err := <-req.done:
+		if err != nil {
+			return kv.Version{}, errors.Trace(err)
+		}
+		return kv.NewVersion(req.ts), nil
+	case <-c.quit:
+		return kv.Version{}, errors.Trace(ErrClientClosed)
+	}
+}
+
+func (c *Client) loop() {
+	defer c.wg.Done()
+	for {
+		var reqs []*request
+		select {
+		case req := <-c.requests:
+			reqs = append(reqs, req)
+		case <-c.quit:
+			if c.rpcClient != nil {
+				c.rpcClient.Close()
+			}
+			return
+		}
+		// Merge the pending requests.
+	collect:
+		for len(reqs) < maxBatchSize {
+			select {
+			case req := <-c.requests:
+				reqs = append(reqs, req)
+			default:
+				break collect
+			}
+		}
+
+		ts, err := c.getTimestamps(uint32(len(reqs)))
+		for i, req := range reqs {
+			req.ts = ts + uint64(i)
+			req.done <- err
+		}
+	}
+}
+
+func (c *Client) getTimestamps(count uint32) (uint64, error) {
+	if c.rpcClient == nil {
+		rpcClient, err := rpc.Dial("tcp", c.addr)
+		if err != nil {
+			return 0, errors.Trace(err)
+		}
+		c.rpcClient = rpcClient
+	}
+	args := &GetTimestampsArgs{Count: count}
+	reply := &GetTimestampsReply{}
+	err := c.rpcClient.Call(serviceName+".GetTimestamps", args, reply)
+	if err != nil {
+		log.Warnf("[tso] get timestamps from %s failed: %v", c.addr, err)
+		// Reconnect on the next request.
+		c.rpcClient.Close()
+		c.rpcClient = nil
+		return 0, errors.Trace(err)
+	}
+	return reply.Timestamp, nil
+}
+
+// Close closes the Client, the following requests return ErrClientClosed.
+func (c *Client) Close() {
+	c.quitOnce.Do(func() {
+		close(c.quit)
+	})
+	c.wg.Wait()
+}
@@ -0,0 +1,152 @@
+// Copyright 2015 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tso
+
+import (
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/juju/errors"
+	"github.com/ngaut/log"
+	"github.com/pingcap/tidb/kv"
+)
+
+// A timestamp is composed of a physical part and a logical part,
+// the physical part is the unix time in milliseconds.
+const (
+	logicalBits = 18
+	maxLogical  = 1 << logicalBits
+)
+
+// saveInterval is how far in milliseconds the high-water mark is saved ahead of
+// the allocated physical time, so the mark is not saved on every allocation.
+const saveInterval = 3000
+
+var (
+	// ErrInvalidCount is returned when the number of the timestamps to allocate is
+	// zero or too large.
+	ErrInvalidCount = errors.New("invalid timestamp count")
+)
+
+// ComposeTS composes a timestamp from the physical and logical parts.
+func ComposeTS(physical, logical int64) uint64 {
+	return uint64(physical<<logicalBits + logical)
+}
+
+// ExtractPhysical returns the physical part of a timestamp.
+func ExtractPhysical(ts uint64) int64 {
+	return int64(ts >> logicalBits)
+}
+
+// Oracle allocates strictly increasing timestamps, it implements kv.VersionProvider.
+// If it is created with a path, the high-water mark of the allocated physical time is
+// persisted to the file before the timestamps are returned, so the timestamps allocated
+// before a restart are never allocated again, even if the clock goes backwards.
+type Oracle struct {
+	mu       sync.Mutex
+	path     string
+	physical int64
+	logical  int64
+	// maxPhysical is the persisted high-water mark, the allocated physical time never exceeds it.
+	maxPhysical int64
+}
+
+// NewOracle creates an Oracle, the high-water mark is persisted in the file of path,
+// if path is empty, it is not persisted.
+func NewOracle(path string) (*Oracle, error) {
+	o := &Oracle{path: path}
+	mark, err := o.loadMark()
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	// The timestamps before the high-water mark may have been allocated.
+	o.physical = mark
+	o.logical = maxLogical
+	o.maxPhysical = mark
+	return o, nil
+}
+
+func (o *Oracle) loadMark() (int64, error) {
+	if o.path == "" {
+		return 0, nil
+	}
+	data, err := ioutil.ReadFile(o.path)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, errors.Trace(err)
+	}
+	mark, err := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+	return mark, errors.Trace(err)
+}
+
+func (o *Oracle) saveMark(mark int64) error {
+	if o.path != "" {
+		// Write to a temporary file and rename it, so a crash never leaves a broken mark.
+		tmp := o.path + ".tmp"
+		err := ioutil.WriteFile(tmp, []byte(strconv.FormatInt(mark, 10)), 0644)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		err = os.Rename(tmp, o.path)
+		if err != nil {
+			return errors.Trace(err)
+		}
+	}
+	o.maxPhysical = mark
+	return nil
+}
+
+// GetTimestamps allocates count consecutive timestamps and returns the first one.
+func (o *Oracle) GetTimestamps(count uint32) (uint64, error) {
+	if count == 0 || count >= maxLogical {
+		return 0, errors.Trace(ErrInvalidCount)
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	now := time.Now().UnixNano() / int64(time.Millisecond)
+	if now > o.physical {
+		o.physical, o.logical = now, 0
+	} else if o.logical+int64(count) > maxLogical {
+		// The logical part is used up in this millisecond, borrow the next one.
+		if now < o.physical {
+			log.Warnf("[tso] physical time %d is ahead of the clock %d", o.physical, now)
+		}
+		o.physical, o.logical = o.physical+1, 0
+	}
+	if o.physical > o.maxPhysical {
+		err := o.saveMark(o.physical + saveInterval)
+		if err != nil {
+			return 0, errors.Trace(err)
+		}
+	}
+	ts := ComposeTS(o.physical, o.logical)
+	o.logical += int64(count)
+	return ts, nil
+}
+
+// CurrentVersion implements the kv.VersionProvider interface.
+func (o *Oracle) CurrentVersion() (kv.Version, error) {
+	ts, err := o.GetTimestamps(1)
+	if err != nil {
+		return kv.Version{}, errors.Trace(err)
+	}
+	return kv.NewVersion(ts), nil
+}
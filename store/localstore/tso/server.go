@@ -0,0 +1,144 @@
+// Copyright 2015 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tso
+
+import (
+	"net"
+	"net/rpc"
+	"sync"
+
+	"github.com/juju/errors"
+	"github.com/ngaut/log"
+)
+
+// serviceName is the rpc service name of the timestamp oracle.
+const serviceName = "TSO"
+
+// GetTimestampsArgs is the argument of the GetTimestamps rpc.
+type GetTimestampsArgs struct {
+	Count uint32
+}
+
+// GetTimestampsReply is the reply of the GetTimestamps rpc.
+type GetTimestampsReply struct {
+	// Timestamp is the first one of the allocated timestamps.
+	Timestamp uint64
+}
+
+type service struct {
+	oracle *Oracle
+}
+
+// GetTimestamps allocates args.Count consecutive timestamps.
+func (s *service) GetTimestamps(args *GetTimestampsArgs, reply *GetTimestampsReply) error {
+	ts, err := s.oracle.GetTimestamps(args.Count)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	reply.Timestamp = ts
+	return nil
+}
+
+// Server serves the timestamps of an Oracle over TCP.
+type Server struct {
+	rpcServer *rpc.Server
+
+	mu       sync.Mutex
+	listener net.Listener
+	conns    map[net.Conn]struct{}
+	closed   bool
+}
+
+// NewServer creates a Server for the Oracle.
+func NewServer(oracle *Oracle) *Server {
+	s := &Server{
+		rpcServer: rpc.NewServer(),
+		conns:     make(map[net.Conn]struct{}),
+	}
+	// The service is valid, it never fails to register.
+	s.rpcServer.RegisterName(serviceName, &service{oracle: oracle})
+	return s
+}
+
+// ListenAndServe listens on the TCP address and serves the connections.
+func (s *Server) ListenAndServe(addr string) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(s.Serve(l))
+}
+
+// Serve serves the connections accepted by the listener until the Server is closed.
+func (s *Server) Serve(l net.Listener) error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		l.Close()
+		return nil
+	}
+	s.listener = l
+	s.mu.Unlock()
+
+	log.Infof("[tso] server listens on %s", l.Addr())
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			s.mu.Lock()
+			closed := s.closed
+			s.mu.Unlock()
+			if closed {
+				return nil
+			}
+			return errors.Trace(err)
+		}
+		s.mu.Lock()
+		s.conns[conn] = struct{}{}
+		s.mu.Unlock()
+		go s.serveConn(conn)
+	}
+}
+
+func (s *Server) serveConn(conn net.Conn) {
+	s.rpcServer.ServeConn(conn)
+	s.mu.Lock()
+	delete(s.conns, conn)
+	s.mu.Unlock()
+}
+
+// Addr returns the listening address, it is nil if the Server is not serving.
+func (s *Server) Addr() net.Addr {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.listener == nil {
+		return nil
+	}
+	return s.listener.Addr()
+}
+
+// Close stops listening and closes all the connections.
+func (s *Server) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+	s.closed = true
+	if s.listener != nil {
+		s.listener.Close()
+	}
+	for conn := range s.conns {
+		conn.Close()
+	}
+}
@@ -0,0 +1,159 @@
+// Copyright 2015 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tso
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/pingcap/check"
+)
+
+func TestT(t *testing.T) {
+	TestingT(t)
+}
+
+var _ = Suite(&testTSOSuite{})
+
+type testTSOSuite struct {
+}
+
+func (s *testTSOSuite) TestOracle(c *C) {
+	o, err := NewOracle("")
+	c.Assert(err, IsNil)
+
+	var last uint64
+	for i := 0; i < 1000; i++ {
+		ver, err := o.CurrentVersion()
+		c.Assert(err, IsNil)
+		c.Assert(ver.Ver > last, IsTrue)
+		last = ver.Ver
+	}
+
+	// A batch takes consecutive timestamps.
+	ts, err := o.GetTimestamps(100)
+	c.Assert(err, IsNil)
+	c.Assert(ts > last, IsTrue)
+	ver, err := o.CurrentVersion()
+	c.Assert(err, IsNil)
+	c.Assert(ver.Ver >= ts+100, IsTrue)
+
+	_, err = o.GetTimestamps(0)
+	c.Assert(err, NotNil)
+	_, err = o.GetTimestamps(maxLogical)
+	c.Assert(err, NotNil)
+
+	// The logical part is used up, the physical part moves on.
+	first, err := o.GetTimestamps(maxLogical - 1)
+	c.Assert(err, IsNil)
+	second, err := o.GetTimestamps(maxLogical - 1)
+	c.Assert(err, IsNil)
+	c.Assert(ExtractPhysical(second) > ExtractPhysical(first), IsTrue)
+}
+
+func (s *testTSOSuite) TestPersistMark(c *C) {
+	dir, err := ioutil.TempDir("", "tso")
+	c.Assert(err, IsNil)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "mark")
+
+	o, err := NewOracle(path)
+	c.Assert(err, IsNil)
+	ts, err := o.GetTimestamps(1)
+	c.Assert(err, IsNil)
+	data, err := ioutil.ReadFile(path)
+	c.Assert(err, IsNil)
+	mark, err := strconv.ParseInt(string(data), 10, 64)
+	c.Assert(err, IsNil)
+	c.Assert(mark > ExtractPhysical(ts), IsTrue)
+
+	// Pretend the clock goes backwards after a restart, the timestamps are still
+	// larger than the ones allocated before the restart.
+	mark = time.Now().Add(time.Hour).UnixNano() / int64(time.Millisecond)
+	err = ioutil.WriteFile(path, []byte(strconv.FormatInt(mark, 10)), 0644)
+	c.Assert(err, IsNil)
+	o, err = NewOracle(path)
+	c.Assert(err, IsNil)
+	ts, err = o.GetTimestamps(1)
+	c.Assert(err, IsNil)
+	c.Assert(ts >= ComposeTS(mark+1, 0), IsTrue)
+}
+
+func (s *testTSOSuite) TestServer(c *C) {
+	o, err := NewOracle("")
+	c.Assert(err, IsNil)
+	server := NewServer(o)
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	c.Assert(err, IsNil)
+	go server.Serve(l)
+	defer server.Close()
+
+	client := NewClient(l.Addr().String())
+	defer client.Close()
+
+	const goroutines, count = 10, 100
+	var (
+		wg  sync.WaitGroup
+		mu  sync.Mutex
+		all = make(map[uint64]struct{})
+	)
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var last uint64
+			for j := 0; j < count; j++ {
+				ver, err := client.CurrentVersion()
+				c.Assert(err, IsNil)
+				c.Assert(ver.Ver > last, IsTrue)
+				last = ver.Ver
+				mu.Lock()
+				all[ver.Ver] = struct{}{}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	c.Assert(all, HasLen, goroutines*count)
+
+	// The timestamps from the server are larger than the ones from the client.
+	ts, err := o.GetTimestamps(1)
+	c.Assert(err, IsNil)
+	for ver := range all {
+		c.Assert(ts > ver, IsTrue)
+	}
+
+	// The client reconnects after the server restarts.
+	server.Close()
+	_, err = client.CurrentVersion()
+	c.Assert(err, NotNil)
+	l, err = net.Listen("tcp", l.Addr().String())
+	c.Assert(err, IsNil)
+	server = NewServer(o)
+	go server.Serve(l)
+	defer server.Close()
+	ver, err := client.CurrentVersion()
+	c.Assert(err, IsNil)
+	c.Assert(ver.Ver > ts, IsTrue)
+
+	client.Close()
+	_, err = client.CurrentVersion()
+	c.Assert(err, NotNil)
+}
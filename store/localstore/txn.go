@@ -15,11 +15,12 @@ package localstore
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/juju/errors"
 	"github.com/ngaut/log"
 	"github.com/pingcap/tidb/kv"
-	"github.com/pingcap/tidb/util/codec"
+	"github.com/pingcap/tidb/metric"
 )
 
 var (
@@ -34,6 +35,16 @@ type dbTxn struct {
 	valid        bool
 	version      kv.Version          // commit version
 	snapshotVals map[string]struct{} // origin version in snapshot
+
+	// pessimistic is true if LockKeys acquires the locks at once.
+	pessimistic     bool
+	lockWaitTimeout time.Duration
+	// forUpdateTS is the version of the data read by the transaction, it is newer than
+	// tid if the snapshot is refreshed by LockKeys. The write conflicts are checked against it.
+	forUpdateTS uint64
+	// opts is the options set on the transaction, they are set again on the new UnionStore
+	// when the snapshot is refreshed.
+	opts map[kv.Option]interface{}
 }
 
 func (txn *dbTxn) markOrigin(k []byte) {
@@ -96,6 +107,10 @@ func (txn *dbTxn) String() string {
 	return fmt.Sprintf("%d", txn.tid)
 }
 
+func (txn *dbTxn) StartTS() uint64 {
+	return txn.tid
+}
+
 func (txn *dbTxn) Seek(k kv.Key) (kv.Iterator, error) {
 	log.Debugf("seek key:%q, txn:%d", k, txn.tid)
 	iter, err := txn.UnionStore.Seek(k)
@@ -109,6 +124,19 @@ func (txn *dbTxn) Seek(k kv.Key) (kv.Iterator, error) {
 	return iter, nil
 }
 
+func (txn *dbTxn) SeekReverse(k kv.Key) (kv.Iterator, error) {
+	log.Debugf("seek reverse key:%q, txn:%d", k, txn.tid)
+	iter, err := txn.UnionStore.SeekReverse(k)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if !iter.Valid() {
+		return &kv.UnionIter{}, nil
+	}
+
+	return iter, nil
+}
+
 func (txn *dbTxn) Delete(k kv.Key) error {
 	log.Debugf("delete key:%q, txn:%d", k, txn.tid)
 	err := txn.UnionStore.Delete(k)
@@ -120,15 +148,44 @@ func (txn *dbTxn) Delete(k kv.Key) error {
 	return nil
 }
 
-func (txn *dbTxn) doCommit() error {
-	b := txn.store.newBatch()
-	keysLocked := make([]string, 0, len(txn.snapshotVals))
-	defer func() {
-		for _, key := range keysLocked {
-			txn.store.unLockKeys(key)
+// mutations returns the keys written or locked by the transaction and chooses the
+// primary, which is the first written key if there is any.
+func (txn *dbTxn) mutations() ([]*mutation, kv.Key, error) {
+	var (
+		mutations []*mutation
+		primary   kv.Key
+	)
+	written := make(map[string]struct{})
+	err := txn.WalkBuffer(func(k kv.Key, v []byte) error {
+		m := &mutation{key: append(kv.Key(nil), k...), op: opPut, value: v}
+		if len(v) == 0 { // Deleted marker
+			m.op = opDelete
 		}
-	}()
+		if primary == nil {
+			primary = m.key
+		}
+		mutations = append(mutations, m)
+		written[string(k)] = struct{}{}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, errors.Trace(err)
+	}
+	// The keys which are read for update are locked to detect the write conflicts.
+	for k := range txn.snapshotVals {
+		if _, ok := written[k]; ok {
+			continue
+		}
+		m := &mutation{key: kv.Key(k), op: opLock}
+		if primary == nil {
+			primary = m.key
+		}
+		mutations = append(mutations, m)
+	}
+	return mutations, primary, nil
+}
 
+func (txn *dbTxn) doCommit() error {
 	// check lazy condition pairs
 	if err := txn.CheckLazyConditionPairs(); err != nil {
 		return errors.Trace(err)
@@ -136,41 +193,47 @@ func (txn *dbTxn) doCommit() error {
 
 	txn.ReleaseSnapshot()
 
-	// Check locked keys
-	for k := range txn.snapshotVals {
-		err := txn.store.tryConditionLockKey(txn.tid, k)
-		if err != nil {
-			return errors.Trace(err)
+	mutations, primary, err := txn.mutations()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if len(mutations) == 0 {
+		txn.version, err = txn.store.versionProvider.CurrentVersion()
+		return errors.Trace(err)
+	}
+	keys := make([]kv.Key, 0, len(mutations))
+	var secondaries []kv.Key
+	for _, m := range mutations {
+		keys = append(keys, m.key)
+		if m.key.Cmp(primary) != 0 {
+			secondaries = append(secondaries, m.key)
 		}
-		keysLocked = append(keysLocked, k)
 	}
 
-	// disable version provider temporarily
-	providerMu.Lock()
-	defer providerMu.Unlock()
-
-	curVer, err := globalVersionProvider.CurrentVersion()
+	err = txn.store.prewrite(txn.tid, txn.forUpdateTS, primary, mutations)
 	if err != nil {
 		return errors.Trace(err)
 	}
-	err = txn.WalkBuffer(func(k kv.Key, v []byte) error {
-		metaKey := codec.EncodeBytes(nil, k)
-		// put dummy meta key, write current version
-		b.Put(metaKey, codec.EncodeUint(nil, curVer.Ver))
-		mvccKey := MvccEncodeVersionKey(k, curVer)
-		if len(v) == 0 { // Deleted marker
-			b.Put(mvccKey, nil)
-		} else {
-			b.Put(mvccKey, v)
-		}
-		return nil
-	})
+	commitVer, err := txn.store.versionProvider.CurrentVersion()
+	if err == nil {
+		err = txn.store.commitKeys(txn.tid, commitVer.Ver, []kv.Key{primary}, true)
+	}
 	if err != nil {
+		if rollbackErr := txn.store.rollbackKeys(txn.tid, keys); rollbackErr != nil {
+			log.Warnf("txn:%d, rollback failed %v", txn.tid, rollbackErr)
+		}
 		return errors.Trace(err)
 	}
+
+	// The transaction is committed with the primary, the locks of the secondaries
+	// will be resolved by the readers if they fail to be committed.
+	err = txn.store.commitKeys(txn.tid, commitVer.Ver, secondaries, false)
+	if err != nil {
+		log.Warnf("txn:%d, commit secondaries failed %v", txn.tid, err)
+	}
 	// Update commit version.
-	txn.version = curVer
-	return txn.store.writeBatch(b)
+	txn.version = commitVer
+	return nil
 }
 
 func (txn *dbTxn) Commit() error {
@@ -178,6 +241,7 @@ func (txn *dbTxn) Commit() error {
 		return errors.Trace(kv.ErrInvalidTxn)
 	}
 	log.Infof("commit txn %d", txn.tid)
+	defer metric.ObserveDuration(metricCommit, time.Now())
 	defer func() {
 		txn.close()
 	}()
@@ -194,6 +258,7 @@ func (txn *dbTxn) CommittedVersion() (kv.Version, error) {
 }
 
 func (txn *dbTxn) close() error {
+	txn.store.lockManager.release(txn.tid)
 	txn.UnionStore.Release()
 	txn.snapshotVals = nil
 	txn.valid = false
@@ -208,9 +273,91 @@ func (txn *dbTxn) Rollback() error {
 	return txn.close()
 }
 
+func (txn *dbTxn) SetOption(opt kv.Option, val interface{}) {
+	if opt == kv.PessimisticLockWaitTimeout {
+		txn.pessimistic = true
+		txn.lockWaitTimeout = kv.DefaultLockWaitTimeout
+		if timeout, ok := val.(time.Duration); ok {
+			txn.lockWaitTimeout = timeout
+		}
+	}
+	if txn.opts == nil {
+		txn.opts = make(map[kv.Option]interface{})
+	}
+	txn.opts[opt] = val
+	txn.UnionStore.SetOption(opt, val)
+}
+
+func (txn *dbTxn) DelOption(opt kv.Option) {
+	if opt == kv.PessimisticLockWaitTimeout {
+		txn.pessimistic = false
+	}
+	delete(txn.opts, opt)
+	txn.UnionStore.DelOption(opt)
+}
+
 func (txn *dbTxn) LockKeys(keys ...kv.Key) error {
 	for _, key := range keys {
 		txn.markOrigin(key)
 	}
+	if !txn.pessimistic {
+		return nil
+	}
+	defer metric.ObserveDuration(metricLockKeys, time.Now())
+
+	changed := false
+	for _, key := range keys {
+		err := txn.store.lockManager.acquire(txn.tid, key, txn.lockWaitTimeout)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		commitTS, err := txn.store.latestCommit(txn.tid, key)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if commitTS > txn.forUpdateTS {
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	log.Infof("txn:%d, locked keys are changed, refresh snapshot", txn.tid)
+	err := txn.refreshSnapshot()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(kv.ErrWriteConflict)
+}
+
+// refreshSnapshot makes the transaction read the latest data, the buffered writes are kept.
+func (txn *dbTxn) refreshSnapshot() error {
+	// The lazy condition pairs are checked with the old snapshot, the keys written
+	// are checked again with the new snapshot in the prewrite.
+	err := txn.CheckLazyConditionPairs()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	ver, err := txn.store.CurrentVersion()
+	if err != nil {
+		return errors.Trace(err)
+	}
+	us := kv.NewUnionStore(newSnapshot(txn.store, txn.store.db, ver))
+	err = txn.WalkBuffer(func(k kv.Key, v []byte) error {
+		if len(v) == 0 { // Deleted marker
+			return errors.Trace(us.Delete(k))
+		}
+		return errors.Trace(us.Set(k, v))
+	})
+	if err != nil {
+		us.Release()
+		return errors.Trace(err)
+	}
+	for opt, val := range txn.opts {
+		us.SetOption(opt, val)
+	}
+	txn.UnionStore.Release()
+	txn.UnionStore = us
+	txn.forUpdateTS = ver.Ver
 	return nil
 }
@@ -565,6 +565,8 @@ func (s *testKVSuite) TestBoltDBDeadlock(c *C) {
 	}
 	path := "boltdb_test"
 	defer os.Remove(path)
+	// The high-water mark of the timestamp oracle is persisted next to the db.
+	defer os.Remove(path + ".tso")
 	store, err := d.Open(path)
 	c.Assert(err, IsNil)
 	defer store.Close()
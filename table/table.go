@@ -39,6 +39,10 @@ type Table interface {
 	// RowWithCols returns a row that contains the given cols.
 	RowWithCols(retriever kv.Retriever, h int64, cols []*column.Col) ([]interface{}, error)
 
+	// DecodeRow returns a row that contains the given cols, value is the value of the row key.
+	// If the row is in column row format, the column values are fetched from retriever.
+	DecodeRow(retriever kv.Retriever, h int64, value []byte, cols []*column.Col) ([]interface{}, error)
+
 	// Row returns a row for all columns.
 	Row(ctx context.Context, h int64) ([]interface{}, error)
 
@@ -108,6 +112,21 @@ type Table interface {
 
 	// LockRow locks a row.
 	LockRow(ctx context.Context, h int64) error
+
+	// LockRecord locks a row in txn.
+	LockRecord(txn kv.Transaction, h int64) error
+
+	// ColValueExists checks whether the row has a value for the column.
+	ColValueExists(retriever kv.Retriever, h int64, col *column.Col) (bool, error)
+
+	// SetRecordColValue sets the column value of a row, it is used to backfill a new column.
+	SetRecordColValue(rm kv.RetrieverMutator, h int64, col *column.Col, data interface{}) error
+
+	// RemoveRecordColValue removes the column value of a row, it is used to drop a column.
+	RemoveRecordColValue(rm kv.RetrieverMutator, h int64, col *column.Col) error
+
+	// ConvertRecord rewrites a row in the row format of the table.
+	ConvertRecord(txn kv.Transaction, h int64) error
 }
 
 // TableFromMeta builds a table.Table from *model.TableInfo.
@@ -0,0 +1,83 @@
+// Copyright 2015 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tables
+
+import (
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/util/codec"
+)
+
+// The record layout in storage depends on the row format of the row.
+//
+// In column row format (key -> value):
+//
+//	r1 -> lock-version
+//	r1_col1 -> r1 col1 value
+//	r1_col2 -> r1 col2 value
+//
+// In single kv row format (key -> value):
+//
+//	r1 -> singleKVRowFlag, col1 id, r1 col1 value, col2 id, r1 col2 value
+//
+// The lock version is the string of a transaction and never starts with
+// singleKVRowFlag, so every row can be decoded whatever the row format of
+// its table is. This lets a table be converted from one format to another
+// row by row.
+const singleKVRowFlag byte = 0x80
+
+// isSingleKVRow checks whether the value of a row key is a row in single kv row format.
+func isSingleKVRow(value []byte) bool {
+	return len(value) > 0 && value[0] == singleKVRowFlag
+}
+
+// encodeRow encodes the flattened column values with their column IDs.
+func encodeRow(colIDs []int64, values []interface{}) ([]byte, error) {
+	if len(colIDs) != len(values) {
+		return nil, errors.Errorf("invalid row, %d column IDs but %d values", len(colIDs), len(values))
+	}
+
+	data := make([]interface{}, 0, 2*len(values))
+	for i, id := range colIDs {
+		data = append(data, id, values[i])
+	}
+
+	b := []byte{singleKVRowFlag}
+	b, err := codec.EncodeValue(b, data...)
+	return b, errors.Trace(err)
+}
+
+// decodeRow decodes a row in single kv row format to a column ID -> flattened value map.
+func decodeRow(value []byte) (map[int64]interface{}, error) {
+	if !isSingleKVRow(value) {
+		return nil, errors.Errorf("invalid single kv row - %q", value)
+	}
+
+	data, err := codec.Decode(value[1:])
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if len(data)%2 != 0 {
+		return nil, errors.Errorf("invalid single kv row - %q", value)
+	}
+
+	row := make(map[int64]interface{}, len(data)/2)
+	for i := 0; i < len(data); i += 2 {
+		id, ok := data[i].(int64)
+		if !ok {
+			return nil, errors.Errorf("invalid column ID %v in single kv row", data[i])
+		}
+		row[id] = data[i+1]
+	}
+	return row, nil
+}
@@ -37,6 +37,7 @@ import (
 	"github.com/pingcap/tidb/terror"
 	"github.com/pingcap/tidb/util"
 	"github.com/pingcap/tidb/util/codec"
+	"github.com/pingcap/tidb/util/collate"
 	"github.com/pingcap/tidb/util/types"
 )
 
@@ -56,6 +57,7 @@ type Table struct {
 	indexPrefix     string
 	alloc           autoid.Allocator
 	state           model.SchemaState
+	rowFormat       model.RowFormat
 }
 
 // TableFromMeta creates a Table instance from model.TableInfo.
@@ -85,15 +87,35 @@ func TableFromMeta(alloc autoid.Allocator, tblInfo *model.TableInfo) (table.Tabl
 			IndexInfo: *idxInfo,
 		}
 
-		idx.X = kv.NewKVIndex(t.IndexPrefix(), idxInfo.Name.L, idxInfo.ID, idxInfo.Unique)
+		idx.X = NewIndex(t.IndexPrefix(), tblInfo.Columns, idxInfo)
 
 		t.AddIndex(idx)
 	}
 
 	t.state = tblInfo.State
+	t.rowFormat = tblInfo.RowFormat
 	return t, nil
 }
 
+// NewIndex builds the KV index for the index info, the indexed values are encoded
+// under the collations of the indexed columns if the index is collated.
+func NewIndex(indexPrefix string, cols []*model.ColumnInfo, idxInfo *model.IndexInfo) kv.Index {
+	if !idxInfo.Collated {
+		return kv.NewKVIndex(indexPrefix, idxInfo.Name.L, idxInfo.ID, idxInfo.Unique)
+	}
+	var collations []string
+	for i, ic := range idxInfo.Columns {
+		if ic.Offset >= len(cols) || !collate.IsCaseInsensitive(cols[ic.Offset].Collate) {
+			continue
+		}
+		if collations == nil {
+			collations = make([]string, len(idxInfo.Columns))
+		}
+		collations[i] = cols[ic.Offset].Collate
+	}
+	return kv.NewCollatedKVIndex(indexPrefix, idxInfo.Name.L, idxInfo.ID, idxInfo.Unique, collations)
+}
+
 // NewTable constructs a Table instance.
 func NewTable(tableID int64, tableName string, cols []*column.Col, alloc autoid.Allocator) *Table {
 	name := model.NewCIStr(tableName)
@@ -135,9 +157,10 @@ func (t *Table) TableName() model.CIStr {
 // Meta implements table.Table Meta interface.
 func (t *Table) Meta() *model.TableInfo {
 	ti := &model.TableInfo{
-		Name:  t.Name,
-		ID:    t.ID,
-		State: t.state,
+		Name:      t.Name,
+		ID:        t.ID,
+		State:     t.state,
+		RowFormat: t.rowFormat,
 	}
 	// load table meta
 	for _, col := range t.Columns {
@@ -185,6 +208,54 @@ func (t *Table) writableCols() []*column.Col {
 	return t.writableColumns
 }
 
+// originCol returns the column whose values are converted and written to col, it returns nil
+// if col is not a changing column.
+func (t *Table) originCol(col *column.Col) *column.Col {
+	if col.OriginID == 0 {
+		return nil
+	}
+	for _, c := range t.Columns {
+		if c.ID == col.OriginID {
+			return c
+		}
+	}
+	return nil
+}
+
+// fillChangingValues extends the row to all the columns and fills the values of the changing
+// columns, which are converted from the values of their origin columns, it returns r if there
+// is no changing column. The conversion error is returned if strict is true and the values are
+// written to a new changing column, the values which can't be converted are set to nil otherwise.
+func (t *Table) fillChangingValues(r []interface{}, strict bool) ([]interface{}, error) {
+	var row []interface{}
+	for _, col := range t.Columns {
+		origin := t.originCol(col)
+		if origin == nil {
+			continue
+		}
+		if row == nil {
+			row = make([]interface{}, len(t.Columns))
+			copy(row, r)
+		}
+
+		v, err := types.Convert(row[origin.Offset], &col.FieldType)
+		if err != nil {
+			// The new column has a greater ID than its origin column, the origin column
+			// is kept writable after it's replaced, but its values are only best effort.
+			if strict && col.ID > origin.ID && col.State != model.StateDeleteOnly && col.State != model.StateDeleteReorganization {
+				return nil, errors.Trace(err)
+			}
+			v = nil
+		}
+		row[col.Offset] = v
+	}
+
+	if row == nil {
+		return r, nil
+	}
+	return row, nil
+}
+
 func (t *Table) unflatten(rec interface{}, col *column.Col) (interface{}, error) {
 	if rec == nil {
 		return nil, nil
@@ -302,6 +373,19 @@ func (t *Table) UpdateRecord(ctx context.Context, h int64, oldData []interface{}
 		return errors.Trace(err)
 	}
 
+	// The values of the changing columns are converted from the old and new values of their origin columns.
+	if oldData, err = t.fillChangingValues(oldData, false); err != nil {
+		return errors.Trace(err)
+	}
+	if currentData, err = t.fillChangingValues(currentData, true); err != nil {
+		return errors.Trace(err)
+	}
+	for _, col := range t.Columns {
+		if origin := t.originCol(col); origin != nil {
+			touched[col.Offset] = touched[origin.Offset]
+		}
+	}
+
 	txn, err := ctx.GetTxn(false)
 	if err != nil {
 		return errors.Trace(err)
@@ -357,17 +441,116 @@ func (t *Table) SetColValue(rm kv.RetrieverMutator, key []byte, data interface{}
 }
 
 func (t *Table) setNewData(rm kv.RetrieverMutator, h int64, touched map[int]bool, data []interface{}) error {
-	for _, col := range t.Cols() {
+	value, err := rm.Get(t.RecordKey(h, nil))
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	if t.rowFormat == model.RowFormatColumn && !isSingleKVRow(value) {
+		for _, col := range t.writableCols() {
+			if !touched[col.Offset] {
+				continue
+			}
+
+			k := t.RecordKey(h, col)
+			if err := t.SetColValue(rm, k, data[col.Offset]); err != nil {
+				return errors.Trace(err)
+			}
+		}
+
+		return nil
+	}
+
+	// The row is rewritten as a whole in single kv row format, so we must keep
+	// the untouched column values, and a row in column row format is converted.
+	row, err := t.fetchRowData(rm, h, value)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if !isSingleKVRow(value) {
+		if err = t.removeColValues(rm, h); err != nil {
+			return errors.Trace(err)
+		}
+	}
+
+	for _, col := range t.writableCols() {
 		if !touched[col.Offset] {
 			continue
 		}
 
-		k := t.RecordKey(h, col)
-		if err := t.SetColValue(rm, k, data[col.Offset]); err != nil {
+		row[col.ID], err = t.flatten(data[col.Offset])
+		if err != nil {
 			return errors.Trace(err)
 		}
 	}
 
+	return t.setRowData(rm, h, row)
+}
+
+// fetchRowData fetches the flattened values of all the columns of a row, keyed by column ID.
+// The value is the value of the row key.
+func (t *Table) fetchRowData(retriever kv.Retriever, h int64, value []byte) (map[int64]interface{}, error) {
+	if isSingleKVRow(value) {
+		row, err := decodeRow(value)
+		return row, errors.Trace(err)
+	}
+
+	row := make(map[int64]interface{}, len(t.Columns))
+	for _, col := range t.Columns {
+		data, err := retriever.Get(t.RecordKey(h, col))
+		if err != nil {
+			if col.State != model.StatePublic && kv.IsErrNotFound(err) {
+				// If the column is not in public state, we may have not added the column,
+				// or already deleted the column, so skip ErrNotExist error.
+				continue
+			}
+
+			return nil, errors.Trace(err)
+		}
+
+		vals, err := codec.Decode(data)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		row[col.ID] = vals[0]
+	}
+	return row, nil
+}
+
+// setRowData writes a row in single kv row format, values of the columns which
+// don't belong to the table any more are discarded.
+func (t *Table) setRowData(rm kv.RetrieverMutator, h int64, row map[int64]interface{}) error {
+	colIDs := make([]int64, 0, len(row))
+	values := make([]interface{}, 0, len(row))
+	for _, col := range t.Columns {
+		if v, ok := row[col.ID]; ok {
+			colIDs = append(colIDs, col.ID)
+			values = append(values, v)
+		}
+	}
+
+	value, err := encodeRow(colIDs, values)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(rm.Set(t.RecordKey(h, nil), value))
+}
+
+// removeColValues removes the column KV pairs of a row in column row format.
+func (t *Table) removeColValues(rm kv.RetrieverMutator, h int64) error {
+	for _, col := range t.Columns {
+		k := t.RecordKey(h, col)
+		err := rm.Delete([]byte(k))
+		if err != nil {
+			if col.State != model.StatePublic && terror.ErrorEqual(err, kv.ErrNotExist) {
+				// If the column is not in public state, we may have not added the column,
+				// or already deleted the column, so skip ErrNotExist error.
+				continue
+			}
+
+			return errors.Trace(err)
+		}
+	}
 	return nil
 }
 
@@ -423,6 +606,9 @@ func (t *Table) AddRecord(ctx context.Context, r []interface{}, h int64) (record
 	bs := kv.NewBufferStore(txn)
 	defer bs.Release()
 
+	if r, err = t.fillChangingValues(r, true); err != nil {
+		return 0, errors.Trace(err)
+	}
 	for _, v := range t.indices {
 		if v == nil || v.State == model.StateDeleteOnly || v.State == model.StateDeleteReorganization {
 			// if index is in delete only or delete reorganization state, we can't add it.
@@ -447,14 +633,17 @@ func (t *Table) AddRecord(ctx context.Context, r []interface{}, h int64) (record
 		}
 	}
 
-	if err = t.LockRow(ctx, recordID); err != nil {
+	var row map[int64]interface{}
+	if t.rowFormat == model.RowFormatSingleKV {
+		row = make(map[int64]interface{}, len(t.writableCols()))
+	} else if err = t.LockRow(ctx, recordID); err != nil {
 		return 0, errors.Trace(err)
 	}
 
 	// Set public and write only column value.
 	for _, col := range t.writableCols() {
 		var value interface{}
-		if col.State == model.StateWriteOnly || col.State == model.StateWriteReorganization {
+		if col.OriginID == 0 && (col.State == model.StateWriteOnly || col.State == model.StateWriteReorganization) {
 			// if col is in write only or write reorganization state, we must add it with its default value.
 			value, _, err = GetColDefaultValue(ctx, &col.ColumnInfo)
 			if err != nil {
@@ -468,6 +657,14 @@ func (t *Table) AddRecord(ctx context.Context, r []interface{}, h int64) (record
 			value = r[col.Offset]
 		}
 
+		if row != nil {
+			row[col.ID], err = t.flatten(value)
+			if err != nil {
+				return 0, errors.Trace(err)
+			}
+			continue
+		}
+
 		key := t.RecordKey(recordID, col)
 		err = t.SetColValue(txn, key, value)
 		if err != nil {
@@ -475,6 +672,12 @@ func (t *Table) AddRecord(ctx context.Context, r []interface{}, h int64) (record
 		}
 	}
 
+	if row != nil {
+		if err = t.setRowData(txn, recordID, row); err != nil {
+			return 0, errors.Trace(err)
+		}
+	}
+
 	if err = bs.SaveTo(txn); err != nil {
 		return 0, errors.Trace(err)
 	}
@@ -505,6 +708,28 @@ func (t *Table) DecodeValue(data []byte, col *column.Col) (interface{}, error) {
 
 // RowWithCols implements table.Table RowWithCols interface.
 func (t *Table) RowWithCols(retriever kv.Retriever, h int64, cols []*column.Col) ([]interface{}, error) {
+	value, err := retriever.Get(t.RecordKey(h, nil))
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+
+	return t.DecodeRow(retriever, h, value, cols)
+}
+
+// DecodeRow implements table.Table DecodeRow interface.
+func (t *Table) DecodeRow(retriever kv.Retriever, h int64, value []byte, cols []*column.Col) ([]interface{}, error) {
+	var (
+		row map[int64]interface{}
+		err error
+	)
+	singleKV := isSingleKVRow(value)
+	if singleKV {
+		row, err = decodeRow(value)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+
 	// use the length of t.Cols() for alignment
 	v := make([]interface{}, len(t.Cols()))
 	for _, col := range cols {
@@ -512,13 +737,17 @@ func (t *Table) RowWithCols(retriever kv.Retriever, h int64, cols []*column.Col)
 			return nil, errors.Errorf("Cannot use none public column - %v", cols)
 		}
 
-		k := t.RecordKey(h, col)
-		data, err := retriever.Get([]byte(k))
-		if err != nil {
-			return nil, errors.Trace(err)
+		var val interface{}
+		if singleKV {
+			val, err = t.unflatten(row[col.ID], col)
+		} else {
+			var data []byte
+			data, err = retriever.Get(t.RecordKey(h, col))
+			if err != nil {
+				return nil, errors.Trace(err)
+			}
+			val, err = t.DecodeValue(data, col)
 		}
-
-		val, err := t.DecodeValue(data, col)
 		if err != nil {
 			return nil, errors.Trace(err)
 		}
@@ -548,13 +777,149 @@ func (t *Table) LockRow(ctx context.Context, h int64) error {
 	if err != nil {
 		return errors.Trace(err)
 	}
+	return t.LockRecord(txn, h)
+}
+
+// LockRecord implements table.Table LockRecord interface.
+func (t *Table) LockRecord(txn kv.Transaction, h int64) error {
 	// Get row lock key
 	lockKey := t.RecordKey(h, nil)
+	value, err := txn.Get(lockKey)
+	if err != nil && !kv.IsErrNotFound(err) {
+		return errors.Trace(err)
+	}
+	if isSingleKVRow(value) {
+		// The row key holds the row data, set the data again to lock the row.
+		return errors.Trace(txn.Set(lockKey, value))
+	}
 	// set row lock key to current txn
-	err = txn.Set([]byte(lockKey), []byte(txn.String()))
+	err = txn.Set(lockKey, []byte(txn.String()))
 	return errors.Trace(err)
 }
 
+// ColValueExists implements table.Table ColValueExists interface.
+func (t *Table) ColValueExists(retriever kv.Retriever, h int64, col *column.Col) (bool, error) {
+	value, err := retriever.Get(t.RecordKey(h, nil))
+	if err != nil {
+		return false, errors.Trace(err)
+	}
+
+	if isSingleKVRow(value) {
+		row, err := decodeRow(value)
+		if err != nil {
+			return false, errors.Trace(err)
+		}
+		_, ok := row[col.ID]
+		return ok, nil
+	}
+
+	_, err = retriever.Get(t.RecordKey(h, col))
+	if kv.IsErrNotFound(err) {
+		return false, nil
+	} else if err != nil {
+		return false, errors.Trace(err)
+	}
+	return true, nil
+}
+
+// SetRecordColValue implements table.Table SetRecordColValue interface.
+func (t *Table) SetRecordColValue(rm kv.RetrieverMutator, h int64, col *column.Col, data interface{}) error {
+	value, err := rm.Get(t.RecordKey(h, nil))
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	if !isSingleKVRow(value) {
+		return t.SetColValue(rm, t.RecordKey(h, col), data)
+	}
+
+	row, err := decodeRow(value)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	row[col.ID], err = t.flatten(data)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	return t.setRowData(rm, h, row)
+}
+
+// RemoveRecordColValue implements table.Table RemoveRecordColValue interface.
+func (t *Table) RemoveRecordColValue(rm kv.RetrieverMutator, h int64, col *column.Col) error {
+	value, err := rm.Get(t.RecordKey(h, nil))
+	if kv.IsErrNotFound(err) {
+		// The row has been deleted.
+		return nil
+	} else if err != nil {
+		return errors.Trace(err)
+	}
+
+	if !isSingleKVRow(value) {
+		err = rm.Delete(t.RecordKey(h, col))
+		if err != nil && !kv.IsErrNotFound(err) {
+			return errors.Trace(err)
+		}
+		return nil
+	}
+
+	row, err := decodeRow(value)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if _, ok := row[col.ID]; !ok {
+		return nil
+	}
+	delete(row, col.ID)
+	return t.setRowData(rm, h, row)
+}
+
+// ConvertRecord implements table.Table ConvertRecord interface.
+func (t *Table) ConvertRecord(txn kv.Transaction, h int64) error {
+	rowKey := t.RecordKey(h, nil)
+	value, err := txn.Get(rowKey)
+	if kv.IsErrNotFound(err) {
+		// The row has been deleted.
+		return nil
+	} else if err != nil {
+		return errors.Trace(err)
+	}
+
+	singleKV := isSingleKVRow(value)
+	if singleKV == (t.rowFormat == model.RowFormatSingleKV) {
+		return nil
+	}
+
+	row, err := t.fetchRowData(txn, h, value)
+	if err != nil {
+		return errors.Trace(err)
+	}
+
+	if !singleKV {
+		if err = t.removeColValues(txn, h); err != nil {
+			return errors.Trace(err)
+		}
+		return t.setRowData(txn, h, row)
+	}
+
+	if err = txn.Set(rowKey, []byte(txn.String())); err != nil {
+		return errors.Trace(err)
+	}
+	for _, col := range t.Columns {
+		v, ok := row[col.ID]
+		if !ok {
+			continue
+		}
+		b, err := codec.EncodeValue(nil, v)
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if err = txn.Set(t.RecordKey(h, col), b); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return nil
+}
+
 // RemoveRecord implements table.Table RemoveRecord interface.
 func (t *Table) RemoveRecord(ctx context.Context, h int64, r []interface{}) error {
 	err := t.removeRowData(ctx, h)
@@ -562,6 +927,9 @@ func (t *Table) RemoveRecord(ctx context.Context, h int64, r []interface{}) erro
 		return errors.Trace(err)
 	}
 
+	if r, err = t.fillChangingValues(r, false); err != nil {
+		return errors.Trace(err)
+	}
 	err = t.removeRowIndices(ctx, h, r)
 	if err != nil {
 		return errors.Trace(err)
@@ -578,21 +946,17 @@ func (t *Table) removeRowData(ctx context.Context, h int64) error {
 	if err != nil {
 		return errors.Trace(err)
 	}
-	// Remove row's colume one by one
-	for _, col := range t.Columns {
-		k := t.RecordKey(h, col)
-		err = txn.Delete([]byte(k))
-		if err != nil {
-			if col.State != model.StatePublic && terror.ErrorEqual(err, kv.ErrNotExist) {
-				// If the column is not in public state, we may have not added the column,
-				// or already deleted the column, so skip ErrNotExist error.
-				continue
-			}
-
+	value, err := txn.Get(t.RecordKey(h, nil))
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if !isSingleKVRow(value) {
+		// Remove row's colume one by one
+		if err = t.removeColValues(txn, h); err != nil {
 			return errors.Trace(err)
 		}
 	}
-	// Remove row lock
+	// Remove row lock, or the row data in single kv row format.
 	err = txn.Delete([]byte(t.RecordKey(h, nil)))
 	if err != nil {
 		return errors.Trace(err)
@@ -663,7 +1027,7 @@ func (t *Table) IterRecords(retriever kv.Retriever, startKey string, cols []*col
 
 	prefix := t.KeyPrefix()
 	for it.Valid() && strings.HasPrefix(it.Key(), prefix) {
-		// first kv pair is row lock information.
+		// first kv pair is row lock information, or the row data in single kv row format.
 		// TODO: check valid lock
 		// get row handle
 		handle, err := DecodeRecordKeyHandle(it.Key())
@@ -671,7 +1035,7 @@ func (t *Table) IterRecords(retriever kv.Retriever, startKey string, cols []*col
 			return errors.Trace(err)
 		}
 
-		data, err := t.RowWithCols(retriever, handle, cols)
+		data, err := t.DecodeRow(retriever, handle, it.Value(), cols)
 		if err != nil {
 			return errors.Trace(err)
 		}
@@ -26,6 +26,7 @@ import (
 	"github.com/pingcap/tidb/sessionctx"
 	"github.com/pingcap/tidb/store/localstore"
 	"github.com/pingcap/tidb/store/localstore/goleveldb"
+	"github.com/pingcap/tidb/table"
 	"github.com/pingcap/tidb/table/tables"
 	"github.com/pingcap/tidb/util"
 )
@@ -126,6 +127,96 @@ func countEntriesWithPrefix(ctx context.Context, prefix string) (int, error) {
 	return cnt, err
 }
 
+func (ts *testSuite) TestRowFormat(c *C) {
+	_, err := ts.se.Execute("CREATE TABLE test.t (a int, b varchar(255), c int)")
+	c.Assert(err, IsNil)
+	ctx := ts.se.(context.Context)
+	dom := sessionctx.GetDomain(ctx)
+	tb, err := dom.InfoSchema().TableByName(model.NewCIStr("test"), model.NewCIStr("t"))
+	c.Assert(err, IsNil)
+	c.Assert(tb.Meta().RowFormat, Equals, model.RowFormatSingleKV)
+
+	// Build the same table in column row format, rows in both formats can be read and written by each table.
+	tblInfo := tb.Meta()
+	tblInfo.RowFormat = model.RowFormatColumn
+	ctb, err := tables.TableFromMeta(nil, tblInfo)
+	c.Assert(err, IsNil)
+
+	recordCnt := func() int {
+		cnt, err2 := countEntriesWithPrefix(ctx, tb.KeyPrefix())
+		c.Assert(err2, IsNil)
+		return cnt
+	}
+	checkRow := func(t table.Table, h int64, expected ...interface{}) {
+		row, err2 := t.Row(ctx, h)
+		c.Assert(err2, IsNil)
+		c.Assert(row, DeepEquals, expected)
+	}
+
+	_, err = tb.AddRecord(ctx, []interface{}{int64(1), "a", int64(10)}, 1)
+	c.Assert(err, IsNil)
+	c.Assert(recordCnt(), Equals, 1)
+	_, err = ctb.AddRecord(ctx, []interface{}{int64(2), "b", int64(20)}, 2)
+	c.Assert(err, IsNil)
+	c.Assert(recordCnt(), Equals, 5)
+	checkRow(tb, 2, int64(2), []byte("b"), int64(20))
+	checkRow(ctb, 1, int64(1), []byte("a"), int64(10))
+
+	txn, err := ctx.GetTxn(false)
+	c.Assert(err, IsNil)
+	var handles []int64
+	err = ctb.IterRecords(txn, tb.FirstKey(), tb.Cols(), func(h int64, data []interface{}, cols []*column.Col) (bool, error) {
+		handles = append(handles, h)
+		c.Assert(data[0], Equals, h)
+		return true, nil
+	})
+	c.Assert(err, IsNil)
+	c.Assert(handles, DeepEquals, []int64{1, 2})
+
+	// Updating a row in column row format converts it in single kv row format table.
+	err = tb.UpdateRecord(ctx, 2, []interface{}{int64(2), "b", int64(20)}, []interface{}{int64(2), "b", int64(21)}, map[int]bool{2: true})
+	c.Assert(err, IsNil)
+	c.Assert(recordCnt(), Equals, 2)
+	checkRow(ctb, 2, int64(2), []byte("b"), int64(21))
+	// A row in single kv row format stays in single kv row format.
+	err = ctb.UpdateRecord(ctx, 1, []interface{}{int64(1), "a", int64(10)}, []interface{}{int64(1), "aa", int64(10)}, map[int]bool{1: true})
+	c.Assert(err, IsNil)
+	c.Assert(recordCnt(), Equals, 2)
+	checkRow(tb, 1, int64(1), []byte("aa"), int64(10))
+
+	// Locking a row in single kv row format keeps the row data.
+	c.Assert(ctb.LockRow(ctx, 1), IsNil)
+	checkRow(tb, 1, int64(1), []byte("aa"), int64(10))
+
+	col := tb.Cols()[1]
+	exist, err := ctb.ColValueExists(txn, 1, col)
+	c.Assert(err, IsNil)
+	c.Assert(exist, IsTrue)
+	c.Assert(ctb.RemoveRecordColValue(txn, 1, col), IsNil)
+	exist, err = ctb.ColValueExists(txn, 1, col)
+	c.Assert(err, IsNil)
+	c.Assert(exist, IsFalse)
+	c.Assert(ctb.SetRecordColValue(txn, 1, col, "c"), IsNil)
+	checkRow(tb, 1, int64(1), []byte("c"), int64(10))
+
+	// Convert all the rows to column row format, and back to single kv row format.
+	c.Assert(ctb.ConvertRecord(txn, 1), IsNil)
+	c.Assert(ctb.ConvertRecord(txn, 2), IsNil)
+	c.Assert(recordCnt(), Equals, 8)
+	checkRow(tb, 1, int64(1), []byte("c"), int64(10))
+	c.Assert(tb.ConvertRecord(txn, 1), IsNil)
+	c.Assert(tb.ConvertRecord(txn, 2), IsNil)
+	c.Assert(recordCnt(), Equals, 2)
+	checkRow(ctb, 2, int64(2), []byte("b"), int64(21))
+
+	c.Assert(tb.RemoveRecord(ctx, 1, []interface{}{int64(1), "c", int64(10)}), IsNil)
+	c.Assert(ctb.RemoveRecord(ctx, 2, []interface{}{int64(2), "b", int64(21)}), IsNil)
+	c.Assert(recordCnt(), Equals, 0)
+
+	_, err = ts.se.Execute("drop table test.t")
+	c.Assert(err, IsNil)
+}
+
 func (ts *testSuite) TestTypes(c *C) {
 	_, err := ts.se.Execute("CREATE TABLE test.t (c1 tinyint, c2 smallint, c3 int, c4 bigint, c5 text, c6 blob, c7 varchar(64), c8 time, c9 timestamp not null default CURRENT_TIMESTAMP, c10 decimal)")
 	c.Assert(err, IsNil)
@@ -198,6 +289,35 @@ func (ts *testSuite) TestUniqueIndexMultipleNullEntries(c *C) {
 	c.Assert(err, IsNil)
 }
 
+func (ts *testSuite) TestCollatedIndex(c *C) {
+	_, err := ts.se.Execute("CREATE TABLE test.t (a int primary key, b varchar(255) collate utf8_general_ci unique)")
+	c.Assert(err, IsNil)
+	dom := sessionctx.GetDomain(ts.se.(context.Context))
+	tb, err := dom.InfoSchema().TableByName(model.NewCIStr("test"), model.NewCIStr("t"))
+	c.Assert(err, IsNil)
+	idxInfo := tb.FindIndexByColName("b").IndexInfo
+	c.Assert(idxInfo.Collated, IsTrue)
+
+	// The strings are encoded as the weight keys under the case insensitive collation.
+	idx := tables.NewIndex(tb.IndexPrefix(), tb.Meta().Columns, &idxInfo)
+	k1, _, err := idx.GenIndexKey([]interface{}{"abc"}, 1)
+	c.Assert(err, IsNil)
+	k2, _, err := idx.GenIndexKey([]interface{}{"ABC"}, 2)
+	c.Assert(err, IsNil)
+	c.Assert(k1, DeepEquals, k2)
+
+	// The strings are encoded by bytes in the index created before the collations are supported.
+	idxInfo.Collated = false
+	idx = tables.NewIndex(tb.IndexPrefix(), tb.Meta().Columns, &idxInfo)
+	k1, _, err = idx.GenIndexKey([]interface{}{"abc"}, 1)
+	c.Assert(err, IsNil)
+	k2, _, err = idx.GenIndexKey([]interface{}{"ABC"}, 2)
+	c.Assert(err, IsNil)
+	c.Assert(k1, Not(DeepEquals), k2)
+	_, err = ts.se.Execute("drop table test.t")
+	c.Assert(err, IsNil)
+}
+
 func (ts *testSuite) TestRowKeyCodec(c *C) {
 	table := []struct {
 		tableID int64
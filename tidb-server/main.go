@@ -22,19 +22,31 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/juju/errors"
 	"github.com/ngaut/log"
 	"github.com/pingcap/tidb"
 	"github.com/pingcap/tidb/metric"
 	"github.com/pingcap/tidb/tidb-server/server"
 	"github.com/pingcap/tidb/util/printer"
+	"github.com/pingcap/tidb/util/stmtsummary"
 )
 
 var (
 	store     = flag.String("store", "goleveldb", "registered store name, [hbase, memory, goleveldb, boltdb]")
-	storePath = flag.String("path", "/tmp/tidb", "tidb storage path")
+	storePath = flag.String("path", "/tmp/tidb", "tidb storage path, append ?tso=host:port to get the versions of localstore from a tso-server")
 	logLevel  = flag.String("L", "debug", "log level: info, debug, warn, error, fatal")
 	port      = flag.String("P", "4000", "mp server port")
 	lease     = flag.Int("lease", 1, "schema lease seconds, very dangerous to change only if you know what you do")
+	sslCert   = flag.String("ssl-cert", "", "path of the PEM encoded server certificate, enables TLS connections with ssl-key")
+	sslKey    = flag.String("ssl-key", "", "path of the PEM encoded private key of ssl-cert")
+	sslCA     = flag.String("ssl-ca", "", "path of the PEM encoded CA certificates to verify the client certificates")
+	slowLog   = flag.String("slow-log-file", "", "path of the slow query log file, disabled if empty")
+	slowTime  = flag.Int("slow-threshold", 300, "the statements running longer than the milliseconds are written to the slow query log")
+	sumEnable = flag.Bool("stmt-summary", true, "aggregate the executions of the statements in INFORMATION_SCHEMA.STATEMENTS_SUMMARY")
+	sumWindow = flag.Int("stmt-summary-refresh", 1800, "seconds of the window of INFORMATION_SCHEMA.STATEMENTS_SUMMARY, it's cleared when the window is refreshed, never refreshed if 0")
+	shutdown  = flag.Int("shutdown-timeout", 30, "seconds to wait for the running statements and transactions when the server is shutting down")
+	socket    = flag.String("socket", "", "path of the Unix socket the server also listens on, disabled if empty")
+	status    = flag.String("status", "", "tidb server status port, serves /status, /metrics and /debug/pprof, disabled by default")
 )
 
 func main() {
@@ -49,10 +61,24 @@ func main() {
 	}
 
 	tidb.SetSchemaLease(time.Duration(*lease) * time.Second)
+	if *slowLog != "" {
+		if err := tidb.SetSlowQueryLog(*slowLog, time.Duration(*slowTime)*time.Millisecond); err != nil {
+			log.Fatal(err)
+		}
+	}
+	stmtsummary.Global().SetEnabled(*sumEnable)
+	stmtsummary.Global().SetRefreshInterval(time.Duration(*sumWindow) * time.Second)
 
 	cfg := &server.Config{
 		Addr:     fmt.Sprintf(":%s", *port),
 		LogLevel: *logLevel,
+		SSLCert:  *sslCert,
+		SSLKey:   *sslKey,
+		SSLCA:    *sslCA,
+		Socket:   *socket,
+	}
+	if *status != "" {
+		cfg.StatusAddr = fmt.Sprintf(":%s", *status)
 	}
 
 	log.SetLevelByString(cfg.LogLevel)
@@ -76,12 +102,20 @@ func main() {
 		syscall.SIGTERM,
 		syscall.SIGQUIT)
 
+	exited := make(chan struct{})
 	go func() {
 		sig := <-sc
 		log.Infof("Got signal [%d] to exit.", sig)
-		svr.Close()
-		os.Exit(0)
+		svr.Shutdown(time.Duration(*shutdown) * time.Second)
+		if err := tidb.CloseStore(store); err != nil {
+			log.Errorf("close store error %v", errors.ErrorStack(err))
+		}
+		close(exited)
 	}()
 
-	log.Error(svr.Run())
+	if err := svr.Run(); err != nil {
+		log.Error(err)
+		return
+	}
+	<-exited
 }
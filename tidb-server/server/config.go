@@ -18,4 +18,16 @@ type Config struct {
 	Addr     string `json:"addr" toml:"addr"`
 	LogLevel string `json:"log_level" toml:"log_level"`
 	SkipAuth bool   `json:"skip_auth" toml:"skip_auth"`
+	// SSLCert and SSLKey are the paths of the PEM encoded certificate and private key of the
+	// server, the clients can connect with TLS if they are set.
+	SSLCert string `json:"ssl_cert" toml:"ssl_cert"`
+	SSLKey  string `json:"ssl_key" toml:"ssl_key"`
+	// SSLCA is the path of the PEM encoded CA certificates to verify the client certificates.
+	SSLCA string `json:"ssl_ca" toml:"ssl_ca"`
+	// StatusAddr is the address of the HTTP server reporting the status and the metrics,
+	// it's disabled if empty.
+	StatusAddr string `json:"status_addr" toml:"status_addr"`
+	// Socket is the path of the Unix socket the server listens on besides Addr, it's disabled
+	// if empty.
+	Socket string `json:"socket" toml:"socket"`
 }
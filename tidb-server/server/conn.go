@@ -35,17 +35,24 @@
 package server
 
 import (
+	"bufio"
 	"bytes"
+	"crypto/tls"
 	"encoding/binary"
 	"fmt"
 	"io"
 	"net"
 	"runtime"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/juju/errors"
 	"github.com/ngaut/log"
+	"github.com/pingcap/tidb/metric"
 	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/privilege"
+	"github.com/pingcap/tidb/sessionctx/processlist"
 	"github.com/pingcap/tidb/terror"
 	"github.com/pingcap/tidb/util/arena"
 	"github.com/pingcap/tidb/util/hack"
@@ -53,7 +60,9 @@ import (
 
 var defaultCapability = mysql.ClientLongPassword | mysql.ClientLongFlag |
 	mysql.ClientConnectWithDB | mysql.ClientProtocol41 |
-	mysql.ClientTransactions | mysql.ClientSecureConnection | mysql.ClientFoundRows
+	mysql.ClientTransactions | mysql.ClientSecureConnection | mysql.ClientFoundRows |
+	mysql.ClientMultiStatements | mysql.ClientMultiResults |
+	mysql.ClientPluginAuth | mysql.ClientPluginAuthLenencClientData | mysql.ClientCompress
 
 type clientConn struct {
 	pkg          *packetIO
@@ -69,6 +78,93 @@ type clientConn struct {
 	alloc        arena.Allocator
 	lastCmd      string
 	ctx          IContext
+	// moreResults is set when the result being written is followed by the results of
+	// the other statements in a multi-statement query.
+	moreResults bool
+
+	// mu protects the fields below, they are read by SHOW PROCESSLIST of other connections.
+	mu          sync.Mutex
+	command     byte
+	commandTime time.Time
+	currentDB   string
+	info        string
+	// inTxn is true if the connection is in a transaction when the command finishes.
+	inTxn bool
+	// closing is set if the connection is closed by closeIfIdle, it doesn't run new commands.
+	closing bool
+}
+
+// commandNames is the command names shown by SHOW PROCESSLIST.
+var commandNames = map[byte]string{
+	mysql.ComSleep:            "Sleep",
+	mysql.ComQuit:             "Quit",
+	mysql.ComInitDB:           "Init DB",
+	mysql.ComQuery:            "Query",
+	mysql.ComFieldList:        "Field List",
+	mysql.ComPing:             "Ping",
+	mysql.ComStmtPrepare:      "Prepare",
+	mysql.ComStmtExecute:      "Execute",
+	mysql.ComStmtSendLongData: "Long Data",
+	mysql.ComStmtClose:        "Close stmt",
+	mysql.ComStmtReset:        "Reset stmt",
+}
+
+// setCommand records the command being executed and its statement for SHOW PROCESSLIST,
+// it returns false if the connection is closing and the command must not run.
+func (cc *clientConn) setCommand(cmd byte, info string) bool {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	if cc.closing && cmd != mysql.ComSleep {
+		return false
+	}
+	cc.command = cmd
+	cc.commandTime = time.Now()
+	cc.info = info
+	if cc.ctx != nil {
+		cc.currentDB = cc.ctx.CurrentDB()
+		cc.inTxn = cc.ctx.Status()&mysql.ServerStatusInTrans > 0
+	}
+	return true
+}
+
+// closeIfIdle closes the connection if it's waiting for the next command out of a transaction,
+// it returns false if the connection is busy.
+func (cc *clientConn) closeIfIdle() bool {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	if cc.closing {
+		return true
+	}
+	if cc.command != mysql.ComSleep || cc.inTxn {
+		return false
+	}
+	cc.closing = true
+	// The Run loop fails to read the next packet and closes the connection.
+	cc.conn.Close()
+	return true
+}
+
+// processInfo returns the information of the connection shown by SHOW PROCESSLIST.
+func (cc *clientConn) processInfo() processlist.ProcessInfo {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	command, ok := commandNames[cc.command]
+	if !ok {
+		command = fmt.Sprintf("Command %d", cc.command)
+	}
+	host := cc.conn.RemoteAddr().String()
+	if cc.isUnixSocket() {
+		host = "localhost"
+	}
+	return processlist.ProcessInfo{
+		ID:      uint64(cc.connectionID),
+		User:    cc.user,
+		Host:    host,
+		DB:      cc.currentDB,
+		Command: command,
+		Time:    cc.commandTime,
+		Info:    cc.info,
+	}
 }
 
 func (cc *clientConn) String() string {
@@ -94,17 +190,23 @@ func (cc *clientConn) handshake() error {
 	}
 
 	err := cc.writePacket(data)
-	cc.pkg.sequence = 0
+	cc.pkg.resetSequence()
 	if err != nil {
 		return errors.Trace(err)
 	}
-
-	return errors.Trace(cc.flush())
+	if err = cc.flush(); err != nil {
+		return errors.Trace(err)
+	}
+	if cc.capability&mysql.ClientCompress > 0 {
+		cc.pkg.enableCompression()
+	}
+	return nil
 }
 
 func (cc *clientConn) Close() error {
 	cc.server.rwlock.Lock()
 	delete(cc.server.clients, cc.connectionID)
+	metric.SetGauge("tidb_server_connections", int64(len(cc.server.clients)))
 	cc.server.rwlock.Unlock()
 	cc.conn.Close()
 	if cc.ctx != nil {
@@ -127,15 +229,16 @@ func (cc *clientConn) writeInitialHandshake() error {
 	data = append(data, cc.salt[0:8]...)
 	// filler [00]
 	data = append(data, 0)
-	// capability flag lower 2 bytes, using default capability here
-	data = append(data, byte(defaultCapability), byte(defaultCapability>>8))
+	// capability flag lower 2 bytes
+	capability := cc.server.capability
+	data = append(data, byte(capability), byte(capability>>8))
 	// charset, utf-8 default
 	data = append(data, uint8(mysql.DefaultCollationID))
 	//status
 	data = append(data, dumpUint16(mysql.ServerStatusAutocommit)...)
 	// below 13 byte may not be used
-	// capability flag upper 2 bytes, using default capability here
-	data = append(data, byte(defaultCapability>>16), byte(defaultCapability>>24))
+	// capability flag upper 2 bytes
+	data = append(data, byte(capability>>16), byte(capability>>24))
 	// filler [0x15], for wireshark dump, value is 0x15
 	data = append(data, 0x15)
 	// reserved 10 [00]
@@ -144,6 +247,11 @@ func (cc *clientConn) writeInitialHandshake() error {
 	data = append(data, cc.salt[8:]...)
 	// filler [00]
 	data = append(data, 0)
+	// auth-plugin name
+	if capability&mysql.ClientPluginAuth > 0 {
+		data = append(data, mysql.AuthNativePassword...)
+		data = append(data, 0)
+	}
 	err := cc.writePacket(data)
 	if err != nil {
 		return errors.Trace(err)
@@ -165,10 +273,28 @@ func (cc *clientConn) readHandshakeResponse() error {
 		return errors.Trace(err)
 	}
 
-	pos := 0
+	if len(data) < 4 {
+		return errors.Trace(mysql.ErrMalformPacket)
+	}
 	// capability
 	cc.capability = binary.LittleEndian.Uint32(data[:4])
-	pos += 4
+	if cc.capability&mysql.ClientSSL > 0 {
+		// The client sends an SSL request packet which contains the fields before the
+		// user name, and then starts the TLS handshake.
+		err = cc.upgradeToTLS()
+		if err != nil {
+			return errors.Trace(err)
+		}
+		data, err = cc.readPacket()
+		if err != nil {
+			return errors.Trace(err)
+		}
+		if len(data) < 4 {
+			return errors.Trace(mysql.ErrMalformPacket)
+		}
+		cc.capability = binary.LittleEndian.Uint32(data[:4])
+	}
+	pos := 4
 	// skip max packet size
 	pos += 4
 	// charset, skip, if you want to use another charset, use set names
@@ -180,37 +306,181 @@ func (cc *clientConn) readHandshakeResponse() error {
 	cc.user = string(data[pos : pos+bytes.IndexByte(data[pos:], 0)])
 	pos += len(cc.user) + 1
 	// auth length and auth
-	authLen := int(data[pos])
-	pos++
+	var authLen int
+	if cc.capability&mysql.ClientPluginAuthLenencClientData > 0 {
+		num, null, n := parseLengthEncodedInt(data[pos:])
+		if null {
+			return errors.Trace(mysql.ErrMalformPacket)
+		}
+		authLen = int(num)
+		pos += n
+	} else {
+		authLen = int(data[pos])
+		pos++
+	}
+	if pos+authLen > len(data) {
+		return errors.Trace(mysql.ErrMalformPacket)
+	}
 	auth := data[pos : pos+authLen]
 	pos += authLen
 	if cc.capability&mysql.ClientConnectWithDB > 0 {
 		if len(data[pos:]) > 0 {
 			idx := bytes.IndexByte(data[pos:], 0)
+			if idx < 0 {
+				idx = len(data[pos:])
+			}
 			cc.dbname = string(data[pos : pos+idx])
+			pos += idx + 1
 		}
 	}
+	var authPlugin string
+	if cc.capability&mysql.ClientPluginAuth > 0 && pos < len(data) {
+		idx := bytes.IndexByte(data[pos:], 0)
+		if idx < 0 {
+			idx = len(data[pos:])
+		}
+		authPlugin = string(data[pos : pos+idx])
+	}
 	// Open session and do auth
-	cc.ctx, err = cc.server.driver.OpenCtx(cc.capability, uint8(cc.collation), cc.dbname)
+	cc.ctx, err = cc.server.driver.OpenCtx(uint64(cc.connectionID), cc.capability, uint8(cc.collation), cc.dbname)
 	if err != nil {
 		cc.Close()
 		return errors.Trace(err)
 	}
+	cc.ctx.SetProcessManager(cc.server)
+	cc.setCommand(mysql.ComSleep, "")
 	if !cc.server.skipAuth() {
 		// Do Auth
-		addr := cc.conn.RemoteAddr().String()
-		host, _, err1 := net.SplitHostPort(addr)
+		host, err1 := cc.peerHost()
+		if err1 != nil {
+			return errors.Trace(mysql.NewErr(mysql.ErrAccessDenied, cc.user, cc.conn.RemoteAddr(), "Yes"))
+		}
+		plugin, auth, err1 := cc.authPlugin(authPlugin, auth)
 		if err1 != nil {
-			return errors.Trace(mysql.NewErr(mysql.ErrAccessDenied, cc.user, addr, "Yes"))
+			return errors.Trace(err1)
 		}
 		user := fmt.Sprintf("%s@%s", cc.user, host)
-		if !cc.ctx.Auth(user, auth, cc.salt) {
+		if !cc.ctx.Auth(user, plugin, &authConn{cc: cc}, auth, cc.salt) {
+			return errors.Trace(mysql.NewErr(mysql.ErrAccessDenied, cc.user, host, "Yes"))
+		}
+		requireSSL, err1 := cc.ctx.RequireSSL(user)
+		if err1 != nil {
+			return errors.Trace(err1)
+		}
+		if requireSSL && !cc.isSecure() {
+			log.Warnf("user %s requires SSL but connects without it", user)
 			return errors.Trace(mysql.NewErr(mysql.ErrAccessDenied, cc.user, host, "Yes"))
 		}
 	}
 	return nil
 }
 
+// isUnixSocket returns true if the client connects by the Unix socket.
+func (cc *clientConn) isUnixSocket() bool {
+	_, ok := cc.conn.LocalAddr().(*net.UnixAddr)
+	return ok
+}
+
+// peerHost returns the host of the client to match the user@host privileges, it's localhost
+// for the Unix socket connections like MySQL.
+func (cc *clientConn) peerHost() (string, error) {
+	if cc.isUnixSocket() {
+		return "localhost", nil
+	}
+	host, _, err := net.SplitHostPort(cc.conn.RemoteAddr().String())
+	return host, errors.Trace(err)
+}
+
+// authPlugin returns the auth plugin to authenticate the client and the auth response for it.
+// If the plugin used by the client is not supported, the client is asked to switch to
+// mysql_native_password by an AuthSwitchRequest.
+func (cc *clientConn) authPlugin(name string, auth []byte) (privilege.AuthPlugin, []byte, error) {
+	if cc.capability&mysql.ClientPluginAuth == 0 {
+		// The client doesn't support the plugins, it always uses mysql_native_password.
+		return privilege.GetAuthPlugin(mysql.AuthNativePassword), auth, nil
+	}
+	if plugin := privilege.GetAuthPlugin(name); plugin != nil {
+		return plugin, auth, nil
+	}
+	log.Infof("client uses unsupported auth plugin %q, switch to %s", name, mysql.AuthNativePassword)
+	data := cc.alloc.AllocWithLen(4, 64)
+	data = append(data, mysql.AuthSwitchHeader)
+	data = append(data, mysql.AuthNativePassword...)
+	data = append(data, 0)
+	data = append(data, cc.salt...)
+	data = append(data, 0)
+	if err := cc.writePacket(data); err != nil {
+		return nil, nil, errors.Trace(err)
+	}
+	if err := cc.flush(); err != nil {
+		return nil, nil, errors.Trace(err)
+	}
+	auth, err := cc.readPacket()
+	if err != nil {
+		return nil, nil, errors.Trace(err)
+	}
+	return privilege.GetAuthPlugin(mysql.AuthNativePassword), auth, nil
+}
+
+// authConn implements privilege.AuthConn for the auth plugins.
+type authConn struct {
+	cc *clientConn
+}
+
+// ReadPacket implements privilege.AuthConn ReadPacket interface.
+func (c *authConn) ReadPacket() ([]byte, error) {
+	return c.cc.readPacket()
+}
+
+// WriteAuthMoreData implements privilege.AuthConn WriteAuthMoreData interface.
+func (c *authConn) WriteAuthMoreData(data []byte) error {
+	packet := make([]byte, 4, 5+len(data))
+	packet = append(packet, mysql.AuthMoreHeader)
+	packet = append(packet, data...)
+	if err := c.cc.writePacket(packet); err != nil {
+		return errors.Trace(err)
+	}
+	return errors.Trace(c.cc.flush())
+}
+
+// IsSecure implements privilege.AuthConn IsSecure interface.
+func (c *authConn) IsSecure() bool {
+	return c.cc.isSecure() || c.cc.isUnixSocket()
+}
+
+// bufferedConn reads from the buffered reader of the packetIO, the data buffered after
+// the SSL request packet belongs to the TLS handshake.
+type bufferedConn struct {
+	net.Conn
+	rb *bufio.Reader
+}
+
+func (c *bufferedConn) Read(b []byte) (int, error) {
+	return c.rb.Read(b)
+}
+
+// upgradeToTLS does the TLS handshake, the following packets are sent over TLS.
+func (cc *clientConn) upgradeToTLS() error {
+	if cc.server.tlsConfig == nil {
+		return errors.Trace(mysql.NewErrf(mysql.ErrUnknown, "SSL connection is not supported by the server"))
+	}
+	tlsConn := tls.Server(&bufferedConn{Conn: cc.conn, rb: cc.pkg.rb}, cc.server.tlsConfig)
+	if err := tlsConn.Handshake(); err != nil {
+		return errors.Trace(err)
+	}
+	sequence := cc.pkg.sequence
+	cc.conn = tlsConn
+	cc.pkg = newPacketIO(tlsConn)
+	cc.pkg.sequence = sequence
+	return nil
+}
+
+// isSecure checks whether the connection uses TLS.
+func (cc *clientConn) isSecure() bool {
+	_, ok := cc.conn.(*tls.Conn)
+	return ok
+}
+
 func (cc *clientConn) Run() {
 	defer func() {
 		r := recover()
@@ -242,7 +512,7 @@ func (cc *clientConn) Run() {
 			cc.writeError(err)
 		}
 
-		cc.pkg.sequence = 0
+		cc.pkg.resetSequence()
 	}
 }
 
@@ -251,9 +521,17 @@ func (cc *clientConn) dispatch(data []byte) error {
 	data = data[1:]
 	cc.lastCmd = hack.String(data)
 
+	var info string
+	if cmd == mysql.ComQuery || cmd == mysql.ComStmtPrepare {
+		info = string(data)
+	}
+	if !cc.setCommand(cmd, info) {
+		// The connection is closed by the server shutdown.
+		return io.EOF
+	}
 	token := cc.server.getToken()
-
 	defer func() {
+		cc.setCommand(mysql.ComSleep, "")
 		cc.server.releaseToken(token)
 	}()
 
@@ -300,13 +578,22 @@ func (cc *clientConn) flush() error {
 	return cc.pkg.flush()
 }
 
+// status returns the server status sent in the OK and EOF packets.
+func (cc *clientConn) status() uint16 {
+	status := cc.ctx.Status()
+	if cc.moreResults {
+		status |= mysql.ServerMoreResultsExists
+	}
+	return status
+}
+
 func (cc *clientConn) writeOK() error {
 	data := cc.alloc.AllocWithLen(4, 32)
 	data = append(data, mysql.OKHeader)
 	data = append(data, dumpLengthEncodedInt(uint64(cc.ctx.AffectedRows()))...)
 	data = append(data, dumpLengthEncodedInt(uint64(cc.ctx.LastInsertID()))...)
 	if cc.capability&mysql.ClientProtocol41 > 0 {
-		data = append(data, dumpUint16(cc.ctx.Status())...)
+		data = append(data, dumpUint16(cc.status())...)
 		data = append(data, dumpUint16(cc.ctx.WarningCount())...)
 	}
 
@@ -349,7 +636,7 @@ func (cc *clientConn) writeEOF() error {
 	data = append(data, mysql.EOFHeader)
 	if cc.capability&mysql.ClientProtocol41 > 0 {
 		data = append(data, dumpUint16(cc.ctx.WarningCount())...)
-		data = append(data, dumpUint16(cc.ctx.Status())...)
+		data = append(data, dumpUint16(cc.status())...)
 	}
 
 	err := cc.writePacket(data)
@@ -357,6 +644,36 @@ func (cc *clientConn) writeEOF() error {
 }
 
 func (cc *clientConn) handleQuery(sql string) (err error) {
+	// Avoid parsing the query twice if it can't have multiple statements.
+	if !strings.Contains(sql, ";") {
+		return errors.Trace(cc.handleStmt(sql))
+	}
+	stmts, err := cc.ctx.SplitStatements(sql)
+	if err != nil {
+		return errors.Trace(err)
+	}
+	if len(stmts) <= 1 {
+		return errors.Trace(cc.handleStmt(sql))
+	}
+	if cc.capability&mysql.ClientMultiStatements == 0 {
+		return errors.Trace(mysql.NewErrf(mysql.ErrParse, "You have an error in your SQL syntax, multi-statements are not enabled by the client"))
+	}
+	// Every statement is executed after the result of the previous one is sent, the
+	// following statements are not executed if one fails.
+	defer func() {
+		cc.moreResults = false
+	}()
+	for i, stmt := range stmts {
+		cc.moreResults = i < len(stmts)-1
+		if err = cc.handleStmt(stmt); err != nil {
+			return errors.Trace(err)
+		}
+	}
+	return nil
+}
+
+// handleStmt executes a statement of the query and writes its result.
+func (cc *clientConn) handleStmt(sql string) error {
 	rs, err := cc.ctx.Execute(sql)
 	if err != nil {
 		return errors.Trace(err)
@@ -0,0 +1,363 @@
+// Copyright 2015 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/binary"
+	"encoding/pem"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb"
+	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/util"
+	"github.com/pingcap/tidb/util/arena"
+)
+
+var _ = Suite(&testConnSuite{})
+
+type testConnSuite struct {
+	driver *TiDBDriver
+}
+
+func (ts *testConnSuite) SetUpSuite(c *C) {
+	store, err := tidb.NewStore("memory:///tmp/tidb_conn")
+	c.Assert(err, IsNil)
+	ts.driver = NewTiDBDriver(store)
+}
+
+// newConn creates a clientConn with the capability, it returns the packetIO of the client side.
+func (ts *testConnSuite) newConn(c *C, capability uint32) (*clientConn, *packetIO) {
+	serverSide, clientSide := net.Pipe()
+	ctx, err := ts.driver.OpenCtx(1, capability, mysql.DefaultCollationID, "test")
+	c.Assert(err, IsNil)
+	cc := &clientConn{
+		conn: serverSide,
+		pkg:  newPacketIO(serverSide),
+		server: &Server{
+			concurrentLimiter: NewTokenLimiter(1),
+			rwlock:            &sync.RWMutex{},
+			clients:           make(map[uint32]*clientConn),
+		},
+		capability: capability,
+		alloc:      arena.NewAllocator(1024),
+		ctx:        ctx,
+	}
+	return cc, newPacketIO(clientSide)
+}
+
+// query sends the query by cc and returns the results read from the client side, every
+// result is followed by "+" if the server says more results exist.
+func (ts *testConnSuite) query(c *C, cc *clientConn, client *packetIO, sql string) []string {
+	done := make(chan struct{}, 1)
+	go func() {
+		err := cc.dispatch(append([]byte{mysql.ComQuery}, sql...))
+		if err != nil {
+			cc.writeError(err)
+		}
+		cc.pkg.resetSequence()
+		done <- struct{}{}
+	}()
+
+	var results []string
+	for {
+		data, err := client.readPacket()
+		c.Assert(err, IsNil)
+		var (
+			result string
+			status uint16
+		)
+		switch data[0] {
+		case mysql.ErrHeader:
+			results = append(results, fmt.Sprintf("error %d", binary.LittleEndian.Uint16(data[1:])))
+			<-done
+			client.resetSequence()
+			return results
+		case mysql.OKHeader:
+			affectedRows, _, n := parseLengthEncodedInt(data[1:])
+			result = fmt.Sprintf("ok %d", affectedRows)
+			_, _, m := parseLengthEncodedInt(data[1+n:])
+			status = binary.LittleEndian.Uint16(data[1+n+m:])
+		default:
+			columnCount, _, _ := parseLengthEncodedInt(data)
+			for i := 0; i < int(columnCount)+1; i++ {
+				_, err = client.readPacket()
+				c.Assert(err, IsNil)
+			}
+			var rows []string
+			for {
+				data, err = client.readPacket()
+				c.Assert(err, IsNil)
+				if data[0] == mysql.EOFHeader && len(data) < 9 {
+					status = binary.LittleEndian.Uint16(data[3:])
+					break
+				}
+				var row []string
+				for pos := 0; pos < len(data); {
+					v, _, n, err := parseLengthEncodedBytes(data[pos:])
+					c.Assert(err, IsNil)
+					row = append(row, string(v))
+					pos += n
+				}
+				rows = append(rows, strings.Join(row, ","))
+			}
+			result = fmt.Sprintf("rows %s", strings.Join(rows, " "))
+		}
+		if status&mysql.ServerMoreResultsExists > 0 {
+			results = append(results, result+" +")
+			continue
+		}
+		results = append(results, result)
+		<-done
+		client.resetSequence()
+		return results
+	}
+}
+
+func (ts *testConnSuite) TestMultiStatements(c *C) {
+	capability := defaultCapability | mysql.ClientMultiStatements
+	cc, client := ts.newConn(c, capability)
+	defer cc.Close()
+
+	results := ts.query(c, cc, client, "drop table if exists t; create table t (a int)")
+	c.Assert(results, DeepEquals, []string{"ok 0 +", "ok 0"})
+	results = ts.query(c, cc, client, "insert t values (1), (2); select a from t; insert t values (3); select count(*) from t")
+	c.Assert(results, DeepEquals, []string{"ok 2 +", "rows 1 2 +", "ok 1 +", "rows 3"})
+	// A single statement doesn't set the flag.
+	results = ts.query(c, cc, client, "select 'a;b';")
+	c.Assert(results, DeepEquals, []string{"rows a;b"})
+
+	// The statements after the failed one are not executed.
+	results = ts.query(c, cc, client, "insert t values (4); select * from not_exists; insert t values (5)")
+	c.Assert(results, DeepEquals, []string{"ok 1 +", fmt.Sprintf("error %d", mysql.ErrUnknown)})
+	results = ts.query(c, cc, client, "select count(*) from t")
+	c.Assert(results, DeepEquals, []string{"rows 4"})
+
+	// The client doesn't enable multi-statements.
+	cc1, client1 := ts.newConn(c, defaultCapability&^mysql.ClientMultiStatements)
+	defer cc1.Close()
+	results = ts.query(c, cc1, client1, "insert t values (5); insert t values (6)")
+	c.Assert(results, DeepEquals, []string{fmt.Sprintf("error %d", mysql.ErrParse)})
+	results = ts.query(c, cc1, client1, "select count(*) from t;")
+	c.Assert(results, DeepEquals, []string{"rows 4"})
+}
+
+func (ts *testConnSuite) TestCompression(c *C) {
+	capability := defaultCapability | mysql.ClientMultiStatements
+	cc, client := ts.newConn(c, capability)
+	defer cc.Close()
+	cc.pkg.enableCompression()
+	client.enableCompression()
+
+	results := ts.query(c, cc, client, "drop table if exists t; create table t (a int, b text)")
+	c.Assert(results, DeepEquals, []string{"ok 0 +", "ok 0"})
+	// The long rows are compressed and sent in many compressed packets.
+	results = ts.query(c, cc, client, "insert t values (1, repeat('a', 100000)), (2, repeat('b', 100000))")
+	c.Assert(results, DeepEquals, []string{"ok 2"})
+	results = ts.query(c, cc, client, "select a, length(b), b = repeat('b', 100000) from t where a = 2; select count(*) from t")
+	c.Assert(results, DeepEquals, []string{"rows 2,100000,1 +", "rows 2"})
+}
+
+func (ts *testConnSuite) TestCompressedPacket(c *C) {
+	serverSide, clientSide := net.Pipe()
+	defer serverSide.Close()
+	defer clientSide.Close()
+	server, client := newPacketIO(serverSide), newPacketIO(clientSide)
+	server.enableCompression()
+	client.enableCompression()
+
+	// The packets longer than MaxPayloadLen are split, the short ones are not compressed.
+	packets := [][]byte{[]byte("short"), bytes.Repeat([]byte("x"), mysql.MaxPayloadLen+100)}
+	done := make(chan error, 1)
+	go func() {
+		for _, p := range packets {
+			if err := client.writePacket(append(make([]byte, 4), p...)); err != nil {
+				done <- err
+				return
+			}
+		}
+		done <- client.flush()
+	}()
+	for _, p := range packets {
+		data, err := server.readPacket()
+		c.Assert(err, IsNil)
+		c.Assert(data, DeepEquals, p)
+	}
+	c.Assert(<-done, IsNil)
+	c.Assert(server.compressed.sequence, Equals, client.compressed.sequence)
+}
+
+// authClient plays the client of the connection phase, it returns the packets sent by the
+// server after the handshake response in a readable form.
+type authClient struct {
+	c        *C
+	pkg      *packetIO
+	salt     []byte
+	password string
+}
+
+// connect connects to the server listening on addr with the auth plugin, it returns the
+// headers of the packets the server sends after the handshake response.
+func (ts *testConnSuite) connect(c *C, addr string, user, password, plugin string) []string {
+	conn, err := net.Dial("tcp", addr)
+	c.Assert(err, IsNil)
+	defer conn.Close()
+	cl := &authClient{c: c, pkg: newPacketIO(conn), password: password}
+
+	data, err := cl.pkg.readPacket()
+	c.Assert(err, IsNil)
+	pos := 1 + bytes.IndexByte(data[1:], 0) + 1 + 4
+	cl.salt = append(cl.salt, data[pos:pos+8]...)
+	pos += 8 + 1 + 2 + 1 + 2 + 2 + 1 + 10
+	cl.salt = append(cl.salt, data[pos:pos+12]...)
+	pos += 13
+	c.Assert(string(data[pos:len(data)-1]), Equals, mysql.AuthNativePassword)
+
+	capability := mysql.ClientProtocol41 | mysql.ClientSecureConnection | mysql.ClientLongPassword | mysql.ClientPluginAuth
+	resp := make([]byte, 4, 128)
+	resp = append(resp, dumpUint32(capability)...)
+	resp = append(resp, 0, 0, 0, 0, mysql.DefaultCollationID)
+	resp = append(resp, make([]byte, 23)...)
+	resp = append(resp, user...)
+	resp = append(resp, 0)
+	auth := cl.authResponse(plugin)
+	resp = append(resp, byte(len(auth)))
+	resp = append(resp, auth...)
+	resp = append(resp, plugin...)
+	resp = append(resp, 0)
+	c.Assert(cl.pkg.writePacket(resp), IsNil)
+	c.Assert(cl.pkg.flush(), IsNil)
+
+	var results []string
+	for {
+		data, err = cl.pkg.readPacket()
+		c.Assert(err, IsNil)
+		switch data[0] {
+		case mysql.OKHeader:
+			return append(results, "ok")
+		case mysql.ErrHeader:
+			return append(results, "error")
+		case mysql.AuthSwitchHeader:
+			name := string(data[1 : 1+bytes.IndexByte(data[1:], 0)])
+			results = append(results, "switch "+name)
+			cl.write(cl.authResponse(name))
+		case mysql.AuthMoreHeader:
+			if len(data) == 2 {
+				results = append(results, fmt.Sprintf("more %d", data[1]))
+				if data[1] == 4 {
+					// Request the public key to send the password.
+					cl.write([]byte{2})
+				}
+				continue
+			}
+			results = append(results, "public key")
+			block, _ := pem.Decode(data[1:])
+			c.Assert(block, NotNil)
+			pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+			c.Assert(err, IsNil)
+			plain := []byte(cl.password + "\x00")
+			for i := range plain {
+				plain[i] ^= cl.salt[i%len(cl.salt)]
+			}
+			enc, err := rsa.EncryptOAEP(sha1.New(), rand.Reader, pub.(*rsa.PublicKey), plain, nil)
+			c.Assert(err, IsNil)
+			cl.write(enc)
+		}
+	}
+}
+
+func (cl *authClient) write(data []byte) {
+	cl.c.Assert(cl.pkg.writePacket(append(make([]byte, 4), data...)), IsNil)
+	cl.c.Assert(cl.pkg.flush(), IsNil)
+}
+
+func (cl *authClient) authResponse(plugin string) []byte {
+	switch plugin {
+	case mysql.AuthNativePassword:
+		return util.CalcPassword(cl.salt, util.Sha1Hash([]byte(cl.password)))
+	case mysql.AuthCachingSha2Password:
+		stage1 := sha256.Sum256([]byte(cl.password))
+		stage2 := sha256.Sum256(stage1[:])
+		h := sha256.New()
+		h.Write(stage2[:])
+		h.Write(cl.salt)
+		scramble := h.Sum(nil)
+		for i := range scramble {
+			scramble[i] ^= stage1[i]
+		}
+		return scramble
+	}
+	return []byte("unknown")
+}
+
+func (ts *testConnSuite) TestAuthPlugin(c *C) {
+	se, err := tidb.CreateSession(ts.driver.store)
+	c.Assert(err, IsNil)
+	_, err = se.Execute("CREATE USER 'plugin'@'%' IDENTIFIED BY 'pwd'")
+	c.Assert(err, IsNil)
+
+	server := &Server{
+		cfg:               &Config{},
+		driver:            ts.driver,
+		concurrentLimiter: NewTokenLimiter(10),
+		rwlock:            &sync.RWMutex{},
+		clients:           make(map[uint32]*clientConn),
+		capability:        defaultCapability,
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	c.Assert(err, IsNil)
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			cc, _ := server.newConn(conn)
+			if err = cc.handshake(); err == nil {
+				cc.Close()
+			}
+			conn.Close()
+		}
+	}()
+	addr := listener.Addr().String()
+
+	results := ts.connect(c, addr, "plugin", "pwd", mysql.AuthNativePassword)
+	c.Assert(results, DeepEquals, []string{"ok"})
+	results = ts.connect(c, addr, "plugin", "wrong", mysql.AuthNativePassword)
+	c.Assert(results, DeepEquals, []string{"error"})
+
+	// The password is sent encrypted by the public key at the first time, then it's cached.
+	results = ts.connect(c, addr, "plugin", "pwd", mysql.AuthCachingSha2Password)
+	c.Assert(results, DeepEquals, []string{"more 4", "public key", "ok"})
+	results = ts.connect(c, addr, "plugin", "pwd", mysql.AuthCachingSha2Password)
+	c.Assert(results, DeepEquals, []string{"more 3", "ok"})
+	results = ts.connect(c, addr, "plugin", "wrong", mysql.AuthCachingSha2Password)
+	c.Assert(results, DeepEquals, []string{"error"})
+
+	// The client switches to mysql_native_password if its plugin is not supported.
+	results = ts.connect(c, addr, "plugin", "pwd", "sha256_password")
+	c.Assert(results, DeepEquals, []string{"switch " + mysql.AuthNativePassword, "ok"})
+	results = ts.connect(c, addr, "plugin", "wrong", "sha256_password")
+	c.Assert(results, DeepEquals, []string{"switch " + mysql.AuthNativePassword, "error"})
+}
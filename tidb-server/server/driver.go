@@ -13,10 +13,15 @@
 
 package server
 
+import (
+	"github.com/pingcap/tidb/privilege"
+	"github.com/pingcap/tidb/sessionctx/processlist"
+)
+
 // IDriver opens IContext.
 type IDriver interface {
-	// OpenCtx opens an IContext with client capability, collation and dbname.
-	OpenCtx(capability uint32, collation uint8, dbname string) (IContext, error)
+	// OpenCtx opens an IContext with connection id, client capability, collation and dbname.
+	OpenCtx(connID uint64, capability uint32, collation uint8, dbname string) (IContext, error)
 }
 
 // IContext is the interface to execute commant.
@@ -39,6 +44,9 @@ type IContext interface {
 	// Execute executes a SQL statement.
 	Execute(sql string) (ResultSet, error)
 
+	// SplitStatements splits a multi-statement query into statements.
+	SplitStatements(sql string) ([]string, error)
+
 	// Prepare prepares a statement.
 	Prepare(sql string) (statement IStatement, columns, params []*ColumnInfo, err error)
 
@@ -51,8 +59,18 @@ type IContext interface {
 	// Close closes the IContext.
 	Close() error
 
-	// Auth verifies user's authentication.
-	Auth(user string, auth []byte, salt []byte) bool
+	// Auth verifies user's authentication by the auth plugin, conn is used by the plugin
+	// to exchange more data with the client.
+	Auth(user string, plugin privilege.AuthPlugin, conn privilege.AuthConn, auth []byte, salt []byte) bool
+
+	// RequireSSL checks whether the user must connect with TLS.
+	RequireSSL(user string) (bool, error)
+
+	// SetProcessManager sets the manager used by SHOW PROCESSLIST and KILL.
+	SetProcessManager(m processlist.Manager)
+
+	// Cancel interrupts the statement being executed.
+	Cancel()
 }
 
 // IStatement is the interface to use a prepared statement.
@@ -16,10 +16,14 @@ package server
 import (
 	"github.com/juju/errors"
 	"github.com/pingcap/tidb"
+	"github.com/pingcap/tidb/context"
 	"github.com/pingcap/tidb/field"
 	"github.com/pingcap/tidb/kv"
 	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/privilege"
 	"github.com/pingcap/tidb/rset"
+	"github.com/pingcap/tidb/sessionctx/db"
+	"github.com/pingcap/tidb/sessionctx/processlist"
 	"github.com/pingcap/tidb/util/types"
 )
 
@@ -110,9 +114,10 @@ func (ts *TiDBStatement) Close() error {
 }
 
 // OpenCtx implements IDriver.
-func (qd *TiDBDriver) OpenCtx(capability uint32, collation uint8, dbname string) (IContext, error) {
+func (qd *TiDBDriver) OpenCtx(connID uint64, capability uint32, collation uint8, dbname string) (IContext, error) {
 	session, _ := tidb.CreateSession(qd.store)
 	session.SetClientCapability(capability)
+	session.SetConnectionID(connID)
 	if dbname != "" {
 		_, err := session.Execute("use " + dbname)
 		if err != nil {
@@ -144,6 +149,10 @@ func (tc *TiDBContext) AffectedRows() uint64 {
 
 // CurrentDB implements IContext CurrentDB method.
 func (tc *TiDBContext) CurrentDB() string {
+	// The current DB may be changed by a USE statement.
+	if ctx, ok := tc.session.(context.Context); ok {
+		return db.GetCurrentSchema(ctx)
+	}
 	return tc.currentDB
 }
 
@@ -167,14 +176,34 @@ func (tc *TiDBContext) Execute(sql string) (rs ResultSet, err error) {
 	return
 }
 
+// SplitStatements implements IContext SplitStatements method.
+func (tc *TiDBContext) SplitStatements(sql string) ([]string, error) {
+	return tc.session.SplitStatements(sql)
+}
+
 // Close implements IContext Close method.
 func (tc *TiDBContext) Close() (err error) {
 	return tc.session.Close()
 }
 
 // Auth implements IContext Auth method.
-func (tc *TiDBContext) Auth(user string, auth []byte, salt []byte) bool {
-	return tc.session.Auth(user, auth, salt)
+func (tc *TiDBContext) Auth(user string, plugin privilege.AuthPlugin, conn privilege.AuthConn, auth []byte, salt []byte) bool {
+	return tc.session.AuthWithPlugin(user, plugin, conn, auth, salt)
+}
+
+// RequireSSL implements IContext RequireSSL method.
+func (tc *TiDBContext) RequireSSL(user string) (bool, error) {
+	return tc.session.RequireSSL(user)
+}
+
+// SetProcessManager implements IContext SetProcessManager method.
+func (tc *TiDBContext) SetProcessManager(m processlist.Manager) {
+	tc.session.SetProcessManager(m)
+}
+
+// Cancel implements IContext Cancel method.
+func (tc *TiDBContext) Cancel() {
+	tc.session.Cancel()
 }
 
 // FieldList implements IContext FieldList method.
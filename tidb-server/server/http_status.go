@@ -0,0 +1,88 @@
+// Copyright 2015 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/pprof"
+
+	"github.com/juju/errors"
+	"github.com/ngaut/log"
+	"github.com/pingcap/tidb"
+	"github.com/pingcap/tidb/metric"
+	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/util/printer"
+)
+
+// status is the response of the /status API.
+type status struct {
+	Connections   int    `json:"connections"`
+	Version       string `json:"version"`
+	GitHash       string `json:"git_hash"`
+	SchemaVersion int64  `json:"schema_version"`
+}
+
+// statusHandler returns the handler of the status HTTP server, it serves:
+// /status: the status of the server in JSON.
+// /metrics: the metrics in the Prometheus text format.
+// /debug/pprof: the runtime profiling data.
+func (s *Server) statusHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/status", s.handleStatus)
+	mux.HandleFunc("/metrics", handleMetrics)
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	return mux
+}
+
+func (s *Server) startStatusHTTP() {
+	log.Infof("Server run status HTTP Listen at [%s]", s.cfg.StatusAddr)
+	err := http.ListenAndServe(s.cfg.StatusAddr, s.statusHandler())
+	if err != nil {
+		log.Errorf("status HTTP server error %s", errors.ErrorStack(err))
+	}
+}
+
+func (s *Server) handleStatus(w http.ResponseWriter, req *http.Request) {
+	st := status{
+		Connections: s.ConnectionCount(),
+		Version:     mysql.ServerVersion,
+		GitHash:     printer.TiDBGitHash,
+	}
+	if d, ok := s.driver.(*TiDBDriver); ok {
+		do, err := tidb.GetDomain(d.store)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		st.SchemaVersion = do.InfoSchema().SchemaMetaVersion()
+	}
+	js, err := json.Marshal(st)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(js)
+}
+
+func handleMetrics(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	if err := metric.WritePrometheus(w); err != nil {
+		log.Errorf("write metrics error %s", errors.ErrorStack(err))
+	}
+}
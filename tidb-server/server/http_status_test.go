@@ -0,0 +1,75 @@
+// Copyright 2015 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+
+	. "github.com/pingcap/check"
+	"github.com/pingcap/tidb"
+	"github.com/pingcap/tidb/mysql"
+)
+
+func (ts *testConnSuite) TestStatusHTTP(c *C) {
+	se, err := tidb.CreateSession(ts.driver.store)
+	c.Assert(err, IsNil)
+	_, err = se.Execute("create table if not exists test.status_t (a int)")
+	c.Assert(err, IsNil)
+	_, err = se.Execute("insert test.status_t values (1)")
+	c.Assert(err, IsNil)
+
+	server := &Server{
+		cfg:     &Config{},
+		driver:  ts.driver,
+		rwlock:  &sync.RWMutex{},
+		clients: map[uint32]*clientConn{1: {}, 2: {}},
+	}
+	handler := server.statusHandler()
+
+	w := httptest.NewRecorder()
+	req, err := http.NewRequest("GET", "/status", nil)
+	c.Assert(err, IsNil)
+	handler.ServeHTTP(w, req)
+	c.Assert(w.Code, Equals, http.StatusOK)
+	var st status
+	c.Assert(json.Unmarshal(w.Body.Bytes(), &st), IsNil)
+	c.Assert(st.Connections, Equals, 2)
+	c.Assert(st.Version, Equals, mysql.ServerVersion)
+	c.Assert(st.SchemaVersion > 0, IsTrue)
+
+	w = httptest.NewRecorder()
+	req, err = http.NewRequest("GET", "/metrics", nil)
+	c.Assert(err, IsNil)
+	handler.ServeHTTP(w, req)
+	c.Assert(w.Code, Equals, http.StatusOK)
+	body := w.Body.String()
+	for _, s := range []string{
+		`tidb_query_total{type="InsertInto",result="OK"}`,
+		`tidb_query_duration_seconds_bucket{type="InsertInto",le="+Inf"}`,
+		`tidb_ddl_jobs_total{type="create table",state="done"}`,
+		`tidb_kv_duration_seconds_count{store="localstore",type="commit"}`,
+	} {
+		c.Assert(strings.Contains(body, s), IsTrue, Commentf("%s not in %s", s, body))
+	}
+
+	w = httptest.NewRecorder()
+	req, err = http.NewRequest("GET", "/debug/pprof/cmdline", nil)
+	c.Assert(err, IsNil)
+	handler.ServeHTTP(w, req)
+	c.Assert(w.Code, Equals, http.StatusOK)
+}
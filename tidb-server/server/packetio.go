@@ -36,6 +36,8 @@ package server
 
 import (
 	"bufio"
+	"bytes"
+	"compress/zlib"
 	"io"
 	"net"
 
@@ -46,6 +48,9 @@ import (
 const (
 	defaultReaderSize = 16 * 1024
 	defaultWriterSize = 16 * 1024
+	// minCompressLength is the min length of the payload to compress, the shorter
+	// payload is sent uncompressed.
+	minCompressLength = 50
 )
 
 type packetIO struct {
@@ -53,6 +58,8 @@ type packetIO struct {
 	wb *bufio.Writer
 
 	sequence uint8
+	// compressed is not nil if the compressed protocol is used.
+	compressed *compressedConn
 }
 
 func newPacketIO(conn net.Conn) *packetIO {
@@ -137,5 +144,135 @@ func (p *packetIO) writePacket(data []byte) error {
 }
 
 func (p *packetIO) flush() error {
-	return p.wb.Flush()
+	if err := p.wb.Flush(); err != nil {
+		return errors.Trace(err)
+	}
+	if p.compressed != nil {
+		return errors.Trace(p.compressed.flush())
+	}
+	return nil
+}
+
+// resetSequence resets the sequences for a new command.
+func (p *packetIO) resetSequence() {
+	p.sequence = 0
+	if p.compressed != nil {
+		p.compressed.sequence = 0
+	}
+}
+
+// enableCompression makes the following packets sent in the compressed packets, the
+// buffered data must be flushed before.
+func (p *packetIO) enableCompression() {
+	p.compressed = &compressedConn{rb: p.rb, wb: p.wb}
+	p.rb = bufio.NewReaderSize(p.compressed, defaultReaderSize)
+	p.wb = bufio.NewWriterSize(p.compressed, defaultWriterSize)
+}
+
+// compressedConn reads and writes the compressed packets, the payload of them is
+// the stream of the MySQL packets. The compressed packets have their own sequence.
+// See: https://dev.mysql.com/doc/internals/en/compressed-packet-header.html
+type compressedConn struct {
+	rb *bufio.Reader
+	wb *bufio.Writer
+
+	sequence uint8
+	// data is the uncompressed payload not read yet.
+	data []byte
+}
+
+// Read implements the io.Reader interface.
+func (c *compressedConn) Read(b []byte) (int, error) {
+	for len(c.data) == 0 {
+		if err := c.readCompressedPacket(); err != nil {
+			return 0, errors.Trace(err)
+		}
+	}
+	n := copy(b, c.data)
+	c.data = c.data[n:]
+	return n, nil
+}
+
+func (c *compressedConn) readCompressedPacket() error {
+	var header [7]byte
+	if _, err := io.ReadFull(c.rb, header[:]); err != nil {
+		return errors.Trace(err)
+	}
+	length := int(uint32(header[0]) | uint32(header[1])<<8 | uint32(header[2])<<16)
+	sequence := uint8(header[3])
+	if sequence != c.sequence {
+		return errors.Errorf("invalid compressed sequence %d != %d", sequence, c.sequence)
+	}
+	c.sequence++
+	uncompressedLength := int(uint32(header[4]) | uint32(header[5])<<8 | uint32(header[6])<<16)
+
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(c.rb, payload); err != nil {
+		return errors.Trace(err)
+	}
+	if uncompressedLength == 0 {
+		// The payload is not compressed.
+		c.data = payload
+		return nil
+	}
+	r, err := zlib.NewReader(bytes.NewReader(payload))
+	if err != nil {
+		return errors.Trace(err)
+	}
+	defer r.Close()
+	c.data = make([]byte, uncompressedLength)
+	_, err = io.ReadFull(r, c.data)
+	return errors.Trace(err)
+}
+
+// Write implements the io.Writer interface.
+func (c *compressedConn) Write(data []byte) (int, error) {
+	total := len(data)
+	for len(data) > 0 {
+		n := len(data)
+		if n > mysql.MaxPayloadLen {
+			n = mysql.MaxPayloadLen
+		}
+		if err := c.writeCompressedPacket(data[:n]); err != nil {
+			return total - len(data), errors.Trace(err)
+		}
+		data = data[n:]
+	}
+	return total, nil
+}
+
+func (c *compressedConn) writeCompressedPacket(data []byte) error {
+	payload, uncompressedLength := data, 0
+	if len(data) >= minCompressLength {
+		var buf bytes.Buffer
+		w := zlib.NewWriter(&buf)
+		if _, err := w.Write(data); err != nil {
+			return errors.Trace(err)
+		}
+		if err := w.Close(); err != nil {
+			return errors.Trace(err)
+		}
+		// Send the payload uncompressed if it can't be compressed.
+		if buf.Len() < len(data) {
+			payload, uncompressedLength = buf.Bytes(), len(data)
+		}
+	}
+	length := len(payload)
+	header := []byte{
+		byte(length), byte(length >> 8), byte(length >> 16),
+		c.sequence,
+		byte(uncompressedLength), byte(uncompressedLength >> 8), byte(uncompressedLength >> 16),
+	}
+	if _, err := c.wb.Write(header); err != nil {
+		return errors.Trace(mysql.ErrBadConn)
+	}
+	if _, err := c.wb.Write(payload); err != nil {
+		return errors.Trace(mysql.ErrBadConn)
+	}
+	c.sequence++
+	return nil
+}
+
+func (c *compressedConn) flush() error {
+	return errors.Trace(c.wb.Flush())
 }
@@ -29,15 +29,21 @@
 package server
 
 import (
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
 	"math/rand"
 	"net"
+	"os"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/juju/errors"
 	"github.com/ngaut/log"
+	"github.com/pingcap/tidb/metric"
 	"github.com/pingcap/tidb/mysql"
+	"github.com/pingcap/tidb/sessionctx/processlist"
 	"github.com/pingcap/tidb/util/arena"
 )
 
@@ -53,6 +59,14 @@ type Server struct {
 	rwlock            *sync.RWMutex
 	concurrentLimiter *TokenLimiter
 	clients           map[uint32]*clientConn
+	// socket is the listener of the Unix socket, it's nil if the socket is not set.
+	socket net.Listener
+	// tlsConfig is nil if TLS is not enabled.
+	tlsConfig *tls.Config
+	// capability is the capability flags advertised in the initial handshake.
+	capability uint32
+	// inShutdown is set to 1 by Shutdown, the new connections are refused after it's set.
+	inShutdown int32
 }
 
 func (s *Server) getToken() *Token {
@@ -103,13 +117,31 @@ func NewServer(cfg *Config, driver IDriver) (*Server, error) {
 		concurrentLimiter: NewTokenLimiter(100),
 		rwlock:            &sync.RWMutex{},
 		clients:           make(map[uint32]*clientConn),
+		capability:        defaultCapability,
 	}
 
 	var err error
+	s.tlsConfig, err = loadTLSConfig(cfg)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	if s.tlsConfig != nil {
+		s.capability |= mysql.ClientSSL
+		log.Infof("Server enables TLS with certificate %s", cfg.SSLCert)
+	}
+
 	s.listener, err = net.Listen("tcp", s.cfg.Addr)
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
+	if cfg.Socket != "" {
+		s.socket, err = listenUnix(cfg.Socket)
+		if err != nil {
+			s.listener.Close()
+			return nil, errors.Trace(err)
+		}
+		log.Infof("Server run MySql Protocol Listen at socket [%s]", cfg.Socket)
+	}
 
 	// Init rand seed for randomBuf()
 	rand.Seed(time.Now().UTC().UnixNano())
@@ -117,11 +149,76 @@ func NewServer(cfg *Config, driver IDriver) (*Server, error) {
 	return s, nil
 }
 
+// listenUnix listens on the Unix socket at path, the socket file left by a server not
+// running is removed.
+func listenUnix(path string) (net.Listener, error) {
+	if _, err := os.Stat(path); err == nil {
+		if conn, err := net.Dial("unix", path); err == nil {
+			conn.Close()
+			return nil, errors.Errorf("socket %s is in use", path)
+		}
+		if err = os.Remove(path); err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
+	l, err := net.Listen("unix", path)
+	return l, errors.Trace(err)
+}
+
+// loadTLSConfig loads the certificates in cfg, it returns nil if the certificate is not set.
+func loadTLSConfig(cfg *Config) (*tls.Config, error) {
+	if cfg.SSLCert == "" && cfg.SSLKey == "" {
+		if cfg.SSLCA != "" {
+			return nil, errors.New("ssl-ca is set without ssl-cert and ssl-key")
+		}
+		return nil, nil
+	}
+	cert, err := tls.LoadX509KeyPair(cfg.SSLCert, cfg.SSLKey)
+	if err != nil {
+		return nil, errors.Trace(err)
+	}
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+	}
+	if cfg.SSLCA != "" {
+		pem, err := ioutil.ReadFile(cfg.SSLCA)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errors.Errorf("no valid certificate in ssl-ca %s", cfg.SSLCA)
+		}
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+	return tlsConfig, nil
+}
+
 // Run runs the server.
 func (s *Server) Run() error {
+	if s.cfg.StatusAddr != "" {
+		go s.startStatusHTTP()
+	}
+	if socket := s.socket; socket != nil {
+		go func() {
+			if err := s.serve(socket); err != nil {
+				log.Errorf("socket error %s", errors.ErrorStack(err))
+			}
+		}()
+	}
+	return s.serve(s.listener)
+}
+
+// serve accepts the connections from l, the connections from the TCP listener and the
+// Unix socket are handled in the same way.
+func (s *Server) serve(l net.Listener) error {
 	for {
-		conn, err := s.listener.Accept()
+		conn, err := l.Accept()
 		if err != nil {
+			if s.isShuttingDown() {
+				return nil
+			}
 			log.Errorf("accept error %s", err.Error())
 			return errors.Trace(err)
 		}
@@ -139,6 +236,111 @@ func (s *Server) Close() {
 		s.listener.Close()
 		s.listener = nil
 	}
+	if s.socket != nil {
+		// The socket file is removed when the listener is closed.
+		s.socket.Close()
+		s.socket = nil
+	}
+}
+
+const (
+	// shutdownCheckInterval is the interval to close the idle connections in Shutdown.
+	shutdownCheckInterval = 100 * time.Millisecond
+	// killWaitTime is the max time to wait for the connections to exit after they're killed.
+	killWaitTime = 5 * time.Second
+)
+
+// Shutdown stops the server gracefully. It stops accepting the connections, then closes the
+// connections when they're idle and out of transactions, so the running statements and
+// transactions can finish. The connections remaining after timeout are killed.
+func (s *Server) Shutdown(timeout time.Duration) {
+	atomic.StoreInt32(&s.inShutdown, 1)
+	s.Close()
+	log.Infof("Server is shutting down, wait for %d connections at most %s", s.ConnectionCount(), timeout)
+
+	ticker := time.NewTicker(shutdownCheckInterval)
+	defer ticker.Stop()
+	deadline := time.Now().Add(timeout)
+	for s.closeIdleConns() > 0 && time.Now().Before(deadline) {
+		<-ticker.C
+	}
+	if n := s.killConns(); n > 0 {
+		log.Warnf("Server kills %d connections after waiting for %s", n, timeout)
+	}
+	deadline = time.Now().Add(killWaitTime)
+	for s.ConnectionCount() > 0 && time.Now().Before(deadline) {
+		<-ticker.C
+	}
+	log.Infof("Server is shut down, %d connections remain", s.ConnectionCount())
+}
+
+func (s *Server) isShuttingDown() bool {
+	return atomic.LoadInt32(&s.inShutdown) == 1
+}
+
+// closeIdleConns closes the idle connections out of transactions, it returns the number of
+// the busy connections.
+func (s *Server) closeIdleConns() int {
+	s.rwlock.RLock()
+	defer s.rwlock.RUnlock()
+	busy := 0
+	for _, cc := range s.clients {
+		if !cc.closeIfIdle() {
+			busy++
+		}
+	}
+	return busy
+}
+
+// killConns kills the statements of all the connections and closes them, it returns the
+// number of the connections killed.
+func (s *Server) killConns() int {
+	s.rwlock.RLock()
+	defer s.rwlock.RUnlock()
+	killed := 0
+	for _, cc := range s.clients {
+		if cc.closeIfIdle() {
+			continue
+		}
+		cc.ctx.Cancel()
+		cc.conn.Close()
+		killed++
+	}
+	return killed
+}
+
+// ConnectionCount returns the number of the connected clients.
+func (s *Server) ConnectionCount() int {
+	s.rwlock.RLock()
+	defer s.rwlock.RUnlock()
+	return len(s.clients)
+}
+
+// ShowProcessList implements the processlist.Manager interface.
+func (s *Server) ShowProcessList() []processlist.ProcessInfo {
+	s.rwlock.RLock()
+	defer s.rwlock.RUnlock()
+	infos := make([]processlist.ProcessInfo, 0, len(s.clients))
+	for _, cc := range s.clients {
+		infos = append(infos, cc.processInfo())
+	}
+	return infos
+}
+
+// Kill implements the processlist.Manager interface.
+func (s *Server) Kill(connectionID uint64, query bool) bool {
+	s.rwlock.RLock()
+	cc, ok := s.clients[uint32(connectionID)]
+	s.rwlock.RUnlock()
+	if !ok || uint64(uint32(connectionID)) != connectionID {
+		return false
+	}
+	cc.ctx.Cancel()
+	if !query {
+		// The Run loop fails to read the next packet and closes the connection.
+		cc.conn.Close()
+	}
+	return true
 }
 
 func (s *Server) onConn(c net.Conn) {
@@ -157,7 +359,13 @@ func (s *Server) onConn(c net.Conn) {
 	}()
 
 	s.rwlock.Lock()
+	if s.isShuttingDown() {
+		s.rwlock.Unlock()
+		conn.Close()
+		return
+	}
 	s.clients[conn.connectionID] = conn
+	metric.SetGauge("tidb_server_connections", int64(len(s.clients)))
 	s.rwlock.Unlock()
 
 	conn.Run()
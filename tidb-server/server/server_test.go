@@ -15,6 +15,7 @@ package server
 
 import (
 	"database/sql"
+	"fmt"
 	"testing"
 
 	_ "github.com/go-sql-driver/mysql"
@@ -237,6 +238,100 @@ func runTestAuth(c *C) {
 	db.Close()
 }
 
+func runTestSocket(c *C, socket string) {
+	runTests(c, dsn, func(dbt *DBTest) {
+		dbt.mustExec(`CREATE USER 'sock'@'localhost' IDENTIFIED BY '123';`)
+	})
+	// The clients connecting by the Unix socket are from localhost.
+	runTests(c, "sock:123@unix("+socket+")/test?strict=true", func(dbt *DBTest) {
+		var host string
+		err := dbt.db.QueryRow("select host from information_schema.processlist where id = connection_id()").Scan(&host)
+		c.Assert(err, IsNil)
+		c.Assert(host, Equals, "localhost")
+	})
+
+	db, err := sql.Open("mysql", "sock:123@tcp(127.0.0.1:4001)/test?strict=true")
+	c.Assert(err, IsNil)
+	err = db.Ping()
+	c.Assert(err, NotNil, Commentf("The user is only allowed from localhost"))
+	db.Close()
+}
+
+func runTestTLS(c *C) {
+	tlsDsn := "root@tcp(localhost:4002)/test?strict=true&tls=skip-verify"
+	runTests(c, tlsDsn, func(dbt *DBTest) {
+		dbt.mustExec(`CREATE USER 'ssl'@'localhost' IDENTIFIED BY '123' REQUIRE SSL;`)
+		dbt.mustExec(`CREATE USER 'ssl'@'127.0.0.1' IDENTIFIED BY '123' REQUIRE SSL;`)
+		dbt.mustExec(`CREATE USER 'ssl'@'::1' IDENTIFIED BY '123' REQUIRE SSL;`)
+	})
+	runTests(c, "ssl:123@tcp(localhost:4002)/test?strict=true&tls=skip-verify", func(dbt *DBTest) {
+		dbt.mustExec(`USE mysql;`)
+	})
+
+	db, err := sql.Open("mysql", "ssl:123@tcp(localhost:4002)/test?strict=true")
+	c.Assert(err, IsNil)
+	err = db.Ping()
+	c.Assert(err, NotNil, Commentf("The user requires SSL, plaintext connection should be failed"))
+	db.Close()
+
+	// The server without certificate refuses the TLS connections.
+	db, err = sql.Open("mysql", "root@tcp(localhost:4001)/test?strict=true&tls=skip-verify")
+	c.Assert(err, IsNil)
+	err = db.Ping()
+	c.Assert(err, NotNil)
+	db.Close()
+}
+
+func runTestKill(c *C) {
+	db1, err := sql.Open("mysql", dsn)
+	c.Assert(err, IsNil)
+	defer db1.Close()
+	db1.SetMaxOpenConns(1)
+	var connID int64
+	err = db1.QueryRow("select connection_id()").Scan(&connID)
+	c.Assert(err, IsNil)
+
+	runTests(c, dsn, func(dbt *DBTest) {
+		var user, command string
+		err := dbt.db.QueryRow("select user, command from information_schema.processlist where id = ?", connID).Scan(&user, &command)
+		c.Assert(err, IsNil)
+		c.Assert(user, Equals, "root")
+		c.Assert(command, Equals, "Sleep")
+		rows := dbt.mustQuery("show full processlist")
+		infos := make(map[int64]string)
+		for rows.Next() {
+			var (
+				id, time               int64
+				user, host, cmd, state string
+				db, info               sql.NullString
+			)
+			err = rows.Scan(&id, &user, &host, &db, &cmd, &time, &state, &info)
+			c.Assert(err, IsNil)
+			infos[id] = info.String
+		}
+		c.Assert(rows.Close(), IsNil)
+		// The idle connection has no statement, the current one shows itself.
+		c.Assert(infos[connID], Equals, "")
+		found := false
+		for _, info := range infos {
+			found = found || info == "show full processlist"
+		}
+		c.Assert(found, IsTrue)
+
+		dbt.mustExec(fmt.Sprintf("kill query %d", connID))
+		dbt.mustExec(fmt.Sprintf("kill connection %d", connID))
+		_, err = dbt.db.Exec("kill 1")
+		c.Assert(err, NotNil)
+	})
+
+	// The killed connection is closed, the pool has to connect again.
+	var newConnID int64
+	err = db1.QueryRow("select connection_id()").Scan(&newConnID)
+	if err == nil {
+		c.Assert(newConnID, Not(Equals), connID)
+	}
+}
+
 func runTestIssues(c *C) {
 	// For issue #263
 	unExistsSchemaDsn := "root@tcp(localhost:4001)/unexists_schema?strict=true"
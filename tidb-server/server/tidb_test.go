@@ -14,10 +14,21 @@
 package server
 
 import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"database/sql"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
 	"time"
 
 	. "github.com/pingcap/check"
 	"github.com/pingcap/tidb"
+	"github.com/pingcap/tidb/kv"
 )
 
 type TidbTestSuite struct {
@@ -34,6 +45,7 @@ func (ts *TidbTestSuite) SetUpSuite(c *C) {
 	cfg := &Config{
 		Addr:     ":4001",
 		LogLevel: "debug",
+		Socket:   filepath.Join(os.TempDir(), "tidb-server-test.sock"),
 	}
 	server, err := NewServer(cfg, ts.tidbdrv)
 	c.Assert(err, IsNil)
@@ -72,6 +84,170 @@ func (ts *TidbTestSuite) TestAuth(c *C) {
 	runTestAuth(c)
 }
 
+func (ts *TidbTestSuite) TestSocket(c *C) {
+	runTestSocket(c, ts.server.cfg.Socket)
+}
+
+func (ts *TidbTestSuite) TestKill(c *C) {
+	runTestKill(c)
+}
+
 func (ts *TidbTestSuite) TestIssues(c *C) {
 	runTestIssues(c)
 }
+
+type TidbTLSTestSuite struct {
+	tidbdrv *TiDBDriver
+	server  *Server
+	dir     string
+}
+
+var _ = Suite(new(TidbTLSTestSuite))
+
+func (ts *TidbTLSTestSuite) SetUpSuite(c *C) {
+	var err error
+	ts.dir, err = ioutil.TempDir("", "tidb-tls")
+	c.Assert(err, IsNil)
+	certPath, keyPath := generateCert(c, ts.dir)
+
+	store, err := tidb.NewStore("memory:///tmp/tidb_tls")
+	c.Assert(err, IsNil)
+	ts.tidbdrv = NewTiDBDriver(store)
+	cfg := &Config{
+		Addr:     ":4002",
+		LogLevel: "debug",
+		SSLCert:  certPath,
+		SSLKey:   keyPath,
+	}
+	server, err := NewServer(cfg, ts.tidbdrv)
+	c.Assert(err, IsNil)
+	ts.server = server
+	go ts.server.Run()
+	time.Sleep(time.Millisecond * 100)
+}
+
+func (ts *TidbTLSTestSuite) TearDownSuite(c *C) {
+	ts.server.Close()
+	os.RemoveAll(ts.dir)
+}
+
+func (ts *TidbTLSTestSuite) TestTLS(c *C) {
+	runTestTLS(c)
+}
+
+type TidbShutdownTestSuite struct {
+	store kv.Storage
+}
+
+var _ = Suite(new(TidbShutdownTestSuite))
+
+func (ts *TidbShutdownTestSuite) SetUpSuite(c *C) {
+	var err error
+	ts.store, err = tidb.NewStore("memory:///tmp/tidb_shutdown")
+	c.Assert(err, IsNil)
+	se, err := tidb.CreateSession(ts.store)
+	c.Assert(err, IsNil)
+	_, err = se.Execute("create table test.shutdown (a int)")
+	c.Assert(err, IsNil)
+}
+
+func (ts *TidbShutdownTestSuite) TearDownSuite(c *C) {
+	c.Assert(tidb.CloseStore(ts.store), IsNil)
+}
+
+func (ts *TidbShutdownTestSuite) startServer(c *C, addr string) *Server {
+	server, err := NewServer(&Config{Addr: addr, LogLevel: "debug"}, NewTiDBDriver(ts.store))
+	c.Assert(err, IsNil)
+	go server.Run()
+	time.Sleep(time.Millisecond * 100)
+	return server
+}
+
+func (ts *TidbShutdownTestSuite) TestShutdown(c *C) {
+	server := ts.startServer(c, ":4003")
+	dsn := "root@tcp(localhost:4003)/test?strict=true"
+	idle, err := sql.Open("mysql", dsn)
+	c.Assert(err, IsNil)
+	defer idle.Close()
+	_, err = idle.Exec("select 1")
+	c.Assert(err, IsNil)
+	db, err := sql.Open("mysql", dsn)
+	c.Assert(err, IsNil)
+	defer db.Close()
+	tx, err := db.Begin()
+	c.Assert(err, IsNil)
+	_, err = tx.Exec("insert shutdown values (1)")
+	c.Assert(err, IsNil)
+
+	done := make(chan struct{})
+	go func() {
+		server.Shutdown(10 * time.Second)
+		close(done)
+	}()
+	time.Sleep(300 * time.Millisecond)
+	// The idle connection is closed and the new connections are refused.
+	_, err = idle.Exec("select 1")
+	c.Assert(err, NotNil)
+	c.Assert(server.ConnectionCount(), Equals, 1)
+
+	// The transaction can be committed, then its connection is closed.
+	_, err = tx.Exec("insert shutdown values (2)")
+	c.Assert(err, IsNil)
+	c.Assert(tx.Commit(), IsNil)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		c.Fatal("shutdown doesn't finish after the transaction is committed")
+	}
+	c.Assert(server.ConnectionCount(), Equals, 0)
+
+	se, err := tidb.CreateSession(ts.store)
+	c.Assert(err, IsNil)
+	rs, err := se.Execute("select count(*) from test.shutdown")
+	c.Assert(err, IsNil)
+	row, err := rs[0].FirstRow()
+	c.Assert(err, IsNil)
+	c.Assert(row[0], Equals, int64(2))
+}
+
+func (ts *TidbShutdownTestSuite) TestShutdownTimeout(c *C) {
+	server := ts.startServer(c, ":4004")
+	db, err := sql.Open("mysql", "root@tcp(localhost:4004)/test?strict=true")
+	c.Assert(err, IsNil)
+	defer db.Close()
+	tx, err := db.Begin()
+	c.Assert(err, IsNil)
+	_, err = tx.Exec("insert shutdown values (3)")
+	c.Assert(err, IsNil)
+
+	// The connection in the transaction is killed after the timeout.
+	start := time.Now()
+	server.Shutdown(300 * time.Millisecond)
+	c.Assert(time.Since(start) < killWaitTime, IsTrue)
+	c.Assert(server.ConnectionCount(), Equals, 0)
+	c.Assert(tx.Commit(), NotNil)
+}
+
+// generateCert writes a self-signed certificate and its private key to dir.
+func generateCert(c *C, dir string) (string, string) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	c.Assert(err, IsNil)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "tidb-server"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	c.Assert(err, IsNil)
+
+	certPath := filepath.Join(dir, "server-cert.pem")
+	keyPath := filepath.Join(dir, "server-key.pem")
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	c.Assert(ioutil.WriteFile(certPath, certPEM, 0600), IsNil)
+	c.Assert(ioutil.WriteFile(keyPath, keyPEM, 0600), IsNil)
+	return certPath, keyPath
+}
@@ -23,6 +23,7 @@ import (
 	// For pprof
 	_ "net/http/pprof"
 	"os"
+	"reflect"
 	"strings"
 	"sync"
 
@@ -36,7 +37,9 @@ import (
 	"github.com/pingcap/tidb/expression"
 	"github.com/pingcap/tidb/field"
 	"github.com/pingcap/tidb/kv"
+	"github.com/pingcap/tidb/metric"
 	"github.com/pingcap/tidb/parser"
+	"github.com/pingcap/tidb/plan"
 	"github.com/pingcap/tidb/rset"
 	"github.com/pingcap/tidb/sessionctx/autocommit"
 	"github.com/pingcap/tidb/sessionctx/variable"
@@ -106,6 +109,27 @@ var (
 	schemaLease = 1 * time.Second
 )
 
+// GetDomain gets the domain of the store, the domain is created if it doesn't exist.
+func GetDomain(store kv.Storage) (*domain.Domain, error) {
+	d, err := domap.Get(store)
+	return d, errors.Trace(err)
+}
+
+// CloseStore stops the domain of the store, then closes the store. The sessions of the store
+// can't be used after it.
+func CloseStore(store kv.Storage) error {
+	domap.mu.Lock()
+	d := domap.domains[store.UUID()]
+	delete(domap.domains, store.UUID())
+	domap.mu.Unlock()
+	if d != nil {
+		if err := d.Close(); err != nil {
+			log.Errorf("close domain error %v", errors.ErrorStack(err))
+		}
+	}
+	return errors.Trace(store.Close())
+}
+
 // SetSchemaLease changes the default schema lease time for DDL.
 // This function is very dangerous, don't use it if you really know what you do.
 // SetSchemaLease only affects not local storage after bootstrapped.
@@ -146,6 +170,10 @@ func Compile(ctx context.Context, src string) ([]stmt.Statement, error) {
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
+	return compileStmts(ctx, rawStmt)
+}
+
+func compileStmts(ctx context.Context, rawStmt []ast.StmtNode) ([]stmt.Statement, error) {
 	stmts := make([]stmt.Statement, len(rawStmt))
 	for i, v := range rawStmt {
 		compiler := &executor.Compiler{}
@@ -161,22 +189,35 @@ func Compile(ctx context.Context, src string) ([]stmt.Statement, error) {
 // CompilePrepare compiles prepared statement, allows placeholder as expr.
 // The return values are compiled statement, parameter list and error.
 func CompilePrepare(ctx context.Context, src string) (stmt.Statement, []*expression.ParamMarker, error) {
+	node, err := parsePrepare(ctx, src)
+	if node == nil || err != nil {
+		return nil, nil, errors.Trace(err)
+	}
+	return convertPrepare(node)
+}
+
+// parsePrepare parses the prepared statement, it returns nil if the sql text doesn't have only
+// one statement.
+func parsePrepare(ctx context.Context, src string) (ast.StmtNode, error) {
 	log.Debug("compiling prepared", src)
 	l := parser.NewLexer(src)
 	l.SetCharsetInfo(getCtxCharsetInfo(ctx))
 	l.SetPrepare()
 	if parser.YYParse(l) != 0 {
 		log.Errorf("compiling %s\n, error: %v", src, l.Errors()[0])
-		return nil, nil, errors.Trace(l.Errors()[0])
+		return nil, errors.Trace(l.Errors()[0])
 	}
 	sms := l.Stmts()
 	if len(sms) != 1 {
 		log.Warnf("compiling %s, error: prepared statement should have only one statement.", src)
-		return nil, nil, nil
+		return nil, nil
 	}
-	sm := sms[0]
+	return sms[0], nil
+}
+
+func convertPrepare(node ast.StmtNode) (stmt.Statement, []*expression.ParamMarker, error) {
 	conv := &converter.Converter{}
-	s, err := conv.Convert(sm)
+	s, err := conv.Convert(node)
 	if err != nil {
 		return nil, nil, errors.Trace(err)
 	}
@@ -203,11 +244,89 @@ func dropPreparedStmt(ctx context.Context, stmtID uint32) error {
 	return err
 }
 
-func runStmt(ctx context.Context, s stmt.Statement, args ...interface{}) (rset.Recordset, error) {
-	var err error
-	var rs rset.Recordset
+// stmtType returns the type of the statement in the metrics, e.g. "Select" for *stmts.SelectStmt
+// and the *ast.SelectStmt compiled by the new optimizer.
+func stmtType(s stmt.Statement) string {
+	var v interface{} = s
+	if node := executor.StmtNode(s); node != nil {
+		v = node
+	}
+	name := reflect.TypeOf(v).String()
+	name = name[strings.LastIndex(name, ".")+1:]
+	return strings.TrimSuffix(name, "Stmt")
+}
+
+// recordQuery records the count and the duration of the query in the metrics.
+func recordQuery(typ string, start time.Time, failed bool) {
+	result := "OK"
+	if failed {
+		result = "Error"
+	}
+	metric.Inc(metric.Label("tidb_query_total", "type", typ, "result", result), 1)
+	metric.ObserveDuration(metric.Label("tidb_query_duration_seconds", "type", typ), start)
+}
+
+// queryRecordset records the metrics of the query when it's closed, since the rows are
+// fetched after the statement is executed and the errors may occur when they are fetched.
+type queryRecordset struct {
+	rset.Recordset
+	typ      string
+	start    time.Time
+	failed   bool
+	recorded bool
+}
+
+// Do implements rset.Recordset Do interface.
+func (rs *queryRecordset) Do(f func(data []interface{}) (bool, error)) error {
+	err := rs.Recordset.Do(f)
+	rs.failed = rs.failed || err != nil
+	return errors.Trace(err)
+}
+
+// FirstRow implements rset.Recordset FirstRow interface.
+func (rs *queryRecordset) FirstRow() ([]interface{}, error) {
+	row, err := rs.Recordset.FirstRow()
+	rs.failed = rs.failed || err != nil
+	return row, errors.Trace(err)
+}
+
+// Rows implements rset.Recordset Rows interface.
+func (rs *queryRecordset) Rows(limit, offset int) ([][]interface{}, error) {
+	rows, err := rs.Recordset.Rows(limit, offset)
+	rs.failed = rs.failed || err != nil
+	return rows, errors.Trace(err)
+}
+
+// Next implements rset.Recordset Next interface.
+func (rs *queryRecordset) Next() (*plan.Row, error) {
+	row, err := rs.Recordset.Next()
+	rs.failed = rs.failed || err != nil
+	return row, errors.Trace(err)
+}
+
+// Close implements rset.Recordset Close interface.
+func (rs *queryRecordset) Close() error {
+	err := rs.Recordset.Close()
+	if !rs.recorded {
+		recordQuery(rs.typ, rs.start, rs.failed || err != nil)
+		rs.recorded = true
+	}
+	return errors.Trace(err)
+}
+
+func runStmt(ctx context.Context, s stmt.Statement, args ...interface{}) (rs rset.Recordset, err error) {
+	startTime := time.Now()
+	defer func() {
+		if err == nil && rs != nil {
+			// The query is recorded when the rows are fetched and the recordset is closed.
+			rs = &queryRecordset{Recordset: rs, typ: stmtType(s), start: startTime}
+			return
+		}
+		recordQuery(stmtType(s), startTime, err != nil)
+	}()
 	// before every execution, we must clear affectedrows.
 	variable.GetSessionVars(ctx).SetAffectedRows(0)
+	variable.GetSessionVars(ctx).RowsExamined = 0
 	switch ts := s.(type) {
 	case *stmts.PreparedStmt:
 		rs, err = runPreparedStmt(ctx, ts)
@@ -266,10 +385,22 @@ func runPreparedStmt(ctx context.Context, ps *stmts.PreparedStmt) (rset.Recordse
 		return nil, nil
 	}
 	// compile SQLText
-	stmt, params, err := CompilePrepare(ctx, SQLText)
+	node, err := parsePrepare(ctx, SQLText)
 	if err != nil {
 		return nil, errors.Trace(err)
 	}
+	var (
+		stmt   stmt.Statement
+		params []*expression.ParamMarker
+	)
+	if node != nil {
+		// The statement is normalized before it is converted like Execute does.
+		ps.Normalized = ctx.(*session).normalizeStmts([]ast.StmtNode{node})[0]
+		stmt, params, err = convertPrepare(node)
+		if err != nil {
+			return nil, errors.Trace(err)
+		}
+	}
 	ps.Params = params
 	ps.SQLStmt = stmt
 	rs, err := ps.Exec(ctx)
@@ -0,0 +1,59 @@
+// Copyright 2015 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"flag"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/ngaut/log"
+	"github.com/pingcap/tidb/store/localstore/tso"
+)
+
+var (
+	addr     = flag.String("addr", "127.0.0.1:4001", "tso server listening address")
+	markPath = flag.String("path", "/tmp/tidb.tso", "file to persist the high-water mark of the timestamps")
+	logLevel = flag.String("L", "info", "log level: info, debug, warn, error, fatal")
+)
+
+// A standalone timestamp oracle shared by the tidb-server processes on the same
+// localstore, start tidb-server with -path "<path>?tso=<addr>" to use it.
+func main() {
+	flag.Parse()
+	log.SetLevelByString(*logLevel)
+
+	o, err := tso.NewOracle(*markPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	svr := tso.NewServer(o)
+
+	sc := make(chan os.Signal, 1)
+	signal.Notify(sc,
+		syscall.SIGHUP,
+		syscall.SIGINT,
+		syscall.SIGTERM,
+		syscall.SIGQUIT)
+
+	go func() {
+		sig := <-sc
+		log.Infof("Got signal [%d] to exit.", sig)
+		svr.Close()
+		os.Exit(0)
+	}()
+
+	log.Error(svr.ListenAndServe(*addr))
+}
@@ -0,0 +1,199 @@
+// Copyright 2015 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package collate implements the string comparison and the weight key generation of the
+// collations. The _general_ci collations of utf8, utf8mb4 and latin1 compare strings case
+// insensitively, the other collations compare strings by the raw bytes like the _bin ones.
+package collate
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/juju/errors"
+	"github.com/pingcap/tidb/util/types"
+)
+
+// Collator compares strings and generates their weight keys under a collation.
+type Collator interface {
+	// Compare returns -1, 0 or 1 if a is less than, equal to or greater than b.
+	Compare(a, b string) int
+	// Key returns the weight key of s, the weight keys of the strings compare by bytes
+	// the same as the strings compare with Compare.
+	Key(s string) []byte
+}
+
+var (
+	binCollator       Collator = binaryCollator{}
+	generalCICollator Collator = generalCollator{}
+)
+
+// GetCollator returns the collator of the collation, the collations which are not case
+// insensitive compare by bytes.
+func GetCollator(collation string) Collator {
+	if IsCaseInsensitive(collation) {
+		return generalCICollator
+	}
+	return binCollator
+}
+
+// IsCaseInsensitive returns true if the collation compares strings case insensitively.
+func IsCaseInsensitive(collation string) bool {
+	switch strings.ToLower(collation) {
+	case "utf8_general_ci", "utf8mb4_general_ci", "latin1_general_ci", "ascii_general_ci":
+		return true
+	}
+	return false
+}
+
+type binaryCollator struct{}
+
+// Compare implements Collator Compare interface.
+func (binaryCollator) Compare(a, b string) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// Key implements Collator Key interface.
+func (binaryCollator) Key(s string) []byte {
+	return []byte(s)
+}
+
+// generalCollator is the collator of the _general_ci collations, each character is mapped
+// to a 16-bit weight and the trailing spaces are ignored.
+type generalCollator struct{}
+
+// Compare implements Collator Compare interface.
+func (generalCollator) Compare(a, b string) int {
+	a, b = truncateTailingSpace(a), truncateTailingSpace(b)
+	for a != "" && b != "" {
+		ra, na := utf8.DecodeRuneInString(a)
+		rb, nb := utf8.DecodeRuneInString(b)
+		wa, wb := generalWeight(ra), generalWeight(rb)
+		if wa != wb {
+			if wa < wb {
+				return -1
+			}
+			return 1
+		}
+		a, b = a[na:], b[nb:]
+	}
+	switch {
+	case a != "":
+		return 1
+	case b != "":
+		return -1
+	}
+	return 0
+}
+
+// Key implements Collator Key interface.
+func (generalCollator) Key(s string) []byte {
+	s = truncateTailingSpace(s)
+	key := make([]byte, 0, 2*len(s))
+	for _, r := range s {
+		w := generalWeight(r)
+		key = append(key, byte(w>>8), byte(w))
+	}
+	return key
+}
+
+func truncateTailingSpace(s string) string {
+	return strings.TrimRight(s, " ")
+}
+
+// plane00 is the weights of the characters from U+00C0 to U+00FF, the accented letters
+// sort as the base letters like MySQL does.
+var plane00 = [64]uint16{
+	'A', 'A', 'A', 'A', 'A', 'A', 0xC6, 'C', 'E', 'E', 'E', 'E', 'I', 'I', 'I', 'I',
+	0xD0, 'N', 'O', 'O', 'O', 'O', 'O', 0xD7, 0xD8, 'U', 'U', 'U', 'U', 'Y', 0xDE, 'S',
+	'A', 'A', 'A', 'A', 'A', 'A', 0xC6, 'C', 'E', 'E', 'E', 'E', 'I', 'I', 'I', 'I',
+	0xD0, 'N', 'O', 'O', 'O', 'O', 'O', 0xF7, 0xD8, 'U', 'U', 'U', 'U', 'Y', 0xDE, 'Y',
+}
+
+func generalWeight(r rune) uint16 {
+	switch {
+	case r > 0xFFFF:
+		// The supplementary characters have the same weight as MySQL does.
+		return 0xFFFD
+	case r >= 0xC0 && r <= 0xFF:
+		return plane00[r-0xC0]
+	}
+	return uint16(unicode.ToUpper(r))
+}
+
+// Fold returns the string whose characters are replaced by the characters of their weights
+// under the collation, the folded strings are equal by bytes if the characters are equal
+// under the collation, the trailing spaces are kept. It's used to match the LIKE patterns.
+func Fold(collation string, s string) string {
+	if !IsCaseInsensitive(collation) {
+		return s
+	}
+	return strings.Map(func(r rune) rune {
+		return rune(generalWeight(r))
+	}, s)
+}
+
+func toString(v interface{}) (string, bool) {
+	switch x := v.(type) {
+	case string:
+		return x, true
+	case []byte:
+		return string(x), true
+	}
+	return "", false
+}
+
+// SortKey returns the value which sorts and groups v under the collation. The weight key is
+// returned as a string for the strings under the case insensitive collations, otherwise v
+// is returned.
+func SortKey(collation string, v interface{}) interface{} {
+	if !IsCaseInsensitive(collation) {
+		return v
+	}
+	if s, ok := toString(v); ok {
+		return string(generalCICollator.Key(s))
+	}
+	return v
+}
+
+// SortKeys converts the values to the sort keys under the collations in place,
+// collations[i] is the collation of values[i].
+func SortKeys(collations []string, values []interface{}) []interface{} {
+	for i := range values {
+		if i < len(collations) {
+			values[i] = SortKey(collations[i], values[i])
+		}
+	}
+	return values
+}
+
+// Compare compares a and b, the strings are compared under the collation, the other
+// values are compared with types.Compare.
+func Compare(collation string, a, b interface{}) (int, error) {
+	if IsCaseInsensitive(collation) {
+		sa, ok1 := toString(a)
+		sb, ok2 := toString(b)
+		if ok1 && ok2 {
+			return generalCICollator.Compare(sa, sb), nil
+		}
+	}
+	n, err := types.Compare(a, b)
+	return n, errors.Trace(err)
+}
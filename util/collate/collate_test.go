@@ -0,0 +1,81 @@
+// Copyright 2015 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package collate
+
+import (
+	"testing"
+
+	. "github.com/pingcap/check"
+)
+
+func TestT(t *testing.T) {
+	TestingT(t)
+}
+
+var _ = Suite(&testCollateSuite{})
+
+type testCollateSuite struct {
+}
+
+func (s *testCollateSuite) TestCompare(c *C) {
+	tbl := []struct {
+		collation string
+		a         string
+		b         string
+		cmp       int
+	}{
+		{"utf8_general_ci", "abc", "ABC", 0},
+		{"utf8_general_ci", "abc", "abd", -1},
+		{"utf8_general_ci", "ABD", "abc", 1},
+		{"utf8_general_ci", "abc  ", "ABC", 0},
+		{"utf8_general_ci", "ab", "abc", -1},
+		{"utf8_general_ci", "résumé", "RESUME", 0},
+		{"utf8_general_ci", "Straße", "STRASE", 0},
+		{"utf8mb4_general_ci", "😀", "😁", 0},
+		{"latin1_general_ci", "Åa", "aA", 0},
+		{"UTF8_GENERAL_CI", "a", "A", 0},
+		{"utf8_bin", "abc", "ABC", 1},
+		{"utf8mb4_bin", "a", "a ", -1},
+		{"", "a", "A", 1},
+	}
+	for _, t := range tbl {
+		comment := Commentf("%s %q %q", t.collation, t.a, t.b)
+		c.Assert(GetCollator(t.collation).Compare(t.a, t.b), Equals, t.cmp, comment)
+		cmp, err := Compare(t.collation, t.a, []byte(t.b))
+		c.Assert(err, IsNil)
+		c.Assert(cmp, Equals, t.cmp, comment)
+
+		// The weight keys compare the same as the strings.
+		ka, kb := SortKey(t.collation, t.a), SortKey(t.collation, t.b)
+		cmp, err = Compare("", ka, kb)
+		c.Assert(err, IsNil)
+		c.Assert(cmp, Equals, t.cmp, comment)
+	}
+
+	cmp, err := Compare("utf8_general_ci", int64(1), "1")
+	c.Assert(err, IsNil)
+	c.Assert(cmp, Equals, 0)
+	c.Assert(SortKey("utf8_general_ci", int64(1)), Equals, int64(1))
+}
+
+func (s *testCollateSuite) TestFold(c *C) {
+	c.Assert(Fold("utf8_general_ci", "aBç %"), Equals, "ABC %")
+	c.Assert(Fold("utf8_bin", "aBç"), Equals, "aBç")
+	c.Assert(Fold("utf8_unicode_ci", "aB"), Equals, "aB")
+	c.Assert(IsCaseInsensitive("utf8mb4_general_ci"), IsTrue)
+	c.Assert(IsCaseInsensitive("utf8_unicode_ci"), IsFalse)
+	c.Assert(IsCaseInsensitive("latin1_swedish_ci"), IsFalse)
+	c.Assert(IsCaseInsensitive("latin2_czech_cs"), IsFalse)
+	c.Assert(IsCaseInsensitive("binary"), IsFalse)
+}
@@ -0,0 +1,226 @@
+// Copyright 2015 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package stmtsummary aggregates the executions of the statements by the schema, the
+// digest of the normalized statement text and the user in memory, the summaries are shown
+// by INFORMATION_SCHEMA.STATEMENTS_SUMMARY.
+package stmtsummary
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// MaxSummaries is the max number of the summaries in a window, the executions of the new
+// statements are dropped if it's reached, so the memory is bounded.
+const MaxSummaries = 3000
+
+// maxSampleLen is the max length of the sample statement text kept in a summary.
+const maxSampleLen = 4096
+
+// Execution is an execution of a statement.
+type Execution struct {
+	SchemaName string
+	// User is the name of the user who executes the statement.
+	User          string
+	Digest        string
+	NormalizedSQL string
+	SQL           string
+	StartTime     time.Time
+	Latency       time.Duration
+	AffectedRows  uint64
+	// Failed is true if the statement returns an error.
+	Failed bool
+}
+
+// Summary is the statistics of the executions of the statements with the same digest in a
+// schema by a user, so the sample text is only shown to the user or the privileged users.
+type Summary struct {
+	SchemaName    string
+	User          string
+	Digest        string
+	NormalizedSQL string
+	// SampleSQL is the text of the last execution.
+	SampleSQL       string
+	ExecCount       uint64
+	SumLatency      time.Duration
+	MaxLatency      time.Duration
+	SumAffectedRows uint64
+	SumErrors       uint64
+	FirstSeen       time.Time
+	LastSeen        time.Time
+}
+
+// AvgLatency returns the average latency of the executions.
+func (s *Summary) AvgLatency() time.Duration {
+	if s.ExecCount == 0 {
+		return 0
+	}
+	return s.SumLatency / time.Duration(s.ExecCount)
+}
+
+type summaryKey struct {
+	schemaName string
+	user       string
+	digest     string
+}
+
+// Aggregator aggregates the executions in a window, the window is refreshed and the summaries
+// are cleared when it's longer than the refresh interval. It's safe for concurrent use.
+type Aggregator struct {
+	// enabled is accessed atomically, it's checked before the lock is taken.
+	enabled         int32
+	mu              sync.Mutex
+	summaries       map[summaryKey]*Summary
+	windowBegin     time.Time
+	refreshInterval time.Duration
+}
+
+// NewAggregator creates an Aggregator, the window is never refreshed if refreshInterval <= 0.
+func NewAggregator(refreshInterval time.Duration) *Aggregator {
+	return &Aggregator{
+		enabled:         1,
+		summaries:       make(map[summaryKey]*Summary),
+		windowBegin:     time.Now(),
+		refreshInterval: refreshInterval,
+	}
+}
+
+// SetEnabled enables or disables the aggregator, the executions are dropped if it's disabled.
+func (a *Aggregator) SetEnabled(enabled bool) {
+	var v int32
+	if enabled {
+		v = 1
+	}
+	atomic.StoreInt32(&a.enabled, v)
+}
+
+// Enabled returns whether the aggregator is enabled, the executions needn't be built if not.
+func (a *Aggregator) Enabled() bool {
+	return atomic.LoadInt32(&a.enabled) == 1
+}
+
+// SetRefreshInterval sets the refresh interval of the window.
+func (a *Aggregator) SetRefreshInterval(refreshInterval time.Duration) {
+	a.mu.Lock()
+	a.refreshInterval = refreshInterval
+	a.mu.Unlock()
+}
+
+// refresh clears the summaries if the window expires, it must be called with the lock held.
+func (a *Aggregator) refresh(now time.Time) {
+	if a.refreshInterval <= 0 || now.Sub(a.windowBegin) < a.refreshInterval {
+		return
+	}
+	a.summaries = make(map[summaryKey]*Summary)
+	a.windowBegin = now
+}
+
+// Add adds the execution to the summary of its schema, user and digest.
+func (a *Aggregator) Add(e *Execution) {
+	if !a.Enabled() {
+		return
+	}
+	now := e.StartTime.Add(e.Latency)
+	sample := e.SQL
+	if len(sample) > maxSampleLen {
+		sample = sample[:maxSampleLen]
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.refresh(now)
+	key := summaryKey{schemaName: e.SchemaName, user: e.User, digest: e.Digest}
+	s, ok := a.summaries[key]
+	if !ok {
+		if len(a.summaries) >= MaxSummaries {
+			return
+		}
+		s = &Summary{
+			SchemaName:    e.SchemaName,
+			User:          e.User,
+			Digest:        e.Digest,
+			NormalizedSQL: e.NormalizedSQL,
+			FirstSeen:     e.StartTime,
+		}
+		a.summaries[key] = s
+	}
+	s.SampleSQL = sample
+	s.ExecCount++
+	s.SumLatency += e.Latency
+	if e.Latency > s.MaxLatency {
+		s.MaxLatency = e.Latency
+	}
+	s.SumAffectedRows += e.AffectedRows
+	if e.Failed {
+		s.SumErrors++
+	}
+	s.LastSeen = e.StartTime
+}
+
+// Summaries returns the begin time of the current window and the copies of its summaries
+// sorted by the sum latency in descending order.
+func (a *Aggregator) Summaries() (time.Time, []Summary) {
+	a.mu.Lock()
+	a.refresh(time.Now())
+	windowBegin := a.windowBegin
+	summaries := make([]Summary, 0, len(a.summaries))
+	for _, s := range a.summaries {
+		summaries = append(summaries, *s)
+	}
+	a.mu.Unlock()
+	sort.Sort(bySumLatency(summaries))
+	return windowBegin, summaries
+}
+
+// Clear clears the summaries and begins a new window.
+func (a *Aggregator) Clear() {
+	a.mu.Lock()
+	a.summaries = make(map[summaryKey]*Summary)
+	a.windowBegin = time.Now()
+	a.mu.Unlock()
+}
+
+type bySumLatency []Summary
+
+func (b bySumLatency) Len() int      { return len(b) }
+func (b bySumLatency) Swap(i, j int) { b[i], b[j] = b[j], b[i] }
+func (b bySumLatency) Less(i, j int) bool {
+	if b[i].SumLatency != b[j].SumLatency {
+		return b[i].SumLatency > b[j].SumLatency
+	}
+	if b[i].SchemaName != b[j].SchemaName {
+		return b[i].SchemaName < b[j].SchemaName
+	}
+	if b[i].User != b[j].User {
+		return b[i].User < b[j].User
+	}
+	return b[i].Digest < b[j].Digest
+}
+
+// DefaultRefreshInterval is the default refresh interval of the global aggregator.
+const DefaultRefreshInterval = 30 * time.Minute
+
+// global is disabled until the server enables it.
+var global = func() *Aggregator {
+	a := NewAggregator(DefaultRefreshInterval)
+	a.SetEnabled(false)
+	return a
+}()
+
+// Global returns the aggregator of the statements executed by the server.
+func Global() *Aggregator {
+	return global
+}
@@ -0,0 +1,102 @@
+// Copyright 2015 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stmtsummary
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	. "github.com/pingcap/check"
+)
+
+func TestT(t *testing.T) {
+	TestingT(t)
+}
+
+var _ = Suite(&testStmtSummarySuite{})
+
+type testStmtSummarySuite struct {
+}
+
+func (s *testStmtSummarySuite) TestAggregator(c *C) {
+	a := NewAggregator(0)
+	start := time.Now()
+	a.Add(&Execution{SchemaName: "test", Digest: "d1", NormalizedSQL: "select ?", SQL: "select 1",
+		StartTime: start, Latency: time.Millisecond})
+	a.Add(&Execution{SchemaName: "test", Digest: "d1", NormalizedSQL: "select ?", SQL: "select 2",
+		StartTime: start.Add(time.Second), Latency: 3 * time.Millisecond, AffectedRows: 2, Failed: true})
+	a.Add(&Execution{SchemaName: "test", Digest: "d2", NormalizedSQL: "select ? from `t`", SQL: "select 1 from t",
+		StartTime: start, Latency: 10 * time.Millisecond})
+	a.Add(&Execution{SchemaName: "other", Digest: "d1", NormalizedSQL: "select ?", SQL: "select 3",
+		StartTime: start, Latency: time.Millisecond})
+	a.Add(&Execution{SchemaName: "test", User: "u1", Digest: "d1", NormalizedSQL: "select ?", SQL: "select 4",
+		StartTime: start, Latency: time.Millisecond})
+
+	_, summaries := a.Summaries()
+	c.Assert(summaries, HasLen, 4)
+	c.Assert(summaries[0].Digest, Equals, "d2")
+	c.Assert(summaries[1].SchemaName, Equals, "test")
+	c.Assert(summaries[1].Digest, Equals, "d1")
+	c.Assert(summaries[2].SchemaName, Equals, "other")
+	c.Assert(summaries[3].User, Equals, "u1")
+	c.Assert(summaries[3].SampleSQL, Equals, "select 4")
+
+	sum := summaries[1]
+	c.Assert(sum.ExecCount, Equals, uint64(2))
+	c.Assert(sum.SumLatency, Equals, 4*time.Millisecond)
+	c.Assert(sum.MaxLatency, Equals, 3*time.Millisecond)
+	c.Assert(sum.AvgLatency(), Equals, 2*time.Millisecond)
+	c.Assert(sum.SumAffectedRows, Equals, uint64(2))
+	c.Assert(sum.SumErrors, Equals, uint64(1))
+	c.Assert(sum.FirstSeen.Equal(start), IsTrue)
+	c.Assert(sum.LastSeen.Equal(start.Add(time.Second)), IsTrue)
+	c.Assert(sum.SampleSQL, Equals, "select 2")
+
+	a.Clear()
+	_, summaries = a.Summaries()
+	c.Assert(summaries, HasLen, 0)
+
+	a.SetEnabled(false)
+	c.Assert(a.Enabled(), IsFalse)
+	a.Add(&Execution{SchemaName: "test", Digest: "d1", NormalizedSQL: "select ?", SQL: "select 1",
+		StartTime: start, Latency: time.Millisecond})
+	_, summaries = a.Summaries()
+	c.Assert(summaries, HasLen, 0)
+	c.Assert(Global().Enabled(), IsFalse)
+}
+
+func (s *testStmtSummarySuite) TestRefresh(c *C) {
+	a := NewAggregator(time.Hour)
+	begin, _ := a.Summaries()
+	a.Add(&Execution{SchemaName: "test", Digest: "d1", StartTime: begin, Latency: time.Millisecond})
+	_, summaries := a.Summaries()
+	c.Assert(summaries, HasLen, 1)
+
+	// The execution after the window expires begins a new window.
+	later := begin.Add(2 * time.Hour)
+	a.Add(&Execution{SchemaName: "test", Digest: "d2", StartTime: later, Latency: time.Millisecond})
+	a.SetRefreshInterval(0)
+	newBegin, summaries := a.Summaries()
+	c.Assert(newBegin.After(begin), IsTrue)
+	c.Assert(summaries, HasLen, 1)
+	c.Assert(summaries[0].Digest, Equals, "d2")
+
+	a = NewAggregator(0)
+	for i := 0; i < MaxSummaries+10; i++ {
+		a.Add(&Execution{Digest: strconv.Itoa(i), StartTime: begin})
+	}
+	_, summaries = a.Summaries()
+	c.Assert(summaries, HasLen, MaxSummaries)
+}